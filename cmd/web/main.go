@@ -3,6 +3,9 @@ package main
 import (
 	"fmt"
 	"go-clean-arch-saas/internal/config"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/joho/godotenv"
 )
@@ -15,7 +18,7 @@ func main() {
 	validate := config.NewValidator(viperConfig)
 	app := config.NewFiber(viperConfig)
 
-	config.Bootstrap(&config.BootstrapConfig{
+	jobs := config.Bootstrap(&config.BootstrapConfig{
 		DB:       db,
 		App:      app,
 		Log:      log,
@@ -23,6 +26,22 @@ func main() {
 		Config:   viperConfig,
 	})
 
+	if viperConfig.GetBool("scheduler.enabled") {
+		jobs.Start()
+	}
+
+	// Shut down gracefully so buffered usage is flushed and running jobs finish
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+
+		jobs.Stop()
+		if err := app.Shutdown(); err != nil {
+			log.Warnf("Failed to shut down server: %v", err)
+		}
+	}()
+
 	webPort := viperConfig.GetInt("web.port")
 	err := app.Listen(fmt.Sprintf(":%d", webPort))
 	if err != nil {
@@ -0,0 +1,23 @@
+-- Valid status values: 'pending', 'accepted', 'revoked'
+-- token_hash stores the SHA-256 of the emailed token, never the token itself
+CREATE TABLE organization_invitations (
+    id UUID NOT NULL PRIMARY KEY,
+    organization_id UUID NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(50) NOT NULL DEFAULT 'member',
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    invited_by UUID NULL,
+    expires_at BIGINT NOT NULL,
+    accepted_at BIGINT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    deleted_at BIGINT NULL,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_invitation_org ON organization_invitations(organization_id);
+CREATE INDEX idx_invitation_email ON organization_invitations(email);
+CREATE INDEX idx_invitation_status ON organization_invitations(status);
+CREATE INDEX idx_invitation_deleted ON organization_invitations(deleted_at);
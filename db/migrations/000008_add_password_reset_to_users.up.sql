@@ -0,0 +1,5 @@
+-- password_reset_token stores the SHA-256 of the emailed token, never the token itself
+ALTER TABLE users ADD COLUMN password_reset_token VARCHAR(64) NULL;
+ALTER TABLE users ADD COLUMN password_reset_expires_at BIGINT NULL;
+
+CREATE INDEX idx_users_password_reset_token ON users(password_reset_token);
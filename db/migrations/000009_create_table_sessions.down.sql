@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN refresh_token VARCHAR(100) NULL;
+ALTER TABLE users ADD COLUMN refresh_token_expires_at BIGINT NULL;
+CREATE INDEX idx_users_refresh_token ON users(refresh_token);
+
+DROP TABLE IF EXISTS sessions;
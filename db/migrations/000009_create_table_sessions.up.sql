@@ -0,0 +1,29 @@
+-- Each row is one refresh token. Rotating a token marks the row as rotated and
+-- inserts a new row with the same family_id, so a family represents one device session.
+-- Presenting a rotated token again revokes the whole family (reuse detection).
+CREATE TABLE sessions (
+    id UUID NOT NULL PRIMARY KEY,
+    family_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    user_agent TEXT,
+    ip_address VARCHAR(45),
+    authenticated_at BIGINT NOT NULL,
+    last_used_at BIGINT NOT NULL,
+    expires_at BIGINT NOT NULL,
+    rotated_at BIGINT NULL,
+    revoked_at BIGINT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    deleted_at BIGINT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_session_user ON sessions(user_id);
+CREATE INDEX idx_session_family ON sessions(family_id);
+CREATE INDEX idx_session_deleted ON sessions(deleted_at);
+
+-- Refresh tokens now live in sessions
+DROP INDEX IF EXISTS idx_users_refresh_token;
+ALTER TABLE users DROP COLUMN IF EXISTS refresh_token;
+ALTER TABLE users DROP COLUMN IF EXISTS refresh_token_expires_at;
@@ -0,0 +1,2 @@
+-- Organization the session is currently acting in (changed by /auth/switch-organization)
+ALTER TABLE sessions ADD COLUMN organization_id UUID NULL REFERENCES organizations(id) ON DELETE SET NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_audit_resource;
+DROP INDEX IF EXISTS idx_audit_org_action;
+DROP INDEX IF EXISTS idx_audit_org_created;
@@ -0,0 +1,3 @@
+CREATE INDEX idx_audit_org_created ON audit_logs(organization_id, created_at);
+CREATE INDEX idx_audit_org_action ON audit_logs(organization_id, action);
+CREATE INDEX idx_audit_resource ON audit_logs(resource, resource_id);
@@ -0,0 +1,14 @@
+-- One row per organization, metric and billing period; quantity is incremented by batched flushes
+CREATE TABLE usage_records (
+    id UUID NOT NULL PRIMARY KEY,
+    organization_id UUID NOT NULL,
+    metric VARCHAR(50) NOT NULL,
+    period_start BIGINT NOT NULL,
+    period_end BIGINT NOT NULL,
+    quantity BIGINT NOT NULL DEFAULT 0,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX idx_usage_org_metric_period ON usage_records(organization_id, metric, period_start);
@@ -0,0 +1,3 @@
+-- A subscription with cancel_at_period_end stays active until current_period_end, then the scheduler expires it
+ALTER TABLE subscriptions ADD COLUMN cancel_at_period_end BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE subscriptions ADD COLUMN cancelled_at BIGINT NULL;
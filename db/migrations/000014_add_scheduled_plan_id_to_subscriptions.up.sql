@@ -0,0 +1,2 @@
+-- A downgrade is kept on the current subscription until current_period_end, when renewal switches to scheduled_plan_id
+ALTER TABLE subscriptions ADD COLUMN scheduled_plan_id UUID NULL REFERENCES plans(id);
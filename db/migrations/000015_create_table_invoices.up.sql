@@ -0,0 +1,48 @@
+-- Invoice numbers are sequential per organization; invoice_sequences holds the last number issued
+CREATE TABLE invoice_sequences (
+    organization_id UUID NOT NULL PRIMARY KEY,
+    last_number BIGINT NOT NULL DEFAULT 0,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE
+);
+
+CREATE TABLE invoices (
+    id UUID NOT NULL PRIMARY KEY,
+    organization_id UUID NOT NULL,
+    subscription_id UUID NOT NULL,
+    number VARCHAR(50) NOT NULL,
+    sequence BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    total NUMERIC(10,2) NOT NULL DEFAULT 0.00,
+    amount_due NUMERIC(10,2) NOT NULL DEFAULT 0.00,
+    period_start BIGINT NOT NULL,
+    period_end BIGINT NOT NULL,
+    issued_at BIGINT NOT NULL,
+    paid_at BIGINT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    FOREIGN KEY (subscription_id) REFERENCES subscriptions(id)
+);
+
+CREATE UNIQUE INDEX idx_invoice_org_sequence ON invoices(organization_id, sequence);
+CREATE INDEX idx_invoice_subscription ON invoices(subscription_id);
+
+CREATE TABLE invoice_line_items (
+    id UUID NOT NULL PRIMARY KEY,
+    invoice_id UUID NOT NULL,
+    position INT NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    description VARCHAR(255) NOT NULL,
+    plan_id UUID NULL,
+    quantity BIGINT NOT NULL DEFAULT 1,
+    unit_amount NUMERIC(10,2) NOT NULL DEFAULT 0.00,
+    amount NUMERIC(10,2) NOT NULL DEFAULT 0.00,
+    period_start BIGINT NOT NULL,
+    period_end BIGINT NOT NULL,
+    created_at BIGINT NOT NULL,
+    FOREIGN KEY (invoice_id) REFERENCES invoices(id) ON DELETE CASCADE,
+    FOREIGN KEY (plan_id) REFERENCES plans(id)
+);
+
+CREATE INDEX idx_invoice_line_invoice ON invoice_line_items(invoice_id);
@@ -0,0 +1,3 @@
+ALTER TABLE invoices DROP COLUMN IF EXISTS payment_charge_id;
+ALTER TABLE organizations DROP COLUMN IF EXISTS payment_method_id;
+ALTER TABLE organizations DROP COLUMN IF EXISTS payment_customer_id;
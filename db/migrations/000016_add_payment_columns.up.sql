@@ -0,0 +1,4 @@
+-- Payment provider references: the organization's customer and default payment method, and the charge that paid an invoice
+ALTER TABLE organizations ADD COLUMN payment_customer_id VARCHAR(255) NULL;
+ALTER TABLE organizations ADD COLUMN payment_method_id VARCHAR(255) NULL;
+ALTER TABLE invoices ADD COLUMN payment_charge_id VARCHAR(255) NULL;
@@ -0,0 +1,12 @@
+-- Every webhook received from the payment provider; the unique event ID makes redelivered events no-ops
+CREATE TABLE billing_events (
+    id UUID NOT NULL PRIMARY KEY,
+    provider VARCHAR(50) NOT NULL,
+    event_id VARCHAR(255) NOT NULL,
+    type VARCHAR(100) NOT NULL,
+    payload TEXT NOT NULL,
+    organization_id UUID NULL,
+    created_at BIGINT NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_billing_event_provider_event ON billing_events(provider, event_id);
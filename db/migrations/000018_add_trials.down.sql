@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_sub_trial_ends_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS trial_reminder_sent_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS trial_ends_at;
+ALTER TABLE plans DROP COLUMN IF EXISTS trial_days;
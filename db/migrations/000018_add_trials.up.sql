@@ -0,0 +1,6 @@
+-- Plans may offer a free trial; a trialing subscription converts to paid, or falls back to free, at trial_ends_at
+ALTER TABLE plans ADD COLUMN trial_days INT NOT NULL DEFAULT 0;
+ALTER TABLE subscriptions ADD COLUMN trial_ends_at BIGINT NULL;
+ALTER TABLE subscriptions ADD COLUMN trial_reminder_sent_at BIGINT NULL;
+
+CREATE INDEX idx_sub_trial_ends_at ON subscriptions(trial_ends_at);
@@ -0,0 +1,4 @@
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS discount_ends_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS coupon_id;
+DROP TABLE IF EXISTS coupon_redemptions;
+DROP TABLE IF EXISTS coupons;
@@ -0,0 +1,39 @@
+-- Discount codes. plan_ids restricts a coupon to some plans; NULL or [] means every plan.
+CREATE TABLE coupons (
+    id UUID NOT NULL PRIMARY KEY,
+    code VARCHAR(50) UNIQUE NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    discount_type VARCHAR(20) NOT NULL,
+    percent_off NUMERIC(5,2) NOT NULL DEFAULT 0.00,
+    amount_off NUMERIC(10,2) NOT NULL DEFAULT 0.00,
+    duration VARCHAR(20) NOT NULL,
+    duration_in_months INT NOT NULL DEFAULT 0,
+    max_redemptions INT NULL,
+    times_redeemed INT NOT NULL DEFAULT 0,
+    expires_at BIGINT NULL,
+    plan_ids JSON,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    deleted_at BIGINT NULL
+);
+
+CREATE INDEX idx_coupon_deleted ON coupons(deleted_at);
+
+-- An organization redeems a coupon at most once
+CREATE TABLE coupon_redemptions (
+    id UUID NOT NULL PRIMARY KEY,
+    coupon_id UUID NOT NULL,
+    organization_id UUID NOT NULL,
+    subscription_id UUID NOT NULL,
+    created_at BIGINT NOT NULL,
+    FOREIGN KEY (coupon_id) REFERENCES coupons(id),
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    FOREIGN KEY (subscription_id) REFERENCES subscriptions(id)
+);
+
+CREATE UNIQUE INDEX idx_coupon_redemption_org ON coupon_redemptions(coupon_id, organization_id);
+
+-- The discount a subscription carries; it applies to invoices for periods starting before discount_ends_at (NULL = forever)
+ALTER TABLE subscriptions ADD COLUMN coupon_id UUID NULL REFERENCES coupons(id);
+ALTER TABLE subscriptions ADD COLUMN discount_ends_at BIGINT NULL;
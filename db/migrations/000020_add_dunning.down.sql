@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_sub_next_dunning_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS next_dunning_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS payment_retry_count;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS grace_period_ends_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS past_due_at;
@@ -0,0 +1,8 @@
+-- A renewal that cannot be charged leaves the subscription past_due: its payment is retried until
+-- grace_period_ends_at, when it falls back to the free plan
+ALTER TABLE subscriptions ADD COLUMN past_due_at BIGINT NULL;
+ALTER TABLE subscriptions ADD COLUMN grace_period_ends_at BIGINT NULL;
+ALTER TABLE subscriptions ADD COLUMN payment_retry_count INT NOT NULL DEFAULT 0;
+ALTER TABLE subscriptions ADD COLUMN next_dunning_at BIGINT NULL;
+
+CREATE INDEX idx_sub_next_dunning_at ON subscriptions(next_dunning_at);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS pending_invoice_items;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS quantity;
+ALTER TABLE plans DROP COLUMN IF EXISTS pricing_model;
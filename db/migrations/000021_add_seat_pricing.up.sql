@@ -0,0 +1,26 @@
+-- Per-seat plans charge their price for every member; a subscription's quantity is its organization's member count
+ALTER TABLE plans ADD COLUMN pricing_model VARCHAR(20) NOT NULL DEFAULT 'flat';
+ALTER TABLE subscriptions ADD COLUMN quantity INT NOT NULL DEFAULT 1;
+
+UPDATE subscriptions SET quantity = GREATEST(1, (
+    SELECT COUNT(*) FROM organization_members WHERE organization_members.organization_id = subscriptions.organization_id
+));
+
+-- Charges and credits for seats added or removed mid-period, billed on the subscription's next invoice
+CREATE TABLE pending_invoice_items (
+    id UUID NOT NULL PRIMARY KEY,
+    subscription_id UUID NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    description VARCHAR(255) NOT NULL,
+    plan_id UUID NULL,
+    quantity BIGINT NOT NULL DEFAULT 1,
+    unit_amount NUMERIC(10,2) NOT NULL DEFAULT 0.00,
+    amount NUMERIC(10,2) NOT NULL DEFAULT 0.00,
+    period_start BIGINT NOT NULL,
+    period_end BIGINT NOT NULL,
+    created_at BIGINT NOT NULL,
+    FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE,
+    FOREIGN KEY (plan_id) REFERENCES plans(id)
+);
+
+CREATE INDEX idx_pending_invoice_item_subscription ON pending_invoice_items(subscription_id);
@@ -0,0 +1,4 @@
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS scheduled_plan_price_id;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS plan_price_id;
+DROP TABLE IF EXISTS plan_prices;
+ALTER TABLE plans DROP COLUMN IF EXISTS billing_interval;
@@ -0,0 +1,21 @@
+-- A plan is billed every billing_interval billing_periods (e.g. 3 monthly = quarterly)
+ALTER TABLE plans ADD COLUMN billing_interval INT NOT NULL DEFAULT 1;
+
+-- Price variants of a plan, e.g. a yearly price next to its monthly one. The plan's own price is its default variant.
+CREATE TABLE plan_prices (
+    id UUID NOT NULL PRIMARY KEY,
+    plan_id UUID NOT NULL,
+    price NUMERIC(10,2) NOT NULL DEFAULT 0.00,
+    billing_period VARCHAR(20) NOT NULL,
+    billing_interval INT NOT NULL DEFAULT 1,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    FOREIGN KEY (plan_id) REFERENCES plans(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX idx_plan_price_period ON plan_prices(plan_id, billing_period, billing_interval);
+
+-- The variant a subscription is billed at, and the one a scheduled downgrade switches to; NULL is the plan's own price
+ALTER TABLE subscriptions ADD COLUMN plan_price_id UUID NULL REFERENCES plan_prices(id);
+ALTER TABLE subscriptions ADD COLUMN scheduled_plan_price_id UUID NULL REFERENCES plan_prices(id);
@@ -0,0 +1,18 @@
+-- TOTP two-factor authentication. mfa_secret is encrypted with encryption.key and is set from enrollment,
+-- before mfa_enabled; mfa_last_used_step rejects a code being replayed within its time step.
+ALTER TABLE users ADD COLUMN mfa_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN mfa_secret TEXT NULL;
+ALTER TABLE users ADD COLUMN mfa_enabled_at BIGINT NULL;
+ALTER TABLE users ADD COLUMN mfa_last_used_step BIGINT NOT NULL DEFAULT 0;
+
+-- One-time recovery codes; only their SHA-256 hash is stored
+CREATE TABLE recovery_codes (
+    id UUID NOT NULL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    code_hash VARCHAR(64) UNIQUE NOT NULL,
+    used_at BIGINT NULL,
+    created_at BIGINT NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_recovery_code_user ON recovery_codes(user_id);
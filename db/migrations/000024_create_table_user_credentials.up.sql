@@ -0,0 +1,19 @@
+-- WebAuthn credentials (passkeys and security keys). sign_count is the authenticator's signature counter,
+-- which must increase with each assertion unless the authenticator does not keep one (always 0).
+CREATE TABLE user_credentials (
+    id UUID NOT NULL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    credential_id VARCHAR(1400) UNIQUE NOT NULL,
+    public_key BYTEA NOT NULL,
+    algorithm INT NOT NULL,
+    sign_count BIGINT NOT NULL DEFAULT 0,
+    aaguid VARCHAR(32) NOT NULL DEFAULT '',
+    transports VARCHAR(255) NULL,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    last_used_at BIGINT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_user_credential_user ON user_credentials(user_id);
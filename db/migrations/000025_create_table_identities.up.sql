@@ -0,0 +1,16 @@
+-- Accounts at external OAuth / OpenID Connect providers users sign in with. subject is the provider's
+-- stable user ID; the email is the one the provider reported at the last login.
+CREATE TABLE identities (
+    id UUID NOT NULL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    last_login_at BIGINT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX idx_identity_provider_subject ON identities(provider, subject);
+CREATE INDEX idx_identity_user ON identities(user_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS sso_domains;
+DROP TABLE IF EXISTS sso_connections;
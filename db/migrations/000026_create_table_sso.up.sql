@@ -0,0 +1,35 @@
+-- Per-organization single sign-on. An organization has at most one connection to its identity provider,
+-- over OpenID Connect (issuer and client) or SAML 2.0 (the identity provider's metadata). The OIDC client
+-- secret is encrypted with the application secret.
+CREATE TABLE sso_connections (
+    id UUID NOT NULL PRIMARY KEY,
+    organization_id UUID NOT NULL UNIQUE,
+    protocol VARCHAR(10) NOT NULL,
+    enforce_sso BOOLEAN NOT NULL DEFAULT FALSE,
+    default_role VARCHAR(20) NOT NULL DEFAULT 'member',
+    oidc_issuer VARCHAR(500) NOT NULL DEFAULT '',
+    oidc_client_id VARCHAR(255) NOT NULL DEFAULT '',
+    oidc_client_secret TEXT NOT NULL DEFAULT '',
+    saml_metadata TEXT NOT NULL DEFAULT '',
+    saml_entity_id VARCHAR(500) NOT NULL DEFAULT '',
+    saml_sso_url VARCHAR(1000) NOT NULL DEFAULT '',
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE
+);
+
+-- Email domains routed to an organization's connection. Several organizations may claim a domain, but only
+-- one can verify it.
+CREATE TABLE sso_domains (
+    id UUID NOT NULL PRIMARY KEY,
+    organization_id UUID NOT NULL,
+    domain VARCHAR(255) NOT NULL,
+    verification_token VARCHAR(100) NOT NULL,
+    verified_at BIGINT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX idx_sso_domain_org_domain ON sso_domains(organization_id, domain);
+CREATE UNIQUE INDEX idx_sso_domain_verified ON sso_domains(domain) WHERE verified_at IS NOT NULL;
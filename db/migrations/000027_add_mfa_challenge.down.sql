@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN IF EXISTS mfa_locked_until;
+ALTER TABLE users DROP COLUMN IF EXISTS mfa_failed_attempts;
+ALTER TABLE users DROP COLUMN IF EXISTS mfa_challenge_hash;
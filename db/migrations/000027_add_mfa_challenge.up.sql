@@ -0,0 +1,5 @@
+-- The MFA challenge of the latest login, stored as its SHA-256 hash and cleared when a code is checked against it,
+-- and the failed codes counted towards a lockout of the second step
+ALTER TABLE users ADD COLUMN mfa_challenge_hash VARCHAR(64) NULL;
+ALTER TABLE users ADD COLUMN mfa_failed_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN mfa_locked_until BIGINT NULL;
@@ -11,13 +11,26 @@ func SeedDatabase(db *gorm.DB) error {
 	// Seed Plans
 	plans := []entity.Plan{
 		{ID: "550e8400-e29b-41d4-a716-446655440001", Name: "Free", Slug: "free", Price: 0.00, BillingPeriod: "monthly", Features: `{"storage": "1GB", "users": "1", "support": "Community"}`, Limits: `{"api_calls_per_month": 1000, "max_users": 1, "storage_gb": 1}`, IsActive: true},
-		{ID: "550e8400-e29b-41d4-a716-446655440002", Name: "Pro", Slug: "pro", Price: 29.00, BillingPeriod: "monthly", Features: `{"storage": "50GB", "users": "10", "support": "Email"}`, Limits: `{"api_calls_per_month": 100000, "max_users": 10, "storage_gb": 50}`, IsActive: true},
+		{ID: "550e8400-e29b-41d4-a716-446655440002", Name: "Pro", Slug: "pro", Price: 29.00, PricingModel: "per_seat", BillingPeriod: "monthly", TrialDays: 14, Features: `{"storage": "50GB", "users": "10", "support": "Email"}`, Limits: `{"api_calls_per_month": 100000, "max_users": 10, "storage_gb": 50}`, IsActive: true},
 		{ID: "550e8400-e29b-41d4-a716-446655440003", Name: "Enterprise", Slug: "enterprise", Price: 99.00, BillingPeriod: "monthly", Features: `{"storage": "Unlimited", "users": "Unlimited", "support": "Priority"}`, Limits: `{"api_calls_per_month": -1, "max_users": -1, "storage_gb": -1}`, IsActive: true},
 	}
 	for _, plan := range plans {
 		db.FirstOrCreate(&plan, entity.Plan{ID: plan.ID})
 	}
 
+	// Seed yearly prices (two months free)
+	prices := []entity.PlanPrice{
+		{ID: "550e8400-e29b-41d4-a716-446655440102", PlanID: "550e8400-e29b-41d4-a716-446655440002", Price: 290.00, BillingPeriod: "yearly", BillingInterval: 1, IsActive: true},
+		{ID: "550e8400-e29b-41d4-a716-446655440103", PlanID: "550e8400-e29b-41d4-a716-446655440003", Price: 990.00, BillingPeriod: "yearly", BillingInterval: 1, IsActive: true},
+	}
+	for _, price := range prices {
+		db.FirstOrCreate(&price, entity.PlanPrice{ID: price.ID})
+	}
+
+	// Seed Coupon (20% off the first three months)
+	coupon := entity.Coupon{ID: "850e8400-e29b-41d4-a716-446655440001", Code: "WELCOME20", Name: "Welcome 20% off", DiscountType: "percent", PercentOff: 20, Duration: "repeating", DurationInMonths: 3, IsActive: true}
+	db.FirstOrCreate(&coupon, entity.Coupon{ID: coupon.ID})
+
 	// Seed Organization
 	org := entity.Organization{ID: "650e8400-e29b-41d4-a716-446655440001", Name: "Demo Organization", Slug: "demo-org"}
 	db.FirstOrCreate(&org, entity.Organization{ID: org.ID})
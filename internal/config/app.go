@@ -4,9 +4,12 @@ import (
 	"go-clean-arch-saas/internal/delivery/http"
 	"go-clean-arch-saas/internal/delivery/http/middleware"
 	"go-clean-arch-saas/internal/delivery/http/route"
+	"go-clean-arch-saas/internal/delivery/scheduler"
 	"go-clean-arch-saas/internal/repository"
 	"go-clean-arch-saas/internal/usecase"
 	"go-clean-arch-saas/pkg/email"
+	"go-clean-arch-saas/pkg/invoice"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
@@ -23,7 +26,9 @@ type BootstrapConfig struct {
 	Config   *viper.Viper
 }
 
-func Bootstrap(config *BootstrapConfig) {
+// Bootstrap wires the application and registers its routes. The returned scheduler is not started;
+// cmd/web starts it so tests and tools can bootstrap without background jobs.
+func Bootstrap(config *BootstrapConfig) *scheduler.Scheduler {
 	// Auto migrate schema
 	// if err := db.RunAutoMigration(config.DB); err != nil {
 	// 	config.Log.Fatalf("Auto migration failed: %v", err)
@@ -52,6 +57,52 @@ func Bootstrap(config *BootstrapConfig) {
 	organizationMemberRepository := repository.NewOrganizationMemberRepository(config.Log)
 	planRepository := repository.NewPlanRepository(config.Log)
 	subscriptionRepository := repository.NewSubscriptionRepository(config.Log)
+	invitationRepository := repository.NewOrganizationInvitationRepository(config.Log)
+	sessionRepository := repository.NewSessionRepository(config.Log)
+	auditLogRepository := repository.NewAuditLogRepository(config.Log)
+	usageRecordRepository := repository.NewUsageRecordRepository(config.Log)
+	invoiceRepository := repository.NewInvoiceRepository(config.Log)
+	billingEventRepository := repository.NewBillingEventRepository(config.Log)
+	couponRepository := repository.NewCouponRepository(config.Log)
+	userCredentialRepository := repository.NewUserCredentialRepository(config.Log)
+	identityRepository := repository.NewIdentityRepository(config.Log)
+	ssoConnectionRepository := repository.NewSSOConnectionRepository(config.Log)
+	ssoDomainRepository := repository.NewSSODomainRepository(config.Log)
+
+	// setup services
+	auditService := usecase.NewAuditService(config.Log, auditLogRepository)
+	paymentProvider := NewPaymentProvider(config.Config, config.Log)
+	paymentService := usecase.NewPaymentService(config.Log, paymentProvider, organizationRepository, invoiceRepository)
+	couponService := usecase.NewCouponService(config.Log, couponRepository)
+	invoiceService := usecase.NewInvoiceService(config.Log, invoiceRepository, couponService, config.Config.GetString("billing.currency"))
+	entitlementService := usecase.NewEntitlementService(config.Log, subscriptionRepository, organizationMemberRepository, invitationRepository)
+	secretBox := NewSecretBox(config.Config, config.Log)
+	mfaService := NewMFAService(config.Config, config.Log, userRepository, secretBox)
+	webAuthnService := usecase.NewWebAuthnService(config.Log, userCredentialRepository, jwtService, NewRelyingParty(config.Config))
+	oauthService := NewOAuthService(config.Config, config.Log, secretBox)
+	seatService := usecase.NewSeatService(config.Log, subscriptionRepository, organizationMemberRepository, invoiceRepository, auditService)
+	ssoService := NewSSOService(
+		config.Config,
+		config.Log,
+		ssoConnectionRepository,
+		ssoDomainRepository,
+		userRepository,
+		organizationMemberRepository,
+		sessionRepository,
+		entitlementService,
+		seatService,
+		auditService,
+		secretBox,
+	)
+	usageMeter := usecase.NewUsageMeter(
+		config.DB,
+		config.Log,
+		usageRecordRepository,
+		entitlementService,
+		time.Duration(config.Config.GetInt("metering.flush_interval_seconds"))*time.Second,
+	)
+	usageMeter.Start()
+	config.App.Hooks().OnShutdown(usageMeter.Stop)
 
 	// setup use cases
 	authUseCase := usecase.NewAuthUseCase(
@@ -63,17 +114,60 @@ func Bootstrap(config *BootstrapConfig) {
 		organizationMemberRepository,
 		planRepository,
 		subscriptionRepository,
+		sessionRepository,
+		identityRepository,
+		auditService,
+		invoiceService,
+		mfaService,
+		webAuthnService,
+		oauthService,
+		ssoService,
 		jwtService,
 		emailService,
 		config.Config.GetString("base_url"),
 	)
-	userUseCase := usecase.NewUserUseCase(config.DB, config.Log, config.Validate, userRepository)
+	mfaUseCase := usecase.NewMFAUseCase(config.DB, config.Log, config.Validate, userRepository, mfaService, auditService)
+	webAuthnUseCase := usecase.NewWebAuthnUseCase(
+		config.DB,
+		config.Log,
+		config.Validate,
+		userRepository,
+		userCredentialRepository,
+		webAuthnService,
+		auditService,
+	)
+	ssoUseCase := usecase.NewSSOUseCase(
+		config.DB,
+		config.Log,
+		config.Validate,
+		ssoConnectionRepository,
+		ssoDomainRepository,
+		ssoService,
+		auditService,
+	)
+	userUseCase := usecase.NewUserUseCase(config.DB, config.Log, config.Validate, userRepository, organizationMemberRepository, auditService)
 	organizationUseCase := usecase.NewOrganizationUseCase(
 		config.DB,
 		config.Log,
 		config.Validate,
 		organizationRepository,
 		organizationMemberRepository,
+		auditService,
+		seatService,
+	)
+	invitationUseCase := usecase.NewInvitationUseCase(
+		config.DB,
+		config.Log,
+		config.Validate,
+		invitationRepository,
+		organizationRepository,
+		organizationMemberRepository,
+		userRepository,
+		auditService,
+		entitlementService,
+		seatService,
+		emailService,
+		config.Config.GetString("base_url"),
 	)
 	subscriptionUseCase := usecase.NewSubscriptionUseCase(
 		config.DB,
@@ -81,27 +175,97 @@ func Bootstrap(config *BootstrapConfig) {
 		config.Validate,
 		subscriptionRepository,
 		planRepository,
+		auditService,
+		entitlementService,
+		usageRecordRepository,
+		usageMeter,
+		invoiceService,
+		paymentService,
+		couponService,
+		invoiceRepository,
+		billingEventRepository,
+		organizationRepository,
+		organizationMemberRepository,
+		emailService,
+		config.Config.GetString("base_url"),
+		time.Duration(config.Config.GetInt("billing.trial_reminder_days"))*24*time.Hour,
+		NewDunningPolicy(config.Config, config.Log),
+	)
+	auditLogUseCase := usecase.NewAuditLogUseCase(config.DB, config.Log, config.Validate, auditLogRepository)
+	planUseCase := usecase.NewPlanUseCase(config.DB, config.Log, config.Validate, planRepository)
+	invoiceUseCase := usecase.NewInvoiceUseCase(
+		config.DB,
+		config.Log,
+		config.Validate,
+		invoiceRepository,
+		organizationRepository,
+		invoice.NewRenderer(config.Log),
+	)
+	billingUseCase := usecase.NewBillingUseCase(
+		config.DB,
+		config.Log,
+		config.Validate,
+		organizationRepository,
+		userRepository,
+		subscriptionRepository,
+		paymentProvider,
+		auditService,
 	)
 
 	// setup controllers
 	authController := http.NewAuthController(authUseCase, config.Log)
+	mfaController := http.NewMFAController(mfaUseCase, config.Log)
+	webAuthnController := http.NewWebAuthnController(webAuthnUseCase, config.Log)
+	ssoController := http.NewSSOController(ssoUseCase, config.Log)
 	userController := http.NewUserController(userUseCase, config.Log)
 	organizationController := http.NewOrganizationController(organizationUseCase, config.Log)
+	invitationController := http.NewInvitationController(invitationUseCase, config.Log)
 	subscriptionController := http.NewSubscriptionController(subscriptionUseCase, config.Log)
+	auditLogController := http.NewAuditLogController(auditLogUseCase, config.Log)
+	planController := http.NewPlanController(planUseCase, config.Log)
+	invoiceController := http.NewInvoiceController(invoiceUseCase, config.Log)
+	billingController := http.NewBillingController(billingUseCase, config.Log)
+	webhookController := http.NewWebhookController(subscriptionUseCase, paymentProvider.SignatureHeader(), config.Log)
 	healthController := http.NewHealthController(config.DB, config.Log)
 
 	// setup middleware
+	requestMetaMiddleware := middleware.NewRequestMeta()
 	authMiddleware := middleware.NewAuth(authUseCase)
+	usageMiddleware := middleware.NewUsageMetering(usageMeter, config.Config.GetBool("metering.enforce_quota"))
+	orgRoleMiddleware := middleware.NewOrgRole(organizationUseCase)
 
 	routeConfig := route.RouteConfig{
 		App:                    config.App,
 		AuthController:         authController,
+		MFAController:          mfaController,
+		WebAuthnController:     webAuthnController,
+		SSOController:          ssoController,
 		UserController:         userController,
 		OrganizationController: organizationController,
+		InvitationController:   invitationController,
 		SubscriptionController: subscriptionController,
+		AuditLogController:     auditLogController,
+		PlanController:         planController,
+		InvoiceController:      invoiceController,
+		BillingController:      billingController,
+		WebhookController:      webhookController,
 		HealthController:       healthController,
+		RequestMetaMiddleware:  requestMetaMiddleware,
 		AuthMiddleware:         authMiddleware,
+		UsageMiddleware:        usageMiddleware,
+		OrgRoleMiddleware:      orgRoleMiddleware,
 		Config:                 config.Config,
 	}
 	routeConfig.Setup()
+
+	return scheduler.NewScheduler(
+		config.DB,
+		config.Log,
+		time.Duration(config.Config.GetInt("scheduler.interval_seconds"))*time.Second,
+		scheduler.Job{Name: "renew_subscriptions", Run: subscriptionUseCase.RenewDueSubscriptions},
+		scheduler.Job{Name: "end_trials", Run: subscriptionUseCase.EndTrials},
+		scheduler.Job{Name: "retry_payments", Run: subscriptionUseCase.RetryPastDuePayments},
+		scheduler.Job{Name: "expire_subscriptions", Run: subscriptionUseCase.ExpireLapsedSubscriptions},
+		scheduler.Job{Name: "remind_trials", Run: subscriptionUseCase.RemindEndingTrials},
+	)
 }
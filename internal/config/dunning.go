@@ -0,0 +1,39 @@
+package config
+
+import (
+	"go-clean-arch-saas/internal/usecase"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewDunningPolicy reads billing.dunning_retry_days, a comma-separated list of days after a failed payment on
+// which it is retried, and billing.dunning_grace_days. Invalid days are skipped.
+func NewDunningPolicy(config *viper.Viper, log *logrus.Logger) usecase.DunningPolicy {
+	var days []int
+	for _, value := range strings.Split(config.GetString("billing.dunning_retry_days"), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		day, err := strconv.Atoi(value)
+		if err != nil || day <= 0 {
+			log.Warnf("Ignoring invalid billing.dunning_retry_days entry: %q", value)
+			continue
+		}
+		days = append(days, day)
+	}
+	slices.Sort(days)
+
+	policy := usecase.DunningPolicy{
+		GracePeriod: time.Duration(config.GetInt("billing.dunning_grace_days")) * 24 * time.Hour,
+	}
+	for _, day := range slices.Compact(days) {
+		policy.RetryDelays = append(policy.RetryDelays, time.Duration(day)*24*time.Hour)
+	}
+	return policy
+}
@@ -0,0 +1,18 @@
+package config
+
+import (
+	"go-clean-arch-saas/pkg/secret"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewSecretBox returns the box encrypting secrets stored in the database with a key derived from encryption.key.
+// Changing the key makes secrets stored with the old one unreadable.
+func NewSecretBox(config *viper.Viper, log *logrus.Logger) *secret.Box {
+	box, err := secret.NewBox(config.GetString("encryption.key"))
+	if err != nil {
+		log.Fatalf("Failed to create secret box: %v", err)
+	}
+	return box
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"go-clean-arch-saas/internal/model"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/spf13/viper"
 )
@@ -17,6 +19,13 @@ func NewFiber(config *viper.Viper) *fiber.App {
 
 func NewErrorHandler() fiber.ErrorHandler {
 	return func(ctx *fiber.Ctx, err error) error {
+		if e, ok := err.(*model.EntitlementError); ok {
+			return ctx.Status(e.Code).JSON(fiber.Map{
+				"errors":      e.Message,
+				"entitlement": entitlementErrorDetails(e),
+			})
+		}
+
 		code := fiber.StatusInternalServerError
 		if e, ok := err.(*fiber.Error); ok {
 			code = e.Code
@@ -27,3 +36,15 @@ func NewErrorHandler() fiber.ErrorHandler {
 		})
 	}
 }
+
+func entitlementErrorDetails(e *model.EntitlementError) fiber.Map {
+	if e.Feature != "" {
+		return fiber.Map{"feature": e.Feature}
+	}
+
+	return fiber.Map{
+		"limit":   e.Limit,
+		"allowed": e.Allowed,
+		"current": e.Current,
+	}
+}
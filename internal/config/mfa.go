@@ -0,0 +1,19 @@
+package config
+
+import (
+	"go-clean-arch-saas/internal/repository"
+	"go-clean-arch-saas/internal/usecase"
+	"go-clean-arch-saas/pkg/secret"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewMFAService reads mfa.issuer, the name authenticator apps list accounts under, which defaults to app.name
+func NewMFAService(config *viper.Viper, log *logrus.Logger, userRepository *repository.UserRepository, secretBox *secret.Box) *usecase.MFAService {
+	issuer := config.GetString("mfa.issuer")
+	if issuer == "" {
+		issuer = config.GetString("app.name")
+	}
+	return usecase.NewMFAService(log, userRepository, secretBox, issuer)
+}
@@ -13,7 +13,23 @@ func RunAutoMigration(db *gorm.DB) error {
 		&entity.User{},
 		&entity.OrganizationMember{},
 		&entity.Plan{},
+		&entity.PlanPrice{},
 		&entity.Subscription{},
 		&entity.AuditLog{},
+		&entity.OrganizationInvitation{},
+		&entity.Session{},
+		&entity.RecoveryCode{},
+		&entity.UserCredential{},
+		&entity.Identity{},
+		&entity.SSOConnection{},
+		&entity.SSODomain{},
+		&entity.UsageRecord{},
+		&entity.InvoiceSequence{},
+		&entity.Invoice{},
+		&entity.InvoiceLineItem{},
+		&entity.BillingEvent{},
+		&entity.Coupon{},
+		&entity.CouponRedemption{},
+		&entity.PendingInvoiceItem{},
 	)
 }
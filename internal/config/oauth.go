@@ -0,0 +1,57 @@
+package config
+
+import (
+	"go-clean-arch-saas/internal/usecase"
+	"go-clean-arch-saas/pkg/oauth"
+	"go-clean-arch-saas/pkg/secret"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// oauthPresets are providers whose endpoints have defaults, so only their client ID and secret need configuring
+var oauthPresets = []string{"google", "github"}
+
+// NewOAuthService reads the providers under oauth.providers. A provider is enabled once its client_id is set.
+// Each provider's redirect_url defaults to the frontend's {base_url}/auth/oauth/{provider}/callback.
+func NewOAuthService(config *viper.Viper, log *logrus.Logger, secretBox *secret.Box) *usecase.OAuthService {
+	names := append([]string{}, oauthPresets...)
+	for name := range config.GetStringMap("oauth.providers") {
+		names = append(names, name)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	providers := make(map[string]*oauth.Provider)
+	for _, name := range names {
+		key := "oauth.providers." + name + "."
+		if _, ok := providers[name]; ok || config.GetString(key+"client_id") == "" {
+			continue
+		}
+
+		redirectURL := config.GetString(key + "redirect_url")
+		if redirectURL == "" {
+			redirectURL = strings.TrimSuffix(config.GetString("base_url"), "/") + "/auth/oauth/" + name + "/callback"
+		}
+
+		providers[name] = &oauth.Provider{
+			Name:         name,
+			ClientID:     config.GetString(key + "client_id"),
+			ClientSecret: config.GetString(key + "client_secret"),
+			AuthURL:      config.GetString(key + "auth_url"),
+			TokenURL:     config.GetString(key + "token_url"),
+			UserInfoURL:  config.GetString(key + "userinfo_url"),
+			EmailsURL:    config.GetString(key + "emails_url"),
+			Issuer:       config.GetString(key + "issuer"),
+			JWKSURL:      config.GetString(key + "jwks_url"),
+			Scopes:       strings.Fields(strings.ReplaceAll(config.GetString(key+"scopes"), ",", " ")),
+			RedirectURL:  redirectURL,
+			Client:       client,
+		}
+		log.Infof("OAuth provider enabled: %s", name)
+	}
+
+	return usecase.NewOAuthService(log, providers, secretBox)
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"go-clean-arch-saas/pkg/payment"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewPaymentProvider returns the provider named by payment.provider. The fake provider needs no credentials;
+// without payment.webhook_secret it rejects every billing webhook, and Stripe refuses to start.
+func NewPaymentProvider(config *viper.Viper, log *logrus.Logger) payment.PaymentProvider {
+	webhookSecret := config.GetString("payment.webhook_secret")
+
+	switch provider := config.GetString("payment.provider"); provider {
+	case payment.ProviderStripe:
+		if webhookSecret == "" {
+			log.Fatal("payment.webhook_secret is required by the stripe payment provider")
+		}
+		return payment.NewStripeProvider(
+			log,
+			config.GetString("payment.stripe.secret_key"),
+			webhookSecret,
+			config.GetString("payment.stripe.base_url"),
+		)
+	case payment.ProviderFake:
+		if webhookSecret == "" {
+			log.Warn("payment.webhook_secret is not set; billing webhooks will be rejected")
+		}
+		return payment.NewFakeProvider(log, webhookSecret)
+	default:
+		log.Fatalf("Unknown payment provider: %s", provider)
+		return nil
+	}
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"go-clean-arch-saas/internal/repository"
+	"go-clean-arch-saas/internal/usecase"
+	"go-clean-arch-saas/pkg/oauth"
+	"go-clean-arch-saas/pkg/secret"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewSSOService reads sso.api_url, the public URL of the API including its prefix, which identity providers post
+// SAML responses to, and sso.callback_url, the frontend page SSO logins return to. They default to the API on
+// localhost and {base_url}/auth/sso/callback. Issuers of OIDC connections are fetched with a client limited to
+// public addresses unless sso.allow_private_issuers is set, for development against a local identity provider.
+func NewSSOService(
+	config *viper.Viper,
+	log *logrus.Logger,
+	connectionRepo *repository.SSOConnectionRepository,
+	domainRepo *repository.SSODomainRepository,
+	userRepo *repository.UserRepository,
+	orgMemberRepo *repository.OrganizationMemberRepository,
+	sessionRepo *repository.SessionRepository,
+	entitlementService *usecase.EntitlementService,
+	seatService *usecase.SeatService,
+	auditService *usecase.AuditService,
+	secretBox *secret.Box,
+) *usecase.SSOService {
+	apiURL := config.GetString("sso.api_url")
+	if apiURL == "" {
+		apiURL = fmt.Sprintf("http://localhost:%d%s/%s", config.GetInt("web.port"), config.GetString("api.prefix"), config.GetString("api.version"))
+	}
+
+	callbackURL := config.GetString("sso.callback_url")
+	if callbackURL == "" {
+		callbackURL = strings.TrimSuffix(config.GetString("base_url"), "/") + "/auth/sso/callback"
+	}
+
+	allowPrivateIssuers := config.GetBool("sso.allow_private_issuers")
+	client := oauth.NewPublicClient(30 * time.Second)
+	if allowPrivateIssuers {
+		log.Warn("sso.allow_private_issuers is set; organization owners can make the server fetch internal URLs")
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return usecase.NewSSOService(
+		log,
+		connectionRepo,
+		domainRepo,
+		userRepo,
+		orgMemberRepo,
+		sessionRepo,
+		entitlementService,
+		seatService,
+		auditService,
+		secretBox,
+		client,
+		allowPrivateIssuers,
+		apiURL,
+		callbackURL,
+	)
+}
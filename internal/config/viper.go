@@ -64,11 +64,37 @@ func NewViper() *viper.Viper {
 	config.BindEnv("jwt.secret", "JWT_SECRET")
 	config.BindEnv("jwt.access_expire_minutes", "JWT_ACCESS_EXPIRE_MINUTES")
 	config.BindEnv("jwt.refresh_expire_days", "JWT_REFRESH_EXPIRE_DAYS")
+	config.BindEnv("encryption.key", "ENCRYPTION_KEY")
+	config.BindEnv("mfa.issuer", "MFA_ISSUER")
+	config.BindEnv("webauthn.rp_id", "WEBAUTHN_RP_ID")
+	config.BindEnv("webauthn.rp_name", "WEBAUTHN_RP_NAME")
+	config.BindEnv("webauthn.origins", "WEBAUTHN_ORIGINS")
+	config.BindEnv("oauth.providers.google.client_id", "OAUTH_GOOGLE_CLIENT_ID")
+	config.BindEnv("oauth.providers.google.client_secret", "OAUTH_GOOGLE_CLIENT_SECRET")
+	config.BindEnv("oauth.providers.google.redirect_url", "OAUTH_GOOGLE_REDIRECT_URL")
+	config.BindEnv("oauth.providers.github.client_id", "OAUTH_GITHUB_CLIENT_ID")
+	config.BindEnv("oauth.providers.github.client_secret", "OAUTH_GITHUB_CLIENT_SECRET")
+	config.BindEnv("oauth.providers.github.redirect_url", "OAUTH_GITHUB_REDIRECT_URL")
+	config.BindEnv("sso.api_url", "SSO_API_URL")
+	config.BindEnv("sso.callback_url", "SSO_CALLBACK_URL")
+	config.BindEnv("sso.allow_private_issuers", "SSO_ALLOW_PRIVATE_ISSUERS")
 	config.BindEnv("cors.allowed_origins", "CORS_ALLOWED_ORIGINS")
 	config.BindEnv("cors.allowed_methods", "CORS_ALLOWED_METHODS")
 	config.BindEnv("cors.allowed_headers", "CORS_ALLOWED_HEADERS")
 	config.BindEnv("rate_limit.enabled", "RATE_LIMIT_ENABLED")
 	config.BindEnv("rate_limit.rpm", "RATE_LIMIT_RPM")
+	config.BindEnv("metering.flush_interval_seconds", "METERING_FLUSH_INTERVAL_SECONDS")
+	config.BindEnv("metering.enforce_quota", "METERING_ENFORCE_QUOTA")
+	config.BindEnv("scheduler.enabled", "SCHEDULER_ENABLED")
+	config.BindEnv("scheduler.interval_seconds", "SCHEDULER_INTERVAL_SECONDS")
+	config.BindEnv("billing.currency", "BILLING_CURRENCY")
+	config.BindEnv("billing.trial_reminder_days", "BILLING_TRIAL_REMINDER_DAYS")
+	config.BindEnv("billing.dunning_retry_days", "BILLING_DUNNING_RETRY_DAYS")
+	config.BindEnv("billing.dunning_grace_days", "BILLING_DUNNING_GRACE_DAYS")
+	config.BindEnv("payment.provider", "PAYMENT_PROVIDER")
+	config.BindEnv("payment.webhook_secret", "PAYMENT_WEBHOOK_SECRET")
+	config.BindEnv("payment.stripe.secret_key", "STRIPE_SECRET_KEY")
+	config.BindEnv("payment.stripe.base_url", "STRIPE_BASE_URL")
 	config.BindEnv("log.level", "LOG_LEVEL")
 	config.BindEnv("email.host", "EMAIL_HOST")
 	config.BindEnv("email.port", "EMAIL_PORT")
@@ -109,6 +135,38 @@ func setDefaults(config *viper.Viper) {
 	config.SetDefault("jwt.access_expire_minutes", 60)
 	config.SetDefault("jwt.refresh_expire_days", 7)
 
+	// Encryption of secrets stored in the database, such as TOTP keys
+	config.SetDefault("encryption.key", "your-encryption-key-change-in-production")
+
+	// Two-factor authentication defaults (empty issuer means app.name)
+	config.SetDefault("mfa.issuer", "")
+
+	// WebAuthn (passkey) defaults: empty name means app.name, empty origins means base_url
+	config.SetDefault("webauthn.rp_id", "localhost")
+	config.SetDefault("webauthn.rp_name", "")
+	config.SetDefault("webauthn.origins", "")
+
+	// OAuth provider presets: a provider is enabled once its client_id is set
+	config.SetDefault("oauth.providers.google.client_id", "")
+	config.SetDefault("oauth.providers.google.client_secret", "")
+	config.SetDefault("oauth.providers.google.auth_url", "https://accounts.google.com/o/oauth2/v2/auth")
+	config.SetDefault("oauth.providers.google.token_url", "https://oauth2.googleapis.com/token")
+	config.SetDefault("oauth.providers.google.issuer", "https://accounts.google.com")
+	config.SetDefault("oauth.providers.google.jwks_url", "https://www.googleapis.com/oauth2/v3/certs")
+	config.SetDefault("oauth.providers.google.scopes", "openid email profile")
+	config.SetDefault("oauth.providers.github.client_id", "")
+	config.SetDefault("oauth.providers.github.client_secret", "")
+	config.SetDefault("oauth.providers.github.auth_url", "https://github.com/login/oauth/authorize")
+	config.SetDefault("oauth.providers.github.token_url", "https://github.com/login/oauth/access_token")
+	config.SetDefault("oauth.providers.github.userinfo_url", "https://api.github.com/user")
+	config.SetDefault("oauth.providers.github.emails_url", "https://api.github.com/user/emails")
+	config.SetDefault("oauth.providers.github.scopes", "read:user user:email")
+
+	// Enterprise SSO defaults (empty URLs are derived from web.port and base_url)
+	config.SetDefault("sso.api_url", "")
+	config.SetDefault("sso.callback_url", "")
+	config.SetDefault("sso.allow_private_issuers", false)
+
 	// CORS defaults
 	config.SetDefault("cors.allowed_origins", "http://localhost:3000,http://localhost:8080")
 	config.SetDefault("cors.allowed_methods", "GET,POST,PUT,PATCH,DELETE")
@@ -118,6 +176,26 @@ func setDefaults(config *viper.Viper) {
 	config.SetDefault("rate_limit.enabled", false)
 	config.SetDefault("rate_limit.rpm", 1000)
 
+	// Usage metering defaults (quota enforcement is opt-in)
+	config.SetDefault("metering.flush_interval_seconds", 10)
+	config.SetDefault("metering.enforce_quota", false)
+
+	// Scheduler defaults (subscription renewal and expiry)
+	config.SetDefault("scheduler.enabled", true)
+	config.SetDefault("scheduler.interval_seconds", 60)
+
+	// Billing defaults (ISO 4217 code printed on invoices)
+	config.SetDefault("billing.currency", "USD")
+	config.SetDefault("billing.trial_reminder_days", 3)
+	config.SetDefault("billing.dunning_retry_days", "1,3,5")
+	config.SetDefault("billing.dunning_grace_days", 7)
+
+	// Payment defaults (the in-process fake provider needs no credentials)
+	config.SetDefault("payment.provider", "fake")
+	config.SetDefault("payment.webhook_secret", "")
+	config.SetDefault("payment.stripe.secret_key", "")
+	config.SetDefault("payment.stripe.base_url", "https://api.stripe.com")
+
 	// Logging defaults
 	config.SetDefault("log.level", 6)
 
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"go-clean-arch-saas/pkg/webauthn"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// NewRelyingParty reads webauthn.rp_id, the domain passkeys are scoped to, webauthn.rp_name, which defaults to
+// app.name, and webauthn.origins, a comma-separated list of origins the frontend runs on, which defaults to base_url
+func NewRelyingParty(config *viper.Viper) *webauthn.RelyingParty {
+	name := config.GetString("webauthn.rp_name")
+	if name == "" {
+		name = config.GetString("app.name")
+	}
+
+	origins := config.GetString("webauthn.origins")
+	if origins == "" {
+		origins = config.GetString("base_url")
+	}
+
+	relyingParty := &webauthn.RelyingParty{
+		ID:   config.GetString("webauthn.rp_id"),
+		Name: name,
+	}
+	for _, origin := range strings.Split(origins, ",") {
+		if origin = strings.TrimSuffix(strings.TrimSpace(origin), "/"); origin != "" {
+			relyingParty.Origins = append(relyingParty.Origins, origin)
+		}
+	}
+	return relyingParty
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"go-clean-arch-saas/internal/delivery/http/middleware"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/usecase"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type AuditLogController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.AuditLogUseCase
+}
+
+func NewAuditLogController(useCase *usecase.AuditLogUseCase, logger *logrus.Logger) *AuditLogController {
+	return &AuditLogController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+// List returns a page of audit history, or the whole filtered history when format is csv or ndjson
+func (c *AuditLogController) List(ctx *fiber.Ctx) error {
+	request := &model.SearchAuditLogRequest{
+		OrganizationID: middleware.GetOrganizationID(ctx),
+		UserID:         ctx.Query("user_id"),
+		Action:         ctx.Query("action"),
+		Resource:       ctx.Query("resource"),
+		ResourceID:     ctx.Query("resource_id"),
+		From:           int64(ctx.QueryInt("from", 0)),
+		To:             int64(ctx.QueryInt("to", 0)),
+		Page:           ctx.QueryInt("page", 1),
+		Size:           ctx.QueryInt("size", 20),
+	}
+
+	switch format := ctx.Query("format", "json"); format {
+	case "json":
+		response, err := c.UseCase.Search(ctx.UserContext(), request)
+		if err != nil {
+			c.Log.WithError(err).Warnf("Failed to search audit logs")
+			return err
+		}
+
+		return ctx.JSON(response)
+	case "csv", "ndjson":
+		responses, err := c.UseCase.Export(ctx.UserContext(), request)
+		if err != nil {
+			c.Log.WithError(err).Warnf("Failed to export audit logs")
+			return err
+		}
+
+		if format == "csv" {
+			return c.writeCSV(ctx, responses)
+		}
+		return c.writeNDJSON(ctx, responses)
+	default:
+		c.Log.Warnf("Unsupported audit log format: %s", format)
+		return fiber.NewError(fiber.StatusBadRequest, "Unsupported format, use json, csv or ndjson")
+	}
+}
+
+func (c *AuditLogController) writeCSV(ctx *fiber.Ctx, responses []model.AuditLogResponse) error {
+	buffer := new(bytes.Buffer)
+	writer := csv.NewWriter(buffer)
+	writer.Write([]string{"id", "created_at", "user_id", "action", "resource", "resource_id", "ip_address", "user_agent", "details"})
+	for _, response := range responses {
+		writer.Write([]string{
+			response.ID,
+			strconv.FormatInt(response.CreatedAt, 10),
+			response.UserID,
+			response.Action,
+			response.Resource,
+			response.ResourceID,
+			response.IPAddress,
+			response.UserAgent,
+			string(response.Details),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		c.Log.Warnf("Failed to write audit log csv: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	ctx.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	ctx.Set(fiber.HeaderContentDisposition, `attachment; filename="audit-logs.csv"`)
+	return ctx.Send(buffer.Bytes())
+}
+
+func (c *AuditLogController) writeNDJSON(ctx *fiber.Ctx, responses []model.AuditLogResponse) error {
+	buffer := new(bytes.Buffer)
+	encoder := json.NewEncoder(buffer)
+	for _, response := range responses {
+		if err := encoder.Encode(response); err != nil {
+			c.Log.Warnf("Failed to write audit log ndjson: %+v", err)
+			return fiber.ErrInternalServerError
+		}
+	}
+
+	ctx.Set(fiber.HeaderContentType, "application/x-ndjson")
+	ctx.Set(fiber.HeaderContentDisposition, `attachment; filename="audit-logs.ndjson"`)
+	return ctx.Send(buffer.Bytes())
+}
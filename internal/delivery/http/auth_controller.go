@@ -1,6 +1,7 @@
 package http
 
 import (
+	"go-clean-arch-saas/internal/delivery/http/middleware"
 	"go-clean-arch-saas/internal/model"
 	"go-clean-arch-saas/internal/usecase"
 
@@ -43,6 +44,8 @@ func (c *AuthController) Login(ctx *fiber.Ctx) error {
 		return fiber.ErrBadRequest
 	}
 
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+	request.IPAddress = ctx.IP()
 	response, err := c.AuthUseCase.Login(ctx.UserContext(), request)
 	if err != nil {
 		c.Log.Warnf("Failed to login: %+v", err)
@@ -52,6 +55,178 @@ func (c *AuthController) Login(ctx *fiber.Ctx) error {
 	return ctx.JSON(model.WebResponse[*model.LoginResponse]{Data: response})
 }
 
+func (c *AuthController) VerifyMFA(ctx *fiber.Ctx) error {
+	request := new(model.MFAVerifyRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.Warnf("Failed to parse request body: %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+	request.IPAddress = ctx.IP()
+	response, err := c.AuthUseCase.VerifyMFA(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to verify MFA: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.LoginResponse]{Data: response})
+}
+
+func (c *AuthController) BeginWebAuthnLogin(ctx *fiber.Ctx) error {
+	request := new(model.WebAuthnLoginBeginRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.Warnf("Failed to parse request body: %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	response, err := c.AuthUseCase.BeginWebAuthnLogin(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to begin passkey login: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.WebAuthnLoginBeginResponse]{Data: response})
+}
+
+func (c *AuthController) FinishWebAuthnLogin(ctx *fiber.Ctx) error {
+	request := new(model.WebAuthnLoginFinishRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.Warnf("Failed to parse request body: %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+	request.IPAddress = ctx.IP()
+	response, err := c.AuthUseCase.FinishWebAuthnLogin(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to finish passkey login: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.LoginResponse]{Data: response})
+}
+
+func (c *AuthController) ListOAuthProviders(ctx *fiber.Ctx) error {
+	response := c.AuthUseCase.ListOAuthProviders(ctx.UserContext())
+	return ctx.JSON(model.WebResponse[[]model.OAuthProviderResponse]{Data: response})
+}
+
+func (c *AuthController) AuthorizeOAuth(ctx *fiber.Ctx) error {
+	request := &model.OAuthAuthorizeRequest{
+		Provider: ctx.Params("provider"),
+	}
+
+	response, err := c.AuthUseCase.AuthorizeOAuth(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to start OAuth login: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.OAuthAuthorizeResponse]{Data: response})
+}
+
+func (c *AuthController) FinishOAuthLogin(ctx *fiber.Ctx) error {
+	request := new(model.OAuthCallbackRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.Warnf("Failed to parse request body: %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	request.Provider = ctx.Params("provider")
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+	request.IPAddress = ctx.IP()
+	response, err := c.AuthUseCase.FinishOAuthLogin(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to finish OAuth login: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.LoginResponse]{Data: response})
+}
+
+func (c *AuthController) AuthorizeSSO(ctx *fiber.Ctx) error {
+	request := new(model.SSOAuthorizeRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.Warnf("Failed to parse request body: %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	response, err := c.AuthUseCase.AuthorizeSSO(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to start SSO login: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.SSOAuthorizeResponse]{Data: response})
+}
+
+func (c *AuthController) FinishSSOLogin(ctx *fiber.Ctx) error {
+	request := new(model.SSOCallbackRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.Warnf("Failed to parse request body: %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+	request.IPAddress = ctx.IP()
+	response, err := c.AuthUseCase.FinishSSOLogin(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to finish SSO login: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.LoginResponse]{Data: response})
+}
+
+// ConsumeSAMLResponse is the assertion consumer service identity providers post SAML responses to, through the
+// user's browser, which is redirected on to the SSO callback page
+func (c *AuthController) ConsumeSAMLResponse(ctx *fiber.Ctx) error {
+	request := new(model.SAMLResponseRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.Warnf("Failed to parse request body: %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	request.ConnectionID = ctx.Params("connectionId")
+	redirectURL, err := c.AuthUseCase.ConsumeSAMLResponse(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to consume SAML response: %+v", err)
+		return err
+	}
+
+	return ctx.Redirect(redirectURL, fiber.StatusSeeOther)
+}
+
+func (c *AuthController) ListIdentities(ctx *fiber.Ctx) error {
+	request := &model.ListIdentitiesRequest{
+		UserID: middleware.GetUserID(ctx),
+	}
+
+	response, err := c.AuthUseCase.ListIdentities(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to list identities: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.IdentityResponse]{Data: response})
+}
+
+func (c *AuthController) DeleteIdentity(ctx *fiber.Ctx) error {
+	request := &model.DeleteIdentityRequest{
+		UserID:         middleware.GetUserID(ctx),
+		OrganizationID: middleware.GetOrganizationID(ctx),
+		ID:             ctx.Params("identityId"),
+	}
+
+	if err := c.AuthUseCase.DeleteIdentity(ctx.UserContext(), request); err != nil {
+		c.Log.Warnf("Failed to unlink identity: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[string]{Data: "Account unlinked successfully"})
+}
+
 func (c *AuthController) Refresh(ctx *fiber.Ctx) error {
 	request := new(model.RefreshTokenRequest)
 	if err := ctx.BodyParser(request); err != nil {
@@ -59,6 +234,8 @@ func (c *AuthController) Refresh(ctx *fiber.Ctx) error {
 		return fiber.ErrBadRequest
 	}
 
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+	request.IPAddress = ctx.IP()
 	response, err := c.AuthUseCase.Refresh(ctx.UserContext(), request)
 	if err != nil {
 		c.Log.Warnf("Failed to refresh token: %+v", err)
@@ -69,9 +246,15 @@ func (c *AuthController) Refresh(ctx *fiber.Ctx) error {
 }
 
 func (c *AuthController) Logout(ctx *fiber.Ctx) error {
-	userID := ctx.Locals("user_id").(string)
+	auth := middleware.GetAuth(ctx)
+
+	request := &model.LogoutRequest{
+		UserID:         auth.UserID,
+		OrganizationID: auth.OrganizationID,
+		SessionID:      auth.SessionID,
+	}
 
-	err := c.AuthUseCase.Logout(ctx.UserContext(), userID)
+	err := c.AuthUseCase.Logout(ctx.UserContext(), request)
 	if err != nil {
 		c.Log.Warnf("Failed to logout: %+v", err)
 		return err
@@ -111,3 +294,88 @@ func (c *AuthController) ResendVerification(ctx *fiber.Ctx) error {
 
 	return ctx.JSON(model.WebResponse[*model.ResendVerificationResponse]{Data: response})
 }
+
+func (c *AuthController) ForgotPassword(ctx *fiber.Ctx) error {
+	request := new(model.ForgotPasswordRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.Warnf("Failed to parse request body: %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	response, err := c.AuthUseCase.ForgotPassword(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to process forgot password: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.ForgotPasswordResponse]{Data: response})
+}
+
+func (c *AuthController) ResetPassword(ctx *fiber.Ctx) error {
+	request := new(model.ResetPasswordRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.Warnf("Failed to parse request body: %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	response, err := c.AuthUseCase.ResetPassword(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to reset password: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.ResetPasswordResponse]{Data: response})
+}
+
+func (c *AuthController) ListSessions(ctx *fiber.Ctx) error {
+	auth := middleware.GetAuth(ctx)
+
+	request := &model.ListSessionsRequest{
+		UserID:           auth.UserID,
+		CurrentSessionID: auth.SessionID,
+	}
+
+	response, err := c.AuthUseCase.ListSessions(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to list sessions: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.SessionResponse]{Data: response})
+}
+
+func (c *AuthController) RevokeSession(ctx *fiber.Ctx) error {
+	request := &model.RevokeSessionRequest{
+		UserID:         middleware.GetUserID(ctx),
+		OrganizationID: middleware.GetOrganizationID(ctx),
+		ID:             ctx.Params("sessionId"),
+	}
+
+	err := c.AuthUseCase.RevokeSession(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to revoke session: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[string]{Data: "Session revoked successfully"})
+}
+
+func (c *AuthController) SwitchOrganization(ctx *fiber.Ctx) error {
+	request := new(model.SwitchOrganizationRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.Warnf("Failed to parse request body: %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	auth := middleware.GetAuth(ctx)
+	request.UserID = auth.UserID
+	request.SessionID = auth.SessionID
+
+	response, err := c.AuthUseCase.SwitchOrganization(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to switch organization: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.SwitchOrganizationResponse]{Data: response})
+}
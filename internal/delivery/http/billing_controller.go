@@ -0,0 +1,40 @@
+package http
+
+import (
+	"go-clean-arch-saas/internal/delivery/http/middleware"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type BillingController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.BillingUseCase
+}
+
+func NewBillingController(useCase *usecase.BillingUseCase, logger *logrus.Logger) *BillingController {
+	return &BillingController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+func (c *BillingController) UpdatePaymentMethod(ctx *fiber.Ctx) error {
+	request := new(model.UpdatePaymentMethodRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.Warnf("Failed to parse request body: %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	request.OrganizationID = middleware.GetOrganizationID(ctx)
+	request.ActorID = middleware.GetUserID(ctx)
+	response, err := c.UseCase.UpdatePaymentMethod(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to update payment method")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.PaymentMethodResponse]{Data: response})
+}
@@ -0,0 +1,86 @@
+package http
+
+import (
+	"go-clean-arch-saas/internal/delivery/http/middleware"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type InvitationController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.InvitationUseCase
+}
+
+func NewInvitationController(useCase *usecase.InvitationUseCase, logger *logrus.Logger) *InvitationController {
+	return &InvitationController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+func (c *InvitationController) Create(ctx *fiber.Ctx) error {
+	request := new(model.CreateInvitationRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.Warnf("Failed to parse request body: %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	request.OrganizationID = middleware.GetOrganizationID(ctx)
+	request.InvitedBy = middleware.GetUserID(ctx)
+	response, err := c.UseCase.Create(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to create invitation")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.InvitationResponse]{Data: response})
+}
+
+func (c *InvitationController) List(ctx *fiber.Ctx) error {
+	request := &model.ListInvitationsRequest{
+		OrganizationID: middleware.GetOrganizationID(ctx),
+	}
+
+	response, err := c.UseCase.List(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to list invitations")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.InvitationResponse]{Data: response})
+}
+
+func (c *InvitationController) Revoke(ctx *fiber.Ctx) error {
+	request := &model.RevokeInvitationRequest{
+		OrganizationID: middleware.GetOrganizationID(ctx),
+		ActorID:        middleware.GetUserID(ctx),
+		ID:             ctx.Params("invitationId"),
+	}
+
+	err := c.UseCase.Revoke(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to revoke invitation")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[string]{Data: "Invitation revoked successfully"})
+}
+
+func (c *InvitationController) Accept(ctx *fiber.Ctx) error {
+	request := new(model.AcceptInvitationRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.Warnf("Failed to parse request body: %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	response, err := c.UseCase.Accept(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to accept invitation")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.AcceptInvitationResponse]{Data: response})
+}
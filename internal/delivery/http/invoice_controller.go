@@ -0,0 +1,69 @@
+package http
+
+import (
+	"go-clean-arch-saas/internal/delivery/http/middleware"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type InvoiceController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.InvoiceUseCase
+}
+
+func NewInvoiceController(useCase *usecase.InvoiceUseCase, logger *logrus.Logger) *InvoiceController {
+	return &InvoiceController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+func (c *InvoiceController) List(ctx *fiber.Ctx) error {
+	request := &model.SearchInvoiceRequest{
+		OrganizationID: middleware.GetOrganizationID(ctx),
+		Page:           ctx.QueryInt("page", 1),
+		Size:           ctx.QueryInt("size", 20),
+	}
+
+	response, err := c.UseCase.Search(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to search invoices")
+		return err
+	}
+
+	return ctx.JSON(response)
+}
+
+// Get returns an invoice with its line items, or the printable invoice when format is html
+func (c *InvoiceController) Get(ctx *fiber.Ctx) error {
+	request := &model.GetInvoiceRequest{
+		OrganizationID: middleware.GetOrganizationID(ctx),
+		ID:             ctx.Params("invoiceId"),
+	}
+
+	switch format := ctx.Query("format", "json"); format {
+	case "json":
+		response, err := c.UseCase.Get(ctx.UserContext(), request)
+		if err != nil {
+			c.Log.WithError(err).Warnf("Failed to get invoice")
+			return err
+		}
+
+		return ctx.JSON(model.WebResponse[*model.InvoiceResponse]{Data: response})
+	case "html":
+		body, err := c.UseCase.RenderHTML(ctx.UserContext(), request)
+		if err != nil {
+			c.Log.WithError(err).Warnf("Failed to render invoice")
+			return err
+		}
+
+		ctx.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return ctx.Send(body)
+	default:
+		c.Log.Warnf("Unsupported invoice format: %s", format)
+		return fiber.NewError(fiber.StatusBadRequest, "Unsupported format, use json or html")
+	}
+}
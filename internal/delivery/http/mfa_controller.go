@@ -0,0 +1,107 @@
+package http
+
+import (
+	"go-clean-arch-saas/internal/delivery/http/middleware"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type MFAController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.MFAUseCase
+}
+
+func NewMFAController(useCase *usecase.MFAUseCase, logger *logrus.Logger) *MFAController {
+	return &MFAController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+func (c *MFAController) Status(ctx *fiber.Ctx) error {
+	request := &model.MFAStatusRequest{
+		UserID: middleware.GetUserID(ctx),
+	}
+
+	response, err := c.UseCase.Status(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to get MFA status: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.MFAStatusResponse]{Data: response})
+}
+
+func (c *MFAController) Enroll(ctx *fiber.Ctx) error {
+	request := &model.MFAEnrollRequest{
+		UserID:         middleware.GetUserID(ctx),
+		OrganizationID: middleware.GetOrganizationID(ctx),
+	}
+
+	response, err := c.UseCase.Enroll(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to enroll in MFA: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.MFAEnrollResponse]{Data: response})
+}
+
+func (c *MFAController) Confirm(ctx *fiber.Ctx) error {
+	request, err := c.parseCodeRequest(ctx)
+	if err != nil {
+		return err
+	}
+
+	response, err := c.UseCase.Confirm(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to confirm MFA: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.MFARecoveryCodesResponse]{Data: response})
+}
+
+func (c *MFAController) Disable(ctx *fiber.Ctx) error {
+	request, err := c.parseCodeRequest(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := c.UseCase.Disable(ctx.UserContext(), request); err != nil {
+		c.Log.Warnf("Failed to disable MFA: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[string]{Data: "Two-factor authentication disabled"})
+}
+
+func (c *MFAController) RegenerateRecoveryCodes(ctx *fiber.Ctx) error {
+	request, err := c.parseCodeRequest(ctx)
+	if err != nil {
+		return err
+	}
+
+	response, err := c.UseCase.RegenerateRecoveryCodes(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to regenerate recovery codes: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.MFARecoveryCodesResponse]{Data: response})
+}
+
+func (c *MFAController) parseCodeRequest(ctx *fiber.Ctx) (*model.MFACodeRequest, error) {
+	request := new(model.MFACodeRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.Warnf("Failed to parse request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	request.UserID = middleware.GetUserID(ctx)
+	request.OrganizationID = middleware.GetOrganizationID(ctx)
+	return request, nil
+}
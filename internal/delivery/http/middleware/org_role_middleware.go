@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// OrgRoleMiddleware builds a handler that requires the caller to hold at least the given organization role
+type OrgRoleMiddleware func(role string) fiber.Handler
+
+// NewOrgRole must be mounted after NewAuth, since it relies on the user and organization set in context
+func NewOrgRole(organizationUseCase *usecase.OrganizationUseCase) OrgRoleMiddleware {
+	return func(role string) fiber.Handler {
+		return func(ctx *fiber.Ctx) error {
+			request := &model.AuthorizeOrganizationMemberRequest{
+				OrganizationID: GetOrganizationID(ctx),
+				UserID:         GetUserID(ctx),
+				Role:           role,
+			}
+
+			member, err := organizationUseCase.Authorize(ctx.UserContext(), request)
+			if err != nil {
+				organizationUseCase.Log.Warnf("Failed to authorize organization role: %+v", err)
+				return err
+			}
+
+			ctx.Locals("org_role", member.Role)
+
+			return ctx.Next()
+		}
+	}
+}
+
+func GetOrganizationRole(ctx *fiber.Ctx) string {
+	role, _ := ctx.Locals("org_role").(string)
+	return role
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"go-clean-arch-saas/internal/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NewRequestMeta stores the client IP and user agent in the user context so use cases can audit them
+func NewRequestMeta() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		meta := model.RequestMeta{
+			IPAddress: ctx.IP(),
+			UserAgent: ctx.Get(fiber.HeaderUserAgent),
+		}
+		ctx.SetUserContext(model.ContextWithRequestMeta(ctx.UserContext(), meta))
+
+		return ctx.Next()
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"go-clean-arch-saas/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NewUsageMetering counts each request against the caller's organization. With enforceQuota set,
+// requests beyond the plan's api_calls_per_month are rejected with 429. Must be mounted after NewAuth.
+func NewUsageMetering(meter *usecase.UsageMeter, enforceQuota bool) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		orgID := GetOrganizationID(ctx)
+		if orgID == "" {
+			return ctx.Next()
+		}
+
+		if enforceQuota {
+			if err := meter.CheckQuota(ctx.UserContext(), orgID); err != nil {
+				meter.Log.Warnf("Organization %s exceeded its API quota", orgID)
+				return err
+			}
+		}
+
+		meter.Record(orgID)
+		return ctx.Next()
+	}
+}
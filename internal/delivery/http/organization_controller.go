@@ -43,6 +43,7 @@ func (c *OrganizationController) Update(ctx *fiber.Ctx) error {
 	}
 
 	request.ID = orgID
+	request.ActorID = middleware.GetUserID(ctx)
 	response, err := c.UseCase.Update(ctx.UserContext(), request)
 	if err != nil {
 		c.Log.WithError(err).Warnf("Failed to update organization")
@@ -71,6 +72,7 @@ func (c *OrganizationController) RemoveMember(ctx *fiber.Ctx) error {
 	request := &model.RemoveOrganizationMemberRequest{
 		OrganizationID: orgID,
 		UserID:         userID,
+		ActorID:        middleware.GetUserID(ctx),
 	}
 
 	err := c.UseCase.RemoveMember(ctx.UserContext(), request)
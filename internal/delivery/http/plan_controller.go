@@ -0,0 +1,50 @@
+package http
+
+import (
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// planCacheControl lets browsers and CDNs reuse the catalog for five minutes, revalidating with the ETag after that
+const planCacheControl = "public, max-age=300"
+
+type PlanController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.PlanUseCase
+}
+
+func NewPlanController(useCase *usecase.PlanUseCase, logger *logrus.Logger) *PlanController {
+	return &PlanController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+func (c *PlanController) List(ctx *fiber.Ctx) error {
+	response, err := c.UseCase.List(ctx.UserContext())
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to list plans")
+		return err
+	}
+
+	ctx.Set(fiber.HeaderCacheControl, planCacheControl)
+	return ctx.JSON(model.WebResponse[[]model.PlanResponse]{Data: response})
+}
+
+func (c *PlanController) Get(ctx *fiber.Ctx) error {
+	request := &model.GetPlanRequest{
+		Slug: ctx.Params("slug"),
+	}
+
+	response, err := c.UseCase.GetBySlug(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to get plan")
+		return err
+	}
+
+	ctx.Set(fiber.HeaderCacheControl, planCacheControl)
+	return ctx.JSON(model.WebResponse[*model.PlanResponse]{Data: response})
+}
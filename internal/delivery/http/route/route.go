@@ -3,19 +3,34 @@ package route
 import (
 	"fmt"
 	"go-clean-arch-saas/internal/delivery/http"
+	"go-clean-arch-saas/internal/delivery/http/middleware"
+	"go-clean-arch-saas/internal/entity"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/etag"
 	"github.com/spf13/viper"
 )
 
 type RouteConfig struct {
 	App                    *fiber.App
 	AuthController         *http.AuthController
+	MFAController          *http.MFAController
+	WebAuthnController     *http.WebAuthnController
+	SSOController          *http.SSOController
 	UserController         *http.UserController
 	OrganizationController *http.OrganizationController
+	InvitationController   *http.InvitationController
 	SubscriptionController *http.SubscriptionController
+	AuditLogController     *http.AuditLogController
+	PlanController         *http.PlanController
+	InvoiceController      *http.InvoiceController
+	BillingController      *http.BillingController
+	WebhookController      *http.WebhookController
 	HealthController       *http.HealthController
+	RequestMetaMiddleware  fiber.Handler
 	AuthMiddleware         fiber.Handler
+	UsageMiddleware        fiber.Handler
+	OrgRoleMiddleware      middleware.OrgRoleMiddleware
 	Config                 *viper.Viper
 }
 
@@ -27,6 +42,7 @@ func (c *RouteConfig) getAPIBasePath() string {
 }
 
 func (c *RouteConfig) Setup() {
+	c.App.Use(c.RequestMetaMiddleware)
 	c.SetupHealthRoutes()
 	c.SetupGuestRoutes()
 	c.SetupAuthRoutes()
@@ -44,9 +60,34 @@ func (c *RouteConfig) SetupGuestRoutes() {
 	auth := api.Group("/auth")
 	auth.Post("/register", c.AuthController.Register)
 	auth.Post("/login", c.AuthController.Login)
+	auth.Post("/mfa/verify", c.AuthController.VerifyMFA)
+	auth.Post("/webauthn/login/begin", c.AuthController.BeginWebAuthnLogin)
+	auth.Post("/webauthn/login/finish", c.AuthController.FinishWebAuthnLogin)
+	auth.Get("/oauth/providers", c.AuthController.ListOAuthProviders)
+	auth.Post("/oauth/:provider/authorize", c.AuthController.AuthorizeOAuth)
+	auth.Post("/oauth/:provider/callback", c.AuthController.FinishOAuthLogin)
+	auth.Post("/sso/authorize", c.AuthController.AuthorizeSSO)
+	auth.Post("/sso/callback", c.AuthController.FinishSSOLogin)
+	auth.Post("/sso/saml/:connectionId/acs", c.AuthController.ConsumeSAMLResponse)
+	auth.Get("/sso/saml/:connectionId/metadata", c.SSOController.SAMLMetadata)
 	auth.Post("/refresh", c.AuthController.Refresh)
 	auth.Post("/verify-email", c.AuthController.VerifyEmail)
 	auth.Post("/resend-verification", c.AuthController.ResendVerification)
+	auth.Post("/forgot-password", c.AuthController.ForgotPassword)
+	auth.Post("/reset-password", c.AuthController.ResetPassword)
+
+	// Invitation routes (invitee may not have an account yet)
+	orgs := api.Group("/organizations")
+	orgs.Post("/invitations/accept", c.InvitationController.Accept)
+
+	// Plan catalog (public, cacheable with ETag revalidation)
+	plans := api.Group("/plans", etag.New())
+	plans.Get("/", c.PlanController.List)
+	plans.Get("/:slug", c.PlanController.Get)
+
+	// Payment provider webhooks (authenticated by their signature)
+	webhooks := api.Group("/webhooks")
+	webhooks.Post("/billing", c.WebhookController.Billing)
 }
 
 func (c *RouteConfig) SetupAuthRoutes() {
@@ -56,22 +97,68 @@ func (c *RouteConfig) SetupAuthRoutes() {
 	// Auth routes (authenticated)
 	auth := api.Group("/auth")
 	auth.Delete("/logout", c.AuthController.Logout)
+	auth.Get("/sessions", c.AuthController.ListSessions)
+	auth.Delete("/sessions/:sessionId", c.AuthController.RevokeSession)
+	auth.Get("/identities", c.AuthController.ListIdentities)
+	auth.Delete("/identities/:identityId", c.AuthController.DeleteIdentity)
+	auth.Post("/switch-organization", c.AuthController.SwitchOrganization)
+
+	// Two-factor authentication (TOTP) of the current user
+	mfa := auth.Group("/mfa")
+	mfa.Get("/", c.MFAController.Status)
+	mfa.Post("/enroll", c.MFAController.Enroll)
+	mfa.Post("/confirm", c.MFAController.Confirm)
+	mfa.Post("/disable", c.MFAController.Disable)
+	mfa.Post("/recovery-codes", c.MFAController.RegenerateRecoveryCodes)
+
+	// Passkeys (WebAuthn credentials) of the current user
+	webauthn := auth.Group("/webauthn")
+	webauthn.Post("/register/begin", c.WebAuthnController.BeginRegistration)
+	webauthn.Post("/register/finish", c.WebAuthnController.FinishRegistration)
+	webauthn.Get("/credentials", c.WebAuthnController.List)
+	webauthn.Delete("/credentials/:credentialId", c.WebAuthnController.Delete)
 
 	// User routes
-	users := api.Group("/users")
+	users := api.Group("/users", c.UsageMiddleware)
 	users.Get("/current", c.UserController.Current)
 	users.Patch("/current", c.UserController.Update)
+	users.Get("/current/organizations", c.UserController.ListOrganizations)
 
 	// Organization routes
-	orgs := api.Group("/organizations")
+	orgs := api.Group("/organizations", c.UsageMiddleware)
 	orgs.Get("/current", c.OrganizationController.GetCurrent)
-	orgs.Patch("/current", c.OrganizationController.Update)
+	orgs.Patch("/current", c.OrgRoleMiddleware(entity.OrgRoleAdmin), c.OrganizationController.Update)
 	orgs.Get("/members", c.OrganizationController.ListMembers)
-	orgs.Delete("/members/:userId", c.OrganizationController.RemoveMember)
+	orgs.Delete("/members/:userId", c.OrgRoleMiddleware(entity.OrgRoleAdmin), c.OrganizationController.RemoveMember)
+	orgs.Get("/invitations", c.OrgRoleMiddleware(entity.OrgRoleAdmin), c.InvitationController.List)
+	orgs.Post("/invitations", c.OrgRoleMiddleware(entity.OrgRoleAdmin), c.InvitationController.Create)
+	orgs.Delete("/invitations/:invitationId", c.OrgRoleMiddleware(entity.OrgRoleAdmin), c.InvitationController.Revoke)
+	orgs.Get("/audit-logs", c.OrgRoleMiddleware(entity.OrgRoleAdmin), c.AuditLogController.List)
 
-	// Subscription routes
+	// Single sign-on through the organization's identity provider (owner-only)
+	sso := orgs.Group("/sso", c.OrgRoleMiddleware(entity.OrgRoleOwner))
+	sso.Get("/", c.SSOController.Get)
+	sso.Put("/", c.SSOController.Upsert)
+	sso.Delete("/", c.SSOController.Delete)
+	sso.Get("/domains", c.SSOController.ListDomains)
+	sso.Post("/domains", c.SSOController.AddDomain)
+	sso.Post("/domains/:domainId/verify", c.SSOController.VerifyDomain)
+	sso.Delete("/domains/:domainId", c.SSOController.DeleteDomain)
+
+	// Subscription routes (plan changes and cancellation are owner-only; not metered so an organization
+	// over quota can still upgrade)
 	subs := api.Group("/subscriptions")
 	subs.Get("/current", c.SubscriptionController.GetCurrent)
-	subs.Post("/upgrade", c.SubscriptionController.Upgrade)
-	subs.Post("/cancel", c.SubscriptionController.Cancel)
+	subs.Get("/entitlements", c.SubscriptionController.GetEntitlements)
+	subs.Get("/usage", c.SubscriptionController.GetUsage)
+	subs.Post("/preview-change", c.OrgRoleMiddleware(entity.OrgRoleOwner), c.SubscriptionController.PreviewChange)
+	subs.Post("/upgrade", c.OrgRoleMiddleware(entity.OrgRoleOwner), c.SubscriptionController.Upgrade)
+	subs.Post("/cancel", c.OrgRoleMiddleware(entity.OrgRoleOwner), c.SubscriptionController.Cancel)
+	subs.Post("/resume", c.OrgRoleMiddleware(entity.OrgRoleOwner), c.SubscriptionController.Resume)
+
+	// Billing routes (owner-only)
+	billing := api.Group("/billing")
+	billing.Get("/invoices", c.OrgRoleMiddleware(entity.OrgRoleOwner), c.InvoiceController.List)
+	billing.Get("/invoices/:invoiceId", c.OrgRoleMiddleware(entity.OrgRoleOwner), c.InvoiceController.Get)
+	billing.Put("/payment-method", c.OrgRoleMiddleware(entity.OrgRoleOwner), c.BillingController.UpdatePaymentMethod)
 }
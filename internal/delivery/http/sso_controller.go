@@ -0,0 +1,143 @@
+package http
+
+import (
+	"go-clean-arch-saas/internal/delivery/http/middleware"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type SSOController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.SSOUseCase
+}
+
+func NewSSOController(useCase *usecase.SSOUseCase, logger *logrus.Logger) *SSOController {
+	return &SSOController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+func (c *SSOController) Get(ctx *fiber.Ctx) error {
+	request := &model.GetSSOConnectionRequest{
+		OrganizationID: middleware.GetOrganizationID(ctx),
+	}
+
+	response, err := c.UseCase.Get(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to get SSO connection: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.SSOConnectionResponse]{Data: response})
+}
+
+func (c *SSOController) Upsert(ctx *fiber.Ctx) error {
+	request := new(model.UpsertSSOConnectionRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.Warnf("Failed to parse request body: %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	request.OrganizationID = middleware.GetOrganizationID(ctx)
+	request.ActorID = middleware.GetUserID(ctx)
+	response, err := c.UseCase.Upsert(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to save SSO connection: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.SSOConnectionResponse]{Data: response})
+}
+
+func (c *SSOController) Delete(ctx *fiber.Ctx) error {
+	request := &model.DeleteSSOConnectionRequest{
+		OrganizationID: middleware.GetOrganizationID(ctx),
+		ActorID:        middleware.GetUserID(ctx),
+	}
+
+	if err := c.UseCase.Delete(ctx.UserContext(), request); err != nil {
+		c.Log.Warnf("Failed to delete SSO connection: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[string]{Data: "SSO connection removed successfully"})
+}
+
+func (c *SSOController) ListDomains(ctx *fiber.Ctx) error {
+	request := &model.ListSSODomainsRequest{
+		OrganizationID: middleware.GetOrganizationID(ctx),
+	}
+
+	response, err := c.UseCase.ListDomains(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to list SSO domains: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.SSODomainResponse]{Data: response})
+}
+
+func (c *SSOController) AddDomain(ctx *fiber.Ctx) error {
+	request := new(model.AddSSODomainRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.Warnf("Failed to parse request body: %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	request.OrganizationID = middleware.GetOrganizationID(ctx)
+	request.ActorID = middleware.GetUserID(ctx)
+	response, err := c.UseCase.AddDomain(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to add SSO domain: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.SSODomainResponse]{Data: response})
+}
+
+func (c *SSOController) VerifyDomain(ctx *fiber.Ctx) error {
+	request := &model.VerifySSODomainRequest{
+		OrganizationID: middleware.GetOrganizationID(ctx),
+		ActorID:        middleware.GetUserID(ctx),
+		ID:             ctx.Params("domainId"),
+	}
+
+	response, err := c.UseCase.VerifyDomain(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to verify SSO domain: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.SSODomainResponse]{Data: response})
+}
+
+func (c *SSOController) DeleteDomain(ctx *fiber.Ctx) error {
+	request := &model.DeleteSSODomainRequest{
+		OrganizationID: middleware.GetOrganizationID(ctx),
+		ActorID:        middleware.GetUserID(ctx),
+		ID:             ctx.Params("domainId"),
+	}
+
+	if err := c.UseCase.DeleteDomain(ctx.UserContext(), request); err != nil {
+		c.Log.Warnf("Failed to delete SSO domain: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[string]{Data: "SSO domain removed successfully"})
+}
+
+// SAMLMetadata serves a SAML connection's service provider metadata, which identity providers can import
+func (c *SSOController) SAMLMetadata(ctx *fiber.Ctx) error {
+	metadata, err := c.UseCase.SAMLMetadata(ctx.UserContext(), ctx.Params("connectionId"))
+	if err != nil {
+		c.Log.Warnf("Failed to get SAML metadata: %+v", err)
+		return err
+	}
+
+	ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationXMLCharsetUTF8)
+	return ctx.Send(metadata)
+}
@@ -43,6 +43,7 @@ func (c *SubscriptionController) Upgrade(ctx *fiber.Ctx) error {
 	}
 
 	request.OrganizationID = orgID
+	request.ActorID = middleware.GetUserID(ctx)
 	response, err := c.UseCase.Upgrade(ctx.UserContext(), request)
 	if err != nil {
 		c.Log.WithError(err).Warnf("Failed to upgrade subscription")
@@ -52,18 +53,84 @@ func (c *SubscriptionController) Upgrade(ctx *fiber.Ctx) error {
 	return ctx.JSON(model.WebResponse[*model.SubscriptionResponse]{Data: response})
 }
 
+func (c *SubscriptionController) PreviewChange(ctx *fiber.Ctx) error {
+	request := new(model.PreviewPlanChangeRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.Warnf("Failed to parse request body: %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	request.OrganizationID = middleware.GetOrganizationID(ctx)
+	response, err := c.UseCase.PreviewChange(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to preview plan change")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.ProrationResponse]{Data: response})
+}
+
 func (c *SubscriptionController) Cancel(ctx *fiber.Ctx) error {
 	orgID := middleware.GetOrganizationID(ctx)
 
-	request := &model.CancelSubscriptionRequest{
-		OrganizationID: orgID,
+	// The body is optional; without it the subscription ends at period end
+	request := new(model.CancelSubscriptionRequest)
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(request); err != nil {
+			c.Log.Warnf("Failed to parse request body: %+v", err)
+			return fiber.ErrBadRequest
+		}
 	}
 
+	request.OrganizationID = orgID
+	request.ActorID = middleware.GetUserID(ctx)
 	err := c.UseCase.Cancel(ctx.UserContext(), request)
 	if err != nil {
 		c.Log.WithError(err).Warnf("Failed to cancel subscription")
 		return err
 	}
 
-	return ctx.JSON(model.WebResponse[string]{Data: "Subscription cancelled successfully"})
+	if request.Immediately {
+		return ctx.JSON(model.WebResponse[string]{Data: "Subscription cancelled successfully"})
+	}
+	return ctx.JSON(model.WebResponse[string]{Data: "Subscription will be cancelled at the end of the current period"})
+}
+
+func (c *SubscriptionController) Resume(ctx *fiber.Ctx) error {
+	request := &model.ResumeSubscriptionRequest{
+		OrganizationID: middleware.GetOrganizationID(ctx),
+		ActorID:        middleware.GetUserID(ctx),
+	}
+
+	response, err := c.UseCase.Resume(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to resume subscription")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.SubscriptionResponse]{Data: response})
+}
+
+func (c *SubscriptionController) GetEntitlements(ctx *fiber.Ctx) error {
+	orgID := middleware.GetOrganizationID(ctx)
+
+	response, err := c.UseCase.GetEntitlements(ctx.UserContext(), orgID)
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to get entitlements")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.EntitlementsResponse]{Data: response})
+}
+
+func (c *SubscriptionController) GetUsage(ctx *fiber.Ctx) error {
+	orgID := middleware.GetOrganizationID(ctx)
+
+	response, err := c.UseCase.GetUsage(ctx.UserContext(), orgID)
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to get usage")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.UsageResponse]{Data: response})
 }
@@ -47,6 +47,7 @@ func (c *UserController) Update(ctx *fiber.Ctx) error {
 	}
 
 	request.ID = userID
+	request.OrganizationID = middleware.GetOrganizationID(ctx)
 	response, err := c.UseCase.Update(ctx.UserContext(), request)
 	if err != nil {
 		c.Log.WithError(err).Warnf("Failed to update user")
@@ -55,3 +56,18 @@ func (c *UserController) Update(ctx *fiber.Ctx) error {
 
 	return ctx.JSON(model.WebResponse[*model.UserResponse]{Data: response})
 }
+
+func (c *UserController) ListOrganizations(ctx *fiber.Ctx) error {
+	request := &model.ListUserOrganizationsRequest{
+		UserID:                middleware.GetUserID(ctx),
+		CurrentOrganizationID: middleware.GetOrganizationID(ctx),
+	}
+
+	response, err := c.UseCase.ListOrganizations(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to list user organizations")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.UserOrganizationResponse]{Data: response})
+}
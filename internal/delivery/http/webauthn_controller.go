@@ -0,0 +1,83 @@
+package http
+
+import (
+	"go-clean-arch-saas/internal/delivery/http/middleware"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type WebAuthnController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.WebAuthnUseCase
+}
+
+func NewWebAuthnController(useCase *usecase.WebAuthnUseCase, logger *logrus.Logger) *WebAuthnController {
+	return &WebAuthnController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+func (c *WebAuthnController) BeginRegistration(ctx *fiber.Ctx) error {
+	request := &model.WebAuthnRegisterBeginRequest{
+		UserID: middleware.GetUserID(ctx),
+	}
+
+	response, err := c.UseCase.BeginRegistration(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to begin passkey registration: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.WebAuthnRegisterBeginResponse]{Data: response})
+}
+
+func (c *WebAuthnController) FinishRegistration(ctx *fiber.Ctx) error {
+	request := new(model.WebAuthnRegisterFinishRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.Warnf("Failed to parse request body: %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	request.UserID = middleware.GetUserID(ctx)
+	request.OrganizationID = middleware.GetOrganizationID(ctx)
+	response, err := c.UseCase.FinishRegistration(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to finish passkey registration: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.WebAuthnCredentialResponse]{Data: response})
+}
+
+func (c *WebAuthnController) List(ctx *fiber.Ctx) error {
+	request := &model.ListWebAuthnCredentialsRequest{
+		UserID: middleware.GetUserID(ctx),
+	}
+
+	response, err := c.UseCase.List(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.Warnf("Failed to list passkeys: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.WebAuthnCredentialResponse]{Data: response})
+}
+
+func (c *WebAuthnController) Delete(ctx *fiber.Ctx) error {
+	request := &model.DeleteWebAuthnCredentialRequest{
+		UserID:         middleware.GetUserID(ctx),
+		OrganizationID: middleware.GetOrganizationID(ctx),
+		ID:             ctx.Params("credentialId"),
+	}
+
+	if err := c.UseCase.Delete(ctx.UserContext(), request); err != nil {
+		c.Log.Warnf("Failed to delete passkey: %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[string]{Data: "Passkey removed successfully"})
+}
@@ -0,0 +1,39 @@
+package http
+
+import (
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type WebhookController struct {
+	Log             *logrus.Logger
+	UseCase         *usecase.SubscriptionUseCase
+	SignatureHeader string
+}
+
+func NewWebhookController(useCase *usecase.SubscriptionUseCase, signatureHeader string, logger *logrus.Logger) *WebhookController {
+	return &WebhookController{
+		Log:             logger,
+		UseCase:         useCase,
+		SignatureHeader: signatureHeader,
+	}
+}
+
+// Billing receives payment provider events. The raw body is passed on because the signature covers its exact bytes.
+func (c *WebhookController) Billing(ctx *fiber.Ctx) error {
+	request := &model.BillingWebhookRequest{
+		Payload:   append([]byte(nil), ctx.Body()...),
+		Signature: ctx.Get(c.SignatureHeader),
+	}
+
+	response, err := c.UseCase.HandleBillingWebhook(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to handle billing webhook")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.BillingWebhookResponse]{Data: response})
+}
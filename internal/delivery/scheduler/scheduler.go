@@ -0,0 +1,115 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// leaderLockKey identifies the Postgres advisory lock held by the replica running the jobs
+const leaderLockKey int64 = 7236001
+
+// Job is a unit of background work run on every tick
+type Job struct {
+	Name string
+	Run  func(ctx context.Context) (int, error)
+}
+
+// Scheduler runs jobs periodically. Each run takes a Postgres advisory lock, so when several
+// replicas run the scheduler only one of them executes the jobs at a time.
+type Scheduler struct {
+	DB       *gorm.DB
+	Log      *logrus.Logger
+	Interval time.Duration
+	Jobs     []Job
+
+	cancel context.CancelFunc
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+func NewScheduler(db *gorm.DB, logger *logrus.Logger, interval time.Duration, jobs ...Job) *Scheduler {
+	return &Scheduler{
+		DB:       db,
+		Log:      logger,
+		Interval: interval,
+		Jobs:     jobs,
+	}
+}
+
+// Start runs the jobs immediately and then every Interval until Stop is called
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.stop = make(chan struct{})
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.Interval)
+		defer ticker.Stop()
+
+		for {
+			s.RunOnce(ctx)
+
+			select {
+			case <-ticker.C:
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+
+	s.Log.Infof("Scheduler started with %d jobs every %s", len(s.Jobs), s.Interval)
+}
+
+// Stop waits for the current run to finish and ends the loop. Jobs are not cancelled mid-run: they may
+// have charged a payment that must still be recorded.
+func (s *Scheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+
+	close(s.stop)
+	s.wg.Wait()
+	s.cancel()
+	s.cancel = nil
+}
+
+// RunOnce runs every job if this replica wins the leader lock, and reports whether it did
+func (s *Scheduler) RunOnce(ctx context.Context) bool {
+	// The transaction pins one connection; the lock is released when it ends
+	tx := s.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	var locked bool
+	if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", leaderLockKey).Scan(&locked).Error; err != nil {
+		s.Log.Warnf("Failed to acquire scheduler lock: %+v", err)
+		return false
+	}
+	if !locked {
+		s.Log.Debugf("Scheduler lock held by another replica, skipping run")
+		return false
+	}
+
+	for _, job := range s.Jobs {
+		if ctx.Err() != nil {
+			break
+		}
+
+		processed, err := job.Run(ctx)
+		if err != nil {
+			s.Log.Warnf("Scheduler job %s failed: %+v", job.Name, err)
+			continue
+		}
+		if processed > 0 {
+			s.Log.Infof("Scheduler job %s processed %d items", job.Name, processed)
+		}
+	}
+
+	return true
+}
@@ -1,13 +1,66 @@
 package entity
 
+// Audit action constants, formatted as "<resource>.<verb>"
+const (
+	AuditActionRegister             = "auth.register"
+	AuditActionLogin                = "auth.login"
+	AuditActionLogout               = "auth.logout"
+	AuditActionRefreshReuse         = "auth.refresh_reuse"
+	AuditActionSessionRevoke        = "auth.session_revoke"
+	AuditActionOrganizationSwitch   = "auth.organization_switch"
+	AuditActionMFAEnable            = "auth.mfa_enable"
+	AuditActionMFADisable           = "auth.mfa_disable"
+	AuditActionMFARecoveryCodes     = "auth.mfa_recovery_codes"
+	AuditActionPasskeyRegister      = "auth.passkey_register"
+	AuditActionPasskeyRemove        = "auth.passkey_remove"
+	AuditActionIdentityLink         = "auth.identity_link"
+	AuditActionIdentityUnlink       = "auth.identity_unlink"
+	AuditActionPasswordReset        = "user.password_reset"
+	AuditActionPasswordChange       = "user.password_change"
+	AuditActionUserUpdate           = "user.update"
+	AuditActionOrganizationUpdate   = "organization.update"
+	AuditActionMemberJoin           = "organization.member_join"
+	AuditActionMemberRemove         = "organization.member_remove"
+	AuditActionInvitationCreate     = "invitation.create"
+	AuditActionInvitationRevoke     = "invitation.revoke"
+	AuditActionSubscriptionUpgrade  = "subscription.upgrade"
+	AuditActionSubscriptionCancel   = "subscription.cancel"
+	AuditActionSubscriptionSchedule = "subscription.schedule_change"
+	AuditActionSubscriptionResume   = "subscription.resume"
+	AuditActionSubscriptionRenew    = "subscription.renew"
+	AuditActionSubscriptionExpire   = "subscription.expire"
+	AuditActionSubscriptionStatus   = "subscription.status_change"
+	AuditActionSubscriptionTrialEnd = "subscription.trial_end"
+	AuditActionSubscriptionRetry    = "subscription.payment_retry"
+	AuditActionSubscriptionQuantity = "subscription.quantity_change"
+	AuditActionPaymentMethodUpdate  = "billing.payment_method_update"
+	AuditActionSSOConnectionUpdate  = "sso.connection_update"
+	AuditActionSSOConnectionDelete  = "sso.connection_delete"
+	AuditActionSSODomainAdd         = "sso.domain_add"
+	AuditActionSSODomainVerify      = "sso.domain_verify"
+	AuditActionSSODomainRemove      = "sso.domain_remove"
+)
+
+// Audit resource constants
+const (
+	AuditResourceUser               = "user"
+	AuditResourceSession            = "session"
+	AuditResourceOrganization       = "organization"
+	AuditResourceOrganizationMember = "organization_member"
+	AuditResourceInvitation         = "invitation"
+	AuditResourceSubscription       = "subscription"
+	AuditResourceSSOConnection      = "sso_connection"
+	AuditResourceSSODomain          = "sso_domain"
+)
+
 // AuditLog is a struct that represents an audit log entity
 type AuditLog struct {
 	ID             string       `gorm:"column:id;primaryKey"`
-	UserID         string       `gorm:"column:user_id"`
-	OrganizationID string       `gorm:"column:organization_id"`
+	UserID         *string      `gorm:"column:user_id"`
+	OrganizationID *string      `gorm:"column:organization_id"`
 	Action         string       `gorm:"column:action"`
 	Resource       string       `gorm:"column:resource"`
-	ResourceID     string       `gorm:"column:resource_id"`
+	ResourceID     *string      `gorm:"column:resource_id"`
 	Details        string       `gorm:"column:details;type:json"`
 	IPAddress      string       `gorm:"column:ip_address"`
 	UserAgent      string       `gorm:"column:user_agent"`
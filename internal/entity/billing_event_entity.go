@@ -0,0 +1,16 @@
+package entity
+
+// BillingEvent is a struct that represents a webhook received from the payment provider
+type BillingEvent struct {
+	ID             string  `gorm:"column:id;primaryKey"`
+	Provider       string  `gorm:"column:provider;uniqueIndex:idx_billing_event_provider_event"`
+	EventID        string  `gorm:"column:event_id;uniqueIndex:idx_billing_event_provider_event"`
+	Type           string  `gorm:"column:type"`
+	Payload        string  `gorm:"column:payload"`
+	OrganizationID *string `gorm:"column:organization_id"`
+	CreatedAt      int64   `gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+func (b *BillingEvent) TableName() string {
+	return "billing_events"
+}
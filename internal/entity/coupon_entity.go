@@ -0,0 +1,51 @@
+package entity
+
+// Coupon discount types
+const (
+	CouponDiscountPercent = "percent"
+	CouponDiscountFixed   = "fixed"
+)
+
+// Coupon durations: the first invoice only, DurationInMonths months of invoices, or every invoice
+const (
+	CouponDurationOnce      = "once"
+	CouponDurationRepeating = "repeating"
+	CouponDurationForever   = "forever"
+)
+
+// Coupon is a struct that represents a discount redeemable with a promotion code
+type Coupon struct {
+	ID               string  `gorm:"column:id;primaryKey"`
+	Code             string  `gorm:"column:code;unique"`
+	Name             string  `gorm:"column:name"`
+	DiscountType     string  `gorm:"column:discount_type"`
+	PercentOff       float64 `gorm:"column:percent_off"`
+	AmountOff        float64 `gorm:"column:amount_off"`
+	Duration         string  `gorm:"column:duration"`
+	DurationInMonths int     `gorm:"column:duration_in_months"`
+	MaxRedemptions   *int    `gorm:"column:max_redemptions"`
+	TimesRedeemed    int     `gorm:"column:times_redeemed"`
+	ExpiresAt        *int64  `gorm:"column:expires_at"`
+	PlanIDs          *string `gorm:"column:plan_ids;type:json"`
+	IsActive         bool    `gorm:"column:is_active;default:true"`
+	CreatedAt        int64   `gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt        int64   `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
+	DeletedAt        *int64  `gorm:"column:deleted_at;index:idx_coupon_deleted"`
+}
+
+func (c *Coupon) TableName() string {
+	return "coupons"
+}
+
+// CouponRedemption is a struct that records an organization redeeming a coupon on a subscription
+type CouponRedemption struct {
+	ID             string `gorm:"column:id;primaryKey"`
+	CouponID       string `gorm:"column:coupon_id;uniqueIndex:idx_coupon_redemption_org"`
+	OrganizationID string `gorm:"column:organization_id;uniqueIndex:idx_coupon_redemption_org"`
+	SubscriptionID string `gorm:"column:subscription_id"`
+	CreatedAt      int64  `gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+func (c *CouponRedemption) TableName() string {
+	return "coupon_redemptions"
+}
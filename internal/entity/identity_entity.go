@@ -0,0 +1,18 @@
+package entity
+
+// Identity is a struct that represents a user's account at an external OAuth or OpenID Connect provider
+type Identity struct {
+	ID          string `gorm:"column:id;primaryKey"`
+	UserID      string `gorm:"column:user_id;index:idx_identity_user"`
+	Provider    string `gorm:"column:provider;uniqueIndex:idx_identity_provider_subject"`
+	Subject     string `gorm:"column:subject;uniqueIndex:idx_identity_provider_subject"` // the provider's stable user ID
+	Email       string `gorm:"column:email"`
+	LastLoginAt *int64 `gorm:"column:last_login_at"`
+	CreatedAt   int64  `gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt   int64  `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
+	User        User   `gorm:"foreignKey:user_id;references:id"`
+}
+
+func (i *Identity) TableName() string {
+	return "identities"
+}
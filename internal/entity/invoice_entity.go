@@ -0,0 +1,90 @@
+package entity
+
+// Invoice statuses
+const (
+	InvoiceStatusOpen     = "open"
+	InvoiceStatusPaid     = "paid"
+	InvoiceStatusRefunded = "refunded"
+)
+
+// Invoice line item kinds
+const (
+	InvoiceLineKindSubscription = "subscription"
+	InvoiceLineKindProration    = "proration"
+	InvoiceLineKindDiscount     = "discount"
+	InvoiceLineKindSeats        = "seats"
+)
+
+// Invoice is a struct that represents what an organization was charged for a subscription period
+type Invoice struct {
+	ID              string            `gorm:"column:id;primaryKey"`
+	OrganizationID  string            `gorm:"column:organization_id;uniqueIndex:idx_invoice_org_sequence"`
+	SubscriptionID  string            `gorm:"column:subscription_id;index:idx_invoice_subscription"`
+	Number          string            `gorm:"column:number"`
+	Sequence        int64             `gorm:"column:sequence;uniqueIndex:idx_invoice_org_sequence"`
+	Status          string            `gorm:"column:status"`
+	Currency        string            `gorm:"column:currency"`
+	Total           float64           `gorm:"column:total"`
+	AmountDue       float64           `gorm:"column:amount_due"`
+	PeriodStart     int64             `gorm:"column:period_start"`
+	PeriodEnd       int64             `gorm:"column:period_end"`
+	IssuedAt        int64             `gorm:"column:issued_at"`
+	PaidAt          *int64            `gorm:"column:paid_at"`
+	PaymentChargeID *string           `gorm:"column:payment_charge_id"`
+	CreatedAt       int64             `gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt       int64             `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
+	LineItems       []InvoiceLineItem `gorm:"foreignKey:invoice_id;references:id"`
+}
+
+func (i *Invoice) TableName() string {
+	return "invoices"
+}
+
+// InvoiceLineItem is a struct that represents one charge or credit on an invoice
+type InvoiceLineItem struct {
+	ID          string  `gorm:"column:id;primaryKey"`
+	InvoiceID   string  `gorm:"column:invoice_id;index:idx_invoice_line_invoice"`
+	Position    int     `gorm:"column:position"`
+	Kind        string  `gorm:"column:kind"`
+	Description string  `gorm:"column:description"`
+	PlanID      *string `gorm:"column:plan_id"`
+	Quantity    int64   `gorm:"column:quantity"`
+	UnitAmount  float64 `gorm:"column:unit_amount"`
+	Amount      float64 `gorm:"column:amount"`
+	PeriodStart int64   `gorm:"column:period_start"`
+	PeriodEnd   int64   `gorm:"column:period_end"`
+	CreatedAt   int64   `gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+func (i *InvoiceLineItem) TableName() string {
+	return "invoice_line_items"
+}
+
+// PendingInvoiceItem is a charge or credit waiting to be added to the subscription's next invoice
+type PendingInvoiceItem struct {
+	ID             string  `gorm:"column:id;primaryKey"`
+	SubscriptionID string  `gorm:"column:subscription_id;index:idx_pending_invoice_item_subscription"`
+	Kind           string  `gorm:"column:kind"`
+	Description    string  `gorm:"column:description"`
+	PlanID         *string `gorm:"column:plan_id"`
+	Quantity       int64   `gorm:"column:quantity"`
+	UnitAmount     float64 `gorm:"column:unit_amount"`
+	Amount         float64 `gorm:"column:amount"`
+	PeriodStart    int64   `gorm:"column:period_start"`
+	PeriodEnd      int64   `gorm:"column:period_end"`
+	CreatedAt      int64   `gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+func (i *PendingInvoiceItem) TableName() string {
+	return "pending_invoice_items"
+}
+
+// InvoiceSequence is a struct that holds the last invoice number issued to an organization
+type InvoiceSequence struct {
+	OrganizationID string `gorm:"column:organization_id;primaryKey"`
+	LastNumber     int64  `gorm:"column:last_number"`
+}
+
+func (i *InvoiceSequence) TableName() string {
+	return "invoice_sequences"
+}
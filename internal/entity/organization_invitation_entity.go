@@ -0,0 +1,34 @@
+package entity
+
+// Invitation status constants
+const (
+	InvitationStatusPending  = "pending"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusRevoked  = "revoked"
+)
+
+// OrganizationInvitation is a struct that represents an invitation to join an organization
+type OrganizationInvitation struct {
+	ID             string       `gorm:"column:id;primaryKey"`
+	OrganizationID string       `gorm:"column:organization_id"`
+	Email          string       `gorm:"column:email;index:idx_invitation_email"`
+	Role           string       `gorm:"column:role;default:member"`
+	TokenHash      string       `gorm:"column:token_hash;unique"`
+	Status         string       `gorm:"column:status;default:pending;index:idx_invitation_status"`
+	InvitedBy      *string      `gorm:"column:invited_by"`
+	ExpiresAt      int64        `gorm:"column:expires_at"`
+	AcceptedAt     *int64       `gorm:"column:accepted_at"`
+	CreatedAt      int64        `gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt      int64        `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
+	DeletedAt      *int64       `gorm:"column:deleted_at;index:idx_invitation_deleted"`
+	Organization   Organization `gorm:"foreignKey:organization_id;references:id"`
+}
+
+func (i *OrganizationInvitation) TableName() string {
+	return "organization_invitations"
+}
+
+// IsPending checks if invitation can still be accepted (pending and not expired)
+func (i *OrganizationInvitation) IsPending(now int64) bool {
+	return i.Status == InvitationStatusPending && i.ExpiresAt > now
+}
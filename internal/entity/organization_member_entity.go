@@ -36,3 +36,17 @@ func (o *OrganizationMember) IsAdmin() bool {
 func (o *OrganizationMember) IsMember() bool {
 	return o.Role == OrgRoleMember || o.IsAdmin()
 }
+
+// HasRole checks if member has at least the given role (owner > admin > member)
+func (o *OrganizationMember) HasRole(role string) bool {
+	switch role {
+	case OrgRoleOwner:
+		return o.IsOwner()
+	case OrgRoleAdmin:
+		return o.IsAdmin()
+	case OrgRoleMember:
+		return o.IsMember()
+	default:
+		return false
+	}
+}
@@ -1,20 +1,65 @@
 package entity
 
-// Plan is a struct that represents a subscription plan entity
+// Plan is a struct that represents a subscription plan entity. Price is charged every BillingInterval
+// BillingPeriods; Prices are other variants of the plan, e.g. a yearly price.
 type Plan struct {
-	ID            string  `gorm:"column:id;primaryKey"`
-	Name          string  `gorm:"column:name"`
-	Slug          string  `gorm:"column:slug;unique"`
-	Price         float64 `gorm:"column:price"`
-	BillingPeriod string  `gorm:"column:billing_period"`
-	Features      string  `gorm:"column:features;type:json"`
-	Limits        string  `gorm:"column:limits;type:json"`
-	IsActive      bool    `gorm:"column:is_active;default:true"`
-	CreatedAt     int64   `gorm:"column:created_at;autoCreateTime:milli"`
-	UpdatedAt     int64   `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
-	DeletedAt     *int64  `gorm:"column:deleted_at;index:idx_plan_deleted"`
+	ID              string      `gorm:"column:id;primaryKey"`
+	Name            string      `gorm:"column:name"`
+	Slug            string      `gorm:"column:slug;unique"`
+	Price           float64     `gorm:"column:price"`
+	BillingPeriod   string      `gorm:"column:billing_period"`
+	BillingInterval int         `gorm:"column:billing_interval;default:1"`
+	PricingModel    string      `gorm:"column:pricing_model;default:flat"`
+	TrialDays       int         `gorm:"column:trial_days"`
+	Features        string      `gorm:"column:features;type:json"`
+	Limits          string      `gorm:"column:limits;type:json"`
+	IsActive        bool        `gorm:"column:is_active;default:true"`
+	CreatedAt       int64       `gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt       int64       `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
+	DeletedAt       *int64      `gorm:"column:deleted_at;index:idx_plan_deleted"`
+	Prices          []PlanPrice `gorm:"foreignKey:plan_id;references:id"`
 }
 
 func (p *Plan) TableName() string {
 	return "plans"
 }
+
+// PlanPrice is a struct that represents a price variant of a plan
+type PlanPrice struct {
+	ID              string  `gorm:"column:id;primaryKey"`
+	PlanID          string  `gorm:"column:plan_id;uniqueIndex:idx_plan_price_period"`
+	Price           float64 `gorm:"column:price"`
+	BillingPeriod   string  `gorm:"column:billing_period;uniqueIndex:idx_plan_price_period"`
+	BillingInterval int     `gorm:"column:billing_interval;default:1;uniqueIndex:idx_plan_price_period"`
+	IsActive        bool    `gorm:"column:is_active;default:true"`
+	CreatedAt       int64   `gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt       int64   `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
+}
+
+func (p *PlanPrice) TableName() string {
+	return "plan_prices"
+}
+
+// Plan pricing models. A per-seat plan's price is charged for every member of the organization.
+const (
+	PricingModelFlat    = "flat"
+	PricingModelPerSeat = "per_seat"
+)
+
+// Plan billing periods
+const (
+	BillingPeriodDaily   = "daily"
+	BillingPeriodWeekly  = "weekly"
+	BillingPeriodMonthly = "monthly"
+	BillingPeriodYearly  = "yearly"
+)
+
+// Keys of the Plan.Limits JSON object
+const (
+	PlanLimitMaxUsers         = "max_users"
+	PlanLimitAPICallsPerMonth = "api_calls_per_month"
+	PlanLimitStorageGB        = "storage_gb"
+)
+
+// PlanLimitUnlimited marks a limit without a cap. Limits missing from Plan.Limits are unlimited too.
+const PlanLimitUnlimited int64 = -1
@@ -0,0 +1,36 @@
+package entity
+
+// Session is a struct that represents a refresh token issued to a device.
+// Rotated tokens keep their row (with RotatedAt set) so replays can be detected.
+type Session struct {
+	ID              string  `gorm:"column:id;primaryKey"`
+	FamilyID        string  `gorm:"column:family_id;index:idx_session_family"`
+	UserID          string  `gorm:"column:user_id;index:idx_session_user"`
+	OrganizationID  *string `gorm:"column:organization_id"`
+	TokenHash       string  `gorm:"column:token_hash;unique"`
+	UserAgent       string  `gorm:"column:user_agent"`
+	IPAddress       string  `gorm:"column:ip_address"`
+	AuthenticatedAt int64   `gorm:"column:authenticated_at"`
+	LastUsedAt      int64   `gorm:"column:last_used_at"`
+	ExpiresAt       int64   `gorm:"column:expires_at"`
+	RotatedAt       *int64  `gorm:"column:rotated_at"`
+	RevokedAt       *int64  `gorm:"column:revoked_at"`
+	CreatedAt       int64   `gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt       int64   `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
+	DeletedAt       *int64  `gorm:"column:deleted_at;index:idx_session_deleted"`
+	User            User    `gorm:"foreignKey:user_id;references:id"`
+}
+
+func (s *Session) TableName() string {
+	return "sessions"
+}
+
+// IsRotated checks if the token was already exchanged for a newer one
+func (s *Session) IsRotated() bool {
+	return s.RotatedAt != nil
+}
+
+// IsRevoked checks if the session was explicitly revoked
+func (s *Session) IsRevoked() bool {
+	return s.RevokedAt != nil
+}
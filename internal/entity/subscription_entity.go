@@ -1,18 +1,47 @@
 package entity
 
+// Subscription statuses
+const (
+	SubscriptionStatusTrialing  = "trialing"
+	SubscriptionStatusActive    = "active"
+	SubscriptionStatusPastDue   = "past_due"
+	SubscriptionStatusCancelled = "cancelled"
+	SubscriptionStatusExpired   = "expired"
+)
+
+// SubscriptionCurrentStatuses are the statuses of an organization's current subscription.
+// A trialing subscription has the plan for free until trial_ends_at; a past_due one keeps its plan while
+// payment is retried, until grace_period_ends_at.
+var SubscriptionCurrentStatuses = []string{SubscriptionStatusTrialing, SubscriptionStatusActive, SubscriptionStatusPastDue}
+
 // Subscription is a struct that represents a subscription entity
 type Subscription struct {
-	ID                 string       `gorm:"column:id;primaryKey"`
-	OrganizationID     string       `gorm:"column:organization_id"`
-	PlanID             string       `gorm:"column:plan_id"`
-	Status             string       `gorm:"column:status;default:active"`
-	CurrentPeriodStart int64        `gorm:"column:current_period_start"`
-	CurrentPeriodEnd   int64        `gorm:"column:current_period_end"`
-	CreatedAt          int64        `gorm:"column:created_at;autoCreateTime:milli"`
-	UpdatedAt          int64        `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
-	DeletedAt          *int64       `gorm:"column:deleted_at;index:idx_sub_deleted"`
-	Organization       Organization `gorm:"foreignKey:organization_id;references:id"`
-	Plan               Plan         `gorm:"foreignKey:plan_id;references:id"`
+	ID                   string       `gorm:"column:id;primaryKey"`
+	OrganizationID       string       `gorm:"column:organization_id"`
+	PlanID               string       `gorm:"column:plan_id"`
+	PlanPriceID          *string      `gorm:"column:plan_price_id"`
+	Status               string       `gorm:"column:status;default:active"`
+	Quantity             int          `gorm:"column:quantity;default:1"`
+	CurrentPeriodStart   int64        `gorm:"column:current_period_start"`
+	CurrentPeriodEnd     int64        `gorm:"column:current_period_end"`
+	CancelAtPeriodEnd    bool         `gorm:"column:cancel_at_period_end"`
+	CancelledAt          *int64       `gorm:"column:cancelled_at"`
+	ScheduledPlanID      *string      `gorm:"column:scheduled_plan_id"`
+	ScheduledPlanPriceID *string      `gorm:"column:scheduled_plan_price_id"`
+	TrialEndsAt          *int64       `gorm:"column:trial_ends_at;index:idx_sub_trial_ends_at"`
+	TrialReminderSentAt  *int64       `gorm:"column:trial_reminder_sent_at"`
+	CouponID             *string      `gorm:"column:coupon_id"`
+	DiscountEndsAt       *int64       `gorm:"column:discount_ends_at"`
+	PastDueAt            *int64       `gorm:"column:past_due_at"`
+	GracePeriodEndsAt    *int64       `gorm:"column:grace_period_ends_at"`
+	PaymentRetryCount    int          `gorm:"column:payment_retry_count"`
+	NextDunningAt        *int64       `gorm:"column:next_dunning_at;index:idx_sub_next_dunning_at"`
+	CreatedAt            int64        `gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt            int64        `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
+	DeletedAt            *int64       `gorm:"column:deleted_at;index:idx_sub_deleted"`
+	Organization         Organization `gorm:"foreignKey:organization_id;references:id"`
+	Plan                 Plan         `gorm:"foreignKey:plan_id;references:id"`
+	PlanPrice            *PlanPrice   `gorm:"foreignKey:plan_price_id;references:id"`
 }
 
 func (s *Subscription) TableName() string {
@@ -0,0 +1,22 @@
+package entity
+
+// Metrics counted in usage_records
+const (
+	UsageMetricAPICalls = "api_calls"
+)
+
+// UsageRecord is a struct that represents an organization's consumption of a metric in one period
+type UsageRecord struct {
+	ID             string `gorm:"column:id;primaryKey"`
+	OrganizationID string `gorm:"column:organization_id;uniqueIndex:idx_usage_org_metric_period"`
+	Metric         string `gorm:"column:metric;uniqueIndex:idx_usage_org_metric_period"`
+	PeriodStart    int64  `gorm:"column:period_start;uniqueIndex:idx_usage_org_metric_period"`
+	PeriodEnd      int64  `gorm:"column:period_end"`
+	Quantity       int64  `gorm:"column:quantity"`
+	CreatedAt      int64  `gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt      int64  `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
+}
+
+func (u *UsageRecord) TableName() string {
+	return "usage_records"
+}
@@ -0,0 +1,22 @@
+package entity
+
+// UserCredential is a struct that represents a WebAuthn credential (passkey or security key) a user signs in with
+type UserCredential struct {
+	ID           string  `gorm:"column:id;primaryKey"`
+	UserID       string  `gorm:"column:user_id;index:idx_user_credential_user"`
+	CredentialID string  `gorm:"column:credential_id;unique"` // base64url, as sent by browsers
+	PublicKey    []byte  `gorm:"column:public_key"`           // COSE_Key encoding
+	Algorithm    int     `gorm:"column:algorithm"`
+	SignCount    int64   `gorm:"column:sign_count"`
+	AAGUID       string  `gorm:"column:aaguid"` // authenticator model, hex encoded
+	Transports   *string `gorm:"column:transports"`
+	Name         string  `gorm:"column:name"`
+	LastUsedAt   *int64  `gorm:"column:last_used_at"`
+	CreatedAt    int64   `gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt    int64   `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
+	User         User    `gorm:"foreignKey:user_id;references:id"`
+}
+
+func (c *UserCredential) TableName() string {
+	return "user_credentials"
+}
@@ -10,27 +10,47 @@ const (
 
 // User is a struct that represents a user entity
 type User struct {
-	ID                    string       `gorm:"column:id;primaryKey"`
-	Name                  string       `gorm:"column:name"`
-	Email                 string       `gorm:"column:email;unique"`
-	Password              string       `gorm:"column:password"`
-	SystemRole            string       `gorm:"column:system_role;default:user;index:idx_users_system_role"`
-	EmailVerified         bool         `gorm:"column:email_verified;default:0"`
-	EmailVerifiedAt       *int64       `gorm:"column:email_verified_at"`
-	VerificationToken     *string      `gorm:"column:verification_token;index:idx_users_verification_token"`
-	RefreshToken          string       `gorm:"column:refresh_token"`
-	RefreshTokenExpiresAt int64        `gorm:"column:refresh_token_expires_at"`
-	OrganizationID        string       `gorm:"column:organization_id"`
-	CreatedAt             int64        `gorm:"column:created_at;autoCreateTime:milli"`
-	UpdatedAt             int64        `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
-	DeletedAt             *int64       `gorm:"column:deleted_at;index:idx_users_deleted"`
-	Organization          Organization `gorm:"foreignKey:organization_id;references:id"`
+	ID                     string       `gorm:"column:id;primaryKey"`
+	Name                   string       `gorm:"column:name"`
+	Email                  string       `gorm:"column:email;unique"`
+	Password               string       `gorm:"column:password"`
+	SystemRole             string       `gorm:"column:system_role;default:user;index:idx_users_system_role"`
+	EmailVerified          bool         `gorm:"column:email_verified;default:0"`
+	EmailVerifiedAt        *int64       `gorm:"column:email_verified_at"`
+	VerificationToken      *string      `gorm:"column:verification_token;index:idx_users_verification_token"`
+	PasswordResetToken     *string      `gorm:"column:password_reset_token;index:idx_users_password_reset_token"`
+	PasswordResetExpiresAt *int64       `gorm:"column:password_reset_expires_at"`
+	MFAEnabled             bool         `gorm:"column:mfa_enabled;default:false"`
+	MFASecret              *string      `gorm:"column:mfa_secret"` // encrypted TOTP secret, set from enrollment on
+	MFAEnabledAt           *int64       `gorm:"column:mfa_enabled_at"`
+	MFALastUsedStep        int64        `gorm:"column:mfa_last_used_step"` // TOTP time step of the last accepted code
+	MFAChallengeHash       *string      `gorm:"column:mfa_challenge_hash"` // hash of the pending login's MFA challenge
+	MFAFailedAttempts      int          `gorm:"column:mfa_failed_attempts"`
+	MFALockedUntil         *int64       `gorm:"column:mfa_locked_until"`
+	OrganizationID         string       `gorm:"column:organization_id"`
+	CreatedAt              int64        `gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt              int64        `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
+	DeletedAt              *int64       `gorm:"column:deleted_at;index:idx_users_deleted"`
+	Organization           Organization `gorm:"foreignKey:organization_id;references:id"`
 }
 
 func (u *User) TableName() string {
 	return "users"
 }
 
+// RecoveryCode is a struct that represents a one-time code signing a user in when their authenticator is lost
+type RecoveryCode struct {
+	ID        string `gorm:"column:id;primaryKey"`
+	UserID    string `gorm:"column:user_id;index:idx_recovery_code_user"`
+	CodeHash  string `gorm:"column:code_hash;unique"`
+	UsedAt    *int64 `gorm:"column:used_at"`
+	CreatedAt int64  `gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+func (r *RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
+
 // IsSystemAdmin checks if user has platform admin access
 func (u *User) IsSystemAdmin() bool {
 	return u.SystemRole == SystemRoleAdmin || u.SystemRole == SystemRoleSuperAdmin
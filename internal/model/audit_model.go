@@ -0,0 +1,61 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// AuditEvent describes a change to be recorded in the audit log
+type AuditEvent struct {
+	UserID         string
+	OrganizationID string
+	Action         string
+	Resource       string
+	ResourceID     string
+	Details        map[string]interface{}
+}
+
+// RequestMeta carries client information of the HTTP request through the use case context
+type RequestMeta struct {
+	IPAddress string
+	UserAgent string
+}
+
+type requestMetaKey struct{}
+
+// ContextWithRequestMeta returns a copy of ctx carrying the request metadata
+func ContextWithRequestMeta(ctx context.Context, meta RequestMeta) context.Context {
+	return context.WithValue(ctx, requestMetaKey{}, meta)
+}
+
+// RequestMetaFromContext returns the request metadata, or an empty value outside an HTTP request
+func RequestMetaFromContext(ctx context.Context) RequestMeta {
+	meta, _ := ctx.Value(requestMetaKey{}).(RequestMeta)
+	return meta
+}
+
+type AuditLogResponse struct {
+	ID             string          `json:"id"`
+	UserID         string          `json:"user_id,omitempty"`
+	OrganizationID string          `json:"organization_id,omitempty"`
+	Action         string          `json:"action"`
+	Resource       string          `json:"resource"`
+	ResourceID     string          `json:"resource_id,omitempty"`
+	Details        json.RawMessage `json:"details"`
+	IPAddress      string          `json:"ip_address"`
+	UserAgent      string          `json:"user_agent"`
+	CreatedAt      int64           `json:"created_at"`
+}
+
+// SearchAuditLogRequest filters an organization's audit history. From and To are inclusive millisecond timestamps.
+type SearchAuditLogRequest struct {
+	OrganizationID string `json:"-" validate:"required,max=100"`
+	UserID         string `json:"user_id" validate:"omitempty,uuid"`
+	Action         string `json:"action" validate:"max=100"`
+	Resource       string `json:"resource" validate:"max=100"`
+	ResourceID     string `json:"resource_id" validate:"omitempty,uuid"`
+	From           int64  `json:"from" validate:"min=0"`
+	To             int64  `json:"to" validate:"min=0"`
+	Page           int    `json:"page" validate:"min=1"`
+	Size           int    `json:"size" validate:"min=1,max=100"`
+}
@@ -4,6 +4,7 @@ type Auth struct {
 	UserID         string
 	Email          string
 	OrganizationID string
+	SessionID      string
 }
 
 // RegisterRequest represents user registration request
@@ -12,6 +13,7 @@ type RegisterRequest struct {
 	Email            string `json:"email" validate:"required,email,max=255"`
 	Password         string `json:"password" validate:"required,min=8,max=100"`
 	OrganizationName string `json:"organization_name" validate:"required,max=200"`
+	Plan             string `json:"plan" validate:"omitempty,max=100"` // slug of a plan to trial; empty means free
 }
 
 // RegisterResponse represents user registration response
@@ -22,29 +24,61 @@ type RegisterResponse struct {
 
 // LoginRequest represents user login request
 type LoginRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required"`
+	Email     string `json:"email" validate:"required,email"`
+	Password  string `json:"password" validate:"required"`
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
 }
 
-// LoginResponse represents user login response with JWT tokens
+// LoginResponse represents user login response with JWT tokens.
+// For users with two-factor authentication it only carries an MFA challenge token, to be exchanged at /auth/mfa/verify.
 type LoginResponse struct {
-	AccessToken  string       `json:"access_token"`
-	RefreshToken string       `json:"refresh_token"`
-	ExpiresIn    int          `json:"expires_in"`
-	TokenType    string       `json:"token_type"`
-	User         UserResponse `json:"user"`
+	AccessToken  string        `json:"access_token,omitempty"`
+	RefreshToken string        `json:"refresh_token,omitempty"`
+	ExpiresIn    int           `json:"expires_in,omitempty"`
+	TokenType    string        `json:"token_type,omitempty"`
+	User         *UserResponse `json:"user,omitempty"`
+	MFARequired  bool          `json:"mfa_required,omitempty"`
+	MFAToken     string        `json:"mfa_token,omitempty"`
 }
 
 // RefreshTokenRequest represents refresh token request
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" validate:"required"`
+	UserAgent    string `json:"-"`
+	IPAddress    string `json:"-"`
 }
 
-// RefreshTokenResponse represents refresh token response
+// RefreshTokenResponse represents refresh token response.
+// The refresh token is rotated on every call; the old one must be discarded.
 type RefreshTokenResponse struct {
-	AccessToken string `json:"access_token"`
-	ExpiresIn   int    `json:"expires_in"`
-	TokenType   string `json:"token_type"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	TokenType    string `json:"token_type"`
+}
+
+// LogoutRequest represents logout request for the current session
+type LogoutRequest struct {
+	UserID         string `json:"-" validate:"required,max=100"`
+	OrganizationID string `json:"-"`
+	SessionID      string `json:"-" validate:"required,max=100"`
+}
+
+// SwitchOrganizationRequest represents switch organization request
+type SwitchOrganizationRequest struct {
+	UserID         string `json:"-" validate:"required,max=100"`
+	SessionID      string `json:"-" validate:"required,max=100"`
+	OrganizationID string `json:"organization_id" validate:"required,max=100"`
+}
+
+// SwitchOrganizationResponse represents a new access token scoped to the chosen organization
+type SwitchOrganizationResponse struct {
+	AccessToken  string               `json:"access_token"`
+	ExpiresIn    int                  `json:"expires_in"`
+	TokenType    string               `json:"token_type"`
+	Organization OrganizationResponse `json:"organization"`
+	Role         string               `json:"role"`
 }
 
 // VerifyUserRequest represents verify user request (for middleware)
@@ -71,3 +105,24 @@ type ResendVerificationRequest struct {
 type ResendVerificationResponse struct {
 	Message string `json:"message"`
 }
+
+// ForgotPasswordRequest represents forgot password request
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ForgotPasswordResponse represents forgot password response
+type ForgotPasswordResponse struct {
+	Message string `json:"message"`
+}
+
+// ResetPasswordRequest represents reset password request
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8,max=100"`
+}
+
+// ResetPasswordResponse represents reset password response
+type ResetPasswordResponse struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,24 @@
+package model
+
+type UpdatePaymentMethodRequest struct {
+	OrganizationID  string `json:"-" validate:"required,max=100"`
+	ActorID         string `json:"-" validate:"required,max=100"`
+	PaymentMethodID string `json:"payment_method_id" validate:"required,max=255"`
+}
+
+type PaymentMethodResponse struct {
+	CustomerID      string `json:"customer_id"`
+	PaymentMethodID string `json:"payment_method_id"`
+}
+
+// BillingWebhookRequest is a payment provider webhook as received, before its signature is verified
+type BillingWebhookRequest struct {
+	Payload   []byte `json:"-"`
+	Signature string `json:"-" validate:"required,max=1000"`
+}
+
+type BillingWebhookResponse struct {
+	EventID   string `json:"event_id"`
+	Type      string `json:"type"`
+	Duplicate bool   `json:"duplicate"`
+}
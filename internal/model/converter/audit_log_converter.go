@@ -0,0 +1,34 @@
+package converter
+
+import (
+	"encoding/json"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+)
+
+func AuditLogToResponse(auditLog *entity.AuditLog) *model.AuditLogResponse {
+	details := json.RawMessage(auditLog.Details)
+	if len(details) == 0 {
+		details = json.RawMessage("{}")
+	}
+
+	return &model.AuditLogResponse{
+		ID:             auditLog.ID,
+		UserID:         stringValue(auditLog.UserID),
+		OrganizationID: stringValue(auditLog.OrganizationID),
+		Action:         auditLog.Action,
+		Resource:       auditLog.Resource,
+		ResourceID:     stringValue(auditLog.ResourceID),
+		Details:        details,
+		IPAddress:      auditLog.IPAddress,
+		UserAgent:      auditLog.UserAgent,
+		CreatedAt:      auditLog.CreatedAt,
+	}
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,16 @@
+package converter
+
+import (
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+)
+
+func IdentityToResponse(identity *entity.Identity) *model.IdentityResponse {
+	return &model.IdentityResponse{
+		ID:          identity.ID,
+		Provider:    identity.Provider,
+		Email:       identity.Email,
+		LastLoginAt: identity.LastLoginAt,
+		CreatedAt:   identity.CreatedAt,
+	}
+}
@@ -0,0 +1,20 @@
+package converter
+
+import (
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+)
+
+func InvitationToResponse(invitation *entity.OrganizationInvitation) *model.InvitationResponse {
+	return &model.InvitationResponse{
+		ID:             invitation.ID,
+		OrganizationID: invitation.OrganizationID,
+		Email:          invitation.Email,
+		Role:           invitation.Role,
+		Status:         invitation.Status,
+		InvitedBy:      invitation.InvitedBy,
+		ExpiresAt:      invitation.ExpiresAt,
+		AcceptedAt:     invitation.AcceptedAt,
+		CreatedAt:      invitation.CreatedAt,
+	}
+}
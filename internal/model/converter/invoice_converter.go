@@ -0,0 +1,41 @@
+package converter
+
+import (
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+)
+
+func InvoiceToResponse(invoice *entity.Invoice) *model.InvoiceResponse {
+	response := &model.InvoiceResponse{
+		ID:             invoice.ID,
+		OrganizationID: invoice.OrganizationID,
+		SubscriptionID: invoice.SubscriptionID,
+		Number:         invoice.Number,
+		Status:         invoice.Status,
+		Currency:       invoice.Currency,
+		Total:          invoice.Total,
+		AmountDue:      invoice.AmountDue,
+		PeriodStart:    invoice.PeriodStart,
+		PeriodEnd:      invoice.PeriodEnd,
+		IssuedAt:       invoice.IssuedAt,
+		PaidAt:         invoice.PaidAt,
+		CreatedAt:      invoice.CreatedAt,
+		UpdatedAt:      invoice.UpdatedAt,
+	}
+
+	for _, item := range invoice.LineItems {
+		response.LineItems = append(response.LineItems, model.InvoiceLineItemResponse{
+			ID:          item.ID,
+			Kind:        item.Kind,
+			Description: item.Description,
+			PlanID:      stringValue(item.PlanID),
+			Quantity:    item.Quantity,
+			UnitAmount:  item.UnitAmount,
+			Amount:      item.Amount,
+			PeriodStart: item.PeriodStart,
+			PeriodEnd:   item.PeriodEnd,
+		})
+	}
+
+	return response
+}
@@ -28,3 +28,12 @@ func OrganizationMemberToResponse(member *entity.OrganizationMember) *model.Orga
 
 	return response
 }
+
+func UserOrganizationToResponse(member *entity.OrganizationMember, currentOrgID string) *model.UserOrganizationResponse {
+	return &model.UserOrganizationResponse{
+		Organization: *OrganizationToResponse(&member.Organization),
+		Role:         member.Role,
+		JoinedAt:     member.JoinedAt,
+		Current:      member.OrganizationID == currentOrgID,
+	}
+}
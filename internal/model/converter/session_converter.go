@@ -0,0 +1,18 @@
+package converter
+
+import (
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+)
+
+func SessionToResponse(session *entity.Session, currentSessionID string) *model.SessionResponse {
+	return &model.SessionResponse{
+		ID:              session.FamilyID,
+		UserAgent:       session.UserAgent,
+		IPAddress:       session.IPAddress,
+		Current:         session.FamilyID == currentSessionID,
+		AuthenticatedAt: session.AuthenticatedAt,
+		LastUsedAt:      session.LastUsedAt,
+		ExpiresAt:       session.ExpiresAt,
+	}
+}
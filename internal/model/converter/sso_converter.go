@@ -0,0 +1,33 @@
+package converter
+
+import (
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+)
+
+func SSOConnectionToResponse(connection *entity.SSOConnection) *model.SSOConnectionResponse {
+	return &model.SSOConnectionResponse{
+		ID:           connection.ID,
+		Protocol:     connection.Protocol,
+		EnforceSSO:   connection.EnforceSSO,
+		DefaultRole:  connection.DefaultRole,
+		OIDCIssuer:   connection.OIDCIssuer,
+		OIDCClientID: connection.OIDCClientID,
+		SAMLEntityID: connection.SAMLEntityID,
+		SAMLSSOURL:   connection.SAMLSSOURL,
+		CreatedAt:    connection.CreatedAt,
+		UpdatedAt:    connection.UpdatedAt,
+	}
+}
+
+func SSODomainToResponse(domain *entity.SSODomain) *model.SSODomainResponse {
+	return &model.SSODomainResponse{
+		ID:                 domain.ID,
+		Domain:             domain.Domain,
+		Verified:           domain.IsVerified(),
+		VerificationRecord: domain.VerificationRecord(),
+		VerificationValue:  domain.VerificationValue(),
+		VerifiedAt:         domain.VerifiedAt,
+		CreatedAt:          domain.CreatedAt,
+	}
+}
@@ -18,33 +18,67 @@ func PlanToResponse(plan *entity.Plan) *model.PlanResponse {
 		json.Unmarshal([]byte(plan.Limits), &limits)
 	}
 
-	return &model.PlanResponse{
-		ID:            plan.ID,
-		Name:          plan.Name,
-		Slug:          plan.Slug,
-		Price:         plan.Price,
-		BillingPeriod: plan.BillingPeriod,
-		Features:      features,
-		Limits:        limits,
-		IsActive:      plan.IsActive,
-		CreatedAt:     plan.CreatedAt,
-		UpdatedAt:     plan.UpdatedAt,
+	response := &model.PlanResponse{
+		ID:              plan.ID,
+		Name:            plan.Name,
+		Slug:            plan.Slug,
+		Price:           plan.Price,
+		PricingModel:    plan.PricingModel,
+		BillingPeriod:   plan.BillingPeriod,
+		BillingInterval: plan.BillingInterval,
+		TrialDays:       plan.TrialDays,
+		Features:        features,
+		Limits:          limits,
+		IsActive:        plan.IsActive,
+		CreatedAt:       plan.CreatedAt,
+		UpdatedAt:       plan.UpdatedAt,
+	}
+
+	for _, price := range plan.Prices {
+		response.Prices = append(response.Prices, *PlanPriceToResponse(&price))
+	}
+
+	return response
+}
+
+func PlanPriceToResponse(price *entity.PlanPrice) *model.PlanPriceResponse {
+	return &model.PlanPriceResponse{
+		ID:              price.ID,
+		Price:           price.Price,
+		BillingPeriod:   price.BillingPeriod,
+		BillingInterval: price.BillingInterval,
 	}
 }
 
 func SubscriptionToResponse(subscription *entity.Subscription) *model.SubscriptionResponse {
 	response := &model.SubscriptionResponse{
-		ID:                 subscription.ID,
-		OrganizationID:     subscription.OrganizationID,
-		Status:             subscription.Status,
-		CurrentPeriodStart: subscription.CurrentPeriodStart,
-		CurrentPeriodEnd:   subscription.CurrentPeriodEnd,
-		CreatedAt:          subscription.CreatedAt,
-		UpdatedAt:          subscription.UpdatedAt,
+		ID:                   subscription.ID,
+		OrganizationID:       subscription.OrganizationID,
+		PlanPriceID:          subscription.PlanPriceID,
+		Status:               subscription.Status,
+		Quantity:             subscription.Quantity,
+		CurrentPeriodStart:   subscription.CurrentPeriodStart,
+		CurrentPeriodEnd:     subscription.CurrentPeriodEnd,
+		CancelAtPeriodEnd:    subscription.CancelAtPeriodEnd,
+		CancelledAt:          subscription.CancelledAt,
+		ScheduledPlanID:      subscription.ScheduledPlanID,
+		ScheduledPlanPriceID: subscription.ScheduledPlanPriceID,
+		TrialEndsAt:          subscription.TrialEndsAt,
+		CouponID:             subscription.CouponID,
+		DiscountEndsAt:       subscription.DiscountEndsAt,
+		InGracePeriod:        subscription.Status == entity.SubscriptionStatusPastDue,
+		GracePeriodEndsAt:    subscription.GracePeriodEndsAt,
+		CreatedAt:            subscription.CreatedAt,
+		UpdatedAt:            subscription.UpdatedAt,
 	}
 
 	if subscription.Plan.ID != "" {
 		response.Plan = *PlanToResponse(&subscription.Plan)
+		if price := subscription.PlanPrice; price != nil {
+			response.Plan.Price = price.Price
+			response.Plan.BillingPeriod = price.BillingPeriod
+			response.Plan.BillingInterval = price.BillingInterval
+		}
 	}
 
 	return response
@@ -11,6 +11,7 @@ func UserToResponse(user *entity.User) *model.UserResponse {
 		Name:           user.Name,
 		Email:          user.Email,
 		EmailVerified:  user.EmailVerified,
+		MFAEnabled:     user.MFAEnabled,
 		OrganizationID: user.OrganizationID,
 		CreatedAt:      user.CreatedAt,
 		UpdatedAt:      user.UpdatedAt,
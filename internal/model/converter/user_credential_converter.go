@@ -0,0 +1,20 @@
+package converter
+
+import (
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+	"strings"
+)
+
+func UserCredentialToResponse(credential *entity.UserCredential) *model.WebAuthnCredentialResponse {
+	response := &model.WebAuthnCredentialResponse{
+		ID:         credential.ID,
+		Name:       credential.Name,
+		LastUsedAt: credential.LastUsedAt,
+		CreatedAt:  credential.CreatedAt,
+	}
+	if credential.Transports != nil {
+		response.Transports = strings.Split(*credential.Transports, ",")
+	}
+	return response
+}
@@ -0,0 +1,92 @@
+package model
+
+import (
+	"fmt"
+	"go-clean-arch-saas/internal/entity"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PlanLimits holds the typed values of Plan.Limits; entity.PlanLimitUnlimited means no cap
+type PlanLimits struct {
+	MaxUsers         int64 `json:"max_users"`
+	APICallsPerMonth int64 `json:"api_calls_per_month"`
+	StorageGB        int64 `json:"storage_gb"`
+}
+
+// Value returns the limit by its Plan.Limits key
+func (l PlanLimits) Value(name string) int64 {
+	switch name {
+	case entity.PlanLimitMaxUsers:
+		return l.MaxUsers
+	case entity.PlanLimitAPICallsPerMonth:
+		return l.APICallsPerMonth
+	case entity.PlanLimitStorageGB:
+		return l.StorageGB
+	default:
+		return entity.PlanLimitUnlimited
+	}
+}
+
+// Entitlements is what an organization may use under its current plan
+type Entitlements struct {
+	PlanID   string
+	PlanSlug string
+	Limits   PlanLimits
+	Features map[string]bool
+}
+
+// CheckLimit returns an EntitlementError when adding requested units to current usage exceeds the limit
+func (e *Entitlements) CheckLimit(name string, current int64, requested int64) error {
+	allowed := e.Limits.Value(name)
+	if allowed == entity.PlanLimitUnlimited || current+requested <= allowed {
+		return nil
+	}
+
+	return &EntitlementError{
+		Code:    fiber.StatusPaymentRequired,
+		Message: fmt.Sprintf("Plan limit exceeded: %s", name),
+		Limit:   name,
+		Allowed: allowed,
+		Current: current,
+	}
+}
+
+// CheckFeature returns an EntitlementError when the plan does not include the feature
+func (e *Entitlements) CheckFeature(name string) error {
+	if e.Features[name] {
+		return nil
+	}
+
+	return &EntitlementError{
+		Code:    fiber.StatusForbidden,
+		Message: fmt.Sprintf("Feature not included in plan: %s", name),
+		Feature: name,
+	}
+}
+
+// EntitlementError is returned when a plan limit or feature blocks an action.
+// The error handler renders the details next to the message so clients can prompt an upgrade.
+type EntitlementError struct {
+	Code    int
+	Message string
+	Limit   string
+	Allowed int64
+	Current int64
+	Feature string
+}
+
+func (e *EntitlementError) Error() string {
+	return e.Message
+}
+
+type EntitlementUsage struct {
+	Users int64 `json:"users"`
+}
+
+type EntitlementsResponse struct {
+	Plan     string           `json:"plan"`
+	Limits   PlanLimits       `json:"limits"`
+	Features map[string]bool  `json:"features"`
+	Usage    EntitlementUsage `json:"usage"`
+}
@@ -0,0 +1,44 @@
+package model
+
+type InvitationResponse struct {
+	ID             string  `json:"id"`
+	OrganizationID string  `json:"organization_id"`
+	Email          string  `json:"email"`
+	Role           string  `json:"role"`
+	Status         string  `json:"status"`
+	InvitedBy      *string `json:"invited_by,omitempty"`
+	ExpiresAt      int64   `json:"expires_at"`
+	AcceptedAt     *int64  `json:"accepted_at,omitempty"`
+	CreatedAt      int64   `json:"created_at"`
+}
+
+type CreateInvitationRequest struct {
+	OrganizationID string `json:"-" validate:"required,max=100"`
+	InvitedBy      string `json:"-" validate:"required,max=100"`
+	Email          string `json:"email" validate:"required,email,max=255"`
+	Role           string `json:"role" validate:"required,oneof=admin member"`
+}
+
+type ListInvitationsRequest struct {
+	OrganizationID string `json:"-" validate:"required,max=100"`
+}
+
+type RevokeInvitationRequest struct {
+	OrganizationID string `json:"-" validate:"required,max=100"`
+	ActorID        string `json:"-" validate:"required,max=100"`
+	ID             string `json:"-" validate:"required,max=100"`
+}
+
+// AcceptInvitationRequest represents invitation acceptance request.
+// Name and Password are only required when the invited email has no account yet.
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Name     string `json:"name,omitempty" validate:"omitempty,max=100"`
+	Password string `json:"password,omitempty" validate:"omitempty,min=8,max=100"`
+}
+
+type AcceptInvitationResponse struct {
+	User         UserResponse         `json:"user"`
+	Organization OrganizationResponse `json:"organization"`
+	Role         string               `json:"role"`
+}
@@ -0,0 +1,42 @@
+package model
+
+type InvoiceResponse struct {
+	ID             string                    `json:"id"`
+	OrganizationID string                    `json:"organization_id"`
+	SubscriptionID string                    `json:"subscription_id"`
+	Number         string                    `json:"number"`
+	Status         string                    `json:"status"`
+	Currency       string                    `json:"currency"`
+	Total          float64                   `json:"total"`
+	AmountDue      float64                   `json:"amount_due"`
+	PeriodStart    int64                     `json:"period_start"`
+	PeriodEnd      int64                     `json:"period_end"`
+	IssuedAt       int64                     `json:"issued_at"`
+	PaidAt         *int64                    `json:"paid_at,omitempty"`
+	LineItems      []InvoiceLineItemResponse `json:"line_items,omitempty"`
+	CreatedAt      int64                     `json:"created_at"`
+	UpdatedAt      int64                     `json:"updated_at"`
+}
+
+type InvoiceLineItemResponse struct {
+	ID          string  `json:"id"`
+	Kind        string  `json:"kind"`
+	Description string  `json:"description"`
+	PlanID      string  `json:"plan_id,omitempty"`
+	Quantity    int64   `json:"quantity"`
+	UnitAmount  float64 `json:"unit_amount"`
+	Amount      float64 `json:"amount"`
+	PeriodStart int64   `json:"period_start"`
+	PeriodEnd   int64   `json:"period_end"`
+}
+
+type SearchInvoiceRequest struct {
+	OrganizationID string `json:"-" validate:"required,max=100"`
+	Page           int    `json:"page" validate:"min=1"`
+	Size           int    `json:"size" validate:"min=1,max=100"`
+}
+
+type GetInvoiceRequest struct {
+	OrganizationID string `json:"-" validate:"required,max=100"`
+	ID             string `json:"-" validate:"required,uuid"`
+}
@@ -0,0 +1,44 @@
+package model
+
+// MFAStatusResponse represents the two-factor authentication state of the current user
+type MFAStatusResponse struct {
+	Enabled                bool   `json:"enabled"`
+	EnabledAt              *int64 `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining int64  `json:"recovery_codes_remaining"`
+}
+
+type MFAStatusRequest struct {
+	UserID string `json:"-" validate:"required,max=100"`
+}
+
+type MFAEnrollRequest struct {
+	UserID         string `json:"-" validate:"required,max=100"`
+	OrganizationID string `json:"-"`
+}
+
+// MFAEnrollResponse carries a new TOTP secret, shown once. The otpauth URI is meant to be rendered as a QR code.
+type MFAEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// MFACodeRequest confirms an action with a code from the authenticator app. Disabling two-factor authentication
+// and regenerating recovery codes also accept a recovery code.
+type MFACodeRequest struct {
+	UserID         string `json:"-" validate:"required,max=100"`
+	OrganizationID string `json:"-"`
+	Code           string `json:"code" validate:"required,max=32"`
+}
+
+// MFARecoveryCodesResponse carries new one-time recovery codes, shown once. Earlier codes no longer work.
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAVerifyRequest exchanges the MFA challenge token returned by login and a code for the login tokens
+type MFAVerifyRequest struct {
+	MFAToken  string `json:"mfa_token" validate:"required"`
+	Code      string `json:"code" validate:"required,max=32"`
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
+}
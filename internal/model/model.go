@@ -7,7 +7,7 @@ type WebResponse[T any] struct {
 }
 
 type PageResponse[T any] struct {
-	Data         []T          `json:"data,omitempty"`
+	Data         []T          `json:"data"`
 	PageMetadata PageMetadata `json:"paging,omitempty"`
 }
 
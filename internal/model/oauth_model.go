@@ -0,0 +1,45 @@
+package model
+
+// OAuthProviderResponse is a provider users can sign in with
+type OAuthProviderResponse struct {
+	Name string `json:"name"`
+}
+
+type OAuthAuthorizeRequest struct {
+	Provider string `json:"-" validate:"required,max=50"`
+}
+
+// OAuthAuthorizeResponse starts a login: the user is sent to the authorization URL, and the state token is sent
+// back with the code the provider redirects with
+type OAuthAuthorizeResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+	StateToken       string `json:"state_token"`
+}
+
+type OAuthCallbackRequest struct {
+	Provider   string `json:"-" validate:"required,max=50"`
+	StateToken string `json:"state_token" validate:"required"`
+	State      string `json:"state" validate:"required,max=255"`
+	Code       string `json:"code" validate:"required,max=2048"`
+	UserAgent  string `json:"-"`
+	IPAddress  string `json:"-"`
+}
+
+// IdentityResponse represents an external account linked to the user
+type IdentityResponse struct {
+	ID          string `json:"id"`
+	Provider    string `json:"provider"`
+	Email       string `json:"email"`
+	LastLoginAt *int64 `json:"last_login_at,omitempty"`
+	CreatedAt   int64  `json:"created_at"`
+}
+
+type ListIdentitiesRequest struct {
+	UserID string `json:"-" validate:"required,max=100"`
+}
+
+type DeleteIdentityRequest struct {
+	UserID         string `json:"-" validate:"required,max=100"`
+	OrganizationID string `json:"-"`
+	ID             string `json:"-" validate:"required,max=100"`
+}
@@ -14,8 +14,9 @@ type CreateOrganizationRequest struct {
 }
 
 type UpdateOrganizationRequest struct {
-	ID   string `json:"-" validate:"required,max=100"`
-	Name string `json:"name,omitempty" validate:"omitempty,max=200"`
+	ID      string `json:"-" validate:"required,max=100"`
+	ActorID string `json:"-" validate:"required,max=100"`
+	Name    string `json:"name,omitempty" validate:"omitempty,max=200"`
 }
 
 type OrganizationMemberResponse struct {
@@ -31,5 +32,12 @@ type ListOrganizationMembersRequest struct {
 
 type RemoveOrganizationMemberRequest struct {
 	OrganizationID string `json:"-" validate:"required,max=100"`
+	ActorID        string `json:"-" validate:"required,max=100"`
 	UserID         string `json:"-" validate:"required,max=100"`
 }
+
+type AuthorizeOrganizationMemberRequest struct {
+	OrganizationID string `json:"-" validate:"required,max=100"`
+	UserID         string `json:"-" validate:"required,max=100"`
+	Role           string `json:"-" validate:"required,oneof=owner admin member"`
+}
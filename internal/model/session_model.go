@@ -0,0 +1,23 @@
+package model
+
+// SessionResponse represents a signed-in device. ID identifies the session across token rotations.
+type SessionResponse struct {
+	ID              string `json:"id"`
+	UserAgent       string `json:"user_agent"`
+	IPAddress       string `json:"ip_address"`
+	Current         bool   `json:"current"`
+	AuthenticatedAt int64  `json:"authenticated_at"`
+	LastUsedAt      int64  `json:"last_used_at"`
+	ExpiresAt       int64  `json:"expires_at"`
+}
+
+type ListSessionsRequest struct {
+	UserID           string `json:"-" validate:"required,max=100"`
+	CurrentSessionID string `json:"-"`
+}
+
+type RevokeSessionRequest struct {
+	UserID         string `json:"-" validate:"required,max=100"`
+	OrganizationID string `json:"-"`
+	ID             string `json:"-" validate:"required,max=100"`
+}
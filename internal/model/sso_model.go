@@ -0,0 +1,107 @@
+package model
+
+// SSOConnectionResponse represents an organization's single sign-on connection. The OIDC client secret is never
+// returned. The service provider fields are the values the identity provider is configured with.
+type SSOConnectionResponse struct {
+	ID           string `json:"id"`
+	Protocol     string `json:"protocol"`
+	EnforceSSO   bool   `json:"enforce_sso"`
+	DefaultRole  string `json:"default_role"`
+	OIDCIssuer   string `json:"oidc_issuer,omitempty"`
+	OIDCClientID string `json:"oidc_client_id,omitempty"`
+	RedirectURL  string `json:"redirect_url,omitempty"`
+	SAMLEntityID string `json:"saml_entity_id,omitempty"`
+	SAMLSSOURL   string `json:"saml_sso_url,omitempty"`
+	SPEntityID   string `json:"sp_entity_id,omitempty"`
+	ACSURL       string `json:"acs_url,omitempty"`
+	MetadataURL  string `json:"metadata_url,omitempty"`
+	CreatedAt    int64  `json:"created_at"`
+	UpdatedAt    int64  `json:"updated_at"`
+}
+
+type GetSSOConnectionRequest struct {
+	OrganizationID string `json:"-" validate:"required,max=100"`
+}
+
+// UpsertSSOConnectionRequest configures the organization's connection. OIDC connections need the issuer and
+// client; the client secret may be left empty on updates to keep the current one. SAML connections need the
+// identity provider's metadata XML.
+type UpsertSSOConnectionRequest struct {
+	OrganizationID   string `json:"-" validate:"required,max=100"`
+	ActorID          string `json:"-" validate:"required,max=100"`
+	Protocol         string `json:"protocol" validate:"required,oneof=oidc saml"`
+	EnforceSSO       bool   `json:"enforce_sso"`
+	DefaultRole      string `json:"default_role" validate:"omitempty,oneof=admin member"`
+	OIDCIssuer       string `json:"oidc_issuer" validate:"omitempty,url,max=500"`
+	OIDCClientID     string `json:"oidc_client_id" validate:"max=255"`
+	OIDCClientSecret string `json:"oidc_client_secret" validate:"max=1000"`
+	SAMLMetadata     string `json:"saml_metadata" validate:"max=200000"`
+}
+
+type DeleteSSOConnectionRequest struct {
+	OrganizationID string `json:"-" validate:"required,max=100"`
+	ActorID        string `json:"-" validate:"required,max=100"`
+}
+
+// SSODomainResponse represents an email domain claimed for single sign-on. Until verified, the TXT record
+// named verification_record with the verification_value must be published in the domain's DNS.
+type SSODomainResponse struct {
+	ID                 string `json:"id"`
+	Domain             string `json:"domain"`
+	Verified           bool   `json:"verified"`
+	VerificationRecord string `json:"verification_record"`
+	VerificationValue  string `json:"verification_value"`
+	VerifiedAt         *int64 `json:"verified_at,omitempty"`
+	CreatedAt          int64  `json:"created_at"`
+}
+
+type ListSSODomainsRequest struct {
+	OrganizationID string `json:"-" validate:"required,max=100"`
+}
+
+type AddSSODomainRequest struct {
+	OrganizationID string `json:"-" validate:"required,max=100"`
+	ActorID        string `json:"-" validate:"required,max=100"`
+	Domain         string `json:"domain" validate:"required,fqdn,max=255"`
+}
+
+type VerifySSODomainRequest struct {
+	OrganizationID string `json:"-" validate:"required,max=100"`
+	ActorID        string `json:"-" validate:"required,max=100"`
+	ID             string `json:"-" validate:"required,max=100"`
+}
+
+type DeleteSSODomainRequest struct {
+	OrganizationID string `json:"-" validate:"required,max=100"`
+	ActorID        string `json:"-" validate:"required,max=100"`
+	ID             string `json:"-" validate:"required,max=100"`
+}
+
+// SSOAuthorizeRequest starts a single sign-on login, routed to a connection by the email's domain
+type SSOAuthorizeRequest struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+// SSOAuthorizeResponse starts a login: the user is sent to the authorization URL, and the state token is sent
+// back with the state and code the login redirects to the SSO callback page with
+type SSOAuthorizeResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+	StateToken       string `json:"state_token"`
+}
+
+// SSOCallbackRequest completes a login with the state and code of the SSO callback page, for OIDC and SAML
+// connections alike
+type SSOCallbackRequest struct {
+	StateToken string `json:"state_token" validate:"required"`
+	State      string `json:"state" validate:"required,max=255"`
+	Code       string `json:"code" validate:"required,max=4096"`
+	UserAgent  string `json:"-"`
+	IPAddress  string `json:"-"`
+}
+
+// SAMLResponseRequest is the form an identity provider posts to a connection's assertion consumer service
+type SAMLResponseRequest struct {
+	ConnectionID string `form:"-" validate:"required,max=100"`
+	SAMLResponse string `form:"SAMLResponse" validate:"required,max=1000000"`
+	RelayState   string `form:"RelayState" validate:"required,max=255"`
+}
@@ -1,27 +1,57 @@
 package model
 
 type PlanResponse struct {
-	ID            string                 `json:"id"`
-	Name          string                 `json:"name"`
-	Slug          string                 `json:"slug"`
-	Price         float64                `json:"price"`
-	BillingPeriod string                 `json:"billing_period"`
-	Features      map[string]interface{} `json:"features"`
-	Limits        map[string]interface{} `json:"limits"`
-	IsActive      bool                   `json:"is_active"`
-	CreatedAt     int64                  `json:"created_at"`
-	UpdatedAt     int64                  `json:"updated_at"`
+	ID              string                 `json:"id"`
+	Name            string                 `json:"name"`
+	Slug            string                 `json:"slug"`
+	Price           float64                `json:"price"`
+	PricingModel    string                 `json:"pricing_model"`
+	BillingPeriod   string                 `json:"billing_period"`
+	BillingInterval int                    `json:"billing_interval"`
+	Prices          []PlanPriceResponse    `json:"prices,omitempty"`
+	TrialDays       int                    `json:"trial_days"`
+	Features        map[string]interface{} `json:"features"`
+	Limits          map[string]interface{} `json:"limits"`
+	IsActive        bool                   `json:"is_active"`
+	CreatedAt       int64                  `json:"created_at"`
+	UpdatedAt       int64                  `json:"updated_at"`
 }
 
+// PlanPriceResponse is a price variant of a plan, picked with price_id when changing plans
+type PlanPriceResponse struct {
+	ID              string  `json:"id"`
+	Price           float64 `json:"price"`
+	BillingPeriod   string  `json:"billing_period"`
+	BillingInterval int     `json:"billing_interval"`
+}
+
+type GetPlanRequest struct {
+	Slug string `json:"-" validate:"required,max=100"`
+}
+
+// SubscriptionResponse is the organization's subscription. InGracePeriod is set while a failed payment is
+// retried and the plan is kept until GracePeriodEndsAt; clients show a banner asking to update the payment method.
+// Plan is priced at the variant the subscription is billed at, PlanPriceID, or at its own price when that is empty.
 type SubscriptionResponse struct {
-	ID                 string       `json:"id"`
-	OrganizationID     string       `json:"organization_id"`
-	Plan               PlanResponse `json:"plan"`
-	Status             string       `json:"status"`
-	CurrentPeriodStart int64        `json:"current_period_start"`
-	CurrentPeriodEnd   int64        `json:"current_period_end"`
-	CreatedAt          int64        `json:"created_at"`
-	UpdatedAt          int64        `json:"updated_at"`
+	ID                   string       `json:"id"`
+	OrganizationID       string       `json:"organization_id"`
+	Plan                 PlanResponse `json:"plan"`
+	PlanPriceID          *string      `json:"plan_price_id,omitempty"`
+	Status               string       `json:"status"`
+	Quantity             int          `json:"quantity"`
+	CurrentPeriodStart   int64        `json:"current_period_start"`
+	CurrentPeriodEnd     int64        `json:"current_period_end"`
+	CancelAtPeriodEnd    bool         `json:"cancel_at_period_end"`
+	CancelledAt          *int64       `json:"cancelled_at,omitempty"`
+	ScheduledPlanID      *string      `json:"scheduled_plan_id,omitempty"`
+	ScheduledPlanPriceID *string      `json:"scheduled_plan_price_id,omitempty"`
+	TrialEndsAt          *int64       `json:"trial_ends_at,omitempty"`
+	CouponID             *string      `json:"coupon_id,omitempty"`
+	DiscountEndsAt       *int64       `json:"discount_ends_at,omitempty"`
+	InGracePeriod        bool         `json:"in_grace_period"`
+	GracePeriodEndsAt    *int64       `json:"grace_period_ends_at,omitempty"`
+	CreatedAt            int64        `json:"created_at"`
+	UpdatedAt            int64        `json:"updated_at"`
 }
 
 type GetCurrentSubscriptionRequest struct {
@@ -29,10 +59,53 @@ type GetCurrentSubscriptionRequest struct {
 }
 
 type UpgradeSubscriptionRequest struct {
+	OrganizationID string `json:"-" validate:"required,max=100"`
+	ActorID        string `json:"-" validate:"required,max=100"`
+	PlanID         string `json:"plan_id" validate:"required,max=100"`
+	PriceID        string `json:"price_id" validate:"omitempty,max=100"`
+	PromoCode      string `json:"promo_code" validate:"omitempty,max=50"`
+}
+
+type PreviewPlanChangeRequest struct {
 	OrganizationID string `json:"-" validate:"required,max=100"`
 	PlanID         string `json:"plan_id" validate:"required,max=100"`
+	PriceID        string `json:"price_id" validate:"omitempty,max=100"`
+	PromoCode      string `json:"promo_code" validate:"omitempty,max=50"`
+}
+
+// Kinds of plan change
+const (
+	PlanChangeUpgrade   = "upgrade"
+	PlanChangeDowngrade = "downgrade"
+)
+
+// ProrationResponse is what a plan change costs now. Upgrades take effect immediately and start a new
+// period, with the unused part of the current period credited against the new plan's price.
+// Downgrades take effect at the end of the current period and cost nothing now.
+// A promotion code's discount is taken off the new plan's price before the credit.
+type ProrationResponse struct {
+	CurrentPlanID     string  `json:"current_plan_id"`
+	NewPlanID         string  `json:"new_plan_id"`
+	Change            string  `json:"change"`
+	EffectiveAt       int64   `json:"effective_at"`
+	RemainingFraction float64 `json:"remaining_fraction"`
+	Credit            float64 `json:"credit"`
+	Charge            float64 `json:"charge"`
+	Discount          float64 `json:"discount"`
+	PromoCode         string  `json:"promo_code,omitempty"`
+	AmountDue         float64 `json:"amount_due"`
+	CreditBalance     float64 `json:"credit_balance"`
 }
 
+// CancelSubscriptionRequest ends the subscription at the end of the current period,
+// or right away when Immediately is set
 type CancelSubscriptionRequest struct {
 	OrganizationID string `json:"-" validate:"required,max=100"`
+	ActorID        string `json:"-" validate:"required,max=100"`
+	Immediately    bool   `json:"immediately"`
+}
+
+type ResumeSubscriptionRequest struct {
+	OrganizationID string `json:"-" validate:"required,max=100"`
+	ActorID        string `json:"-" validate:"required,max=100"`
 }
@@ -0,0 +1,12 @@
+package model
+
+// UsageResponse is an organization's consumption of a metric in the current period.
+// Limit and Remaining are -1 when the plan does not cap the metric.
+type UsageResponse struct {
+	Metric      string `json:"metric"`
+	PeriodStart int64  `json:"period_start"`
+	PeriodEnd   int64  `json:"period_end"`
+	Used        int64  `json:"used"`
+	Limit       int64  `json:"limit"`
+	Remaining   int64  `json:"remaining"`
+}
@@ -5,17 +5,31 @@ type UserResponse struct {
 	Name           string `json:"name"`
 	Email          string `json:"email"`
 	EmailVerified  bool   `json:"email_verified"`
+	MFAEnabled     bool   `json:"mfa_enabled"`
 	OrganizationID string `json:"organization_id,omitempty"`
 	CreatedAt      int64  `json:"created_at"`
 	UpdatedAt      int64  `json:"updated_at"`
 }
 
 type UpdateUserRequest struct {
-	ID       string `json:"-" validate:"required,max=100"`
-	Name     string `json:"name,omitempty" validate:"omitempty,max=100"`
-	Password string `json:"password,omitempty" validate:"omitempty,min=8,max=100"`
+	ID             string `json:"-" validate:"required,max=100"`
+	OrganizationID string `json:"-"`
+	Name           string `json:"name,omitempty" validate:"omitempty,max=100"`
+	Password       string `json:"password,omitempty" validate:"omitempty,min=8,max=100"`
 }
 
 type GetUserRequest struct {
 	ID string `json:"id" validate:"required,max=100"`
 }
+
+type UserOrganizationResponse struct {
+	Organization OrganizationResponse `json:"organization"`
+	Role         string               `json:"role"`
+	JoinedAt     int64                `json:"joined_at"`
+	Current      bool                 `json:"current"`
+}
+
+type ListUserOrganizationsRequest struct {
+	UserID                string `json:"-" validate:"required,max=100"`
+	CurrentOrganizationID string `json:"-"`
+}
@@ -0,0 +1,135 @@
+package model
+
+// WebAuthn options and credentials use the JSON serialization of the WebAuthn API (camelCase, base64url binary
+// values), so public_key can be passed to PublicKeyCredential.parseCreationOptionsFromJSON() or
+// parseRequestOptionsFromJSON(), and the credential returned by the browser's toJSON() sent back as is.
+
+type WebAuthnRelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type WebAuthnUserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type WebAuthnCredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+type WebAuthnCredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+type WebAuthnAuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// WebAuthnCreationOptions are the options of navigator.credentials.create()
+type WebAuthnCreationOptions struct {
+	Challenge              string                         `json:"challenge"`
+	RP                     WebAuthnRelyingPartyEntity     `json:"rp"`
+	User                   WebAuthnUserEntity             `json:"user"`
+	PubKeyCredParams       []WebAuthnCredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                          `json:"timeout"`
+	ExcludeCredentials     []WebAuthnCredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection WebAuthnAuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                         `json:"attestation"`
+}
+
+// WebAuthnRequestOptions are the options of navigator.credentials.get()
+type WebAuthnRequestOptions struct {
+	Challenge        string                         `json:"challenge"`
+	RPID             string                         `json:"rpId"`
+	Timeout          int64                          `json:"timeout"`
+	AllowCredentials []WebAuthnCredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                         `json:"userVerification"`
+}
+
+type WebAuthnRegisterBeginRequest struct {
+	UserID string `json:"-" validate:"required,max=100"`
+}
+
+// WebAuthnRegisterBeginResponse starts a registration; the challenge token is sent back with the new credential
+type WebAuthnRegisterBeginResponse struct {
+	ChallengeToken string                  `json:"challenge_token"`
+	PublicKey      WebAuthnCreationOptions `json:"public_key"`
+}
+
+type WebAuthnAttestationResponse struct {
+	ClientDataJSON    string   `json:"clientDataJSON" validate:"required"`
+	AttestationObject string   `json:"attestationObject" validate:"required"`
+	Transports        []string `json:"transports" validate:"max=10,dive,max=20"`
+}
+
+// WebAuthnAttestationCredential is the PublicKeyCredential returned by navigator.credentials.create()
+type WebAuthnAttestationCredential struct {
+	ID       string                      `json:"id" validate:"required,max=1400"`
+	Type     string                      `json:"type" validate:"required,eq=public-key"`
+	Response WebAuthnAttestationResponse `json:"response"`
+}
+
+type WebAuthnRegisterFinishRequest struct {
+	UserID         string                        `json:"-" validate:"required,max=100"`
+	OrganizationID string                        `json:"-"`
+	ChallengeToken string                        `json:"challenge_token" validate:"required"`
+	Name           string                        `json:"name" validate:"max=100"` // label shown in the credential list
+	Credential     WebAuthnAttestationCredential `json:"credential"`
+}
+
+// WebAuthnCredentialResponse represents a registered passkey or security key
+type WebAuthnCredentialResponse struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Transports []string `json:"transports,omitempty"`
+	LastUsedAt *int64   `json:"last_used_at,omitempty"`
+	CreatedAt  int64    `json:"created_at"`
+}
+
+type ListWebAuthnCredentialsRequest struct {
+	UserID string `json:"-" validate:"required,max=100"`
+}
+
+type DeleteWebAuthnCredentialRequest struct {
+	UserID         string `json:"-" validate:"required,max=100"`
+	OrganizationID string `json:"-"`
+	ID             string `json:"-" validate:"required,max=100"`
+}
+
+// WebAuthnLoginBeginRequest starts a passkey login. Without an email any discoverable credential may answer.
+type WebAuthnLoginBeginRequest struct {
+	Email string `json:"email" validate:"omitempty,email"`
+}
+
+// WebAuthnLoginBeginResponse starts a login; the challenge token is sent back with the assertion
+type WebAuthnLoginBeginResponse struct {
+	ChallengeToken string                 `json:"challenge_token"`
+	PublicKey      WebAuthnRequestOptions `json:"public_key"`
+}
+
+type WebAuthnAssertionResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON" validate:"required"`
+	AuthenticatorData string `json:"authenticatorData" validate:"required"`
+	Signature         string `json:"signature" validate:"required"`
+	UserHandle        string `json:"userHandle"`
+}
+
+// WebAuthnAssertionCredential is the PublicKeyCredential returned by navigator.credentials.get()
+type WebAuthnAssertionCredential struct {
+	ID       string                    `json:"id" validate:"required,max=1400"`
+	Type     string                    `json:"type" validate:"required,eq=public-key"`
+	Response WebAuthnAssertionResponse `json:"response"`
+}
+
+type WebAuthnLoginFinishRequest struct {
+	ChallengeToken string                      `json:"challenge_token" validate:"required"`
+	Credential     WebAuthnAssertionCredential `json:"credential"`
+	UserAgent      string                      `json:"-"`
+	IPAddress      string                      `json:"-"`
+}
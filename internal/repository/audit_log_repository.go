@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type AuditLogRepository struct {
+	Repository[entity.AuditLog]
+	Log *logrus.Logger
+}
+
+func NewAuditLogRepository(log *logrus.Logger) *AuditLogRepository {
+	return &AuditLogRepository{
+		Log: log,
+	}
+}
+
+// Search returns one page of matching entries, newest first, and the total number of matches
+func (r *AuditLogRepository) Search(db *gorm.DB, request *model.SearchAuditLogRequest) ([]entity.AuditLog, int64, error) {
+	var auditLogs []entity.AuditLog
+	if err := db.Scopes(r.FilterAuditLog(request)).
+		Order("created_at DESC").
+		Offset((request.Page - 1) * request.Size).
+		Limit(request.Size).
+		Find(&auditLogs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if err := db.Model(new(entity.AuditLog)).Scopes(r.FilterAuditLog(request)).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return auditLogs, total, nil
+}
+
+// FindAll returns every matching entry, oldest first, up to limit rows
+func (r *AuditLogRepository) FindAll(db *gorm.DB, request *model.SearchAuditLogRequest, limit int) ([]entity.AuditLog, error) {
+	var auditLogs []entity.AuditLog
+	err := db.Scopes(r.FilterAuditLog(request)).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&auditLogs).Error
+	return auditLogs, err
+}
+
+func (r *AuditLogRepository) FilterAuditLog(request *model.SearchAuditLogRequest) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		tx = tx.Where("organization_id = ? AND deleted_at IS NULL", request.OrganizationID)
+
+		if request.UserID != "" {
+			tx = tx.Where("user_id = ?", request.UserID)
+		}
+		if request.Action != "" {
+			tx = tx.Where("action = ?", request.Action)
+		}
+		if request.Resource != "" {
+			tx = tx.Where("resource = ?", request.Resource)
+		}
+		if request.ResourceID != "" {
+			tx = tx.Where("resource_id = ?", request.ResourceID)
+		}
+		if request.From > 0 {
+			tx = tx.Where("created_at >= ?", request.From)
+		}
+		if request.To > 0 {
+			tx = tx.Where("created_at <= ?", request.To)
+		}
+
+		return tx
+	}
+}
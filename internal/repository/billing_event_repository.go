@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"go-clean-arch-saas/internal/entity"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BillingEventRepository struct {
+	Repository[entity.BillingEvent]
+	Log *logrus.Logger
+}
+
+func NewBillingEventRepository(log *logrus.Logger) *BillingEventRepository {
+	return &BillingEventRepository{
+		Log: log,
+	}
+}
+
+// CreateIfNotExists stores the event and reports false when the provider already delivered an event with its ID
+func (r *BillingEventRepository) CreateIfNotExists(db *gorm.DB, event *entity.BillingEvent) (bool, error) {
+	result := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "provider"}, {Name: "event_id"}},
+		DoNothing: true,
+	}).Create(event)
+	return result.RowsAffected > 0, result.Error
+}
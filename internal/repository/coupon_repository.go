@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"go-clean-arch-saas/internal/entity"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type CouponRepository struct {
+	Repository[entity.Coupon]
+	Log *logrus.Logger
+}
+
+func NewCouponRepository(log *logrus.Logger) *CouponRepository {
+	return &CouponRepository{
+		Log: log,
+	}
+}
+
+// FindByCode loads an active coupon by its code
+func (r *CouponRepository) FindByCode(db *gorm.DB, coupon *entity.Coupon, code string) error {
+	return db.Where("code = ? AND is_active = ? AND deleted_at IS NULL", code, true).Take(coupon).Error
+}
+
+// CountRedemptions returns how many times the organization redeemed the coupon
+func (r *CouponRepository) CountRedemptions(db *gorm.DB, couponID string, orgID string) (int64, error) {
+	var total int64
+	err := db.Model(&entity.CouponRedemption{}).Where("coupon_id = ? AND organization_id = ?", couponID, orgID).Count(&total).Error
+	return total, err
+}
+
+// IncrementRedemptions counts one more redemption unless the coupon reached max_redemptions, and reports whether it did
+func (r *CouponRepository) IncrementRedemptions(db *gorm.DB, coupon *entity.Coupon) (bool, error) {
+	result := db.Model(coupon).
+		Where("max_redemptions IS NULL OR times_redeemed < max_redemptions").
+		Update("times_redeemed", gorm.Expr("times_redeemed + 1"))
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *CouponRepository) CreateRedemption(db *gorm.DB, redemption *entity.CouponRedemption) error {
+	return db.Create(redemption).Error
+}
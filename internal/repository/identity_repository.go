@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"go-clean-arch-saas/internal/entity"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type IdentityRepository struct {
+	Repository[entity.Identity]
+	Log *logrus.Logger
+}
+
+func NewIdentityRepository(log *logrus.Logger) *IdentityRepository {
+	return &IdentityRepository{
+		Log: log,
+	}
+}
+
+func (r *IdentityRepository) FindByProviderSubject(db *gorm.DB, identity *entity.Identity, provider string, subject string) error {
+	return db.Where("provider = ? AND subject = ?", provider, subject).Take(identity).Error
+}
+
+func (r *IdentityRepository) FindByIdAndUser(db *gorm.DB, identity *entity.Identity, id string, userID string) error {
+	return db.Where("id = ? AND user_id = ?", id, userID).Take(identity).Error
+}
+
+func (r *IdentityRepository) ListByUser(db *gorm.DB, userID string) ([]entity.Identity, error) {
+	var identities []entity.Identity
+	err := db.Where("user_id = ?", userID).Order("created_at ASC").Find(&identities).Error
+	return identities, err
+}
+
+func (r *IdentityRepository) CountByUser(db *gorm.DB, userID string) (int64, error) {
+	var total int64
+	err := db.Model(&entity.Identity{}).Where("user_id = ?", userID).Count(&total).Error
+	return total, err
+}
+
+func (r *IdentityRepository) DeleteByUser(db *gorm.DB, userID string, id string) (int64, error) {
+	result := db.Where("id = ? AND user_id = ?", id, userID).Delete(&entity.Identity{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InvoiceRepository struct {
+	Repository[entity.Invoice]
+	Log *logrus.Logger
+}
+
+func NewInvoiceRepository(log *logrus.Logger) *InvoiceRepository {
+	return &InvoiceRepository{
+		Log: log,
+	}
+}
+
+// NextSequence returns the organization's next invoice number. The sequence row stays locked until
+// the transaction ends, so concurrent invoices of one organization get consecutive numbers without gaps.
+func (r *InvoiceRepository) NextSequence(db *gorm.DB, orgID string) (int64, error) {
+	sequence := &entity.InvoiceSequence{OrganizationID: orgID, LastNumber: 1}
+	err := db.Clauses(
+		clause.OnConflict{
+			Columns:   []clause.Column{{Name: "organization_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"last_number": gorm.Expr("invoice_sequences.last_number + 1")}),
+		},
+		clause.Returning{Columns: []clause.Column{{Name: "last_number"}}},
+	).Create(sequence).Error
+	return sequence.LastNumber, err
+}
+
+// MarkPaid records the charge that collected the invoice
+func (r *InvoiceRepository) MarkPaid(db *gorm.DB, invoice *entity.Invoice, chargeID string, paidAt int64) error {
+	invoice.Status = entity.InvoiceStatusPaid
+	invoice.PaidAt = &paidAt
+	invoice.PaymentChargeID = &chargeID
+	return db.Model(invoice).Updates(map[string]interface{}{
+		"status":            invoice.Status,
+		"paid_at":           paidAt,
+		"payment_charge_id": chargeID,
+	}).Error
+}
+
+// MarkRefunded records that the invoice's charge was returned in full
+func (r *InvoiceRepository) MarkRefunded(db *gorm.DB, invoice *entity.Invoice) error {
+	invoice.Status = entity.InvoiceStatusRefunded
+	return db.Model(invoice).Update("status", invoice.Status).Error
+}
+
+// FindByChargeId loads the invoice paid by a provider charge
+func (r *InvoiceRepository) FindByChargeId(db *gorm.DB, invoice *entity.Invoice, chargeID string) error {
+	return db.Where("payment_charge_id = ?", chargeID).Take(invoice).Error
+}
+
+// FindLatestOpenBySubscription loads the most recent unpaid invoice of the subscription
+func (r *InvoiceRepository) FindLatestOpenBySubscription(db *gorm.DB, invoice *entity.Invoice, subscriptionID string) error {
+	return db.Where("subscription_id = ? AND status = ?", subscriptionID, entity.InvoiceStatusOpen).
+		Order("sequence DESC").
+		Take(invoice).Error
+}
+
+// CreatePendingItem stores an item for the subscription's next invoice
+func (r *InvoiceRepository) CreatePendingItem(db *gorm.DB, item *entity.PendingInvoiceItem) error {
+	return db.Create(item).Error
+}
+
+// FindPendingItems returns the items waiting for the subscription's next invoice, oldest first
+func (r *InvoiceRepository) FindPendingItems(db *gorm.DB, subscriptionID string) ([]entity.PendingInvoiceItem, error) {
+	var items []entity.PendingInvoiceItem
+	err := db.Where("subscription_id = ?", subscriptionID).Order("created_at ASC").Find(&items).Error
+	return items, err
+}
+
+// DeletePendingItems removes the subscription's pending items once they are invoiced
+func (r *InvoiceRepository) DeletePendingItems(db *gorm.DB, subscriptionID string) error {
+	return db.Where("subscription_id = ?", subscriptionID).Delete(&entity.PendingInvoiceItem{}).Error
+}
+
+// MovePendingItems hands the pending items of a replaced subscription to the one replacing it
+func (r *InvoiceRepository) MovePendingItems(db *gorm.DB, fromSubscriptionID string, toSubscriptionID string) error {
+	return db.Model(&entity.PendingInvoiceItem{}).
+		Where("subscription_id = ?", fromSubscriptionID).
+		Update("subscription_id", toSubscriptionID).Error
+}
+
+// Search returns one page of the organization's invoices, newest first, and the total number of invoices
+func (r *InvoiceRepository) Search(db *gorm.DB, request *model.SearchInvoiceRequest) ([]entity.Invoice, int64, error) {
+	var invoices []entity.Invoice
+	if err := db.Where("organization_id = ?", request.OrganizationID).
+		Order("sequence DESC").
+		Offset((request.Page - 1) * request.Size).
+		Limit(request.Size).
+		Find(&invoices).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if err := db.Model(new(entity.Invoice)).Where("organization_id = ?", request.OrganizationID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return invoices, total, nil
+}
+
+// FindByIdAndOrganization loads an invoice of the organization with its line items in order
+func (r *InvoiceRepository) FindByIdAndOrganization(db *gorm.DB, invoice *entity.Invoice, id string, orgID string) error {
+	return db.Where("id = ? AND organization_id = ?", id, orgID).
+		Preload("LineItems", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Take(invoice).Error
+}
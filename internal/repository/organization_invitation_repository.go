@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"go-clean-arch-saas/internal/entity"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type OrganizationInvitationRepository struct {
+	Repository[entity.OrganizationInvitation]
+	Log *logrus.Logger
+}
+
+func NewOrganizationInvitationRepository(log *logrus.Logger) *OrganizationInvitationRepository {
+	return &OrganizationInvitationRepository{
+		Log: log,
+	}
+}
+
+func (r *OrganizationInvitationRepository) FindByTokenHash(db *gorm.DB, invitation *entity.OrganizationInvitation, tokenHash string) error {
+	return db.Where("token_hash = ?", tokenHash).Preload("Organization").First(invitation).Error
+}
+
+func (r *OrganizationInvitationRepository) FindByOrgAndID(db *gorm.DB, invitation *entity.OrganizationInvitation, orgID, id string) error {
+	return db.Where("organization_id = ? AND id = ?", orgID, id).First(invitation).Error
+}
+
+func (r *OrganizationInvitationRepository) CountPendingByOrgAndEmail(db *gorm.DB, orgID, email string, now int64) (int64, error) {
+	var count int64
+	err := db.Model(&entity.OrganizationInvitation{}).
+		Where("organization_id = ? AND email = ? AND status = ? AND expires_at > ?", orgID, email, entity.InvitationStatusPending, now).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *OrganizationInvitationRepository) CountPendingByOrganization(db *gorm.DB, orgID string, now int64) (int64, error) {
+	var count int64
+	err := db.Model(&entity.OrganizationInvitation{}).
+		Where("organization_id = ? AND status = ? AND expires_at > ?", orgID, entity.InvitationStatusPending, now).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *OrganizationInvitationRepository) ListPendingByOrganization(db *gorm.DB, orgID string, now int64) ([]entity.OrganizationInvitation, error) {
+	var invitations []entity.OrganizationInvitation
+	err := db.Where("organization_id = ? AND status = ? AND expires_at > ?", orgID, entity.InvitationStatusPending, now).
+		Order("created_at DESC").
+		Find(&invitations).Error
+	return invitations, err
+}
@@ -28,6 +28,18 @@ func (r *OrganizationMemberRepository) ListByOrganization(db *gorm.DB, orgID str
 	return members, err
 }
 
+func (r *OrganizationMemberRepository) ListByUser(db *gorm.DB, userID string) ([]entity.OrganizationMember, error) {
+	var members []entity.OrganizationMember
+	err := db.Where("user_id = ?", userID).Preload("Organization").Order("joined_at ASC").Find(&members).Error
+	return members, err
+}
+
 func (r *OrganizationMemberRepository) DeleteByOrgAndUser(db *gorm.DB, orgID, userID string) error {
 	return db.Where("organization_id = ? AND user_id = ?", orgID, userID).Delete(&entity.OrganizationMember{}).Error
 }
+
+func (r *OrganizationMemberRepository) CountByOrganization(db *gorm.DB, orgID string) (int64, error) {
+	var count int64
+	err := db.Model(&entity.OrganizationMember{}).Where("organization_id = ?", orgID).Count(&count).Error
+	return count, err
+}
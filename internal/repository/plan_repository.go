@@ -19,11 +19,21 @@ func NewPlanRepository(log *logrus.Logger) *PlanRepository {
 }
 
 func (r *PlanRepository) FindBySlug(db *gorm.DB, plan *entity.Plan, slug string) error {
-	return db.Where("slug = ? AND is_active = ?", slug, true).First(plan).Error
+	return db.Where("slug = ? AND is_active = ?", slug, true).Preload("Prices", activePrices).First(plan).Error
 }
 
 func (r *PlanRepository) FindAllActive(db *gorm.DB) ([]entity.Plan, error) {
 	var plans []entity.Plan
-	err := db.Where("is_active = ?", true).Find(&plans).Error
+	err := db.Where("is_active = ?", true).Preload("Prices", activePrices).Order("price ASC").Find(&plans).Error
 	return plans, err
 }
+
+// FindPrice finds an active price variant of the plan
+func (r *PlanRepository) FindPrice(db *gorm.DB, price *entity.PlanPrice, planID string, priceID string) error {
+	return db.Where("id = ? AND plan_id = ? AND is_active = ?", priceID, planID, true).Take(price).Error
+}
+
+// activePrices preloads a plan's active price variants, cheapest first
+func activePrices(db *gorm.DB) *gorm.DB {
+	return db.Where("is_active = ?", true).Order("price ASC")
+}
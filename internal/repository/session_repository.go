@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"go-clean-arch-saas/internal/entity"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type SessionRepository struct {
+	Repository[entity.Session]
+	Log *logrus.Logger
+}
+
+func NewSessionRepository(log *logrus.Logger) *SessionRepository {
+	return &SessionRepository{
+		Log: log,
+	}
+}
+
+func (r *SessionRepository) FindByTokenHash(db *gorm.DB, session *entity.Session, tokenHash string) error {
+	return db.Where("token_hash = ?", tokenHash).First(session).Error
+}
+
+// FindActiveByFamily returns the current token of a session family
+func (r *SessionRepository) FindActiveByFamily(db *gorm.DB, session *entity.Session, familyID string) error {
+	return db.Where("family_id = ? AND rotated_at IS NULL AND revoked_at IS NULL", familyID).First(session).Error
+}
+
+// ListActiveByUser returns the current (not rotated, not revoked, not expired) token of each session family
+func (r *SessionRepository) ListActiveByUser(db *gorm.DB, userID string, now int64) ([]entity.Session, error) {
+	var sessions []entity.Session
+	err := db.Where("user_id = ? AND rotated_at IS NULL AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Rotate retires a session's token unless it was already rotated or revoked, and returns whether it did. The
+// condition is checked by the update itself, so of concurrent rotations of the same token only one succeeds.
+func (r *SessionRepository) Rotate(db *gorm.DB, id string, now int64) (bool, error) {
+	result := db.Model(&entity.Session{}).
+		Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", id).
+		Update("rotated_at", now)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *SessionRepository) RevokeFamily(db *gorm.DB, familyID string, now int64) error {
+	return db.Model(&entity.Session{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", now).Error
+}
+
+func (r *SessionRepository) RevokeFamilyByUser(db *gorm.DB, userID, familyID string, now int64) (int64, error) {
+	result := db.Model(&entity.Session{}).
+		Where("user_id = ? AND family_id = ? AND revoked_at IS NULL", userID, familyID).
+		Update("revoked_at", now)
+	return result.RowsAffected, result.Error
+}
+
+func (r *SessionRepository) RevokeAllByUser(db *gorm.DB, userID string, now int64) error {
+	return db.Model(&entity.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error
+}
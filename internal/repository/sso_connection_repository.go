@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"go-clean-arch-saas/internal/entity"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type SSOConnectionRepository struct {
+	Repository[entity.SSOConnection]
+	Log *logrus.Logger
+}
+
+func NewSSOConnectionRepository(log *logrus.Logger) *SSOConnectionRepository {
+	return &SSOConnectionRepository{
+		Log: log,
+	}
+}
+
+func (r *SSOConnectionRepository) FindByOrganization(db *gorm.DB, connection *entity.SSOConnection, orgID string) error {
+	return db.Where("organization_id = ?", orgID).Take(connection).Error
+}
+
+func (r *SSOConnectionRepository) DeleteByOrganization(db *gorm.DB, orgID string) (int64, error) {
+	result := db.Where("organization_id = ?", orgID).Delete(&entity.SSOConnection{})
+	return result.RowsAffected, result.Error
+}
+
+// CountEnforcedForUser counts the organizations enforcing single sign-on in which the user is a member other
+// than the owner
+func (r *SSOConnectionRepository) CountEnforcedForUser(db *gorm.DB, userID string) (int64, error) {
+	var total int64
+	err := db.Model(&entity.SSOConnection{}).
+		Joins("JOIN organization_members ON organization_members.organization_id = sso_connections.organization_id").
+		Where("sso_connections.enforce_sso = ? AND organization_members.user_id = ? AND organization_members.role <> ?", true, userID, entity.OrgRoleOwner).
+		Count(&total).Error
+	return total, err
+}
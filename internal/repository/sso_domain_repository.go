@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"go-clean-arch-saas/internal/entity"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type SSODomainRepository struct {
+	Repository[entity.SSODomain]
+	Log *logrus.Logger
+}
+
+func NewSSODomainRepository(log *logrus.Logger) *SSODomainRepository {
+	return &SSODomainRepository{
+		Log: log,
+	}
+}
+
+func (r *SSODomainRepository) FindByIdAndOrganization(db *gorm.DB, domain *entity.SSODomain, id string, orgID string) error {
+	return db.Where("id = ? AND organization_id = ?", id, orgID).Take(domain).Error
+}
+
+// FindVerified returns the organization that verified the domain
+func (r *SSODomainRepository) FindVerified(db *gorm.DB, domain *entity.SSODomain, name string) error {
+	return db.Where("domain = ? AND verified_at IS NOT NULL", name).Take(domain).Error
+}
+
+func (r *SSODomainRepository) ListByOrganization(db *gorm.DB, orgID string) ([]entity.SSODomain, error) {
+	var domains []entity.SSODomain
+	err := db.Where("organization_id = ?", orgID).Order("created_at ASC").Find(&domains).Error
+	return domains, err
+}
+
+func (r *SSODomainRepository) CountByOrganizationAndDomain(db *gorm.DB, orgID string, name string) (int64, error) {
+	var total int64
+	err := db.Model(&entity.SSODomain{}).Where("organization_id = ? AND domain = ?", orgID, name).Count(&total).Error
+	return total, err
+}
+
+func (r *SSODomainRepository) CountVerified(db *gorm.DB, name string) (int64, error) {
+	var total int64
+	err := db.Model(&entity.SSODomain{}).Where("domain = ? AND verified_at IS NOT NULL", name).Count(&total).Error
+	return total, err
+}
+
+func (r *SSODomainRepository) DeleteByOrganization(db *gorm.DB, orgID string, id string) (int64, error) {
+	result := db.Where("id = ? AND organization_id = ?", id, orgID).Delete(&entity.SSODomain{})
+	return result.RowsAffected, result.Error
+}
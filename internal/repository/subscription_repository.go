@@ -5,6 +5,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type SubscriptionRepository struct {
@@ -19,9 +20,69 @@ func NewSubscriptionRepository(log *logrus.Logger) *SubscriptionRepository {
 }
 
 func (r *SubscriptionRepository) FindByOrganization(db *gorm.DB, subscription *entity.Subscription, orgID string) error {
-	return db.Where("organization_id = ?", orgID).Preload("Plan").First(subscription).Error
+	return db.Where("organization_id = ?", orgID).Preload("Plan").Preload("PlanPrice").First(subscription).Error
 }
 
+// FindActiveByOrganization returns the organization's current subscription, active or past due
 func (r *SubscriptionRepository) FindActiveByOrganization(db *gorm.DB, subscription *entity.Subscription, orgID string) error {
-	return db.Where("organization_id = ? AND status = ?", orgID, "active").Preload("Plan").First(subscription).Error
+	return db.Where("organization_id = ? AND status IN ?", orgID, entity.SubscriptionCurrentStatuses).Preload("Plan").Preload("PlanPrice").First(subscription).Error
+}
+
+// FindByIdForUpdate loads the subscription and locks its row until the transaction ends
+func (r *SubscriptionRepository) FindByIdForUpdate(db *gorm.DB, subscription *entity.Subscription, id string) error {
+	return db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).Preload("Plan").Preload("PlanPrice").Take(subscription).Error
+}
+
+// FindDueForRenewal returns up to limit active subscriptions whose period ended at or before now and that are not being cancelled
+func (r *SubscriptionRepository) FindDueForRenewal(db *gorm.DB, now int64, limit int) ([]entity.Subscription, error) {
+	var subscriptions []entity.Subscription
+	err := db.Where("status = ? AND cancel_at_period_end = ? AND current_period_end <= ?", entity.SubscriptionStatusActive, false, now).
+		Order("current_period_end ASC").
+		Limit(limit).
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// FindEndedTrials returns up to limit trialing subscriptions whose trial ended at or before now and that are not being cancelled
+func (r *SubscriptionRepository) FindEndedTrials(db *gorm.DB, now int64, limit int) ([]entity.Subscription, error) {
+	var subscriptions []entity.Subscription
+	err := db.Where("status = ? AND cancel_at_period_end = ? AND trial_ends_at <= ?", entity.SubscriptionStatusTrialing, false, now).
+		Order("trial_ends_at ASC").
+		Limit(limit).
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// FindTrialsToRemind returns up to limit trialing subscriptions ending at or before deadline whose owners were not reminded yet
+func (r *SubscriptionRepository) FindTrialsToRemind(db *gorm.DB, deadline int64, limit int) ([]entity.Subscription, error) {
+	var subscriptions []entity.Subscription
+	err := db.Where("status = ? AND cancel_at_period_end = ? AND trial_reminder_sent_at IS NULL AND trial_ends_at <= ?",
+		entity.SubscriptionStatusTrialing, false, deadline).
+		Order("trial_ends_at ASC").
+		Limit(limit).
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// FindDunningDue returns up to limit past_due subscriptions whose next payment retry, or the end of whose
+// grace period, is at or before now
+func (r *SubscriptionRepository) FindDunningDue(db *gorm.DB, now int64, limit int) ([]entity.Subscription, error) {
+	var subscriptions []entity.Subscription
+	err := db.Where("status = ? AND next_dunning_at <= ?", entity.SubscriptionStatusPastDue, now).
+		Order("next_dunning_at ASC").
+		Limit(limit).
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// FindLapsed returns up to limit subscriptions whose period ended at or before now and that are cancelled
+// or set to cancel at period end
+func (r *SubscriptionRepository) FindLapsed(db *gorm.DB, now int64, limit int) ([]entity.Subscription, error) {
+	var subscriptions []entity.Subscription
+	err := db.Where("(status = ? OR (status IN ? AND cancel_at_period_end = ?)) AND current_period_end <= ?",
+		entity.SubscriptionStatusCancelled, entity.SubscriptionCurrentStatuses, true, now).
+		Order("current_period_end ASC").
+		Limit(limit).
+		Find(&subscriptions).Error
+	return subscriptions, err
 }
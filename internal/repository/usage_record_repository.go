@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"go-clean-arch-saas/internal/entity"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UsageRecordRepository struct {
+	Repository[entity.UsageRecord]
+	Log *logrus.Logger
+}
+
+func NewUsageRecordRepository(log *logrus.Logger) *UsageRecordRepository {
+	return &UsageRecordRepository{
+		Log: log,
+	}
+}
+
+// Increment adds record.Quantity to the stored row of the same organization, metric and period, creating it if needed
+func (r *UsageRecordRepository) Increment(db *gorm.DB, record *entity.UsageRecord) error {
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "organization_id"}, {Name: "metric"}, {Name: "period_start"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"quantity":   gorm.Expr("usage_records.quantity + EXCLUDED.quantity"),
+			"updated_at": time.Now().UnixMilli(),
+		}),
+	}).Create(record).Error
+}
+
+// FindQuantity returns the stored quantity, or zero when nothing was recorded in the period
+func (r *UsageRecordRepository) FindQuantity(db *gorm.DB, orgID string, metric string, periodStart int64) (int64, error) {
+	var quantities []int64
+	err := db.Model(&entity.UsageRecord{}).
+		Where("organization_id = ? AND metric = ? AND period_start = ?", orgID, metric, periodStart).
+		Pluck("quantity", &quantities).Error
+	if err != nil || len(quantities) == 0 {
+		return 0, err
+	}
+	return quantities[0], nil
+}
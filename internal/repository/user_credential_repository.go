@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"go-clean-arch-saas/internal/entity"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserCredentialRepository struct {
+	Repository[entity.UserCredential]
+	Log *logrus.Logger
+}
+
+func NewUserCredentialRepository(log *logrus.Logger) *UserCredentialRepository {
+	return &UserCredentialRepository{
+		Log: log,
+	}
+}
+
+// FindByCredentialIDForUpdate loads a credential by its WebAuthn ID and locks it, so concurrent assertions see each other's sign count
+func (r *UserCredentialRepository) FindByCredentialIDForUpdate(db *gorm.DB, credential *entity.UserCredential, credentialID string) error {
+	return db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("credential_id = ?", credentialID).Take(credential).Error
+}
+
+func (r *UserCredentialRepository) ListByUser(db *gorm.DB, userID string) ([]entity.UserCredential, error) {
+	var credentials []entity.UserCredential
+	err := db.Where("user_id = ?", userID).Order("created_at ASC").Find(&credentials).Error
+	return credentials, err
+}
+
+func (r *UserCredentialRepository) CountByUser(db *gorm.DB, userID string) (int64, error) {
+	var total int64
+	err := db.Model(&entity.UserCredential{}).Where("user_id = ?", userID).Count(&total).Error
+	return total, err
+}
+
+func (r *UserCredentialRepository) CountByCredentialID(db *gorm.DB, credentialID string) (int64, error) {
+	var total int64
+	err := db.Model(&entity.UserCredential{}).Where("credential_id = ?", credentialID).Count(&total).Error
+	return total, err
+}
+
+func (r *UserCredentialRepository) DeleteByUser(db *gorm.DB, userID string, id string) (int64, error) {
+	result := db.Where("id = ? AND user_id = ?", id, userID).Delete(&entity.UserCredential{})
+	return result.RowsAffected, result.Error
+}
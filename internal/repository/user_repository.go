@@ -5,6 +5,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository struct {
@@ -22,10 +23,6 @@ func (r *UserRepository) FindByEmail(db *gorm.DB, user *entity.User, email strin
 	return db.Where("email = ?", email).First(user).Error
 }
 
-func (r *UserRepository) FindByRefreshToken(db *gorm.DB, user *entity.User, refreshToken string) error {
-	return db.Where("refresh_token = ?", refreshToken).First(user).Error
-}
-
 func (r *UserRepository) CountByEmail(db *gorm.DB, email string) (int64, error) {
 	var count int64
 	err := db.Model(&entity.User{}).Where("email = ?", email).Count(&count).Error
@@ -35,3 +32,36 @@ func (r *UserRepository) CountByEmail(db *gorm.DB, email string) (int64, error)
 func (r *UserRepository) FindByVerificationToken(db *gorm.DB, user *entity.User, token string) error {
 	return db.Where("verification_token = ?", token).First(user).Error
 }
+
+// FindByPasswordResetToken loads the user with the reset token and locks its row until the transaction ends,
+// so a token used by concurrent requests is only consumed once
+func (r *UserRepository) FindByPasswordResetToken(db *gorm.DB, user *entity.User, tokenHash string) error {
+	return db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("password_reset_token = ?", tokenHash).Take(user).Error
+}
+
+// FindByIdForUpdate loads the user and locks its row until the transaction ends
+func (r *UserRepository) FindByIdForUpdate(db *gorm.DB, user *entity.User, id string) error {
+	return db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).Take(user).Error
+}
+
+func (r *UserRepository) CreateRecoveryCodes(db *gorm.DB, codes []entity.RecoveryCode) error {
+	return db.Create(&codes).Error
+}
+
+// UseRecoveryCode marks an unused recovery code of the user as used and reports whether there was one
+func (r *UserRepository) UseRecoveryCode(db *gorm.DB, userID string, codeHash string, now int64) (bool, error) {
+	result := db.Model(&entity.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", now)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *UserRepository) CountUnusedRecoveryCodes(db *gorm.DB, userID string) (int64, error) {
+	var total int64
+	err := db.Model(&entity.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&total).Error
+	return total, err
+}
+
+func (r *UserRepository) DeleteRecoveryCodes(db *gorm.DB, userID string) error {
+	return db.Where("user_id = ?", userID).Delete(&entity.RecoveryCode{}).Error
+}
@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"context"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/model/converter"
+	"go-clean-arch-saas/internal/repository"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// auditExportLimit caps a single export so it stays within memory; narrow the time range for more
+const auditExportLimit = 10000
+
+type AuditLogUseCase struct {
+	DB                 *gorm.DB
+	Log                *logrus.Logger
+	Validate           *validator.Validate
+	AuditLogRepository *repository.AuditLogRepository
+}
+
+func NewAuditLogUseCase(
+	db *gorm.DB,
+	logger *logrus.Logger,
+	validate *validator.Validate,
+	auditLogRepo *repository.AuditLogRepository,
+) *AuditLogUseCase {
+	return &AuditLogUseCase{
+		DB:                 db,
+		Log:                logger,
+		Validate:           validate,
+		AuditLogRepository: auditLogRepo,
+	}
+}
+
+func (u *AuditLogUseCase) Search(ctx context.Context, request *model.SearchAuditLogRequest) (*model.PageResponse[model.AuditLogResponse], error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	auditLogs, total, err := u.AuditLogRepository.Search(tx, request)
+	if err != nil {
+		u.Log.Warnf("Failed to search audit logs: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	responses := make([]model.AuditLogResponse, 0, len(auditLogs))
+	for _, auditLog := range auditLogs {
+		responses = append(responses, *converter.AuditLogToResponse(&auditLog))
+	}
+
+	totalPage := total / int64(request.Size)
+	if total%int64(request.Size) > 0 {
+		totalPage++
+	}
+
+	return &model.PageResponse[model.AuditLogResponse]{
+		Data: responses,
+		PageMetadata: model.PageMetadata{
+			Page:      request.Page,
+			Size:      request.Size,
+			TotalItem: total,
+			TotalPage: totalPage,
+		},
+	}, nil
+}
+
+// Export returns every entry matching the filters, oldest first, ignoring pagination
+func (u *AuditLogUseCase) Export(ctx context.Context, request *model.SearchAuditLogRequest) ([]model.AuditLogResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	auditLogs, err := u.AuditLogRepository.FindAll(tx, request, auditExportLimit+1)
+	if err != nil {
+		u.Log.Warnf("Failed to export audit logs: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if len(auditLogs) > auditExportLimit {
+		u.Log.Warnf("Audit log export for organization %s exceeds %d entries", request.OrganizationID, auditExportLimit)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Too many audit log entries to export, narrow the time range")
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	responses := make([]model.AuditLogResponse, 0, len(auditLogs))
+	for _, auditLog := range auditLogs {
+		responses = append(responses, *converter.AuditLogToResponse(&auditLog))
+	}
+
+	return responses, nil
+}
@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// AuditService writes audit log rows for use cases
+type AuditService struct {
+	Log                *logrus.Logger
+	AuditLogRepository *repository.AuditLogRepository
+}
+
+func NewAuditService(logger *logrus.Logger, auditLogRepo *repository.AuditLogRepository) *AuditService {
+	return &AuditService{
+		Log:                logger,
+		AuditLogRepository: auditLogRepo,
+	}
+}
+
+// Record writes the event using tx so it commits or rolls back together with the change it describes.
+// IP address and user agent are taken from the request metadata in ctx.
+func (s *AuditService) Record(ctx context.Context, tx *gorm.DB, event *model.AuditEvent) error {
+	details := "{}"
+	if len(event.Details) > 0 {
+		encoded, err := json.Marshal(event.Details)
+		if err != nil {
+			return err
+		}
+		details = string(encoded)
+	}
+
+	meta := model.RequestMetaFromContext(ctx)
+	auditLog := &entity.AuditLog{
+		ID:             uuid.New().String(),
+		UserID:         optionalString(event.UserID),
+		OrganizationID: optionalString(event.OrganizationID),
+		Action:         event.Action,
+		Resource:       event.Resource,
+		ResourceID:     optionalString(event.ResourceID),
+		Details:        details,
+		IPAddress:      meta.IPAddress,
+		UserAgent:      meta.UserAgent,
+	}
+
+	return s.AuditLogRepository.Create(tx, auditLog)
+}
@@ -3,13 +3,16 @@ package usecase
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"go-clean-arch-saas/internal/entity"
 	"go-clean-arch-saas/internal/model"
 	"go-clean-arch-saas/internal/model/converter"
 	"go-clean-arch-saas/internal/repository"
 	"go-clean-arch-saas/pkg/email"
 	jwtPkg "go-clean-arch-saas/pkg/jwt"
+	"go-clean-arch-saas/pkg/oauth"
 	"strings"
 	"time"
 
@@ -21,6 +24,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// passwordResetTTL is how long a password reset link stays valid
+const passwordResetTTL = time.Hour
+
 type AuthUseCase struct {
 	DB                           *gorm.DB
 	Log                          *logrus.Logger
@@ -30,6 +36,14 @@ type AuthUseCase struct {
 	OrganizationMemberRepository *repository.OrganizationMemberRepository
 	PlanRepository               *repository.PlanRepository
 	SubscriptionRepository       *repository.SubscriptionRepository
+	SessionRepository            *repository.SessionRepository
+	IdentityRepository           *repository.IdentityRepository
+	AuditService                 *AuditService
+	InvoiceService               *InvoiceService
+	MFAService                   *MFAService
+	WebAuthnService              *WebAuthnService
+	OAuthService                 *OAuthService
+	SSOService                   *SSOService
 	JWTService                   *jwtPkg.JWTService
 	EmailService                 *email.EmailService
 	BaseURL                      string
@@ -44,6 +58,14 @@ func NewAuthUseCase(
 	orgMemberRepo *repository.OrganizationMemberRepository,
 	planRepo *repository.PlanRepository,
 	subRepo *repository.SubscriptionRepository,
+	sessionRepo *repository.SessionRepository,
+	identityRepo *repository.IdentityRepository,
+	auditService *AuditService,
+	invoiceService *InvoiceService,
+	mfaService *MFAService,
+	webAuthnService *WebAuthnService,
+	oauthService *OAuthService,
+	ssoService *SSOService,
 	jwtService *jwtPkg.JWTService,
 	emailService *email.EmailService,
 	baseURL string,
@@ -57,6 +79,14 @@ func NewAuthUseCase(
 		OrganizationMemberRepository: orgMemberRepo,
 		PlanRepository:               planRepo,
 		SubscriptionRepository:       subRepo,
+		SessionRepository:            sessionRepo,
+		IdentityRepository:           identityRepo,
+		AuditService:                 auditService,
+		InvoiceService:               invoiceService,
+		MFAService:                   mfaService,
+		WebAuthnService:              webAuthnService,
+		OAuthService:                 oauthService,
+		SSOService:                   ssoService,
 		JWTService:                   jwtService,
 		EmailService:                 emailService,
 		BaseURL:                      baseURL,
@@ -84,21 +114,22 @@ func (u *AuthUseCase) Register(ctx context.Context, request *model.RegisterReque
 		return nil, fiber.NewError(fiber.StatusConflict, "Email already exists")
 	}
 
-	// Create organization
-	orgSlug := strings.ToLower(strings.ReplaceAll(request.OrganizationName, " ", "-"))
-	orgID := uuid.New().String()
-
-	organization := &entity.Organization{
-		ID:        orgID,
-		Name:      request.OrganizationName,
-		Slug:      orgSlug,
-		CreatedAt: time.Now().UnixMilli(),
-		UpdatedAt: time.Now().UnixMilli(),
-	}
-
-	if err := u.OrganizationRepository.Create(tx, organization); err != nil {
-		u.Log.Warnf("Failed to create organization: %+v", err)
-		return nil, fiber.ErrInternalServerError
+	// New organizations start on the free plan, or on a free trial of the requested plan
+	plan := new(entity.Plan)
+	if request.Plan == "" || request.Plan == "free" {
+		if err := u.PlanRepository.FindBySlug(tx, plan, "free"); err != nil {
+			u.Log.Warnf("Failed to find free plan: %+v", err)
+			return nil, fiber.ErrInternalServerError
+		}
+	} else {
+		if err := u.PlanRepository.FindBySlug(tx, plan, request.Plan); err != nil {
+			u.Log.Warnf("Failed to find plan %s: %+v", request.Plan, err)
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Plan not found")
+		}
+		if plan.TrialDays <= 0 {
+			u.Log.Warnf("Plan %s has no free trial", plan.Slug)
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Plan has no free trial")
+		}
 	}
 
 	// Hash password
@@ -125,11 +156,67 @@ func (u *AuthUseCase) Register(ctx context.Context, request *model.RegisterReque
 		SystemRole:        entity.SystemRoleUser, // Default to regular user
 		EmailVerified:     false,
 		VerificationToken: &verificationToken,
-		OrganizationID:    orgID,
 		CreatedAt:         time.Now().UnixMilli(),
 		UpdatedAt:         time.Now().UnixMilli(),
 	}
 
+	orgSlug := strings.ToLower(strings.ReplaceAll(request.OrganizationName, " ", "-"))
+	organization, err := u.createAccount(tx, user, request.OrganizationName, orgSlug, plan)
+	if err != nil {
+		return nil, err
+	}
+	orgID := organization.ID
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         userID,
+		OrganizationID: orgID,
+		Action:         entity.AuditActionRegister,
+		Resource:       entity.AuditResourceUser,
+		ResourceID:     userID,
+		Details:        map[string]interface{}{"email": user.Email, "organization_name": organization.Name, "plan": plan.Slug},
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	// Send verification email (non-blocking, don't fail if email fails)
+	go func() {
+		if err := u.EmailService.SendVerificationEmail(user.Email, user.Name, verificationToken, u.BaseURL); err != nil {
+			u.Log.Warnf("Failed to send verification email to %s: %+v", user.Email, err)
+		} else {
+			u.Log.Infof("Verification email sent to %s", user.Email)
+		}
+	}()
+
+	return &model.RegisterResponse{
+		User:         *converter.UserToResponse(user),
+		Organization: *converter.OrganizationToResponse(organization),
+	}, nil
+}
+
+// createAccount creates the user together with an organization they own, subscribed to the plan: on a trial
+// when the plan has one, otherwise active and invoiced
+func (u *AuthUseCase) createAccount(tx *gorm.DB, user *entity.User, organizationName string, orgSlug string, plan *entity.Plan) (*entity.Organization, error) {
+	orgID := uuid.New().String()
+	organization := &entity.Organization{
+		ID:        orgID,
+		Name:      organizationName,
+		Slug:      orgSlug,
+		CreatedAt: time.Now().UnixMilli(),
+		UpdatedAt: time.Now().UnixMilli(),
+	}
+
+	if err := u.OrganizationRepository.Create(tx, organization); err != nil {
+		u.Log.Warnf("Failed to create organization: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	user.OrganizationID = orgID
 	if err := u.UserRepository.Create(tx, user); err != nil {
 		u.Log.Warnf("Failed to create user: %+v", err)
 		return nil, fiber.ErrInternalServerError
@@ -138,7 +225,7 @@ func (u *AuthUseCase) Register(ctx context.Context, request *model.RegisterReque
 	// Add user as owner of organization
 	orgMember := &entity.OrganizationMember{
 		OrganizationID: orgID,
-		UserID:         userID,
+		UserID:         user.ID,
 		Role:           entity.OrgRoleOwner, // Use constant instead of hardcoded string
 		JoinedAt:       time.Now().UnixMilli(),
 	}
@@ -148,48 +235,42 @@ func (u *AuthUseCase) Register(ctx context.Context, request *model.RegisterReque
 		return nil, fiber.ErrInternalServerError
 	}
 
-	// Get free plan
-	freePlan := new(entity.Plan)
-	if err := u.PlanRepository.FindBySlug(tx, freePlan, "free"); err != nil {
-		u.Log.Warnf("Failed to find free plan: %+v", err)
-		return nil, fiber.ErrInternalServerError
-	}
-
-	// Create subscription with free plan
+	// Create subscription
+	now := time.Now().UnixMilli()
 	subscription := &entity.Subscription{
 		ID:                 uuid.New().String(),
 		OrganizationID:     orgID,
-		PlanID:             freePlan.ID,
-		Status:             "active",
-		CurrentPeriodStart: time.Now().UnixMilli(),
-		CurrentPeriodEnd:   time.Now().AddDate(0, 1, 0).UnixMilli(), // 1 month
-		CreatedAt:          time.Now().UnixMilli(),
-		UpdatedAt:          time.Now().UnixMilli(),
+		PlanID:             plan.ID,
+		Status:             entity.SubscriptionStatusActive,
+		CurrentPeriodStart: now,
+		CurrentPeriodEnd:   PeriodEnd(plan, now),
+		CreatedAt:          now,
+		UpdatedAt:          now,
 	}
 
-	if err := u.SubscriptionRepository.Create(tx, subscription); err != nil {
-		u.Log.Warnf("Failed to create subscription: %+v", err)
-		return nil, fiber.ErrInternalServerError
+	// A trial lasts one period; the scheduler converts it to paid or falls back to free at its end
+	if plan.TrialDays > 0 && plan.Slug != "free" {
+		trialEndsAt := time.Now().AddDate(0, 0, plan.TrialDays).UnixMilli()
+		subscription.Status = entity.SubscriptionStatusTrialing
+		subscription.CurrentPeriodEnd = trialEndsAt
+		subscription.TrialEndsAt = &trialEndsAt
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		u.Log.Warnf("Failed to commit transaction: %+v", err)
+	if err := u.SubscriptionRepository.Create(tx, subscription); err != nil {
+		u.Log.Warnf("Failed to create subscription: %+v", err)
 		return nil, fiber.ErrInternalServerError
 	}
 
-	// Send verification email (non-blocking, don't fail if email fails)
-	go func() {
-		if err := u.EmailService.SendVerificationEmail(user.Email, user.Name, verificationToken, u.BaseURL); err != nil {
-			u.Log.Warnf("Failed to send verification email to %s: %+v", user.Email, err)
-		} else {
-			u.Log.Infof("Verification email sent to %s", user.Email)
+	// Trials are not invoiced until they convert
+	subscription.Plan = *plan
+	if subscription.Status == entity.SubscriptionStatusActive {
+		if _, err := u.InvoiceService.Generate(tx, subscription); err != nil {
+			u.Log.Warnf("Failed to generate invoice: %+v", err)
+			return nil, fiber.ErrInternalServerError
 		}
-	}()
+	}
 
-	return &model.RegisterResponse{
-		User:         *converter.UserToResponse(user),
-		Organization: *converter.OrganizationToResponse(organization),
-	}, nil
+	return organization, nil
 }
 
 func (u *AuthUseCase) Login(ctx context.Context, request *model.LoginRequest) (*model.LoginResponse, error) {
@@ -215,38 +296,113 @@ func (u *AuthUseCase) Login(ctx context.Context, request *model.LoginRequest) (*
 		return nil, fiber.ErrUnauthorized
 	}
 
-	// Generate access token (JWT)
-	accessToken, err := u.JWTService.GenerateAccessToken(user.ID, user.Email, user.OrganizationID)
+	if err := u.SSOService.CheckNonSSOLogin(tx, user); err != nil {
+		return nil, err
+	}
+
+	// With two-factor authentication the password only earns a challenge for the second step
+	var response *model.LoginResponse
+	var err error
+	if user.MFAEnabled {
+		response, err = u.mfaChallenge(tx, user)
+	} else {
+		response, err = u.startSession(ctx, tx, user, request.UserAgent, request.IPAddress, nil)
+	}
 	if err != nil {
-		u.Log.Warnf("Failed to generate access token: %+v", err)
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
 		return nil, fiber.ErrInternalServerError
 	}
 
-	// Generate refresh token (UUID)
-	refreshToken := uuid.New().String()
-	refreshTokenExpiresAt := time.Now().Add(u.JWTService.GetRefreshTokenExpiration()).UnixMilli()
+	return response, nil
+}
+
+// VerifyMFA completes a login of a user with two-factor authentication: it exchanges the challenge token
+// returned by Login and a TOTP or recovery code for the login tokens. A challenge token is good for one code,
+// right or wrong, and mfaMaxFailedAttempts wrong codes in a row lock the second step for mfaLockout.
+func (u *AuthUseCase) VerifyMFA(ctx context.Context, request *model.MFAVerifyRequest) (*model.LoginResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	claims, err := u.JWTService.ValidateChallengeToken(request.MFAToken, jwtPkg.AudienceMFAChallenge)
+	if err != nil {
+		u.Log.Warnf("Invalid MFA challenge token: %+v", err)
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired MFA token")
+	}
+
+	// Locked so a code cannot be used twice by concurrent requests
+	user := new(entity.User)
+	if err := u.UserRepository.FindByIdForUpdate(tx, user, claims.UserID); err != nil {
+		u.Log.Warnf("Failed to find user: %+v", err)
+		return nil, fiber.ErrUnauthorized
+	}
+	if !user.MFAEnabled {
+		u.Log.Warnf("Two-factor authentication is not enabled for user %s", user.ID)
+		return nil, fiber.ErrUnauthorized
+	}
+	if user.MFAChallengeHash == nil || *user.MFAChallengeHash != hashToken(claims.Challenge) {
+		u.Log.Warnf("MFA challenge of user %s was already used or replaced", user.ID)
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired MFA token")
+	}
+
+	now := time.Now()
+	if user.MFALockedUntil != nil && *user.MFALockedUntil > now.UnixMilli() {
+		u.Log.Warnf("Two-factor authentication of user %s is locked", user.ID)
+		return nil, fiber.NewError(fiber.StatusTooManyRequests, "Too many invalid verification codes, try again later")
+	}
+
+	// The challenge is used up by this attempt
+	user.MFAChallengeHash = nil
 
-	// Save refresh token to database
-	user.RefreshToken = refreshToken
-	user.RefreshTokenExpiresAt = refreshTokenExpiresAt
+	method, err := u.MFAService.Verify(tx, user, request.Code, now)
+	if err != nil {
+		return nil, err
+	}
+	if method == "" {
+		u.Log.Warnf("Invalid MFA code for user %s", user.ID)
+		user.MFAFailedAttempts++
+		if user.MFAFailedAttempts >= mfaMaxFailedAttempts {
+			lockedUntil := now.Add(mfaLockout).UnixMilli()
+			user.MFALockedUntil = &lockedUntil
+			user.MFAFailedAttempts = 0
+		}
+		if err := u.UserRepository.Update(tx, user); err != nil {
+			u.Log.Warnf("Failed to update user: %+v", err)
+			return nil, fiber.ErrInternalServerError
+		}
+		if err := tx.Commit().Error; err != nil {
+			u.Log.Warnf("Failed to commit transaction: %+v", err)
+			return nil, fiber.ErrInternalServerError
+		}
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid verification code")
+	}
 
+	user.MFAFailedAttempts = 0
+	user.MFALockedUntil = nil
 	if err := u.UserRepository.Update(tx, user); err != nil {
-		u.Log.Warnf("Failed to update user refresh token: %+v", err)
+		u.Log.Warnf("Failed to update user: %+v", err)
 		return nil, fiber.ErrInternalServerError
 	}
 
+	response, err := u.startSession(ctx, tx, user, request.UserAgent, request.IPAddress, map[string]interface{}{"mfa_method": method})
+	if err != nil {
+		return nil, err
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		u.Log.Warnf("Failed to commit transaction: %+v", err)
 		return nil, fiber.ErrInternalServerError
 	}
 
-	return &model.LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    int(u.JWTService.GetAccessTokenExpiration().Seconds()),
-		TokenType:    "Bearer",
-		User:         *converter.UserToResponse(user),
-	}, nil
+	return response, nil
 }
 
 func (u *AuthUseCase) Refresh(ctx context.Context, request *model.RefreshTokenRequest) (*model.RefreshTokenResponse, error) {
@@ -259,21 +415,77 @@ func (u *AuthUseCase) Refresh(ctx context.Context, request *model.RefreshTokenRe
 		return nil, fiber.ErrBadRequest
 	}
 
-	// Find user by refresh token
-	user := new(entity.User)
-	if err := u.UserRepository.FindByRefreshToken(tx, user, request.RefreshToken); err != nil {
-		u.Log.Warnf("Failed to find user by refresh token: %+v", err)
+	// Find session by refresh token hash
+	session := new(entity.Session)
+	if err := u.SessionRepository.FindByTokenHash(tx, session, hashToken(request.RefreshToken)); err != nil {
+		u.Log.Warnf("Failed to find session by refresh token: %+v", err)
+		return nil, fiber.ErrUnauthorized
+	}
+
+	now := time.Now().UnixMilli()
+
+	if session.IsRevoked() {
+		u.Log.Warnf("Refresh token revoked for session family: %s", session.FamilyID)
 		return nil, fiber.ErrUnauthorized
 	}
 
+	// A rotated token being presented again means it leaked: kill the whole family
+	if session.IsRotated() {
+		return nil, u.revokeReusedFamily(ctx, tx, session, now)
+	}
+
 	// Check if refresh token is expired
-	if user.RefreshTokenExpiresAt < time.Now().UnixMilli() {
-		u.Log.Warnf("Refresh token expired for user: %s", user.ID)
+	if session.ExpiresAt < now {
+		u.Log.Warnf("Refresh token expired for user: %s", session.UserID)
 		return nil, fiber.NewError(fiber.StatusUnauthorized, "Refresh token expired")
 	}
 
+	user := new(entity.User)
+	if err := u.UserRepository.FindById(tx, user, session.UserID); err != nil {
+		u.Log.Warnf("Failed to find user: %+v", err)
+		return nil, fiber.ErrUnauthorized
+	}
+
+	// Stay in the session's organization unless the user has since left it
+	preferredOrgID := ""
+	if session.OrganizationID != nil {
+		preferredOrgID = *session.OrganizationID
+	}
+	orgID, err := u.resolveOrganizationID(tx, user, preferredOrgID)
+	if err != nil {
+		u.Log.Warnf("Failed to resolve organization: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	// Rotate: retire the presented token and issue a new one in the same family. Losing the race to a
+	// concurrent refresh with the same token is reuse as well.
+	rotatedOnce, err := u.SessionRepository.Rotate(tx, session.ID, now)
+	if err != nil {
+		u.Log.Warnf("Failed to rotate session: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+	if !rotatedOnce {
+		return nil, u.revokeReusedFamily(ctx, tx, session, now)
+	}
+
+	rotated := &entity.Session{
+		ID:              uuid.New().String(),
+		FamilyID:        session.FamilyID,
+		UserID:          session.UserID,
+		OrganizationID:  optionalString(orgID),
+		UserAgent:       request.UserAgent,
+		IPAddress:       request.IPAddress,
+		AuthenticatedAt: session.AuthenticatedAt,
+	}
+
+	refreshToken, err := u.issueRefreshToken(tx, rotated, now)
+	if err != nil {
+		u.Log.Warnf("Failed to create session: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
 	// Generate new access token
-	accessToken, err := u.JWTService.GenerateAccessToken(user.ID, user.Email, user.OrganizationID)
+	accessToken, err := u.JWTService.GenerateAccessToken(user.ID, user.Email, orgID, rotated.FamilyID)
 	if err != nil {
 		u.Log.Warnf("Failed to generate access token: %+v", err)
 		return nil, fiber.ErrInternalServerError
@@ -285,29 +497,60 @@ func (u *AuthUseCase) Refresh(ctx context.Context, request *model.RefreshTokenRe
 	}
 
 	return &model.RefreshTokenResponse{
-		AccessToken: accessToken,
-		ExpiresIn:   int(u.JWTService.GetAccessTokenExpiration().Seconds()),
-		TokenType:   "Bearer",
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(u.JWTService.GetAccessTokenExpiration().Seconds()),
+		TokenType:    "Bearer",
 	}, nil
 }
 
-func (u *AuthUseCase) Logout(ctx context.Context, userID string) error {
+// revokeReusedFamily revokes the family of a refresh token presented after its rotation and commits, so the
+// revocation holds even though the refresh fails
+func (u *AuthUseCase) revokeReusedFamily(ctx context.Context, tx *gorm.DB, session *entity.Session, now int64) error {
+	u.Log.Warnf("Refresh token reuse detected for user %s, revoking session family %s", session.UserID, session.FamilyID)
+	if err := u.SessionRepository.RevokeFamily(tx, session.FamilyID, now); err != nil {
+		u.Log.Warnf("Failed to revoke session family: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:     session.UserID,
+		Action:     entity.AuditActionRefreshReuse,
+		Resource:   entity.AuditResourceSession,
+		ResourceID: session.FamilyID,
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+	return fiber.NewError(fiber.StatusUnauthorized, "Refresh token reuse detected")
+}
+
+func (u *AuthUseCase) Logout(ctx context.Context, request *model.LogoutRequest) error {
 	tx := u.DB.WithContext(ctx).Begin()
 	defer tx.Rollback()
 
-	// Find user
-	user := new(entity.User)
-	if err := u.UserRepository.FindById(tx, user, userID); err != nil {
-		u.Log.Warnf("Failed to find user: %+v", err)
-		return fiber.ErrNotFound
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return fiber.ErrBadRequest
 	}
 
-	// Clear refresh token
-	user.RefreshToken = ""
-	user.RefreshTokenExpiresAt = 0
+	// Revoke the current session
+	if _, err := u.SessionRepository.RevokeFamilyByUser(tx, request.UserID, request.SessionID, time.Now().UnixMilli()); err != nil {
+		u.Log.Warnf("Failed to revoke session: %+v", err)
+		return fiber.ErrInternalServerError
+	}
 
-	if err := u.UserRepository.Update(tx, user); err != nil {
-		u.Log.Warnf("Failed to update user: %+v", err)
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         request.UserID,
+		OrganizationID: request.OrganizationID,
+		Action:         entity.AuditActionLogout,
+		Resource:       entity.AuditResourceSession,
+		ResourceID:     request.SessionID,
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
 		return fiber.ErrInternalServerError
 	}
 
@@ -319,89 +562,837 @@ func (u *AuthUseCase) Logout(ctx context.Context, userID string) error {
 	return nil
 }
 
-func (u *AuthUseCase) VerifyToken(ctx context.Context, token string) (*model.Auth, error) {
-	// Remove "Bearer " prefix if exists
-	token = strings.Replace(token, "Bearer ", "", 1)
+func (u *AuthUseCase) ListSessions(ctx context.Context, request *model.ListSessionsRequest) ([]model.SessionResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
 
-	// Validate JWT token
-	claims, err := u.JWTService.ValidateToken(token)
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	sessions, err := u.SessionRepository.ListActiveByUser(tx, request.UserID, time.Now().UnixMilli())
 	if err != nil {
-		u.Log.Warnf("Invalid JWT token: %+v", err)
-		return nil, fiber.ErrUnauthorized
+		u.Log.Warnf("Failed to list sessions: %+v", err)
+		return nil, fiber.ErrInternalServerError
 	}
 
-	return &model.Auth{
-		UserID:         claims.UserID,
-		Email:          claims.Email,
-		OrganizationID: claims.OrganizationID,
-	}, nil
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	responses := make([]model.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		responses = append(responses, *converter.SessionToResponse(&session, request.CurrentSessionID))
+	}
+
+	return responses, nil
 }
 
-func (u *AuthUseCase) VerifyEmail(ctx context.Context, request *model.VerifyEmailRequest) (*model.VerifyEmailResponse, error) {
+func (u *AuthUseCase) RevokeSession(ctx context.Context, request *model.RevokeSessionRequest) error {
 	tx := u.DB.WithContext(ctx).Begin()
 	defer tx.Rollback()
 
-	// Validate request
 	if err := u.Validate.Struct(request); err != nil {
 		u.Log.Warnf("Invalid request body: %+v", err)
-		return nil, fiber.ErrBadRequest
+		return fiber.ErrBadRequest
 	}
 
-	// Find user by verification token
-	user := new(entity.User)
-	if err := u.UserRepository.FindByVerificationToken(tx, user, request.Token); err != nil {
-		u.Log.Warnf("Invalid verification token: %+v", err)
-		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid or expired verification token")
+	revoked, err := u.SessionRepository.RevokeFamilyByUser(tx, request.UserID, request.ID, time.Now().UnixMilli())
+	if err != nil {
+		u.Log.Warnf("Failed to revoke session: %+v", err)
+		return fiber.ErrInternalServerError
 	}
-
-	// Check if already verified
-	if user.EmailVerified {
-		return &model.VerifyEmailResponse{
-			Message: "Email already verified",
-		}, nil
+	if revoked == 0 {
+		u.Log.Warnf("Session not found: %s", request.ID)
+		return fiber.ErrNotFound
 	}
 
-	// Update user - mark email as verified
-	now := time.Now().UnixMilli()
-	user.EmailVerified = true
-	user.EmailVerifiedAt = &now
-	user.VerificationToken = nil // Clear token after verification
-
-	if err := u.UserRepository.Update(tx, user); err != nil {
-		u.Log.Warnf("Failed to update user: %+v", err)
-		return nil, fiber.ErrInternalServerError
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         request.UserID,
+		OrganizationID: request.OrganizationID,
+		Action:         entity.AuditActionSessionRevoke,
+		Resource:       entity.AuditResourceSession,
+		ResourceID:     request.ID,
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return fiber.ErrInternalServerError
 	}
 
 	if err := tx.Commit().Error; err != nil {
 		u.Log.Warnf("Failed to commit transaction: %+v", err)
-		return nil, fiber.ErrInternalServerError
+		return fiber.ErrInternalServerError
 	}
 
-	u.Log.Infof("Email verified for user: %s (%s)", user.ID, user.Email)
-
-	return &model.VerifyEmailResponse{
-		Message: "Email verified successfully",
-	}, nil
+	return nil
 }
 
-func (u *AuthUseCase) ResendVerification(ctx context.Context, request *model.ResendVerificationRequest) (*model.ResendVerificationResponse, error) {
+func (u *AuthUseCase) SwitchOrganization(ctx context.Context, request *model.SwitchOrganizationRequest) (*model.SwitchOrganizationResponse, error) {
 	tx := u.DB.WithContext(ctx).Begin()
 	defer tx.Rollback()
 
-	// Validate request
 	if err := u.Validate.Struct(request); err != nil {
 		u.Log.Warnf("Invalid request body: %+v", err)
 		return nil, fiber.ErrBadRequest
 	}
 
-	// Find user by email
 	user := new(entity.User)
-	if err := u.UserRepository.FindByEmail(tx, user, request.Email); err != nil {
-		// Don't reveal if email exists or not for security
-		u.Log.Warnf("User not found for resend verification: %s", request.Email)
-		return &model.ResendVerificationResponse{
-			Message: "If the email exists, a verification link has been sent",
-		}, nil
+	if err := u.UserRepository.FindById(tx, user, request.UserID); err != nil {
+		u.Log.Warnf("Failed to find user: %+v", err)
+		return nil, fiber.ErrNotFound
+	}
+
+	member := new(entity.OrganizationMember)
+	if err := u.OrganizationMemberRepository.FindByOrgAndUser(tx, member, request.OrganizationID, user.ID); err != nil {
+		u.Log.Warnf("User %s is not a member of organization %s: %+v", user.ID, request.OrganizationID, err)
+		return nil, fiber.NewError(fiber.StatusForbidden, "You are not a member of this organization")
+	}
+
+	organization := new(entity.Organization)
+	if err := u.OrganizationRepository.FindById(tx, organization, request.OrganizationID); err != nil {
+		u.Log.Warnf("Failed to find organization: %+v", err)
+		return nil, fiber.ErrNotFound
+	}
+
+	// Refreshes of this session keep acting in the chosen organization
+	session := new(entity.Session)
+	if err := u.SessionRepository.FindActiveByFamily(tx, session, request.SessionID); err != nil {
+		u.Log.Warnf("Failed to find session: %+v", err)
+		return nil, fiber.ErrUnauthorized
+	}
+
+	session.OrganizationID = &organization.ID
+	if err := u.SessionRepository.Update(tx, session); err != nil {
+		u.Log.Warnf("Failed to update session: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	// Remember the choice as the default for the next login
+	user.OrganizationID = organization.ID
+	if err := u.UserRepository.Update(tx, user); err != nil {
+		u.Log.Warnf("Failed to update user: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         user.ID,
+		OrganizationID: organization.ID,
+		Action:         entity.AuditActionOrganizationSwitch,
+		Resource:       entity.AuditResourceOrganization,
+		ResourceID:     organization.ID,
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	accessToken, err := u.JWTService.GenerateAccessToken(user.ID, user.Email, organization.ID, request.SessionID)
+	if err != nil {
+		u.Log.Warnf("Failed to generate access token: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return &model.SwitchOrganizationResponse{
+		AccessToken:  accessToken,
+		ExpiresIn:    int(u.JWTService.GetAccessTokenExpiration().Seconds()),
+		TokenType:    "Bearer",
+		Organization: *converter.OrganizationToResponse(organization),
+		Role:         member.Role,
+	}, nil
+}
+
+// resolveOrganizationID picks the organization a session acts in: the preferred one if the user
+// still belongs to it, then the user's default organization, then their oldest membership
+func (u *AuthUseCase) resolveOrganizationID(tx *gorm.DB, user *entity.User, preferred string) (string, error) {
+	for _, orgID := range []string{preferred, user.OrganizationID} {
+		if orgID == "" {
+			continue
+		}
+		member := new(entity.OrganizationMember)
+		if err := u.OrganizationMemberRepository.FindByOrgAndUser(tx, member, orgID, user.ID); err == nil {
+			return orgID, nil
+		}
+	}
+
+	members, err := u.OrganizationMemberRepository.ListByUser(tx, user.ID)
+	if err != nil {
+		return "", err
+	}
+	if len(members) == 0 {
+		return "", nil
+	}
+
+	return members[0].OrganizationID, nil
+}
+
+// BeginWebAuthnLogin starts a passkey login for the user with the email, or for any discoverable passkey
+func (u *AuthUseCase) BeginWebAuthnLogin(ctx context.Context, request *model.WebAuthnLoginBeginRequest) (*model.WebAuthnLoginBeginResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	// An unknown email gets options any passkey could answer, so the response does not reveal accounts
+	userID := ""
+	if request.Email != "" {
+		user := new(entity.User)
+		if err := u.UserRepository.FindByEmail(tx, user, request.Email); err == nil {
+			userID = user.ID
+		}
+	}
+
+	response, err := u.WebAuthnService.BeginLogin(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return response, nil
+}
+
+// FinishWebAuthnLogin signs in with a passkey assertion. A passkey that verified the user (biometrics or PIN)
+// is a second factor in itself; otherwise users with two-factor authentication still get an MFA challenge.
+func (u *AuthUseCase) FinishWebAuthnLogin(ctx context.Context, request *model.WebAuthnLoginFinishRequest) (*model.LoginResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	credential, userVerified, err := u.WebAuthnService.FinishLogin(tx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	user := new(entity.User)
+	if err := u.UserRepository.FindById(tx, user, credential.UserID); err != nil {
+		u.Log.Warnf("Failed to find user: %+v", err)
+		return nil, fiber.ErrUnauthorized
+	}
+
+	if err := u.SSOService.CheckNonSSOLogin(tx, user); err != nil {
+		return nil, err
+	}
+
+	var response *model.LoginResponse
+	if user.MFAEnabled && !userVerified {
+		response, err = u.mfaChallenge(tx, user)
+	} else {
+		response, err = u.startSession(ctx, tx, user, request.UserAgent, request.IPAddress, map[string]interface{}{"method": "passkey", "credential_id": credential.ID})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The new signature counter is kept even when a second factor is still required
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return response, nil
+}
+
+// ListOAuthProviders lists the providers users can sign in with
+func (u *AuthUseCase) ListOAuthProviders(ctx context.Context) []model.OAuthProviderResponse {
+	responses := make([]model.OAuthProviderResponse, 0, len(u.OAuthService.Providers))
+	for _, name := range u.OAuthService.ProviderNames() {
+		responses = append(responses, model.OAuthProviderResponse{Name: name})
+	}
+	return responses
+}
+
+// AuthorizeOAuth starts a login with an OAuth or OpenID Connect provider
+func (u *AuthUseCase) AuthorizeOAuth(ctx context.Context, request *model.OAuthAuthorizeRequest) (*model.OAuthAuthorizeResponse, error) {
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	return u.OAuthService.Authorize(request.Provider)
+}
+
+// FinishOAuthLogin signs in with the code a provider redirected back with. The first login with an external
+// account links it to the user with the same, provider-verified email, or creates a user with a personal
+// organization as Register does.
+func (u *AuthUseCase) FinishOAuthLogin(ctx context.Context, request *model.OAuthCallbackRequest) (*model.LoginResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	info, err := u.OAuthService.Identify(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	user := new(entity.User)
+	identity := new(entity.Identity)
+	err = u.IdentityRepository.FindByProviderSubject(tx, identity, request.Provider, info.Subject)
+	switch {
+	case err == nil:
+		if err := u.UserRepository.FindById(tx, user, identity.UserID); err != nil {
+			u.Log.Warnf("Failed to find user: %+v", err)
+			return nil, fiber.ErrUnauthorized
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		identity, err = u.linkIdentity(ctx, tx, user, request.Provider, info)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		u.Log.Warnf("Failed to find identity: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := u.SSOService.CheckNonSSOLogin(tx, user); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixMilli()
+	identity.LastLoginAt = &now
+	if info.Email != "" {
+		identity.Email = info.Email
+	}
+	if err := u.IdentityRepository.Update(tx, identity); err != nil {
+		u.Log.Warnf("Failed to update identity: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	// The provider replaces the password step; two-factor authentication still applies
+	var response *model.LoginResponse
+	if user.MFAEnabled {
+		response, err = u.mfaChallenge(tx, user)
+	} else {
+		response, err = u.startSession(ctx, tx, user, request.UserAgent, request.IPAddress, map[string]interface{}{"method": "oauth", "provider": request.Provider})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// A new link or account is kept even when a second factor is still required
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return response, nil
+}
+
+// linkIdentity links a new external account to the user with its email, creating the user when there is none,
+// and loads that user into user. Only emails the provider verified are trusted for either.
+func (u *AuthUseCase) linkIdentity(ctx context.Context, tx *gorm.DB, user *entity.User, provider string, info *oauth.UserInfo) (*entity.Identity, error) {
+	if info.Email == "" || !info.EmailVerified {
+		u.Log.Warnf("Provider %s did not return a verified email for subject %s", provider, info.Subject)
+		return nil, fiber.NewError(fiber.StatusForbidden, "The provider did not return a verified email address")
+	}
+
+	now := time.Now().UnixMilli()
+	err := u.UserRepository.FindByEmail(tx, user, info.Email)
+	switch {
+	case err == nil:
+		// The provider proved the email belongs to this person. A password set before the email was verified
+		// may have been chosen by someone else registering the address first, so it is dropped.
+		if !user.EmailVerified {
+			user.Password = ""
+			user.EmailVerified = true
+			user.EmailVerifiedAt = &now
+			user.VerificationToken = nil
+			if err := u.UserRepository.Update(tx, user); err != nil {
+				u.Log.Warnf("Failed to update user: %+v", err)
+				return nil, fiber.ErrInternalServerError
+			}
+			if err := u.SessionRepository.RevokeAllByUser(tx, user.ID, now); err != nil {
+				u.Log.Warnf("Failed to revoke sessions: %+v", err)
+				return nil, fiber.ErrInternalServerError
+			}
+		}
+
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		plan := new(entity.Plan)
+		if err := u.PlanRepository.FindBySlug(tx, plan, "free"); err != nil {
+			u.Log.Warnf("Failed to find free plan: %+v", err)
+			return nil, fiber.ErrInternalServerError
+		}
+
+		name := info.Name
+		if name == "" {
+			name, _, _ = strings.Cut(info.Email, "@")
+		}
+
+		// Users signing up through a provider have no password until they set one
+		*user = entity.User{
+			ID:              uuid.New().String(),
+			Name:            name,
+			Email:           info.Email,
+			SystemRole:      entity.SystemRoleUser,
+			EmailVerified:   true,
+			EmailVerifiedAt: &now,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+
+		// The personal organization's slug gets a random suffix, as names are not unique
+		orgSlug := strings.ToLower(strings.ReplaceAll(name, " ", "-")) + "-" + uuid.New().String()[:8]
+		organization, err := u.createAccount(tx, user, name, orgSlug, plan)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+			UserID:         user.ID,
+			OrganizationID: organization.ID,
+			Action:         entity.AuditActionRegister,
+			Resource:       entity.AuditResourceUser,
+			ResourceID:     user.ID,
+			Details:        map[string]interface{}{"email": user.Email, "organization_name": organization.Name, "plan": plan.Slug, "provider": provider},
+		}); err != nil {
+			u.Log.Warnf("Failed to record audit log: %+v", err)
+			return nil, fiber.ErrInternalServerError
+		}
+
+	default:
+		u.Log.Warnf("Failed to find user by email: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	identity := &entity.Identity{
+		ID:       uuid.New().String(),
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  info.Subject,
+		Email:    info.Email,
+	}
+	if err := u.IdentityRepository.Create(tx, identity); err != nil {
+		u.Log.Warnf("Failed to create identity: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         user.ID,
+		OrganizationID: user.OrganizationID,
+		Action:         entity.AuditActionIdentityLink,
+		Resource:       entity.AuditResourceUser,
+		ResourceID:     user.ID,
+		Details:        map[string]interface{}{"provider": provider, "email": info.Email},
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return identity, nil
+}
+
+func (u *AuthUseCase) ListIdentities(ctx context.Context, request *model.ListIdentitiesRequest) ([]model.IdentityResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	identities, err := u.IdentityRepository.ListByUser(tx, request.UserID)
+	if err != nil {
+		u.Log.Warnf("Failed to list identities: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	responses := make([]model.IdentityResponse, 0, len(identities))
+	for _, identity := range identities {
+		responses = append(responses, *converter.IdentityToResponse(&identity))
+	}
+
+	return responses, nil
+}
+
+// DeleteIdentity unlinks an external account. Users without a password keep at least one to sign in with.
+func (u *AuthUseCase) DeleteIdentity(ctx context.Context, request *model.DeleteIdentityRequest) error {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	// Locked so concurrent requests cannot unlink the last two accounts at once
+	user := new(entity.User)
+	if err := u.UserRepository.FindByIdForUpdate(tx, user, request.UserID); err != nil {
+		u.Log.Warnf("Failed to find user: %+v", err)
+		return fiber.ErrNotFound
+	}
+
+	identity := new(entity.Identity)
+	if err := u.IdentityRepository.FindByIdAndUser(tx, identity, request.ID, request.UserID); err != nil {
+		u.Log.Warnf("Identity not found: %s", request.ID)
+		return fiber.ErrNotFound
+	}
+
+	// Without a password, another linked account or a passkey must remain to sign in with
+	if user.Password == "" {
+		identities, err := u.IdentityRepository.CountByUser(tx, request.UserID)
+		if err != nil {
+			u.Log.Warnf("Failed to count identities: %+v", err)
+			return fiber.ErrInternalServerError
+		}
+		passkeys, err := u.WebAuthnService.UserCredentialRepository.CountByUser(tx, request.UserID)
+		if err != nil {
+			u.Log.Warnf("Failed to count passkeys: %+v", err)
+			return fiber.ErrInternalServerError
+		}
+		if identities+passkeys <= 1 {
+			u.Log.Warnf("User %s has no password and would lose their last sign-in method", request.UserID)
+			return fiber.NewError(fiber.StatusConflict, "Set a password before unlinking your last account")
+		}
+	}
+
+	if _, err := u.IdentityRepository.DeleteByUser(tx, request.UserID, request.ID); err != nil {
+		u.Log.Warnf("Failed to delete identity: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         request.UserID,
+		OrganizationID: request.OrganizationID,
+		Action:         entity.AuditActionIdentityUnlink,
+		Resource:       entity.AuditResourceUser,
+		ResourceID:     request.UserID,
+		Details:        map[string]interface{}{"provider": identity.Provider, "email": identity.Email},
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	return nil
+}
+
+// AuthorizeSSO starts a single sign-on login through the connection of the organization that verified the
+// email's domain
+func (u *AuthUseCase) AuthorizeSSO(ctx context.Context, request *model.SSOAuthorizeRequest) (*model.SSOAuthorizeResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	connection, err := u.SSOService.FindConnectionByEmail(tx, request.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := u.SSOService.Authorize(ctx, connection)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return response, nil
+}
+
+// ConsumeSAMLResponse handles a SAML response posted by an identity provider and returns the SSO callback page
+// URL to redirect the browser to
+func (u *AuthUseCase) ConsumeSAMLResponse(ctx context.Context, request *model.SAMLResponseRequest) (string, error) {
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return "", fiber.ErrBadRequest
+	}
+
+	return u.SSOService.ConsumeSAMLResponse(u.DB.WithContext(ctx), request), nil
+}
+
+// FinishSSOLogin signs in with the state and code of the SSO callback page. Users are provisioned into the
+// connection's organization at their first login; two-factor authentication still applies.
+func (u *AuthUseCase) FinishSSOLogin(ctx context.Context, request *model.SSOCallbackRequest) (*model.LoginResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	connection, info, err := u.SSOService.Identify(ctx, tx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := u.SSOService.Provision(ctx, tx, connection, info)
+	if err != nil {
+		return nil, err
+	}
+
+	var response *model.LoginResponse
+	if user.MFAEnabled {
+		response, err = u.mfaChallenge(tx, user)
+	} else {
+		response, err = u.startSession(ctx, tx, user, request.UserAgent, request.IPAddress, map[string]interface{}{"method": "sso", "sso_connection_id": connection.ID})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// A provisioned account is kept even when a second factor is still required
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return response, nil
+}
+
+// mfaChallenge returns the login response of a user who passed the first step and must provide a second factor.
+// The challenge is stored on the user, replacing any earlier one, so that VerifyMFA accepts it only once.
+// The caller commits the transaction.
+func (u *AuthUseCase) mfaChallenge(tx *gorm.DB, user *entity.User) (*model.LoginResponse, error) {
+	challenge, err := generateVerificationToken()
+	if err != nil {
+		u.Log.Warnf("Failed to generate MFA challenge: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	challengeHash := hashToken(challenge)
+	user.MFAChallengeHash = &challengeHash
+	if err := u.UserRepository.Update(tx, user); err != nil {
+		u.Log.Warnf("Failed to update user: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	mfaToken, err := u.JWTService.GenerateChallengeToken(jwtPkg.AudienceMFAChallenge, user.ID, challenge, mfaChallengeTTL)
+	if err != nil {
+		u.Log.Warnf("Failed to generate MFA challenge token: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return &model.LoginResponse{
+		MFARequired: true,
+		MFAToken:    mfaToken,
+	}, nil
+}
+
+// startSession signs in an authenticated user: it starts a session family for the device in the organization
+// the user acts in and returns the login tokens. The caller commits the transaction.
+func (u *AuthUseCase) startSession(ctx context.Context, tx *gorm.DB, user *entity.User, userAgent string, ipAddress string, details map[string]interface{}) (*model.LoginResponse, error) {
+	// Pick the organization this session acts in
+	orgID, err := u.resolveOrganizationID(tx, user, "")
+	if err != nil {
+		u.Log.Warnf("Failed to resolve organization: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	// Keep the default organization in sync with actual memberships
+	if orgID != "" && orgID != user.OrganizationID {
+		user.OrganizationID = orgID
+		if err := u.UserRepository.Update(tx, user); err != nil {
+			u.Log.Warnf("Failed to update user: %+v", err)
+			return nil, fiber.ErrInternalServerError
+		}
+	}
+
+	// Start a new session family for this device
+	now := time.Now().UnixMilli()
+	session := &entity.Session{
+		ID:              uuid.New().String(),
+		FamilyID:        uuid.New().String(),
+		UserID:          user.ID,
+		OrganizationID:  optionalString(orgID),
+		UserAgent:       userAgent,
+		IPAddress:       ipAddress,
+		AuthenticatedAt: now,
+	}
+
+	refreshToken, err := u.issueRefreshToken(tx, session, now)
+	if err != nil {
+		u.Log.Warnf("Failed to create session: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         user.ID,
+		OrganizationID: orgID,
+		Action:         entity.AuditActionLogin,
+		Resource:       entity.AuditResourceSession,
+		ResourceID:     session.FamilyID,
+		Details:        details,
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	// Generate access token (JWT)
+	accessToken, err := u.JWTService.GenerateAccessToken(user.ID, user.Email, orgID, session.FamilyID)
+	if err != nil {
+		u.Log.Warnf("Failed to generate access token: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return &model.LoginResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(u.JWTService.GetAccessTokenExpiration().Seconds()),
+		TokenType:    "Bearer",
+		User:         converter.UserToResponse(user),
+	}, nil
+}
+
+// issueRefreshToken generates a refresh token, stores its hash on the session and persists the session
+func (u *AuthUseCase) issueRefreshToken(tx *gorm.DB, session *entity.Session, now int64) (string, error) {
+	refreshToken, err := generateVerificationToken()
+	if err != nil {
+		return "", err
+	}
+
+	session.TokenHash = hashToken(refreshToken)
+	session.LastUsedAt = now
+	session.ExpiresAt = now + u.JWTService.GetRefreshTokenExpiration().Milliseconds()
+	session.CreatedAt = now
+	session.UpdatedAt = now
+
+	if err := u.SessionRepository.Create(tx, session); err != nil {
+		return "", err
+	}
+
+	return refreshToken, nil
+}
+
+func (u *AuthUseCase) VerifyToken(ctx context.Context, token string) (*model.Auth, error) {
+	// Remove "Bearer " prefix if exists
+	token = strings.Replace(token, "Bearer ", "", 1)
+
+	// Validate JWT token
+	claims, err := u.JWTService.ValidateToken(token)
+	if err != nil {
+		u.Log.Warnf("Invalid JWT token: %+v", err)
+		return nil, fiber.ErrUnauthorized
+	}
+
+	// Reject tokens of sessions revoked by logout, reuse detection or a password reset, without waiting for
+	// them to expire. Every access token is issued for a session.
+	if claims.SessionID == "" {
+		u.Log.Warnf("Access token of user %s has no session", claims.UserID)
+		return nil, fiber.ErrUnauthorized
+	}
+	session := new(entity.Session)
+	if err := u.SessionRepository.FindActiveByFamily(u.DB.WithContext(ctx), session, claims.SessionID); err != nil || session.UserID != claims.UserID {
+		u.Log.Warnf("Session %s of user %s is no longer active: %+v", claims.SessionID, claims.UserID, err)
+		return nil, fiber.ErrUnauthorized
+	}
+
+	// Reject tokens for organizations the user no longer belongs to
+	if claims.OrganizationID != "" {
+		member := new(entity.OrganizationMember)
+		if err := u.OrganizationMemberRepository.FindByOrgAndUser(u.DB.WithContext(ctx), member, claims.OrganizationID, claims.UserID); err != nil {
+			u.Log.Warnf("User %s is no longer a member of organization %s: %+v", claims.UserID, claims.OrganizationID, err)
+			return nil, fiber.ErrUnauthorized
+		}
+	}
+
+	return &model.Auth{
+		UserID:         claims.UserID,
+		Email:          claims.Email,
+		OrganizationID: claims.OrganizationID,
+		SessionID:      claims.SessionID,
+	}, nil
+}
+
+func (u *AuthUseCase) VerifyEmail(ctx context.Context, request *model.VerifyEmailRequest) (*model.VerifyEmailResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	// Validate request
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	// Find user by verification token
+	user := new(entity.User)
+	if err := u.UserRepository.FindByVerificationToken(tx, user, request.Token); err != nil {
+		u.Log.Warnf("Invalid verification token: %+v", err)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid or expired verification token")
+	}
+
+	// Check if already verified
+	if user.EmailVerified {
+		return &model.VerifyEmailResponse{
+			Message: "Email already verified",
+		}, nil
+	}
+
+	// Update user - mark email as verified
+	now := time.Now().UnixMilli()
+	user.EmailVerified = true
+	user.EmailVerifiedAt = &now
+	user.VerificationToken = nil // Clear token after verification
+
+	if err := u.UserRepository.Update(tx, user); err != nil {
+		u.Log.Warnf("Failed to update user: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	u.Log.Infof("Email verified for user: %s (%s)", user.ID, user.Email)
+
+	return &model.VerifyEmailResponse{
+		Message: "Email verified successfully",
+	}, nil
+}
+
+func (u *AuthUseCase) ResendVerification(ctx context.Context, request *model.ResendVerificationRequest) (*model.ResendVerificationResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	// Validate request
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	// Find user by email
+	user := new(entity.User)
+	if err := u.UserRepository.FindByEmail(tx, user, request.Email); err != nil {
+		// Don't reveal if email exists or not for security
+		u.Log.Warnf("User not found for resend verification: %s", request.Email)
+		return &model.ResendVerificationResponse{
+			Message: "If the email exists, a verification link has been sent",
+		}, nil
 	}
 
 	// Check if already verified
@@ -444,6 +1435,130 @@ func (u *AuthUseCase) ResendVerification(ctx context.Context, request *model.Res
 	}, nil
 }
 
+func (u *AuthUseCase) ForgotPassword(ctx context.Context, request *model.ForgotPasswordRequest) (*model.ForgotPasswordResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	// Validate request
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	response := &model.ForgotPasswordResponse{
+		Message: "If the email exists, a password reset link has been sent",
+	}
+
+	// Find user by email
+	user := new(entity.User)
+	if err := u.UserRepository.FindByEmail(tx, user, request.Email); err != nil {
+		// Don't reveal if email exists or not for security
+		u.Log.Warnf("User not found for forgot password: %s", request.Email)
+		return response, nil
+	}
+
+	// Generate reset token, only its hash is stored
+	resetToken, err := generateVerificationToken()
+	if err != nil {
+		u.Log.Warnf("Failed to generate password reset token: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	tokenHash := hashToken(resetToken)
+	expiresAt := time.Now().Add(passwordResetTTL).UnixMilli()
+	user.PasswordResetToken = &tokenHash
+	user.PasswordResetExpiresAt = &expiresAt
+
+	if err := u.UserRepository.Update(tx, user); err != nil {
+		u.Log.Warnf("Failed to update user: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	// Send password reset email (non-blocking)
+	go func() {
+		if err := u.EmailService.SendPasswordResetEmail(user.Email, user.Name, resetToken, u.BaseURL); err != nil {
+			u.Log.Warnf("Failed to send password reset email to %s: %+v", user.Email, err)
+		} else {
+			u.Log.Infof("Password reset email sent to %s", user.Email)
+		}
+	}()
+
+	return response, nil
+}
+
+func (u *AuthUseCase) ResetPassword(ctx context.Context, request *model.ResetPasswordRequest) (*model.ResetPasswordResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	// Validate request
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	// Find user by reset token hash
+	user := new(entity.User)
+	if err := u.UserRepository.FindByPasswordResetToken(tx, user, hashToken(request.Token)); err != nil {
+		u.Log.Warnf("Invalid password reset token: %+v", err)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid or expired password reset token")
+	}
+
+	// Check if reset token is expired
+	if user.PasswordResetExpiresAt == nil || *user.PasswordResetExpiresAt < time.Now().UnixMilli() {
+		u.Log.Warnf("Password reset token expired for user: %s", user.ID)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid or expired password reset token")
+	}
+
+	// Hash new password
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+	if err != nil {
+		u.Log.Warnf("Failed to hash password: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	// Update password and consume the token
+	user.Password = string(hashedPassword)
+	user.PasswordResetToken = nil
+	user.PasswordResetExpiresAt = nil
+
+	if err := u.UserRepository.Update(tx, user); err != nil {
+		u.Log.Warnf("Failed to update user: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	// Sign out every device
+	if err := u.SessionRepository.RevokeAllByUser(tx, user.ID, time.Now().UnixMilli()); err != nil {
+		u.Log.Warnf("Failed to revoke sessions: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:     user.ID,
+		Action:     entity.AuditActionPasswordReset,
+		Resource:   entity.AuditResourceUser,
+		ResourceID: user.ID,
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	u.Log.Infof("Password reset for user: %s (%s)", user.ID, user.Email)
+
+	return &model.ResetPasswordResponse{
+		Message: "Password has been reset successfully",
+	}, nil
+}
+
 // generateVerificationToken generates a random verification token
 func generateVerificationToken() (string, error) {
 	bytes := make([]byte, 32)
@@ -452,3 +1567,17 @@ func generateVerificationToken() (string, error) {
 	}
 	return hex.EncodeToString(bytes), nil
 }
+
+// optionalString returns nil for an empty string, for nullable columns
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// hashToken returns the SHA-256 hex digest of a token so only the hash is stored
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"go-clean-arch-saas/internal/entity"
+	"time"
+)
+
+// PeriodEnd returns the end of the plan's billing period starting at start. Unknown billing periods are monthly.
+func PeriodEnd(plan *entity.Plan, start int64) int64 {
+	interval := max(plan.BillingInterval, 1)
+	from := time.UnixMilli(start)
+
+	switch plan.BillingPeriod {
+	case entity.BillingPeriodDaily:
+		return from.AddDate(0, 0, interval).UnixMilli()
+	case entity.BillingPeriodWeekly:
+		return from.AddDate(0, 0, 7*interval).UnixMilli()
+	case entity.BillingPeriodYearly:
+		return from.AddDate(interval, 0, 0).UnixMilli()
+	default:
+		return from.AddDate(0, interval, 0).UnixMilli()
+	}
+}
+
+// PricedPlan returns the plan billed at price, one of its variants, or the plan itself when price is nil
+func PricedPlan(plan *entity.Plan, price *entity.PlanPrice) *entity.Plan {
+	if price == nil {
+		return plan
+	}
+
+	priced := *plan
+	priced.Price = price.Price
+	priced.BillingPeriod = price.BillingPeriod
+	priced.BillingInterval = price.BillingInterval
+	return &priced
+}
+
+// BillingPlan returns the subscription's plan at the price variant the subscription is billed at
+func BillingPlan(subscription *entity.Subscription) *entity.Plan {
+	return PricedPlan(&subscription.Plan, subscription.PlanPrice)
+}
+
+// periodMonths is the length of the plan's billing period in months
+func periodMonths(plan *entity.Plan) float64 {
+	interval := float64(max(plan.BillingInterval, 1))
+
+	switch plan.BillingPeriod {
+	case entity.BillingPeriodDaily:
+		return interval * 12 / 365
+	case entity.BillingPeriodWeekly:
+		return interval * 12 / 52
+	case entity.BillingPeriodYearly:
+		return interval * 12
+	default:
+		return interval
+	}
+}
@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/repository"
+	"go-clean-arch-saas/pkg/payment"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type BillingUseCase struct {
+	DB                     *gorm.DB
+	Log                    *logrus.Logger
+	Validate               *validator.Validate
+	OrganizationRepository *repository.OrganizationRepository
+	UserRepository         *repository.UserRepository
+	SubscriptionRepository *repository.SubscriptionRepository
+	PaymentProvider        payment.PaymentProvider
+	AuditService           *AuditService
+}
+
+func NewBillingUseCase(
+	db *gorm.DB,
+	logger *logrus.Logger,
+	validate *validator.Validate,
+	orgRepo *repository.OrganizationRepository,
+	userRepo *repository.UserRepository,
+	subRepo *repository.SubscriptionRepository,
+	paymentProvider payment.PaymentProvider,
+	auditService *AuditService,
+) *BillingUseCase {
+	return &BillingUseCase{
+		DB:                     db,
+		Log:                    logger,
+		Validate:               validate,
+		OrganizationRepository: orgRepo,
+		UserRepository:         userRepo,
+		SubscriptionRepository: subRepo,
+		PaymentProvider:        paymentProvider,
+		AuditService:           auditService,
+	}
+}
+
+// UpdatePaymentMethod makes the payment method the organization's default, registering the organization
+// as a customer with the payment provider first if needed
+func (u *BillingUseCase) UpdatePaymentMethod(ctx context.Context, request *model.UpdatePaymentMethodRequest) (*model.PaymentMethodResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	organization := new(entity.Organization)
+	if err := u.OrganizationRepository.FindById(tx, organization, request.OrganizationID); err != nil {
+		u.Log.Warnf("Failed to find organization: %+v", err)
+		return nil, fiber.ErrNotFound
+	}
+
+	if organization.PaymentCustomerID == nil {
+		actor := new(entity.User)
+		if err := u.UserRepository.FindById(tx, actor, request.ActorID); err != nil {
+			u.Log.Warnf("Failed to find user: %+v", err)
+			return nil, fiber.ErrInternalServerError
+		}
+
+		customerID, err := u.PaymentProvider.CreateCustomer(ctx, &payment.CustomerParams{
+			OrganizationID: organization.ID,
+			Name:           organization.Name,
+			Email:          actor.Email,
+		})
+		if err != nil {
+			u.Log.Warnf("Failed to create payment customer: %+v", err)
+			return nil, fiber.NewError(fiber.StatusBadGateway, "Payment provider is unavailable")
+		}
+		organization.PaymentCustomerID = &customerID
+	}
+
+	if err := u.PaymentProvider.AttachPaymentMethod(ctx, *organization.PaymentCustomerID, request.PaymentMethodID); err != nil {
+		u.Log.Warnf("Failed to attach payment method: %+v", err)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Payment method could not be attached")
+	}
+	organization.PaymentMethodID = &request.PaymentMethodID
+
+	if err := u.OrganizationRepository.Update(tx, organization); err != nil {
+		u.Log.Warnf("Failed to update organization: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	// A past due payment is retried with the new payment method on the next scheduler run
+	subscription := new(entity.Subscription)
+	if err := u.SubscriptionRepository.FindActiveByOrganization(tx, subscription, organization.ID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		u.Log.Warnf("Failed to find subscription: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+	if subscription.Status == entity.SubscriptionStatusPastDue && subscription.NextDunningAt != nil {
+		now := time.Now().UnixMilli()
+		subscription.NextDunningAt = &now
+		if err := u.SubscriptionRepository.Update(tx, subscription); err != nil {
+			u.Log.Warnf("Failed to schedule payment retry: %+v", err)
+			return nil, fiber.ErrInternalServerError
+		}
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         request.ActorID,
+		OrganizationID: organization.ID,
+		Action:         entity.AuditActionPaymentMethodUpdate,
+		Resource:       entity.AuditResourceOrganization,
+		ResourceID:     organization.ID,
+		Details:        map[string]interface{}{"payment_method_id": request.PaymentMethodID},
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return &model.PaymentMethodResponse{
+		CustomerID:      *organization.PaymentCustomerID,
+		PaymentMethodID: *organization.PaymentMethodID,
+	}, nil
+}
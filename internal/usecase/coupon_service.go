@@ -0,0 +1,163 @@
+package usecase
+
+import (
+	"encoding/json"
+	"errors"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/repository"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// CouponService validates promotion codes and resolves the discount a subscription carries
+type CouponService struct {
+	Log              *logrus.Logger
+	CouponRepository *repository.CouponRepository
+}
+
+func NewCouponService(logger *logrus.Logger, couponRepo *repository.CouponRepository) *CouponService {
+	return &CouponService{
+		Log:              logger,
+		CouponRepository: couponRepo,
+	}
+}
+
+// Find returns the coupon of a promotion code if the organization may redeem it on plan at now.
+// Codes are case-insensitive.
+func (s *CouponService) Find(tx *gorm.DB, code string, orgID string, plan *entity.Plan, now time.Time) (*entity.Coupon, error) {
+	coupon := new(entity.Coupon)
+	if err := s.CouponRepository.FindByCode(tx, coupon, NormalizePromoCode(code)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.Log.Warnf("Promo code %s not found", code)
+			return nil, fiber.NewError(fiber.StatusNotFound, "Promo code not found")
+		}
+		s.Log.Warnf("Failed to find coupon: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if coupon.ExpiresAt != nil && *coupon.ExpiresAt <= now.UnixMilli() {
+		s.Log.Warnf("Promo code %s expired", coupon.Code)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Promo code has expired")
+	}
+
+	if coupon.MaxRedemptions != nil && coupon.TimesRedeemed >= *coupon.MaxRedemptions {
+		s.Log.Warnf("Promo code %s reached its redemption limit", coupon.Code)
+		return nil, fiber.NewError(fiber.StatusConflict, "Promo code has reached its redemption limit")
+	}
+
+	applies, err := CouponAppliesTo(coupon, plan.ID)
+	if err != nil {
+		s.Log.Warnf("Failed to parse plans of coupon %s: %+v", coupon.Code, err)
+		return nil, fiber.ErrInternalServerError
+	}
+	if !applies {
+		s.Log.Warnf("Promo code %s does not apply to plan %s", coupon.Code, plan.Slug)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Promo code does not apply to this plan")
+	}
+
+	redeemed, err := s.CouponRepository.CountRedemptions(tx, coupon.ID, orgID)
+	if err != nil {
+		s.Log.Warnf("Failed to count coupon redemptions: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+	if redeemed > 0 {
+		s.Log.Warnf("Promo code %s was already redeemed by organization %s", coupon.Code, orgID)
+		return nil, fiber.NewError(fiber.StatusConflict, "Promo code was already redeemed by this organization")
+	}
+
+	return coupon, nil
+}
+
+// Redeem counts the coupon's use by a subscription that was created with StartDiscount
+func (s *CouponService) Redeem(tx *gorm.DB, coupon *entity.Coupon, subscription *entity.Subscription) error {
+	claimed, err := s.CouponRepository.IncrementRedemptions(tx, coupon)
+	if err != nil {
+		s.Log.Warnf("Failed to count coupon redemption: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+	if !claimed {
+		s.Log.Warnf("Promo code %s reached its redemption limit", coupon.Code)
+		return fiber.NewError(fiber.StatusConflict, "Promo code has reached its redemption limit")
+	}
+
+	if err := s.CouponRepository.CreateRedemption(tx, &entity.CouponRedemption{
+		ID:             uuid.New().String(),
+		CouponID:       coupon.ID,
+		OrganizationID: subscription.OrganizationID,
+		SubscriptionID: subscription.ID,
+	}); err != nil {
+		s.Log.Warnf("Failed to record coupon redemption: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	return nil
+}
+
+// ForPeriod returns the coupon discounting the subscription's period starting at periodStart on plan,
+// or nil when its discount has ended or does not cover the plan
+func (s *CouponService) ForPeriod(tx *gorm.DB, subscription *entity.Subscription, plan *entity.Plan, periodStart int64) (*entity.Coupon, error) {
+	if subscription.CouponID == nil || (subscription.DiscountEndsAt != nil && periodStart >= *subscription.DiscountEndsAt) {
+		return nil, nil
+	}
+
+	coupon := new(entity.Coupon)
+	if err := s.CouponRepository.FindById(tx, coupon, *subscription.CouponID); err != nil {
+		return nil, err
+	}
+
+	applies, err := CouponAppliesTo(coupon, plan.ID)
+	if err != nil || !applies {
+		return nil, err
+	}
+	return coupon, nil
+}
+
+// StartDiscount attaches the coupon to a subscription starting its first period.
+// A once coupon covers that period, a repeating one DurationInMonths months, a forever one every period.
+func StartDiscount(subscription *entity.Subscription, coupon *entity.Coupon) {
+	subscription.CouponID = &coupon.ID
+	subscription.DiscountEndsAt = nil
+
+	var endsAt int64
+	switch coupon.Duration {
+	case entity.CouponDurationOnce:
+		endsAt = subscription.CurrentPeriodEnd
+	case entity.CouponDurationRepeating:
+		endsAt = time.UnixMilli(subscription.CurrentPeriodStart).AddDate(0, coupon.DurationInMonths, 0).UnixMilli()
+	default:
+		return
+	}
+	subscription.DiscountEndsAt = &endsAt
+}
+
+// CouponAppliesTo reports whether the coupon may discount planID. A coupon without plan IDs covers every plan.
+func CouponAppliesTo(coupon *entity.Coupon, planID string) (bool, error) {
+	if coupon.PlanIDs == nil {
+		return true, nil
+	}
+
+	var planIDs []string
+	if err := json.Unmarshal([]byte(*coupon.PlanIDs), &planIDs); err != nil {
+		return false, err
+	}
+	return len(planIDs) == 0 || slices.Contains(planIDs, planID), nil
+}
+
+// DiscountAmount returns how much the coupon takes off amount, never more than amount
+func DiscountAmount(coupon *entity.Coupon, amount float64) float64 {
+	if coupon.DiscountType == entity.CouponDiscountPercent {
+		return roundCents(amount * coupon.PercentOff / 100)
+	}
+	return roundCents(min(coupon.AmountOff, amount))
+}
+
+// NormalizePromoCode returns the form coupon codes are stored in
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
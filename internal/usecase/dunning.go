@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"go-clean-arch-saas/internal/entity"
+	"time"
+)
+
+// DunningPolicy is how a subscription whose payment failed is chased. The payment is retried RetryDelays after
+// the first failure, and the organization keeps its plan for GracePeriod before falling back to the free plan.
+type DunningPolicy struct {
+	RetryDelays []time.Duration
+	GracePeriod time.Duration
+}
+
+// Start moves the subscription to past_due as its payment fails at now, scheduling the first retry
+func (p DunningPolicy) Start(subscription *entity.Subscription, now time.Time) {
+	pastDueAt := now.UnixMilli()
+	gracePeriodEndsAt := now.Add(p.GracePeriod).UnixMilli()
+
+	subscription.Status = entity.SubscriptionStatusPastDue
+	subscription.PastDueAt = &pastDueAt
+	subscription.GracePeriodEndsAt = &gracePeriodEndsAt
+	subscription.PaymentRetryCount = 0
+	p.scheduleNext(subscription)
+}
+
+// RetryFailed counts a failed retry and schedules the next one
+func (p DunningPolicy) RetryFailed(subscription *entity.Subscription) {
+	subscription.PaymentRetryCount++
+	p.scheduleNext(subscription)
+}
+
+// scheduleNext sets NextDunningAt to the next retry that falls within the grace period, or else to its end
+func (p DunningPolicy) scheduleNext(subscription *entity.Subscription) {
+	next := *subscription.GracePeriodEndsAt
+	if subscription.PaymentRetryCount < len(p.RetryDelays) {
+		next = min(next, time.UnixMilli(*subscription.PastDueAt).Add(p.RetryDelays[subscription.PaymentRetryCount]).UnixMilli())
+	}
+	subscription.NextDunningAt = &next
+}
+
+// EndDunning returns a past_due subscription whose payment was collected to active
+func EndDunning(subscription *entity.Subscription) {
+	subscription.Status = entity.SubscriptionStatusActive
+	subscription.PastDueAt = nil
+	subscription.GracePeriodEndsAt = nil
+	subscription.PaymentRetryCount = 0
+	subscription.NextDunningAt = nil
+}
+
+// GracePeriodEnded reports whether a past_due subscription ran out of time to pay at now
+func GracePeriodEnded(subscription *entity.Subscription, now time.Time) bool {
+	return subscription.GracePeriodEndsAt != nil && *subscription.GracePeriodEndsAt <= now.UnixMilli()
+}
@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"encoding/json"
+	"errors"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/repository"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// EntitlementService resolves what an organization's plan allows and checks actions against it
+type EntitlementService struct {
+	Log                          *logrus.Logger
+	SubscriptionRepository       *repository.SubscriptionRepository
+	OrganizationMemberRepository *repository.OrganizationMemberRepository
+	InvitationRepository         *repository.OrganizationInvitationRepository
+}
+
+func NewEntitlementService(
+	logger *logrus.Logger,
+	subRepo *repository.SubscriptionRepository,
+	orgMemberRepo *repository.OrganizationMemberRepository,
+	invitationRepo *repository.OrganizationInvitationRepository,
+) *EntitlementService {
+	return &EntitlementService{
+		Log:                          logger,
+		SubscriptionRepository:       subRepo,
+		OrganizationMemberRepository: orgMemberRepo,
+		InvitationRepository:         invitationRepo,
+	}
+}
+
+// Get returns the entitlements of the organization's active subscription
+func (s *EntitlementService) Get(tx *gorm.DB, orgID string) (*model.Entitlements, error) {
+	subscription := new(entity.Subscription)
+	if err := s.SubscriptionRepository.FindActiveByOrganization(tx, subscription, orgID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.Log.Warnf("No active subscription for organization %s", orgID)
+			return nil, fiber.NewError(fiber.StatusPaymentRequired, "No active subscription")
+		}
+		s.Log.Warnf("Failed to find subscription: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	entitlements, err := ParseEntitlements(&subscription.Plan)
+	if err != nil {
+		s.Log.Warnf("Failed to parse entitlements of plan %s: %+v", subscription.Plan.Slug, err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return entitlements, nil
+}
+
+// CheckMemberLimit checks that one more member fits in the plan's max_users.
+// Pending invitations hold a seat when includePending is set.
+func (s *EntitlementService) CheckMemberLimit(tx *gorm.DB, orgID string, includePending bool) error {
+	entitlements, err := s.Get(tx, orgID)
+	if err != nil {
+		return err
+	}
+
+	seats, err := s.countSeats(tx, orgID, includePending)
+	if err != nil {
+		return err
+	}
+
+	return entitlements.CheckLimit(entity.PlanLimitMaxUsers, seats, 1)
+}
+
+// CheckFeature checks that the organization's plan includes the feature
+func (s *EntitlementService) CheckFeature(tx *gorm.DB, orgID string, feature string) error {
+	entitlements, err := s.Get(tx, orgID)
+	if err != nil {
+		return err
+	}
+
+	return entitlements.CheckFeature(feature)
+}
+
+// Usage returns the organization's current consumption of its limits
+func (s *EntitlementService) Usage(tx *gorm.DB, orgID string) (*model.EntitlementUsage, error) {
+	users, err := s.countSeats(tx, orgID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.EntitlementUsage{Users: users}, nil
+}
+
+func (s *EntitlementService) countSeats(tx *gorm.DB, orgID string, includePending bool) (int64, error) {
+	members, err := s.OrganizationMemberRepository.CountByOrganization(tx, orgID)
+	if err != nil {
+		s.Log.Warnf("Failed to count organization members: %+v", err)
+		return 0, fiber.ErrInternalServerError
+	}
+
+	if !includePending {
+		return members, nil
+	}
+
+	pending, err := s.InvitationRepository.CountPendingByOrganization(tx, orgID, time.Now().UnixMilli())
+	if err != nil {
+		s.Log.Warnf("Failed to count pending invitations: %+v", err)
+		return 0, fiber.ErrInternalServerError
+	}
+
+	return members + pending, nil
+}
+
+// ParseEntitlements reads Plan.Limits and Plan.Features. Missing limits are unlimited.
+// Features may be a list of names or an object; object entries are enabled unless false, empty or zero.
+func ParseEntitlements(plan *entity.Plan) (*model.Entitlements, error) {
+	entitlements := &model.Entitlements{
+		PlanID:   plan.ID,
+		PlanSlug: plan.Slug,
+		Limits: model.PlanLimits{
+			MaxUsers:         entity.PlanLimitUnlimited,
+			APICallsPerMonth: entity.PlanLimitUnlimited,
+			StorageGB:        entity.PlanLimitUnlimited,
+		},
+		Features: map[string]bool{},
+	}
+
+	if plan.Limits != "" {
+		if err := json.Unmarshal([]byte(plan.Limits), &entitlements.Limits); err != nil {
+			return nil, err
+		}
+	}
+
+	if plan.Features == "" {
+		return entitlements, nil
+	}
+
+	var features interface{}
+	if err := json.Unmarshal([]byte(plan.Features), &features); err != nil {
+		return nil, err
+	}
+
+	switch value := features.(type) {
+	case []interface{}:
+		for _, item := range value {
+			if name, ok := item.(string); ok {
+				entitlements.Features[name] = true
+			}
+		}
+	case map[string]interface{}:
+		for name, item := range value {
+			switch item := item.(type) {
+			case bool:
+				entitlements.Features[name] = item
+			case string:
+				entitlements.Features[name] = item != ""
+			case float64:
+				entitlements.Features[name] = item != 0
+			default:
+				entitlements.Features[name] = item != nil
+			}
+		}
+	}
+
+	return entitlements, nil
+}
@@ -0,0 +1,354 @@
+package usecase
+
+import (
+	"context"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/model/converter"
+	"go-clean-arch-saas/internal/repository"
+	"go-clean-arch-saas/pkg/email"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// invitationTTL is how long an invitation link stays valid
+const invitationTTL = 7 * 24 * time.Hour
+
+type InvitationUseCase struct {
+	DB                           *gorm.DB
+	Log                          *logrus.Logger
+	Validate                     *validator.Validate
+	InvitationRepository         *repository.OrganizationInvitationRepository
+	OrganizationRepository       *repository.OrganizationRepository
+	OrganizationMemberRepository *repository.OrganizationMemberRepository
+	UserRepository               *repository.UserRepository
+	AuditService                 *AuditService
+	EntitlementService           *EntitlementService
+	SeatService                  *SeatService
+	EmailService                 *email.EmailService
+	BaseURL                      string
+}
+
+func NewInvitationUseCase(
+	db *gorm.DB,
+	logger *logrus.Logger,
+	validate *validator.Validate,
+	invitationRepo *repository.OrganizationInvitationRepository,
+	orgRepo *repository.OrganizationRepository,
+	orgMemberRepo *repository.OrganizationMemberRepository,
+	userRepo *repository.UserRepository,
+	auditService *AuditService,
+	entitlementService *EntitlementService,
+	seatService *SeatService,
+	emailService *email.EmailService,
+	baseURL string,
+) *InvitationUseCase {
+	return &InvitationUseCase{
+		DB:                           db,
+		Log:                          logger,
+		Validate:                     validate,
+		InvitationRepository:         invitationRepo,
+		OrganizationRepository:       orgRepo,
+		OrganizationMemberRepository: orgMemberRepo,
+		UserRepository:               userRepo,
+		AuditService:                 auditService,
+		EntitlementService:           entitlementService,
+		SeatService:                  seatService,
+		EmailService:                 emailService,
+		BaseURL:                      baseURL,
+	}
+}
+
+func (u *InvitationUseCase) Create(ctx context.Context, request *model.CreateInvitationRequest) (*model.InvitationResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	org := new(entity.Organization)
+	if err := u.OrganizationRepository.FindById(tx, org, request.OrganizationID); err != nil {
+		u.Log.Warnf("Failed to find organization: %+v", err)
+		return nil, fiber.ErrNotFound
+	}
+
+	inviter := new(entity.User)
+	if err := u.UserRepository.FindById(tx, inviter, request.InvitedBy); err != nil {
+		u.Log.Warnf("Failed to find inviter: %+v", err)
+		return nil, fiber.ErrNotFound
+	}
+
+	// Reject invitations for users who already belong to the organization
+	existingUser := new(entity.User)
+	if err := u.UserRepository.FindByEmail(tx, existingUser, request.Email); err == nil {
+		member := new(entity.OrganizationMember)
+		if err := u.OrganizationMemberRepository.FindByOrgAndUser(tx, member, org.ID, existingUser.ID); err == nil {
+			u.Log.Warnf("User %s is already a member of organization %s", request.Email, org.ID)
+			return nil, fiber.NewError(fiber.StatusConflict, "User is already a member of this organization")
+		}
+	}
+
+	now := time.Now()
+	count, err := u.InvitationRepository.CountPendingByOrgAndEmail(tx, org.ID, request.Email, now.UnixMilli())
+	if err != nil {
+		u.Log.Warnf("Failed to count pending invitations: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+	if count > 0 {
+		u.Log.Warnf("Pending invitation already exists for %s", request.Email)
+		return nil, fiber.NewError(fiber.StatusConflict, "A pending invitation already exists for this email")
+	}
+
+	// Pending invitations hold a seat so admins cannot over-invite
+	if err := u.EntitlementService.CheckMemberLimit(tx, org.ID, true); err != nil {
+		u.Log.Warnf("Organization %s cannot invite more members: %+v", org.ID, err)
+		return nil, err
+	}
+
+	token, err := generateVerificationToken()
+	if err != nil {
+		u.Log.Warnf("Failed to generate invitation token: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	invitation := &entity.OrganizationInvitation{
+		ID:             uuid.New().String(),
+		OrganizationID: org.ID,
+		Email:          request.Email,
+		Role:           request.Role,
+		TokenHash:      hashToken(token),
+		Status:         entity.InvitationStatusPending,
+		InvitedBy:      &inviter.ID,
+		ExpiresAt:      now.Add(invitationTTL).UnixMilli(),
+		CreatedAt:      now.UnixMilli(),
+		UpdatedAt:      now.UnixMilli(),
+	}
+
+	if err := u.InvitationRepository.Create(tx, invitation); err != nil {
+		u.Log.Warnf("Failed to create invitation: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         inviter.ID,
+		OrganizationID: org.ID,
+		Action:         entity.AuditActionInvitationCreate,
+		Resource:       entity.AuditResourceInvitation,
+		ResourceID:     invitation.ID,
+		Details:        map[string]interface{}{"email": invitation.Email, "role": invitation.Role},
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	// Send invitation email (non-blocking)
+	go func() {
+		if err := u.EmailService.SendInvitationEmail(invitation.Email, org.Name, inviter.Name, invitation.Role, token, u.BaseURL); err != nil {
+			u.Log.Warnf("Failed to send invitation email to %s: %+v", invitation.Email, err)
+		} else {
+			u.Log.Infof("Invitation email sent to %s", invitation.Email)
+		}
+	}()
+
+	return converter.InvitationToResponse(invitation), nil
+}
+
+func (u *InvitationUseCase) List(ctx context.Context, request *model.ListInvitationsRequest) ([]model.InvitationResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	invitations, err := u.InvitationRepository.ListPendingByOrganization(tx, request.OrganizationID, time.Now().UnixMilli())
+	if err != nil {
+		u.Log.Warnf("Failed to list invitations: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	responses := make([]model.InvitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		responses = append(responses, *converter.InvitationToResponse(&invitation))
+	}
+
+	return responses, nil
+}
+
+func (u *InvitationUseCase) Revoke(ctx context.Context, request *model.RevokeInvitationRequest) error {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	invitation := new(entity.OrganizationInvitation)
+	if err := u.InvitationRepository.FindByOrgAndID(tx, invitation, request.OrganizationID, request.ID); err != nil {
+		u.Log.Warnf("Invitation not found: %+v", err)
+		return fiber.ErrNotFound
+	}
+
+	if invitation.Status != entity.InvitationStatusPending {
+		u.Log.Warnf("Invitation %s is %s, cannot revoke", invitation.ID, invitation.Status)
+		return fiber.NewError(fiber.StatusConflict, "Invitation is no longer pending")
+	}
+
+	invitation.Status = entity.InvitationStatusRevoked
+	if err := u.InvitationRepository.Update(tx, invitation); err != nil {
+		u.Log.Warnf("Failed to revoke invitation: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         request.ActorID,
+		OrganizationID: request.OrganizationID,
+		Action:         entity.AuditActionInvitationRevoke,
+		Resource:       entity.AuditResourceInvitation,
+		ResourceID:     invitation.ID,
+		Details:        map[string]interface{}{"email": invitation.Email},
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (u *InvitationUseCase) Accept(ctx context.Context, request *model.AcceptInvitationRequest) (*model.AcceptInvitationResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	invitation := new(entity.OrganizationInvitation)
+	if err := u.InvitationRepository.FindByTokenHash(tx, invitation, hashToken(request.Token)); err != nil {
+		u.Log.Warnf("Invalid invitation token: %+v", err)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid or expired invitation")
+	}
+
+	now := time.Now().UnixMilli()
+	if !invitation.IsPending(now) {
+		u.Log.Warnf("Invitation %s is not pending (status: %s)", invitation.ID, invitation.Status)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid or expired invitation")
+	}
+
+	// Find invitee, creating the account if the email is new
+	user := new(entity.User)
+	if err := u.UserRepository.FindByEmail(tx, user, invitation.Email); err != nil {
+		if request.Name == "" || request.Password == "" {
+			u.Log.Warnf("Missing name or password for new invitee: %s", invitation.Email)
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Name and password are required to create an account")
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+		if err != nil {
+			u.Log.Warnf("Failed to hash password: %+v", err)
+			return nil, fiber.ErrInternalServerError
+		}
+
+		// Email is verified since the token was delivered to it
+		user = &entity.User{
+			ID:              uuid.New().String(),
+			Name:            request.Name,
+			Email:           invitation.Email,
+			Password:        string(hashedPassword),
+			SystemRole:      entity.SystemRoleUser,
+			EmailVerified:   true,
+			EmailVerifiedAt: &now,
+			OrganizationID:  invitation.OrganizationID,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+
+		if err := u.UserRepository.Create(tx, user); err != nil {
+			u.Log.Warnf("Failed to create user: %+v", err)
+			return nil, fiber.ErrInternalServerError
+		}
+	}
+
+	member := new(entity.OrganizationMember)
+	if err := u.OrganizationMemberRepository.FindByOrgAndUser(tx, member, invitation.OrganizationID, user.ID); err == nil {
+		u.Log.Warnf("User %s is already a member of organization %s", user.ID, invitation.OrganizationID)
+		return nil, fiber.NewError(fiber.StatusConflict, "User is already a member of this organization")
+	}
+
+	if err := u.EntitlementService.CheckMemberLimit(tx, invitation.OrganizationID, false); err != nil {
+		u.Log.Warnf("Organization %s cannot add members: %+v", invitation.OrganizationID, err)
+		return nil, err
+	}
+
+	member = &entity.OrganizationMember{
+		OrganizationID: invitation.OrganizationID,
+		UserID:         user.ID,
+		Role:           invitation.Role,
+		JoinedAt:       now,
+	}
+
+	if err := u.OrganizationMemberRepository.Create(tx, member); err != nil {
+		u.Log.Warnf("Failed to create organization member: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := u.SeatService.Sync(ctx, tx, invitation.OrganizationID, user.ID); err != nil {
+		return nil, err
+	}
+
+	invitation.Status = entity.InvitationStatusAccepted
+	invitation.AcceptedAt = &now
+	if err := u.InvitationRepository.Update(tx, invitation); err != nil {
+		u.Log.Warnf("Failed to update invitation: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         user.ID,
+		OrganizationID: invitation.OrganizationID,
+		Action:         entity.AuditActionMemberJoin,
+		Resource:       entity.AuditResourceOrganizationMember,
+		ResourceID:     user.ID,
+		Details:        map[string]interface{}{"invitation_id": invitation.ID, "role": member.Role},
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return &model.AcceptInvitationResponse{
+		User:         *converter.UserToResponse(user),
+		Organization: *converter.OrganizationToResponse(&invitation.Organization),
+		Role:         member.Role,
+	}, nil
+}
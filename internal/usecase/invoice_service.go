@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"fmt"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// InvoiceService issues invoices for subscription periods inside the caller's transaction
+type InvoiceService struct {
+	Log               *logrus.Logger
+	InvoiceRepository *repository.InvoiceRepository
+	CouponService     *CouponService
+	Currency          string
+}
+
+func NewInvoiceService(logger *logrus.Logger, invoiceRepo *repository.InvoiceRepository, couponService *CouponService, currency string) *InvoiceService {
+	return &InvoiceService{
+		Log:               logger,
+		InvoiceRepository: invoiceRepo,
+		CouponService:     couponService,
+		Currency:          currency,
+	}
+}
+
+// Generate issues an invoice charging the subscription's plan for its current period, less the discount of
+// its coupon, followed by any extra lines such as a proration credit and the subscription's pending items,
+// which are then cleared. Subscription.Plan must be loaded.
+// Nothing is issued when every line is zero, so free plans produce no invoices.
+func (s *InvoiceService) Generate(tx *gorm.DB, subscription *entity.Subscription, extra ...entity.InvoiceLineItem) (*entity.Invoice, error) {
+	lines := []entity.InvoiceLineItem{SubscriptionLine(subscription)}
+
+	coupon, err := s.CouponService.ForPeriod(tx, subscription, &subscription.Plan, subscription.CurrentPeriodStart)
+	if err != nil {
+		return nil, err
+	}
+	if coupon != nil {
+		lines = append(lines, DiscountLine(subscription, coupon))
+	}
+	lines = append(lines, extra...)
+
+	pending, err := s.InvoiceRepository.FindPendingItems(tx, subscription.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range pending {
+		lines = append(lines, PendingLine(&item))
+	}
+
+	billable := false
+	total := 0.0
+	for _, line := range lines {
+		billable = billable || line.Amount != 0
+		total += line.Amount
+	}
+	if !billable {
+		return nil, nil
+	}
+
+	sequence, err := s.InvoiceRepository.NextSequence(tx, subscription.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixMilli()
+	invoice := &entity.Invoice{
+		ID:             uuid.New().String(),
+		OrganizationID: subscription.OrganizationID,
+		SubscriptionID: subscription.ID,
+		Number:         invoiceNumber(sequence),
+		Sequence:       sequence,
+		Status:         entity.InvoiceStatusOpen,
+		Currency:       s.Currency,
+		Total:          roundCents(total),
+		AmountDue:      roundCents(max(total, 0)),
+		PeriodStart:    subscription.CurrentPeriodStart,
+		PeriodEnd:      subscription.CurrentPeriodEnd,
+		IssuedAt:       now,
+	}
+
+	// Fully credited invoices have nothing to collect
+	if invoice.AmountDue == 0 {
+		invoice.Status = entity.InvoiceStatusPaid
+		invoice.PaidAt = &now
+	}
+
+	for i := range lines {
+		lines[i].ID = uuid.New().String()
+		lines[i].Position = i + 1
+	}
+	invoice.LineItems = lines
+
+	if err := s.InvoiceRepository.Create(tx, invoice); err != nil {
+		return nil, err
+	}
+
+	if len(pending) > 0 {
+		if err := s.InvoiceRepository.DeletePendingItems(tx, subscription.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return invoice, nil
+}
+
+// SubscriptionLine charges the subscription's plan price for its current period, per seat on a per-seat plan
+func SubscriptionLine(subscription *entity.Subscription) entity.InvoiceLineItem {
+	planID := subscription.PlanID
+	plan := BillingPlan(subscription)
+	quantity := int64(1)
+	if plan.PricingModel == entity.PricingModelPerSeat {
+		quantity = int64(max(subscription.Quantity, 1))
+	}
+	return entity.InvoiceLineItem{
+		Kind:        entity.InvoiceLineKindSubscription,
+		Description: plan.Name,
+		PlanID:      &planID,
+		Quantity:    quantity,
+		UnitAmount:  plan.Price,
+		Amount:      PlanAmount(plan, subscription.Quantity),
+		PeriodStart: subscription.CurrentPeriodStart,
+		PeriodEnd:   subscription.CurrentPeriodEnd,
+	}
+}
+
+// DiscountLine takes the coupon's discount off the subscription's plan price for its current period
+func DiscountLine(subscription *entity.Subscription, coupon *entity.Coupon) entity.InvoiceLineItem {
+	amount := DiscountAmount(coupon, PlanAmount(BillingPlan(subscription), subscription.Quantity))
+	return entity.InvoiceLineItem{
+		Kind:        entity.InvoiceLineKindDiscount,
+		Description: coupon.Name + " (" + coupon.Code + ")",
+		Quantity:    1,
+		UnitAmount:  -amount,
+		Amount:      -amount,
+		PeriodStart: subscription.CurrentPeriodStart,
+		PeriodEnd:   subscription.CurrentPeriodEnd,
+	}
+}
+
+// ProrationCreditLine credits the unused time of the subscription being replaced.
+// Call it before the previous subscription's period is cut short.
+func ProrationCreditLine(previous *entity.Subscription, proration *model.ProrationResponse) entity.InvoiceLineItem {
+	planID := previous.PlanID
+	return entity.InvoiceLineItem{
+		Kind:        entity.InvoiceLineKindProration,
+		Description: "Unused time on " + previous.Plan.Name,
+		PlanID:      &planID,
+		Quantity:    1,
+		UnitAmount:  -proration.Credit,
+		Amount:      -proration.Credit,
+		PeriodStart: proration.EffectiveAt,
+		PeriodEnd:   previous.CurrentPeriodEnd,
+	}
+}
+
+// PendingLine turns an item waiting for the subscription's next invoice into a line of it
+func PendingLine(item *entity.PendingInvoiceItem) entity.InvoiceLineItem {
+	return entity.InvoiceLineItem{
+		Kind:        item.Kind,
+		Description: item.Description,
+		PlanID:      item.PlanID,
+		Quantity:    item.Quantity,
+		UnitAmount:  item.UnitAmount,
+		Amount:      item.Amount,
+		PeriodStart: item.PeriodStart,
+		PeriodEnd:   item.PeriodEnd,
+	}
+}
+
+// invoiceNumber formats an organization's invoice sequence, e.g. INV-000042
+func invoiceNumber(sequence int64) string {
+	return fmt.Sprintf("INV-%06d", sequence)
+}
@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"context"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/model/converter"
+	"go-clean-arch-saas/internal/repository"
+	"go-clean-arch-saas/pkg/invoice"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type InvoiceUseCase struct {
+	DB                     *gorm.DB
+	Log                    *logrus.Logger
+	Validate               *validator.Validate
+	InvoiceRepository      *repository.InvoiceRepository
+	OrganizationRepository *repository.OrganizationRepository
+	Renderer               *invoice.Renderer
+}
+
+func NewInvoiceUseCase(
+	db *gorm.DB,
+	logger *logrus.Logger,
+	validate *validator.Validate,
+	invoiceRepo *repository.InvoiceRepository,
+	orgRepo *repository.OrganizationRepository,
+	renderer *invoice.Renderer,
+) *InvoiceUseCase {
+	return &InvoiceUseCase{
+		DB:                     db,
+		Log:                    logger,
+		Validate:               validate,
+		InvoiceRepository:      invoiceRepo,
+		OrganizationRepository: orgRepo,
+		Renderer:               renderer,
+	}
+}
+
+func (u *InvoiceUseCase) Search(ctx context.Context, request *model.SearchInvoiceRequest) (*model.PageResponse[model.InvoiceResponse], error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	invoices, total, err := u.InvoiceRepository.Search(tx, request)
+	if err != nil {
+		u.Log.Warnf("Failed to search invoices: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	responses := make([]model.InvoiceResponse, 0, len(invoices))
+	for _, invoice := range invoices {
+		responses = append(responses, *converter.InvoiceToResponse(&invoice))
+	}
+
+	totalPage := total / int64(request.Size)
+	if total%int64(request.Size) > 0 {
+		totalPage++
+	}
+
+	return &model.PageResponse[model.InvoiceResponse]{
+		Data: responses,
+		PageMetadata: model.PageMetadata{
+			Page:      request.Page,
+			Size:      request.Size,
+			TotalItem: total,
+			TotalPage: totalPage,
+		},
+	}, nil
+}
+
+func (u *InvoiceUseCase) Get(ctx context.Context, request *model.GetInvoiceRequest) (*model.InvoiceResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	invoice, err := u.find(tx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.InvoiceToResponse(invoice), nil
+}
+
+// RenderHTML returns the invoice as a printable HTML page
+func (u *InvoiceUseCase) RenderHTML(ctx context.Context, request *model.GetInvoiceRequest) ([]byte, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	found, err := u.find(tx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	organization := new(entity.Organization)
+	if err := u.OrganizationRepository.FindById(tx, organization, found.OrganizationID); err != nil {
+		u.Log.Warnf("Failed to find organization: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	document := &invoice.Document{
+		Number:           found.Number,
+		OrganizationName: organization.Name,
+		Status:           found.Status,
+		Currency:         found.Currency,
+		IssuedAt:         found.IssuedAt,
+		PeriodStart:      found.PeriodStart,
+		PeriodEnd:        found.PeriodEnd,
+		Total:            found.Total,
+		AmountDue:        found.AmountDue,
+	}
+	if found.PaidAt != nil {
+		document.PaidAt = *found.PaidAt
+	}
+	for _, item := range found.LineItems {
+		document.Lines = append(document.Lines, invoice.Line{
+			Description: item.Description,
+			Quantity:    item.Quantity,
+			UnitAmount:  item.UnitAmount,
+			Amount:      item.Amount,
+			PeriodStart: item.PeriodStart,
+			PeriodEnd:   item.PeriodEnd,
+		})
+	}
+
+	body, err := u.Renderer.RenderHTML(document)
+	if err != nil {
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return body, nil
+}
+
+func (u *InvoiceUseCase) find(tx *gorm.DB, request *model.GetInvoiceRequest) (*entity.Invoice, error) {
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	invoice := new(entity.Invoice)
+	if err := u.InvoiceRepository.FindByIdAndOrganization(tx, invoice, request.ID, request.OrganizationID); err != nil {
+		u.Log.Warnf("Failed to find invoice: %+v", err)
+		return nil, fiber.ErrNotFound
+	}
+
+	return invoice, nil
+}
@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/repository"
+	"go-clean-arch-saas/pkg/secret"
+	"go-clean-arch-saas/pkg/totp"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Second factors a code can be verified as
+const (
+	MFAMethodTOTP         = "totp"
+	MFAMethodRecoveryCode = "recovery_code"
+)
+
+const (
+	// mfaChallengeTTL is how long the second step of a login may take
+	mfaChallengeTTL = 5 * time.Minute
+	// mfaMaxFailedAttempts is how many wrong codes lock the second step of logins for mfaLockout
+	mfaMaxFailedAttempts = 5
+	mfaLockout           = 15 * time.Minute
+	// mfaClockSkew is how many time steps a TOTP code may be off, for authenticators with a drifting clock
+	mfaClockSkew = 1
+	// recoveryCodeCount is how many recovery codes a user gets at a time
+	recoveryCodeCount = 10
+)
+
+// MFAService manages TOTP secrets and recovery codes and verifies second factors.
+// Secrets are stored encrypted; recovery codes only as their hash.
+type MFAService struct {
+	Log            *logrus.Logger
+	UserRepository *repository.UserRepository
+	SecretBox      *secret.Box
+	Issuer         string
+}
+
+func NewMFAService(logger *logrus.Logger, userRepo *repository.UserRepository, secretBox *secret.Box, issuer string) *MFAService {
+	return &MFAService{
+		Log:            logger,
+		UserRepository: userRepo,
+		SecretBox:      secretBox,
+		Issuer:         issuer,
+	}
+}
+
+// NewSecret sets a new encrypted TOTP secret on the user, without saving it, and returns it with its otpauth URI
+func (s *MFAService) NewSecret(user *entity.User) (string, string, error) {
+	key, err := totp.GenerateSecret()
+	if err != nil {
+		s.Log.Warnf("Failed to generate TOTP secret: %+v", err)
+		return "", "", fiber.ErrInternalServerError
+	}
+
+	sealed, err := s.SecretBox.Seal(key)
+	if err != nil {
+		s.Log.Warnf("Failed to encrypt TOTP secret: %+v", err)
+		return "", "", fiber.ErrInternalServerError
+	}
+
+	user.MFASecret = &sealed
+	user.MFALastUsedStep = 0
+	return key, totp.URI(s.Issuer, user.Email, key), nil
+}
+
+// VerifyTOTP checks a code from the user's authenticator app. A code is accepted once: its time step is
+// saved on the user, which the caller must have locked.
+func (s *MFAService) VerifyTOTP(tx *gorm.DB, user *entity.User, code string, now time.Time) (bool, error) {
+	if user.MFASecret == nil {
+		return false, nil
+	}
+
+	key, err := s.SecretBox.Open(*user.MFASecret)
+	if err != nil {
+		s.Log.Warnf("Failed to decrypt TOTP secret of user %s: %+v", user.ID, err)
+		return false, fiber.ErrInternalServerError
+	}
+
+	step, ok := totp.Validate(key, strings.TrimSpace(code), now, mfaClockSkew)
+	if !ok || step <= user.MFALastUsedStep {
+		return false, nil
+	}
+
+	user.MFALastUsedStep = step
+	if err := s.UserRepository.Update(tx, user); err != nil {
+		s.Log.Warnf("Failed to update user: %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+
+	return true, nil
+}
+
+// Verify checks a code from the authenticator app or an unused recovery code, which it uses up.
+// It returns the method the code was verified as, or an empty string when the code is invalid.
+func (s *MFAService) Verify(tx *gorm.DB, user *entity.User, code string, now time.Time) (string, error) {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		ok, err := s.VerifyTOTP(tx, user, code, now)
+		if err != nil || !ok {
+			return "", err
+		}
+		return MFAMethodTOTP, nil
+	}
+
+	used, err := s.UserRepository.UseRecoveryCode(tx, user.ID, hashToken(normalizeRecoveryCode(code)), now.UnixMilli())
+	if err != nil {
+		s.Log.Warnf("Failed to use recovery code: %+v", err)
+		return "", fiber.ErrInternalServerError
+	}
+	if !used {
+		return "", nil
+	}
+	return MFAMethodRecoveryCode, nil
+}
+
+// GenerateRecoveryCodes replaces the user's recovery codes and returns the new ones in plain text
+func (s *MFAService) GenerateRecoveryCodes(tx *gorm.DB, userID string) ([]string, error) {
+	if err := s.UserRepository.DeleteRecoveryCodes(tx, userID); err != nil {
+		s.Log.Warnf("Failed to delete recovery codes: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]entity.RecoveryCode, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		bytes := make([]byte, 5)
+		if _, err := rand.Read(bytes); err != nil {
+			s.Log.Warnf("Failed to generate recovery code: %+v", err)
+			return nil, fiber.ErrInternalServerError
+		}
+
+		code := hex.EncodeToString(bytes)
+		codes = append(codes, code[:5]+"-"+code[5:])
+		records = append(records, entity.RecoveryCode{
+			ID:       uuid.New().String(),
+			UserID:   userID,
+			CodeHash: hashToken(code),
+		})
+	}
+
+	if err := s.UserRepository.CreateRecoveryCodes(tx, records); err != nil {
+		s.Log.Warnf("Failed to create recovery codes: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return codes, nil
+}
+
+// normalizeRecoveryCode accepts recovery codes typed in any case and with or without the dash
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -0,0 +1,295 @@
+package usecase
+
+import (
+	"context"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/repository"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// MFAUseCase lets users enroll in, confirm and disable TOTP two-factor authentication
+type MFAUseCase struct {
+	DB             *gorm.DB
+	Log            *logrus.Logger
+	Validate       *validator.Validate
+	UserRepository *repository.UserRepository
+	MFAService     *MFAService
+	AuditService   *AuditService
+}
+
+func NewMFAUseCase(
+	db *gorm.DB,
+	logger *logrus.Logger,
+	validate *validator.Validate,
+	userRepo *repository.UserRepository,
+	mfaService *MFAService,
+	auditService *AuditService,
+) *MFAUseCase {
+	return &MFAUseCase{
+		DB:             db,
+		Log:            logger,
+		Validate:       validate,
+		UserRepository: userRepo,
+		MFAService:     mfaService,
+		AuditService:   auditService,
+	}
+}
+
+func (u *MFAUseCase) Status(ctx context.Context, request *model.MFAStatusRequest) (*model.MFAStatusResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	user := new(entity.User)
+	if err := u.UserRepository.FindById(tx, user, request.UserID); err != nil {
+		u.Log.Warnf("Failed to find user: %+v", err)
+		return nil, fiber.ErrNotFound
+	}
+
+	remaining, err := u.UserRepository.CountUnusedRecoveryCodes(tx, user.ID)
+	if err != nil {
+		u.Log.Warnf("Failed to count recovery codes: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return &model.MFAStatusResponse{
+		Enabled:                user.MFAEnabled,
+		EnabledAt:              user.MFAEnabledAt,
+		RecoveryCodesRemaining: remaining,
+	}, nil
+}
+
+// Enroll starts enrollment with a new TOTP secret. Two-factor authentication is only enabled once Confirm
+// receives a first code; enrolling again before that replaces the secret.
+func (u *MFAUseCase) Enroll(ctx context.Context, request *model.MFAEnrollRequest) (*model.MFAEnrollResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	user := new(entity.User)
+	if err := u.UserRepository.FindByIdForUpdate(tx, user, request.UserID); err != nil {
+		u.Log.Warnf("Failed to find user: %+v", err)
+		return nil, fiber.ErrNotFound
+	}
+	if user.MFAEnabled {
+		u.Log.Warnf("Two-factor authentication already enabled for user %s", user.ID)
+		return nil, fiber.NewError(fiber.StatusConflict, "Two-factor authentication is already enabled")
+	}
+
+	secret, uri, err := u.MFAService.NewSecret(user)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.UserRepository.Update(tx, user); err != nil {
+		u.Log.Warnf("Failed to update user: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return &model.MFAEnrollResponse{
+		Secret:     secret,
+		OTPAuthURI: uri,
+	}, nil
+}
+
+// Confirm enables two-factor authentication once the user proves their authenticator app works,
+// and returns their recovery codes
+func (u *MFAUseCase) Confirm(ctx context.Context, request *model.MFACodeRequest) (*model.MFARecoveryCodesResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	user := new(entity.User)
+	if err := u.UserRepository.FindByIdForUpdate(tx, user, request.UserID); err != nil {
+		u.Log.Warnf("Failed to find user: %+v", err)
+		return nil, fiber.ErrNotFound
+	}
+	if user.MFAEnabled {
+		u.Log.Warnf("Two-factor authentication already enabled for user %s", user.ID)
+		return nil, fiber.NewError(fiber.StatusConflict, "Two-factor authentication is already enabled")
+	}
+	if user.MFASecret == nil {
+		u.Log.Warnf("No two-factor enrollment to confirm for user %s", user.ID)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Two-factor enrollment has not been started")
+	}
+
+	now := time.Now()
+	ok, err := u.MFAService.VerifyTOTP(tx, user, request.Code, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		u.Log.Warnf("Invalid MFA code for user %s", user.ID)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid verification code")
+	}
+
+	enabledAt := now.UnixMilli()
+	user.MFAEnabled = true
+	user.MFAEnabledAt = &enabledAt
+	if err := u.UserRepository.Update(tx, user); err != nil {
+		u.Log.Warnf("Failed to update user: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	codes, err := u.MFAService.GenerateRecoveryCodes(tx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         user.ID,
+		OrganizationID: request.OrganizationID,
+		Action:         entity.AuditActionMFAEnable,
+		Resource:       entity.AuditResourceUser,
+		ResourceID:     user.ID,
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return &model.MFARecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Disable turns two-factor authentication off, given a current code or a recovery code
+func (u *MFAUseCase) Disable(ctx context.Context, request *model.MFACodeRequest) error {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	user, err := u.verifyEnabled(tx, request)
+	if err != nil {
+		return err
+	}
+
+	user.MFAEnabled = false
+	user.MFASecret = nil
+	user.MFAEnabledAt = nil
+	user.MFALastUsedStep = 0
+	if err := u.UserRepository.Update(tx, user); err != nil {
+		u.Log.Warnf("Failed to update user: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	if err := u.UserRepository.DeleteRecoveryCodes(tx, user.ID); err != nil {
+		u.Log.Warnf("Failed to delete recovery codes: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         user.ID,
+		OrganizationID: request.OrganizationID,
+		Action:         entity.AuditActionMFADisable,
+		Resource:       entity.AuditResourceUser,
+		ResourceID:     user.ID,
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes, given a current code or a recovery code
+func (u *MFAUseCase) RegenerateRecoveryCodes(ctx context.Context, request *model.MFACodeRequest) (*model.MFARecoveryCodesResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	user, err := u.verifyEnabled(tx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, err := u.MFAService.GenerateRecoveryCodes(tx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         user.ID,
+		OrganizationID: request.OrganizationID,
+		Action:         entity.AuditActionMFARecoveryCodes,
+		Resource:       entity.AuditResourceUser,
+		ResourceID:     user.ID,
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return &model.MFARecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// verifyEnabled loads and locks the user, who must have two-factor authentication enabled, and verifies the request's code
+func (u *MFAUseCase) verifyEnabled(tx *gorm.DB, request *model.MFACodeRequest) (*entity.User, error) {
+	user := new(entity.User)
+	if err := u.UserRepository.FindByIdForUpdate(tx, user, request.UserID); err != nil {
+		u.Log.Warnf("Failed to find user: %+v", err)
+		return nil, fiber.ErrNotFound
+	}
+	if !user.MFAEnabled {
+		u.Log.Warnf("Two-factor authentication not enabled for user %s", user.ID)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Two-factor authentication is not enabled")
+	}
+
+	method, err := u.MFAService.Verify(tx, user, request.Code, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if method == "" {
+		u.Log.Warnf("Invalid MFA code for user %s", user.ID)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid verification code")
+	}
+
+	return user, nil
+}
@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/pkg/oauth"
+	"go-clean-arch-saas/pkg/secret"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// oauthStateTTL is how long a user has to complete the provider's consent page
+const oauthStateTTL = 10 * time.Minute
+
+// oauthState is the state of an authorization request, sealed into the state token so no state is stored
+// server side. The PKCE verifier and nonce must stay secret, hence encrypted rather than signed.
+type oauthState struct {
+	Provider     string `json:"provider"`
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+	ExpiresAt    int64  `json:"expires_at"`
+}
+
+// OAuthService runs the authorization code flow with the configured OAuth and OpenID Connect providers
+type OAuthService struct {
+	Log       *logrus.Logger
+	Providers map[string]*oauth.Provider
+	SecretBox *secret.Box
+}
+
+func NewOAuthService(logger *logrus.Logger, providers map[string]*oauth.Provider, secretBox *secret.Box) *OAuthService {
+	return &OAuthService{
+		Log:       logger,
+		Providers: providers,
+		SecretBox: secretBox,
+	}
+}
+
+// ProviderNames lists the configured providers in alphabetical order
+func (s *OAuthService) ProviderNames() []string {
+	names := make([]string, 0, len(s.Providers))
+	for name := range s.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Authorize starts a login with the provider
+func (s *OAuthService) Authorize(name string) (*model.OAuthAuthorizeResponse, error) {
+	provider, ok := s.Providers[name]
+	if !ok {
+		s.Log.Warnf("OAuth provider not configured: %s", name)
+		return nil, fiber.NewError(fiber.StatusNotFound, "Provider not found")
+	}
+
+	state := &oauthState{Provider: name, ExpiresAt: time.Now().Add(oauthStateTTL).UnixMilli()}
+	for _, value := range []*string{&state.State, &state.Nonce, &state.CodeVerifier} {
+		random, err := oauth.RandomString()
+		if err != nil {
+			s.Log.Warnf("Failed to generate OAuth state: %+v", err)
+			return nil, fiber.ErrInternalServerError
+		}
+		*value = random
+	}
+
+	payload, err := json.Marshal(state)
+	if err != nil {
+		s.Log.Warnf("Failed to encode OAuth state: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+	stateToken, err := s.SecretBox.Seal(string(payload))
+	if err != nil {
+		s.Log.Warnf("Failed to seal OAuth state: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return &model.OAuthAuthorizeResponse{
+		AuthorizationURL: provider.AuthCodeURL(state.State, state.Nonce, state.CodeVerifier),
+		StateToken:       stateToken,
+	}, nil
+}
+
+// Identify completes a login: it checks the callback belongs to the authorization request of the state token,
+// redeems the code and returns the user the provider signed in
+func (s *OAuthService) Identify(ctx context.Context, request *model.OAuthCallbackRequest) (*oauth.UserInfo, error) {
+	provider, ok := s.Providers[request.Provider]
+	if !ok {
+		s.Log.Warnf("OAuth provider not configured: %s", request.Provider)
+		return nil, fiber.NewError(fiber.StatusNotFound, "Provider not found")
+	}
+
+	payload, err := s.SecretBox.Open(request.StateToken)
+	if err != nil {
+		s.Log.Warnf("Invalid OAuth state token: %+v", err)
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired state token")
+	}
+	state := new(oauthState)
+	if err := json.Unmarshal([]byte(payload), state); err != nil {
+		s.Log.Warnf("Invalid OAuth state: %+v", err)
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired state token")
+	}
+
+	// The state returned by the provider ties the callback to the browser that started the login
+	if state.Provider != request.Provider ||
+		time.Now().UnixMilli() > state.ExpiresAt ||
+		subtle.ConstantTimeCompare([]byte(state.State), []byte(request.State)) != 1 {
+		s.Log.Warnf("OAuth state mismatch for provider %s", request.Provider)
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired state token")
+	}
+
+	token, err := provider.Exchange(ctx, request.Code, state.CodeVerifier)
+	if err != nil {
+		s.Log.Warnf("Failed to exchange OAuth code with %s: %+v", request.Provider, err)
+		return nil, fiber.ErrUnauthorized
+	}
+
+	info, err := provider.Identify(ctx, token, state.Nonce)
+	if err != nil {
+		s.Log.Warnf("Failed to identify OAuth user with %s: %+v", request.Provider, err)
+		return nil, fiber.ErrUnauthorized
+	}
+
+	return info, nil
+}
@@ -19,6 +19,8 @@ type OrganizationUseCase struct {
 	Validate                     *validator.Validate
 	OrganizationRepository       *repository.OrganizationRepository
 	OrganizationMemberRepository *repository.OrganizationMemberRepository
+	AuditService                 *AuditService
+	SeatService                  *SeatService
 }
 
 func NewOrganizationUseCase(
@@ -27,6 +29,8 @@ func NewOrganizationUseCase(
 	validate *validator.Validate,
 	orgRepo *repository.OrganizationRepository,
 	orgMemberRepo *repository.OrganizationMemberRepository,
+	auditService *AuditService,
+	seatService *SeatService,
 ) *OrganizationUseCase {
 	return &OrganizationUseCase{
 		DB:                           db,
@@ -34,6 +38,8 @@ func NewOrganizationUseCase(
 		Validate:                     validate,
 		OrganizationRepository:       orgRepo,
 		OrganizationMemberRepository: orgMemberRepo,
+		AuditService:                 auditService,
+		SeatService:                  seatService,
 	}
 }
 
@@ -70,6 +76,7 @@ func (u *OrganizationUseCase) Update(ctx context.Context, request *model.UpdateO
 		return nil, fiber.ErrNotFound
 	}
 
+	previousName := org.Name
 	if request.Name != "" {
 		org.Name = request.Name
 	}
@@ -79,6 +86,18 @@ func (u *OrganizationUseCase) Update(ctx context.Context, request *model.UpdateO
 		return nil, fiber.ErrInternalServerError
 	}
 
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         request.ActorID,
+		OrganizationID: org.ID,
+		Action:         entity.AuditActionOrganizationUpdate,
+		Resource:       entity.AuditResourceOrganization,
+		ResourceID:     org.ID,
+		Details:        map[string]interface{}{"previous_name": previousName, "name": org.Name},
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		u.Log.Warnf("Failed to commit transaction: %+v", err)
 		return nil, fiber.ErrInternalServerError
@@ -137,6 +156,22 @@ func (u *OrganizationUseCase) RemoveMember(ctx context.Context, request *model.R
 		return fiber.ErrInternalServerError
 	}
 
+	if err := u.SeatService.Sync(ctx, tx, request.OrganizationID, request.ActorID); err != nil {
+		return err
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         request.ActorID,
+		OrganizationID: request.OrganizationID,
+		Action:         entity.AuditActionMemberRemove,
+		Resource:       entity.AuditResourceOrganizationMember,
+		ResourceID:     request.UserID,
+		Details:        map[string]interface{}{"role": member.Role},
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		u.Log.Warnf("Failed to commit transaction: %+v", err)
 		return fiber.ErrInternalServerError
@@ -144,3 +179,32 @@ func (u *OrganizationUseCase) RemoveMember(ctx context.Context, request *model.R
 
 	return nil
 }
+
+// Authorize loads the caller's membership and checks it grants at least the requested role
+func (u *OrganizationUseCase) Authorize(ctx context.Context, request *model.AuthorizeOrganizationMemberRequest) (*model.OrganizationMemberResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	member := new(entity.OrganizationMember)
+	if err := u.OrganizationMemberRepository.FindByOrgAndUser(tx, member, request.OrganizationID, request.UserID); err != nil {
+		u.Log.Warnf("Member not found: %+v", err)
+		return nil, fiber.NewError(fiber.StatusForbidden, "You are not a member of this organization")
+	}
+
+	if !member.HasRole(request.Role) {
+		u.Log.Warnf("User %s has role %s, requires %s", request.UserID, member.Role, request.Role)
+		return nil, fiber.NewError(fiber.StatusForbidden, "Insufficient organization role")
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.OrganizationMemberToResponse(member), nil
+}
@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/repository"
+	"go-clean-arch-saas/pkg/payment"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// PaymentService collects invoices through the configured payment provider
+type PaymentService struct {
+	Log                    *logrus.Logger
+	Provider               payment.PaymentProvider
+	OrganizationRepository *repository.OrganizationRepository
+	InvoiceRepository      *repository.InvoiceRepository
+}
+
+func NewPaymentService(
+	logger *logrus.Logger,
+	provider payment.PaymentProvider,
+	orgRepo *repository.OrganizationRepository,
+	invoiceRepo *repository.InvoiceRepository,
+) *PaymentService {
+	return &PaymentService{
+		Log:                    logger,
+		Provider:               provider,
+		OrganizationRepository: orgRepo,
+		InvoiceRepository:      invoiceRepo,
+	}
+}
+
+// Collect charges the invoice's amount due to the organization's default payment method and marks it paid.
+// Call it as the last step before commit, and Refund if the commit fails.
+func (s *PaymentService) Collect(ctx context.Context, tx *gorm.DB, invoice *entity.Invoice) error {
+	organization := new(entity.Organization)
+	if err := s.OrganizationRepository.FindById(tx, organization, invoice.OrganizationID); err != nil {
+		s.Log.Warnf("Failed to find organization: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	if organization.PaymentCustomerID == nil || organization.PaymentMethodID == nil {
+		s.Log.Warnf("Organization %s has no payment method", organization.ID)
+		return fiber.NewError(fiber.StatusPaymentRequired, "A payment method is required for a paid plan")
+	}
+
+	charge, err := s.Provider.Charge(ctx, &payment.ChargeParams{
+		CustomerID:      *organization.PaymentCustomerID,
+		PaymentMethodID: *organization.PaymentMethodID,
+		Amount:          invoice.AmountDue,
+		Currency:        invoice.Currency,
+		Description:     "Invoice " + invoice.Number,
+		IdempotencyKey:  invoice.ID,
+		Metadata:        map[string]string{"invoice_id": invoice.ID, "organization_id": organization.ID},
+	})
+	if err != nil {
+		if errors.Is(err, payment.ErrPaymentDeclined) {
+			s.Log.Warnf("Payment for invoice %s declined: %+v", invoice.ID, err)
+			return fiber.NewError(fiber.StatusPaymentRequired, "Payment was declined")
+		}
+		s.Log.Warnf("Failed to charge invoice %s: %+v", invoice.ID, err)
+		return fiber.NewError(fiber.StatusBadGateway, "Payment provider is unavailable")
+	}
+
+	if err := s.InvoiceRepository.MarkPaid(tx, invoice, charge.ID, time.Now().UnixMilli()); err != nil {
+		s.Log.Warnf("Failed to mark invoice %s paid: %+v", invoice.ID, err)
+		return fiber.ErrInternalServerError
+	}
+
+	return nil
+}
+
+// Refund returns the charge of an invoice whose transaction did not commit. Failures are logged for follow-up.
+// The refund is sent even when ctx is cancelled, e.g. by a client that disconnected after being charged.
+func (s *PaymentService) Refund(ctx context.Context, invoice *entity.Invoice) {
+	if invoice == nil || invoice.PaymentChargeID == nil {
+		return
+	}
+
+	if err := s.Provider.Refund(context.WithoutCancel(ctx), *invoice.PaymentChargeID, invoice.AmountDue); err != nil {
+		s.Log.Errorf("Failed to refund charge %s of uncommitted invoice %s: %+v", *invoice.PaymentChargeID, invoice.ID, err)
+	}
+}
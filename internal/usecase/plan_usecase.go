@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"context"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/model/converter"
+	"go-clean-arch-saas/internal/repository"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type PlanUseCase struct {
+	DB             *gorm.DB
+	Log            *logrus.Logger
+	Validate       *validator.Validate
+	PlanRepository *repository.PlanRepository
+}
+
+func NewPlanUseCase(
+	db *gorm.DB,
+	logger *logrus.Logger,
+	validate *validator.Validate,
+	planRepo *repository.PlanRepository,
+) *PlanUseCase {
+	return &PlanUseCase{
+		DB:             db,
+		Log:            logger,
+		Validate:       validate,
+		PlanRepository: planRepo,
+	}
+}
+
+// List returns the active plans ordered by price
+func (u *PlanUseCase) List(ctx context.Context) ([]model.PlanResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	plans, err := u.PlanRepository.FindAllActive(tx)
+	if err != nil {
+		u.Log.Warnf("Failed to list plans: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	responses := make([]model.PlanResponse, 0, len(plans))
+	for _, plan := range plans {
+		responses = append(responses, *converter.PlanToResponse(&plan))
+	}
+
+	return responses, nil
+}
+
+func (u *PlanUseCase) GetBySlug(ctx context.Context, request *model.GetPlanRequest) (*model.PlanResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	plan := new(entity.Plan)
+	if err := u.PlanRepository.FindBySlug(tx, plan, request.Slug); err != nil {
+		u.Log.Warnf("Failed to find plan: %+v", err)
+		return nil, fiber.ErrNotFound
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.PlanToResponse(plan), nil
+}
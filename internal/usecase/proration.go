@@ -0,0 +1,84 @@
+package usecase
+
+import (
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+	"math"
+	"time"
+)
+
+// CalculateProration prices a change from the subscription's plan to newPlan at now, for the subscription's seats.
+// Both plans are priced at their variant (see PricedPlan). A plan costing less per month than the current one is a
+// downgrade and waits for the period end; anything else is an upgrade that starts a new period now, credited with
+// the unused part of the current one. A trial, or a past due period, has no paid time to credit.
+func CalculateProration(subscription *entity.Subscription, newPlan *entity.Plan, now time.Time) *model.ProrationResponse {
+	proration := &model.ProrationResponse{
+		CurrentPlanID:     subscription.PlanID,
+		NewPlanID:         newPlan.ID,
+		RemainingFraction: RemainingFraction(subscription, now),
+	}
+
+	current := BillingPlan(subscription)
+	if IsDowngrade(current, newPlan, subscription.Quantity) {
+		proration.Change = model.PlanChangeDowngrade
+		proration.EffectiveAt = subscription.CurrentPeriodEnd
+		return proration
+	}
+
+	proration.Change = model.PlanChangeUpgrade
+	proration.EffectiveAt = now.UnixMilli()
+	if subscription.Status != entity.SubscriptionStatusTrialing && subscription.Status != entity.SubscriptionStatusPastDue {
+		proration.Credit = roundCents(PlanAmount(current, subscription.Quantity) * proration.RemainingFraction)
+	}
+	proration.Charge = roundCents(PlanAmount(newPlan, subscription.Quantity))
+	proration.AmountDue = roundCents(max(proration.Charge-proration.Credit, 0))
+	proration.CreditBalance = roundCents(max(proration.Credit-proration.Charge, 0))
+
+	return proration
+}
+
+// ApplyDiscount takes the coupon's discount off the charge of an upgrade and recomputes what is due
+func ApplyDiscount(proration *model.ProrationResponse, coupon *entity.Coupon) {
+	if proration.Change != model.PlanChangeUpgrade {
+		return
+	}
+
+	proration.Discount = DiscountAmount(coupon, proration.Charge)
+	proration.PromoCode = coupon.Code
+	net := proration.Charge - proration.Discount
+	proration.AmountDue = roundCents(max(net-proration.Credit, 0))
+	proration.CreditBalance = roundCents(max(proration.Credit-net, 0))
+}
+
+// IsDowngrade reports whether newPlan costs less per month than current for quantity seats
+func IsDowngrade(current *entity.Plan, newPlan *entity.Plan, quantity int) bool {
+	return monthlyAmount(newPlan, quantity) < monthlyAmount(current, quantity)
+}
+
+// PlanAmount is what the plan costs per billing period for quantity seats. Flat plans ignore the quantity.
+func PlanAmount(plan *entity.Plan, quantity int) float64 {
+	if plan.PricingModel == entity.PricingModelPerSeat {
+		return roundCents(plan.Price * float64(max(quantity, 1)))
+	}
+	return plan.Price
+}
+
+// RemainingFraction returns the unused share of the subscription's current period at now, between 0 and 1
+func RemainingFraction(subscription *entity.Subscription, now time.Time) float64 {
+	length := subscription.CurrentPeriodEnd - subscription.CurrentPeriodStart
+	if length <= 0 {
+		return 0
+	}
+
+	remaining := min(max(subscription.CurrentPeriodEnd-now.UnixMilli(), 0), length)
+	return float64(remaining) / float64(length)
+}
+
+// monthlyAmount normalizes the plan's amount to one month so plans with different billing periods compare
+func monthlyAmount(plan *entity.Plan, quantity int) float64 {
+	return PlanAmount(plan, quantity) / periodMonths(plan)
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,142 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/repository"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// SeatService keeps the quantity of an organization's subscription equal to its member count
+type SeatService struct {
+	Log                          *logrus.Logger
+	SubscriptionRepository       *repository.SubscriptionRepository
+	OrganizationMemberRepository *repository.OrganizationMemberRepository
+	InvoiceRepository            *repository.InvoiceRepository
+	AuditService                 *AuditService
+}
+
+func NewSeatService(
+	logger *logrus.Logger,
+	subRepo *repository.SubscriptionRepository,
+	orgMemberRepo *repository.OrganizationMemberRepository,
+	invoiceRepo *repository.InvoiceRepository,
+	auditService *AuditService,
+) *SeatService {
+	return &SeatService{
+		Log:                          logger,
+		SubscriptionRepository:       subRepo,
+		OrganizationMemberRepository: orgMemberRepo,
+		InvoiceRepository:            invoiceRepo,
+		AuditService:                 auditService,
+	}
+}
+
+// Sync sets the quantity of the organization's subscription to its member count after members joined or left.
+// On a per-seat plan the seats added or removed are charged or credited for the rest of the current period
+// on the subscription's next invoice.
+func (s *SeatService) Sync(ctx context.Context, tx *gorm.DB, orgID string, actorID string) error {
+	current := new(entity.Subscription)
+	if err := s.SubscriptionRepository.FindActiveByOrganization(tx, current, orgID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		s.Log.Warnf("Failed to find subscription: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	// Concurrent joins must not both start from the same quantity
+	subscription := new(entity.Subscription)
+	if err := s.SubscriptionRepository.FindByIdForUpdate(tx, subscription, current.ID); err != nil {
+		s.Log.Warnf("Failed to lock subscription: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	members, err := s.OrganizationMemberRepository.CountByOrganization(tx, orgID)
+	if err != nil {
+		s.Log.Warnf("Failed to count organization members: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	quantity := int(max(members, 1))
+	if quantity == subscription.Quantity {
+		return nil
+	}
+
+	details := map[string]interface{}{
+		"plan_id":           subscription.PlanID,
+		"previous_quantity": subscription.Quantity,
+		"quantity":          quantity,
+	}
+
+	if item := SeatProrationItem(subscription, quantity, time.Now()); item != nil {
+		if err := s.InvoiceRepository.CreatePendingItem(tx, item); err != nil {
+			s.Log.Warnf("Failed to create seat proration: %+v", err)
+			return fiber.ErrInternalServerError
+		}
+		details["prorated_amount"] = item.Amount
+	}
+
+	subscription.Quantity = quantity
+	if err := s.SubscriptionRepository.Update(tx, subscription); err != nil {
+		s.Log.Warnf("Failed to update subscription quantity: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	if err := s.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         actorID,
+		OrganizationID: orgID,
+		Action:         entity.AuditActionSubscriptionQuantity,
+		Resource:       entity.AuditResourceSubscription,
+		ResourceID:     subscription.ID,
+		Details:        details,
+	}); err != nil {
+		s.Log.Warnf("Failed to record audit log: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	return nil
+}
+
+// SeatProrationItem prices the change of a subscription to quantity seats at now over the rest of its period.
+// It is nil unless the subscription is paying for a per-seat plan.
+func SeatProrationItem(subscription *entity.Subscription, quantity int, now time.Time) *entity.PendingInvoiceItem {
+	plan := BillingPlan(subscription)
+	paying := subscription.Status == entity.SubscriptionStatusActive || subscription.Status == entity.SubscriptionStatusPastDue
+	if !paying || plan.PricingModel != entity.PricingModelPerSeat || plan.Price == 0 {
+		return nil
+	}
+
+	change := quantity - max(subscription.Quantity, 1)
+	unitAmount := roundCents(plan.Price * RemainingFraction(subscription, now))
+	if change == 0 || unitAmount == 0 {
+		return nil
+	}
+
+	description := fmt.Sprintf("%d seat(s) added to %s for the rest of the period", change, plan.Name)
+	if change < 0 {
+		description = fmt.Sprintf("%d seat(s) removed from %s for the rest of the period", -change, plan.Name)
+	}
+
+	planID := subscription.PlanID
+	return &entity.PendingInvoiceItem{
+		ID:             uuid.New().String(),
+		SubscriptionID: subscription.ID,
+		Kind:           entity.InvoiceLineKindSeats,
+		Description:    description,
+		PlanID:         &planID,
+		Quantity:       int64(change),
+		UnitAmount:     unitAmount,
+		Amount:         roundCents(unitAmount * float64(change)),
+		PeriodStart:    now.UnixMilli(),
+		PeriodEnd:      subscription.CurrentPeriodEnd,
+	}
+}
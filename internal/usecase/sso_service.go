@@ -0,0 +1,526 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/repository"
+	"go-clean-arch-saas/pkg/oauth"
+	"go-clean-arch-saas/pkg/saml"
+	"go-clean-arch-saas/pkg/secret"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	// ssoStateTTL is how long a user has to sign in at their identity provider
+	ssoStateTTL = 10 * time.Minute
+	// samlCodeTTL is how long the callback page has to redeem a verified SAML response
+	samlCodeTTL = 5 * time.Minute
+)
+
+// ssoState is the state of a login, sealed into the state token as for OAuth. SAML logins use the state as
+// RelayState and derive the AuthnRequest ID from it.
+type ssoState struct {
+	ConnectionID string `json:"connection_id"`
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+	ExpiresAt    int64  `json:"expires_at"`
+}
+
+// samlCode is a verified SAML response, sealed into the code the assertion consumer service redirects to the
+// callback page with. Like an OAuth code it is only redeemed together with the state token of the login.
+type samlCode struct {
+	ConnectionID string `json:"connection_id"`
+	State        string `json:"state"`
+	Subject      string `json:"subject"`
+	Email        string `json:"email"`
+	Name         string `json:"name"`
+	ExpiresAt    int64  `json:"expires_at"`
+}
+
+type ssoProvider struct {
+	updatedAt int64
+	provider  *oauth.Provider
+}
+
+// SSOService signs members in through their organization's identity provider and provisions them at their first
+// login. Connections are looked up by the email domain, which the organization must have verified.
+type SSOService struct {
+	Log                          *logrus.Logger
+	SSOConnectionRepository      *repository.SSOConnectionRepository
+	SSODomainRepository          *repository.SSODomainRepository
+	UserRepository               *repository.UserRepository
+	OrganizationMemberRepository *repository.OrganizationMemberRepository
+	SessionRepository            *repository.SessionRepository
+	EntitlementService           *EntitlementService
+	SeatService                  *SeatService
+	AuditService                 *AuditService
+	SecretBox                    *secret.Box
+	Client                       *http.Client
+	AllowPrivateIssuers          bool   // accept http issuers; the client is then not limited to public addresses
+	APIURL                       string // public URL of the API, where identity providers post SAML responses
+	CallbackURL                  string // frontend page logins return to
+
+	providersMu sync.Mutex
+	providers   map[string]*ssoProvider
+}
+
+func NewSSOService(
+	logger *logrus.Logger,
+	connectionRepo *repository.SSOConnectionRepository,
+	domainRepo *repository.SSODomainRepository,
+	userRepo *repository.UserRepository,
+	orgMemberRepo *repository.OrganizationMemberRepository,
+	sessionRepo *repository.SessionRepository,
+	entitlementService *EntitlementService,
+	seatService *SeatService,
+	auditService *AuditService,
+	secretBox *secret.Box,
+	client *http.Client,
+	allowPrivateIssuers bool,
+	apiURL string,
+	callbackURL string,
+) *SSOService {
+	return &SSOService{
+		Log:                          logger,
+		SSOConnectionRepository:      connectionRepo,
+		SSODomainRepository:          domainRepo,
+		UserRepository:               userRepo,
+		OrganizationMemberRepository: orgMemberRepo,
+		SessionRepository:            sessionRepo,
+		EntitlementService:           entitlementService,
+		SeatService:                  seatService,
+		AuditService:                 auditService,
+		SecretBox:                    secretBox,
+		Client:                       client,
+		AllowPrivateIssuers:          allowPrivateIssuers,
+		APIURL:                       strings.TrimSuffix(apiURL, "/"),
+		CallbackURL:                  callbackURL,
+		providers:                    make(map[string]*ssoProvider),
+	}
+}
+
+// ServiceProvider returns this application as the service provider of a SAML connection. Each connection has
+// its own entity ID, so organizations sharing an identity provider tenant get distinct applications there.
+func (s *SSOService) ServiceProvider(connectionID string) *saml.ServiceProvider {
+	base := s.APIURL + "/auth/sso/saml/" + connectionID
+	return &saml.ServiceProvider{
+		EntityID: base + "/metadata",
+		ACSURL:   base + "/acs",
+	}
+}
+
+// FindConnectionByEmail returns the connection of the organization that verified the email's domain
+func (s *SSOService) FindConnectionByEmail(tx *gorm.DB, email string) (*entity.SSOConnection, error) {
+	domain := new(entity.SSODomain)
+	if err := s.SSODomainRepository.FindVerified(tx, domain, emailDomain(email)); err != nil {
+		s.Log.Warnf("No verified SSO domain for %s: %+v", email, err)
+		return nil, fiber.NewError(fiber.StatusNotFound, "Single sign-on is not configured for this email domain")
+	}
+
+	connection := new(entity.SSOConnection)
+	if err := s.SSOConnectionRepository.FindByOrganization(tx, connection, domain.OrganizationID); err != nil {
+		s.Log.Warnf("Organization %s has no SSO connection: %+v", domain.OrganizationID, err)
+		return nil, fiber.NewError(fiber.StatusNotFound, "Single sign-on is not configured for this email domain")
+	}
+
+	return connection, nil
+}
+
+// Authorize starts a login through the connection
+func (s *SSOService) Authorize(ctx context.Context, connection *entity.SSOConnection) (*model.SSOAuthorizeResponse, error) {
+	state := &ssoState{ConnectionID: connection.ID, ExpiresAt: time.Now().Add(ssoStateTTL).UnixMilli()}
+	for _, value := range []*string{&state.State, &state.Nonce, &state.CodeVerifier} {
+		random, err := oauth.RandomString()
+		if err != nil {
+			s.Log.Warnf("Failed to generate SSO state: %+v", err)
+			return nil, fiber.ErrInternalServerError
+		}
+		*value = random
+	}
+
+	var authorizationURL string
+	switch connection.Protocol {
+	case entity.SSOProtocolOIDC:
+		provider, err := s.oidcProvider(ctx, connection)
+		if err != nil {
+			return nil, err
+		}
+		authorizationURL = provider.AuthCodeURL(state.State, state.Nonce, state.CodeVerifier)
+	case entity.SSOProtocolSAML:
+		idp, err := saml.ParseMetadata([]byte(connection.SAMLMetadata))
+		if err != nil {
+			s.Log.Warnf("Invalid SAML metadata for connection %s: %+v", connection.ID, err)
+			return nil, fiber.ErrBadGateway
+		}
+		authorizationURL, err = s.ServiceProvider(connection.ID).AuthnRequestURL(idp, samlRequestID(state.State), state.State, time.Now())
+		if err != nil {
+			s.Log.Warnf("Failed to build SAML request: %+v", err)
+			return nil, fiber.ErrInternalServerError
+		}
+	default:
+		s.Log.Warnf("Unknown SSO protocol %s for connection %s", connection.Protocol, connection.ID)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	stateToken, err := s.seal(state)
+	if err != nil {
+		s.Log.Warnf("Failed to seal SSO state: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return &model.SSOAuthorizeResponse{
+		AuthorizationURL: authorizationURL,
+		StateToken:       stateToken,
+	}, nil
+}
+
+// ConsumeSAMLResponse verifies a response posted to a connection's assertion consumer service and returns where
+// to redirect the browser: the callback page with the state and a code, or with an error
+func (s *SSOService) ConsumeSAMLResponse(tx *gorm.DB, request *model.SAMLResponseRequest) string {
+	query := url.Values{}
+	query.Set("state", request.RelayState)
+
+	code, err := s.verifySAMLResponse(tx, request)
+	if err != nil {
+		s.Log.Warnf("Rejected SAML response for connection %s: %+v", request.ConnectionID, err)
+		query.Set("error", "access_denied")
+	} else {
+		query.Set("code", code)
+	}
+
+	separator := "?"
+	if strings.Contains(s.CallbackURL, "?") {
+		separator = "&"
+	}
+	return s.CallbackURL + separator + query.Encode()
+}
+
+func (s *SSOService) verifySAMLResponse(tx *gorm.DB, request *model.SAMLResponseRequest) (string, error) {
+	connection := new(entity.SSOConnection)
+	if err := s.SSOConnectionRepository.FindById(tx, connection, request.ConnectionID); err != nil {
+		return "", err
+	}
+	if connection.Protocol != entity.SSOProtocolSAML {
+		return "", errors.New("not a SAML connection")
+	}
+
+	idp, err := saml.ParseMetadata([]byte(connection.SAMLMetadata))
+	if err != nil {
+		return "", err
+	}
+	assertion, err := s.ServiceProvider(connection.ID).ParseResponse(idp, request.SAMLResponse, samlRequestID(request.RelayState), time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	return s.seal(&samlCode{
+		ConnectionID: connection.ID,
+		State:        request.RelayState,
+		Subject:      assertion.NameID,
+		Email:        assertion.Email(),
+		Name:         assertion.Name(),
+		ExpiresAt:    time.Now().Add(samlCodeTTL).UnixMilli(),
+	})
+}
+
+// Identify completes a login: it checks the callback belongs to the login of the state token and returns the
+// connection and the user the identity provider signed in
+func (s *SSOService) Identify(ctx context.Context, tx *gorm.DB, request *model.SSOCallbackRequest) (*entity.SSOConnection, *oauth.UserInfo, error) {
+	state := new(ssoState)
+	if err := s.open(request.StateToken, state); err != nil {
+		s.Log.Warnf("Invalid SSO state token: %+v", err)
+		return nil, nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired state token")
+	}
+	if time.Now().UnixMilli() > state.ExpiresAt || subtle.ConstantTimeCompare([]byte(state.State), []byte(request.State)) != 1 {
+		s.Log.Warnf("SSO state mismatch for connection %s", state.ConnectionID)
+		return nil, nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired state token")
+	}
+
+	connection := new(entity.SSOConnection)
+	if err := s.SSOConnectionRepository.FindById(tx, connection, state.ConnectionID); err != nil {
+		s.Log.Warnf("SSO connection %s not found: %+v", state.ConnectionID, err)
+		return nil, nil, fiber.ErrUnauthorized
+	}
+
+	info := new(oauth.UserInfo)
+	switch connection.Protocol {
+	case entity.SSOProtocolOIDC:
+		provider, err := s.oidcProvider(ctx, connection)
+		if err != nil {
+			return nil, nil, err
+		}
+		token, err := provider.Exchange(ctx, request.Code, state.CodeVerifier)
+		if err != nil {
+			s.Log.Warnf("Failed to exchange SSO code for connection %s: %+v", connection.ID, err)
+			return nil, nil, fiber.ErrUnauthorized
+		}
+		if info, err = provider.Identify(ctx, token, state.Nonce); err != nil {
+			s.Log.Warnf("Failed to identify SSO user for connection %s: %+v", connection.ID, err)
+			return nil, nil, fiber.ErrUnauthorized
+		}
+	case entity.SSOProtocolSAML:
+		code := new(samlCode)
+		if err := s.open(request.Code, code); err != nil ||
+			code.ConnectionID != connection.ID ||
+			code.State != state.State ||
+			time.Now().UnixMilli() > code.ExpiresAt {
+			s.Log.Warnf("Invalid SAML code for connection %s: %+v", connection.ID, err)
+			return nil, nil, fiber.ErrUnauthorized
+		}
+		info = &oauth.UserInfo{Subject: code.Subject, Email: code.Email, Name: code.Name}
+	default:
+		s.Log.Warnf("Unknown SSO protocol %s for connection %s", connection.Protocol, connection.ID)
+		return nil, nil, fiber.ErrInternalServerError
+	}
+
+	return connection, info, nil
+}
+
+// Provision returns the user an identity provider signed in, creating the account on the first login and
+// adding it to the connection's organization with the default role. The organization becomes the user's
+// default one so the session acts in it.
+func (s *SSOService) Provision(ctx context.Context, tx *gorm.DB, connection *entity.SSOConnection, info *oauth.UserInfo) (*entity.User, error) {
+	// The identity provider is only trusted for the domains its organization proved it owns
+	if info.Email == "" {
+		s.Log.Warnf("SSO connection %s did not return an email for subject %s", connection.ID, info.Subject)
+		return nil, fiber.NewError(fiber.StatusForbidden, "The identity provider did not return an email address")
+	}
+	domain := new(entity.SSODomain)
+	if err := s.SSODomainRepository.FindVerified(tx, domain, emailDomain(info.Email)); err != nil || domain.OrganizationID != connection.OrganizationID {
+		s.Log.Warnf("Email %s is not in a verified domain of organization %s", info.Email, connection.OrganizationID)
+		return nil, fiber.NewError(fiber.StatusForbidden, "Email domain is not verified for this organization")
+	}
+
+	now := time.Now().UnixMilli()
+	user := new(entity.User)
+	err := s.UserRepository.FindByEmail(tx, user, info.Email)
+	switch {
+	case err == nil:
+		// As with social login, a password set before the email was verified is dropped
+		if !user.EmailVerified {
+			user.Password = ""
+			user.EmailVerified = true
+			user.EmailVerifiedAt = &now
+			user.VerificationToken = nil
+			if err := s.SessionRepository.RevokeAllByUser(tx, user.ID, now); err != nil {
+				s.Log.Warnf("Failed to revoke sessions: %+v", err)
+				return nil, fiber.ErrInternalServerError
+			}
+		}
+
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		name := info.Name
+		if name == "" {
+			name, _, _ = strings.Cut(info.Email, "@")
+		}
+
+		// Provisioned users have no password and no personal organization
+		*user = entity.User{
+			ID:              uuid.New().String(),
+			Name:            name,
+			Email:           info.Email,
+			SystemRole:      entity.SystemRoleUser,
+			EmailVerified:   true,
+			EmailVerifiedAt: &now,
+			OrganizationID:  connection.OrganizationID,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+		if err := s.UserRepository.Create(tx, user); err != nil {
+			s.Log.Warnf("Failed to create user: %+v", err)
+			return nil, fiber.ErrInternalServerError
+		}
+
+		if err := s.AuditService.Record(ctx, tx, &model.AuditEvent{
+			UserID:         user.ID,
+			OrganizationID: connection.OrganizationID,
+			Action:         entity.AuditActionRegister,
+			Resource:       entity.AuditResourceUser,
+			ResourceID:     user.ID,
+			Details:        map[string]interface{}{"email": user.Email, "sso_connection_id": connection.ID},
+		}); err != nil {
+			s.Log.Warnf("Failed to record audit log: %+v", err)
+			return nil, fiber.ErrInternalServerError
+		}
+
+	default:
+		s.Log.Warnf("Failed to find user by email: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	member := new(entity.OrganizationMember)
+	if err := s.OrganizationMemberRepository.FindByOrgAndUser(tx, member, connection.OrganizationID, user.ID); err != nil {
+		if err := s.EntitlementService.CheckMemberLimit(tx, connection.OrganizationID, false); err != nil {
+			s.Log.Warnf("Organization %s cannot add members: %+v", connection.OrganizationID, err)
+			return nil, err
+		}
+
+		member = &entity.OrganizationMember{
+			OrganizationID: connection.OrganizationID,
+			UserID:         user.ID,
+			Role:           connection.DefaultRole,
+			JoinedAt:       now,
+		}
+		if err := s.OrganizationMemberRepository.Create(tx, member); err != nil {
+			s.Log.Warnf("Failed to create organization member: %+v", err)
+			return nil, fiber.ErrInternalServerError
+		}
+
+		if err := s.SeatService.Sync(ctx, tx, connection.OrganizationID, user.ID); err != nil {
+			return nil, err
+		}
+
+		if err := s.AuditService.Record(ctx, tx, &model.AuditEvent{
+			UserID:         user.ID,
+			OrganizationID: connection.OrganizationID,
+			Action:         entity.AuditActionMemberJoin,
+			Resource:       entity.AuditResourceOrganizationMember,
+			ResourceID:     user.ID,
+			Details:        map[string]interface{}{"sso_connection_id": connection.ID, "role": member.Role},
+		}); err != nil {
+			s.Log.Warnf("Failed to record audit log: %+v", err)
+			return nil, fiber.ErrInternalServerError
+		}
+	}
+
+	user.OrganizationID = connection.OrganizationID
+	if err := s.UserRepository.Update(tx, user); err != nil {
+		s.Log.Warnf("Failed to update user: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return user, nil
+}
+
+// CheckNonSSOLogin refuses password, passkey and social logins of members of organizations enforcing single
+// sign-on. Owners are exempt so a misconfigured identity provider cannot lock an organization out.
+func (s *SSOService) CheckNonSSOLogin(tx *gorm.DB, user *entity.User) error {
+	total, err := s.SSOConnectionRepository.CountEnforcedForUser(tx, user.ID)
+	if err != nil {
+		s.Log.Warnf("Failed to check SSO enforcement: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+	if total > 0 {
+		s.Log.Warnf("Login refused for user %s: organization enforces SSO", user.ID)
+		return fiber.NewError(fiber.StatusForbidden, "Your organization requires single sign-on")
+	}
+	return nil
+}
+
+// SignOutEnforcedMembers revokes the sessions of the organization's members other than owners when it starts
+// enforcing single sign-on, since those may have been started with a password, passkey or social login.
+// Members sign in again through the identity provider. It returns how many members were signed out.
+func (s *SSOService) SignOutEnforcedMembers(tx *gorm.DB, orgID string, now int64) (int, error) {
+	members, err := s.OrganizationMemberRepository.ListByOrganization(tx, orgID)
+	if err != nil {
+		s.Log.Warnf("Failed to list organization members: %+v", err)
+		return 0, fiber.ErrInternalServerError
+	}
+
+	signedOut := 0
+	for _, member := range members {
+		if member.Role == entity.OrgRoleOwner {
+			continue
+		}
+		if err := s.SessionRepository.RevokeAllByUser(tx, member.UserID, now); err != nil {
+			s.Log.Warnf("Failed to revoke sessions: %+v", err)
+			return 0, fiber.ErrInternalServerError
+		}
+		signedOut++
+	}
+	return signedOut, nil
+}
+
+// OIDCProvider discovers the endpoints of an OIDC connection's issuer, used to check a connection before saving it.
+// Issuers must be https URLs, and Client only reaches public addresses, since any organization owner picks them.
+func (s *SSOService) OIDCProvider(ctx context.Context, issuer string) (*oauth.Provider, error) {
+	if parsed, err := url.Parse(issuer); err != nil || parsed.Host == "" || (parsed.Scheme != "https" && !(parsed.Scheme == "http" && s.AllowPrivateIssuers)) {
+		s.Log.Warnf("OIDC issuer %s is not an https URL", issuer)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "The OpenID Connect issuer must be an https URL")
+	}
+
+	provider, err := oauth.Discover(ctx, s.Client, issuer)
+	if err != nil {
+		s.Log.Warnf("Failed to discover OIDC issuer %s: %+v", issuer, err)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "OpenID Connect discovery failed for the issuer")
+	}
+	return provider, nil
+}
+
+// oidcProvider returns the provider of an OIDC connection. Providers are kept until the connection changes, which
+// keeps the discovered endpoints and fetched signing keys across logins.
+func (s *SSOService) oidcProvider(ctx context.Context, connection *entity.SSOConnection) (*oauth.Provider, error) {
+	s.providersMu.Lock()
+	cached, ok := s.providers[connection.ID]
+	s.providersMu.Unlock()
+	if ok && cached.updatedAt == connection.UpdatedAt {
+		return cached.provider, nil
+	}
+
+	clientSecret, err := s.SecretBox.Open(connection.OIDCClientSecret)
+	if err != nil {
+		s.Log.Warnf("Failed to open client secret of connection %s: %+v", connection.ID, err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	provider, err := oauth.Discover(ctx, s.Client, connection.OIDCIssuer)
+	if err != nil {
+		s.Log.Warnf("Failed to discover OIDC issuer of connection %s: %+v", connection.ID, err)
+		return nil, fiber.ErrBadGateway
+	}
+	provider.Name = "sso"
+	provider.ClientID = connection.OIDCClientID
+	provider.ClientSecret = clientSecret
+	provider.RedirectURL = s.CallbackURL
+
+	s.providersMu.Lock()
+	s.providers[connection.ID] = &ssoProvider{updatedAt: connection.UpdatedAt, provider: provider}
+	s.providersMu.Unlock()
+
+	return provider, nil
+}
+
+func (s *SSOService) seal(value interface{}) (string, error) {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return s.SecretBox.Seal(string(payload))
+}
+
+func (s *SSOService) open(token string, value interface{}) error {
+	payload, err := s.SecretBox.Open(token)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(payload), value)
+}
+
+// samlRequestID derives the AuthnRequest ID from the login's state, so responses can be matched to the request
+// without storing it
+func samlRequestID(state string) string {
+	sum := sha256.Sum256([]byte("saml-request:" + state))
+	return "_" + hex.EncodeToString(sum[:20])
+}
+
+// emailDomain returns the lowercased domain of an email address
+func emailDomain(email string) string {
+	_, domain, _ := strings.Cut(email, "@")
+	return strings.ToLower(strings.TrimSpace(domain))
+}
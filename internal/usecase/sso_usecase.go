@@ -0,0 +1,474 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/model/converter"
+	"go-clean-arch-saas/internal/repository"
+	"go-clean-arch-saas/pkg/saml"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// dnsLookupTimeout bounds the TXT lookup of a domain verification
+const dnsLookupTimeout = 10 * time.Second
+
+// SSOUseCase lets organization owners configure single sign-on: the connection to their identity provider and
+// the email domains routed to it. Signing in through it is in AuthUseCase.
+type SSOUseCase struct {
+	DB                      *gorm.DB
+	Log                     *logrus.Logger
+	Validate                *validator.Validate
+	SSOConnectionRepository *repository.SSOConnectionRepository
+	SSODomainRepository     *repository.SSODomainRepository
+	SSOService              *SSOService
+	AuditService            *AuditService
+	LookupTXT               func(ctx context.Context, name string) ([]string, error)
+}
+
+func NewSSOUseCase(
+	db *gorm.DB,
+	logger *logrus.Logger,
+	validate *validator.Validate,
+	connectionRepo *repository.SSOConnectionRepository,
+	domainRepo *repository.SSODomainRepository,
+	ssoService *SSOService,
+	auditService *AuditService,
+) *SSOUseCase {
+	return &SSOUseCase{
+		DB:                      db,
+		Log:                     logger,
+		Validate:                validate,
+		SSOConnectionRepository: connectionRepo,
+		SSODomainRepository:     domainRepo,
+		SSOService:              ssoService,
+		AuditService:            auditService,
+		LookupTXT:               net.DefaultResolver.LookupTXT,
+	}
+}
+
+func (u *SSOUseCase) Get(ctx context.Context, request *model.GetSSOConnectionRequest) (*model.SSOConnectionResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	connection := new(entity.SSOConnection)
+	if err := u.SSOConnectionRepository.FindByOrganization(tx, connection, request.OrganizationID); err != nil {
+		u.Log.Warnf("SSO connection not found for organization %s: %+v", request.OrganizationID, err)
+		return nil, fiber.ErrNotFound
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return u.toResponse(connection), nil
+}
+
+// Upsert creates or replaces the organization's connection. OIDC issuers are checked with discovery and SAML
+// metadata is parsed before anything is saved. Turning on enforcement signs out the members it applies to.
+func (u *SSOUseCase) Upsert(ctx context.Context, request *model.UpsertSSOConnectionRequest) (*model.SSOConnectionResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	connection := new(entity.SSOConnection)
+	err := u.SSOConnectionRepository.FindByOrganization(tx, connection, request.OrganizationID)
+	created := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !created {
+		u.Log.Warnf("Failed to find SSO connection: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+	wasEnforced := !created && connection.EnforceSSO
+	if created {
+		connection = &entity.SSOConnection{
+			ID:             uuid.New().String(),
+			OrganizationID: request.OrganizationID,
+		}
+	}
+
+	switch request.Protocol {
+	case entity.SSOProtocolOIDC:
+		if request.OIDCIssuer == "" || request.OIDCClientID == "" {
+			u.Log.Warnf("OIDC connection without issuer or client ID")
+			return nil, fiber.NewError(fiber.StatusBadRequest, "OIDC connections need an issuer and a client ID")
+		}
+
+		// An empty secret keeps the current one, which a SAML connection does not have
+		clientSecret := connection.OIDCClientSecret
+		if request.OIDCClientSecret != "" {
+			if clientSecret, err = u.SSOService.SecretBox.Seal(request.OIDCClientSecret); err != nil {
+				u.Log.Warnf("Failed to seal client secret: %+v", err)
+				return nil, fiber.ErrInternalServerError
+			}
+		}
+		if clientSecret == "" {
+			u.Log.Warnf("OIDC connection without client secret")
+			return nil, fiber.NewError(fiber.StatusBadRequest, "OIDC connections need a client secret")
+		}
+
+		if _, err := u.SSOService.OIDCProvider(ctx, request.OIDCIssuer); err != nil {
+			return nil, err
+		}
+
+		connection.OIDCIssuer = request.OIDCIssuer
+		connection.OIDCClientID = request.OIDCClientID
+		connection.OIDCClientSecret = clientSecret
+		connection.SAMLMetadata, connection.SAMLEntityID, connection.SAMLSSOURL = "", "", ""
+
+	case entity.SSOProtocolSAML:
+		if request.SAMLMetadata == "" {
+			u.Log.Warnf("SAML connection without metadata")
+			return nil, fiber.NewError(fiber.StatusBadRequest, "SAML connections need the identity provider's metadata")
+		}
+		idp, err := saml.ParseMetadata([]byte(request.SAMLMetadata))
+		if err != nil {
+			u.Log.Warnf("Invalid SAML metadata: %+v", err)
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid SAML metadata")
+		}
+
+		connection.SAMLMetadata = request.SAMLMetadata
+		connection.SAMLEntityID = idp.EntityID
+		connection.SAMLSSOURL = idp.SSOURL
+		connection.OIDCIssuer, connection.OIDCClientID, connection.OIDCClientSecret = "", "", ""
+	}
+
+	connection.Protocol = request.Protocol
+	connection.EnforceSSO = request.EnforceSSO
+	connection.DefaultRole = request.DefaultRole
+	if connection.DefaultRole == "" {
+		connection.DefaultRole = entity.OrgRoleMember
+	}
+
+	if created {
+		err = u.SSOConnectionRepository.Create(tx, connection)
+	} else {
+		err = u.SSOConnectionRepository.Update(tx, connection)
+	}
+	if err != nil {
+		u.Log.Warnf("Failed to save SSO connection: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	details := map[string]interface{}{
+		"protocol":     connection.Protocol,
+		"enforce_sso":  connection.EnforceSSO,
+		"default_role": connection.DefaultRole,
+	}
+
+	// Sessions started before enforcement must not outlive it
+	if connection.EnforceSSO && !wasEnforced {
+		signedOut, err := u.SSOService.SignOutEnforcedMembers(tx, request.OrganizationID, time.Now().UnixMilli())
+		if err != nil {
+			return nil, err
+		}
+		details["signed_out_members"] = signedOut
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         request.ActorID,
+		OrganizationID: request.OrganizationID,
+		Action:         entity.AuditActionSSOConnectionUpdate,
+		Resource:       entity.AuditResourceSSOConnection,
+		ResourceID:     connection.ID,
+		Details:        details,
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return u.toResponse(connection), nil
+}
+
+// Delete removes the organization's connection, which also lifts SSO enforcement. Domains are kept.
+func (u *SSOUseCase) Delete(ctx context.Context, request *model.DeleteSSOConnectionRequest) error {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	connection := new(entity.SSOConnection)
+	if err := u.SSOConnectionRepository.FindByOrganization(tx, connection, request.OrganizationID); err != nil {
+		u.Log.Warnf("SSO connection not found for organization %s: %+v", request.OrganizationID, err)
+		return fiber.ErrNotFound
+	}
+
+	if _, err := u.SSOConnectionRepository.DeleteByOrganization(tx, request.OrganizationID); err != nil {
+		u.Log.Warnf("Failed to delete SSO connection: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         request.ActorID,
+		OrganizationID: request.OrganizationID,
+		Action:         entity.AuditActionSSOConnectionDelete,
+		Resource:       entity.AuditResourceSSOConnection,
+		ResourceID:     connection.ID,
+		Details:        map[string]interface{}{"protocol": connection.Protocol},
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (u *SSOUseCase) ListDomains(ctx context.Context, request *model.ListSSODomainsRequest) ([]model.SSODomainResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	domains, err := u.SSODomainRepository.ListByOrganization(tx, request.OrganizationID)
+	if err != nil {
+		u.Log.Warnf("Failed to list SSO domains: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	responses := make([]model.SSODomainResponse, 0, len(domains))
+	for _, domain := range domains {
+		responses = append(responses, *converter.SSODomainToResponse(&domain))
+	}
+
+	return responses, nil
+}
+
+// AddDomain claims an email domain for the organization. It routes logins once verified with VerifyDomain.
+func (u *SSOUseCase) AddDomain(ctx context.Context, request *model.AddSSODomainRequest) (*model.SSODomainResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	request.Domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(request.Domain), "."))
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	total, err := u.SSODomainRepository.CountByOrganizationAndDomain(tx, request.OrganizationID, request.Domain)
+	if err != nil {
+		u.Log.Warnf("Failed to count SSO domains: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+	if total > 0 {
+		u.Log.Warnf("Domain %s already added to organization %s", request.Domain, request.OrganizationID)
+		return nil, fiber.NewError(fiber.StatusConflict, "Domain already added")
+	}
+
+	token, err := generateVerificationToken()
+	if err != nil {
+		u.Log.Warnf("Failed to generate verification token: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	domain := &entity.SSODomain{
+		ID:                uuid.New().String(),
+		OrganizationID:    request.OrganizationID,
+		Domain:            request.Domain,
+		VerificationToken: token,
+	}
+	if err := u.SSODomainRepository.Create(tx, domain); err != nil {
+		u.Log.Warnf("Failed to create SSO domain: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         request.ActorID,
+		OrganizationID: request.OrganizationID,
+		Action:         entity.AuditActionSSODomainAdd,
+		Resource:       entity.AuditResourceSSODomain,
+		ResourceID:     domain.ID,
+		Details:        map[string]interface{}{"domain": domain.Domain},
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.SSODomainToResponse(domain), nil
+}
+
+// VerifyDomain checks the domain's DNS publishes the verification token. A domain can only be verified by one
+// organization.
+func (u *SSOUseCase) VerifyDomain(ctx context.Context, request *model.VerifySSODomainRequest) (*model.SSODomainResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	domain := new(entity.SSODomain)
+	if err := u.SSODomainRepository.FindByIdAndOrganization(tx, domain, request.ID, request.OrganizationID); err != nil {
+		u.Log.Warnf("SSO domain not found: %s", request.ID)
+		return nil, fiber.ErrNotFound
+	}
+	if domain.IsVerified() {
+		return converter.SSODomainToResponse(domain), nil
+	}
+
+	total, err := u.SSODomainRepository.CountVerified(tx, domain.Domain)
+	if err != nil {
+		u.Log.Warnf("Failed to count verified SSO domains: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+	if total > 0 {
+		u.Log.Warnf("Domain %s is already verified by another organization", domain.Domain)
+		return nil, fiber.NewError(fiber.StatusConflict, "Domain is already verified by another organization")
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, dnsLookupTimeout)
+	defer cancel()
+	records, err := u.LookupTXT(lookupCtx, domain.VerificationRecord())
+	if err != nil {
+		u.Log.Warnf("Failed to look up TXT records of %s: %+v", domain.VerificationRecord(), err)
+	}
+	verified := false
+	for _, record := range records {
+		verified = verified || strings.TrimSpace(record) == domain.VerificationValue()
+	}
+	if !verified {
+		u.Log.Warnf("Verification record not found for domain %s", domain.Domain)
+		return nil, fiber.NewError(fiber.StatusUnprocessableEntity, "Verification TXT record not found")
+	}
+
+	now := time.Now().UnixMilli()
+	domain.VerifiedAt = &now
+	if err := u.SSODomainRepository.Update(tx, domain); err != nil {
+		// the unique index on verified domains settles concurrent verifications
+		u.Log.Warnf("Failed to verify SSO domain: %+v", err)
+		return nil, fiber.NewError(fiber.StatusConflict, "Domain is already verified by another organization")
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         request.ActorID,
+		OrganizationID: request.OrganizationID,
+		Action:         entity.AuditActionSSODomainVerify,
+		Resource:       entity.AuditResourceSSODomain,
+		ResourceID:     domain.ID,
+		Details:        map[string]interface{}{"domain": domain.Domain},
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.SSODomainToResponse(domain), nil
+}
+
+func (u *SSOUseCase) DeleteDomain(ctx context.Context, request *model.DeleteSSODomainRequest) error {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	domain := new(entity.SSODomain)
+	if err := u.SSODomainRepository.FindByIdAndOrganization(tx, domain, request.ID, request.OrganizationID); err != nil {
+		u.Log.Warnf("SSO domain not found: %s", request.ID)
+		return fiber.ErrNotFound
+	}
+
+	if _, err := u.SSODomainRepository.DeleteByOrganization(tx, request.OrganizationID, request.ID); err != nil {
+		u.Log.Warnf("Failed to delete SSO domain: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         request.ActorID,
+		OrganizationID: request.OrganizationID,
+		Action:         entity.AuditActionSSODomainRemove,
+		Resource:       entity.AuditResourceSSODomain,
+		ResourceID:     domain.ID,
+		Details:        map[string]interface{}{"domain": domain.Domain},
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	return nil
+}
+
+// SAMLMetadata returns the service provider metadata of a SAML connection, for its identity provider
+func (u *SSOUseCase) SAMLMetadata(ctx context.Context, connectionID string) ([]byte, error) {
+	connection := new(entity.SSOConnection)
+	if err := u.SSOConnectionRepository.FindById(u.DB.WithContext(ctx), connection, connectionID); err != nil {
+		u.Log.Warnf("SSO connection not found: %s", connectionID)
+		return nil, fiber.ErrNotFound
+	}
+	if connection.Protocol != entity.SSOProtocolSAML {
+		u.Log.Warnf("SSO connection %s is not a SAML connection", connectionID)
+		return nil, fiber.ErrNotFound
+	}
+
+	return u.SSOService.ServiceProvider(connection.ID).Metadata(), nil
+}
+
+// toResponse adds the values the identity provider is configured with
+func (u *SSOUseCase) toResponse(connection *entity.SSOConnection) *model.SSOConnectionResponse {
+	response := converter.SSOConnectionToResponse(connection)
+	switch connection.Protocol {
+	case entity.SSOProtocolOIDC:
+		response.RedirectURL = u.SSOService.CallbackURL
+	case entity.SSOProtocolSAML:
+		sp := u.SSOService.ServiceProvider(connection.ID)
+		response.SPEntityID = sp.EntityID
+		response.ACSURL = sp.ACSURL
+		response.MetadataURL = sp.EntityID
+	}
+	return response
+}
@@ -2,10 +2,14 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"go-clean-arch-saas/internal/entity"
 	"go-clean-arch-saas/internal/model"
 	"go-clean-arch-saas/internal/model/converter"
 	"go-clean-arch-saas/internal/repository"
+	"go-clean-arch-saas/pkg/email"
+	"go-clean-arch-saas/pkg/payment"
+	"slices"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -16,11 +20,26 @@ import (
 )
 
 type SubscriptionUseCase struct {
-	DB                     *gorm.DB
-	Log                    *logrus.Logger
-	Validate               *validator.Validate
-	SubscriptionRepository *repository.SubscriptionRepository
-	PlanRepository         *repository.PlanRepository
+	DB                           *gorm.DB
+	Log                          *logrus.Logger
+	Validate                     *validator.Validate
+	SubscriptionRepository       *repository.SubscriptionRepository
+	PlanRepository               *repository.PlanRepository
+	AuditService                 *AuditService
+	EntitlementService           *EntitlementService
+	UsageRecordRepository        *repository.UsageRecordRepository
+	UsageMeter                   *UsageMeter
+	InvoiceService               *InvoiceService
+	PaymentService               *PaymentService
+	CouponService                *CouponService
+	InvoiceRepository            *repository.InvoiceRepository
+	BillingEventRepository       *repository.BillingEventRepository
+	OrganizationRepository       *repository.OrganizationRepository
+	OrganizationMemberRepository *repository.OrganizationMemberRepository
+	EmailService                 *email.EmailService
+	BaseURL                      string
+	TrialReminderWindow          time.Duration
+	Dunning                      DunningPolicy
 }
 
 func NewSubscriptionUseCase(
@@ -29,13 +48,43 @@ func NewSubscriptionUseCase(
 	validate *validator.Validate,
 	subRepo *repository.SubscriptionRepository,
 	planRepo *repository.PlanRepository,
+	auditService *AuditService,
+	entitlementService *EntitlementService,
+	usageRecordRepo *repository.UsageRecordRepository,
+	usageMeter *UsageMeter,
+	invoiceService *InvoiceService,
+	paymentService *PaymentService,
+	couponService *CouponService,
+	invoiceRepo *repository.InvoiceRepository,
+	billingEventRepo *repository.BillingEventRepository,
+	orgRepo *repository.OrganizationRepository,
+	orgMemberRepo *repository.OrganizationMemberRepository,
+	emailService *email.EmailService,
+	baseURL string,
+	trialReminderWindow time.Duration,
+	dunning DunningPolicy,
 ) *SubscriptionUseCase {
 	return &SubscriptionUseCase{
-		DB:                     db,
-		Log:                    logger,
-		Validate:               validate,
-		SubscriptionRepository: subRepo,
-		PlanRepository:         planRepo,
+		DB:                           db,
+		Log:                          logger,
+		Validate:                     validate,
+		SubscriptionRepository:       subRepo,
+		PlanRepository:               planRepo,
+		AuditService:                 auditService,
+		EntitlementService:           entitlementService,
+		UsageRecordRepository:        usageRecordRepo,
+		UsageMeter:                   usageMeter,
+		InvoiceService:               invoiceService,
+		PaymentService:               paymentService,
+		CouponService:                couponService,
+		InvoiceRepository:            invoiceRepo,
+		BillingEventRepository:       billingEventRepo,
+		OrganizationRepository:       orgRepo,
+		OrganizationMemberRepository: orgMemberRepo,
+		EmailService:                 emailService,
+		BaseURL:                      baseURL,
+		TrialReminderWindow:          trialReminderWindow,
+		Dunning:                      dunning,
 	}
 }
 
@@ -57,6 +106,9 @@ func (u *SubscriptionUseCase) GetCurrentSubscription(ctx context.Context, orgID
 	return converter.SubscriptionToResponse(subscription), nil
 }
 
+// Upgrade changes the organization's plan. Upgrades start a new period now, credited with the unused part
+// of the current one; downgrades are scheduled for the end of the current period.
+// Choosing the current plan again drops a scheduled downgrade.
 func (u *SubscriptionUseCase) Upgrade(ctx context.Context, request *model.UpgradeSubscriptionRequest) (*model.SubscriptionResponse, error) {
 	tx := u.DB.WithContext(ctx).Begin()
 	defer tx.Rollback()
@@ -73,6 +125,11 @@ func (u *SubscriptionUseCase) Upgrade(ctx context.Context, request *model.Upgrad
 		return nil, fiber.ErrNotFound
 	}
 
+	// Concurrent plan changes and renewals must not both price and charge the same subscription
+	if err := u.lockCurrentSubscription(tx, currentSub); err != nil {
+		return nil, err
+	}
+
 	// Get new plan
 	newPlan := new(entity.Plan)
 	if err := u.PlanRepository.FindById(tx, newPlan, request.PlanID); err != nil {
@@ -80,8 +137,70 @@ func (u *SubscriptionUseCase) Upgrade(ctx context.Context, request *model.Upgrad
 		return nil, fiber.ErrNotFound
 	}
 
-	// Cancel current subscription
-	currentSub.Status = "cancelled"
+	price, err := u.findPlanPrice(tx, newPlan, request.PriceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if onPlanPrice(currentSub, newPlan, price) {
+		if currentSub.ScheduledPlanID == nil {
+			u.Log.Warnf("Organization %s is already on plan %s", request.OrganizationID, newPlan.Slug)
+			return nil, fiber.NewError(fiber.StatusConflict, "Organization is already on this plan")
+		}
+		return u.schedulePlan(ctx, tx, currentSub, nil, nil, request.ActorID)
+	}
+
+	// A downgrade must still fit the organization's current usage
+	newEntitlements, err := ParseEntitlements(newPlan)
+	if err != nil {
+		u.Log.Warnf("Failed to parse entitlements of plan %s: %+v", newPlan.Slug, err)
+		return nil, fiber.ErrInternalServerError
+	}
+	usage, err := u.EntitlementService.Usage(tx, request.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	if err := newEntitlements.CheckLimit(entity.PlanLimitMaxUsers, usage.Users, 0); err != nil {
+		u.Log.Warnf("Organization %s does not fit plan %s: %+v", request.OrganizationID, newPlan.Slug, err)
+		return nil, err
+	}
+
+	now := time.Now()
+	billedPlan := PricedPlan(newPlan, price)
+	proration := CalculateProration(currentSub, billedPlan, now)
+
+	if proration.Change == model.PlanChangeDowngrade {
+		if request.PromoCode != "" {
+			u.Log.Warnf("Promo code given for a downgrade of organization %s", request.OrganizationID)
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Promo codes only apply to upgrades")
+		}
+		if currentSub.CancelAtPeriodEnd {
+			u.Log.Warnf("Subscription %s is scheduled for cancellation", currentSub.ID)
+			return nil, fiber.NewError(fiber.StatusConflict, "Subscription is scheduled for cancellation")
+		}
+		return u.schedulePlan(ctx, tx, currentSub, newPlan, price, request.ActorID)
+	}
+
+	coupon, carried, err := u.upgradeCoupon(tx, currentSub, newPlan, request.PromoCode, now)
+	if err != nil {
+		return nil, err
+	}
+	if coupon != nil {
+		ApplyDiscount(proration, coupon)
+	}
+
+	var credits []entity.InvoiceLineItem
+	if proration.Credit > 0 {
+		credits = append(credits, ProrationCreditLine(currentSub, proration))
+	}
+
+	// End the current subscription now; its unused time is credited to the new one
+	nowMilli := now.UnixMilli()
+	currentSub.Status = entity.SubscriptionStatusCancelled
+	currentSub.CancelledAt = &nowMilli
+	currentSub.CurrentPeriodEnd = nowMilli
+	currentSub.ScheduledPlanID = nil
+	currentSub.ScheduledPlanPriceID = nil
 	if err := u.SubscriptionRepository.Update(tx, currentSub); err != nil {
 		u.Log.Warnf("Failed to cancel current subscription: %+v", err)
 		return nil, fiber.ErrInternalServerError
@@ -92,27 +211,244 @@ func (u *SubscriptionUseCase) Upgrade(ctx context.Context, request *model.Upgrad
 		ID:                 uuid.New().String(),
 		OrganizationID:     request.OrganizationID,
 		PlanID:             request.PlanID,
-		Status:             "active",
-		CurrentPeriodStart: time.Now().UnixMilli(),
-		CurrentPeriodEnd:   time.Now().AddDate(0, 1, 0).UnixMilli(), // 1 month
-		CreatedAt:          time.Now().UnixMilli(),
-		UpdatedAt:          time.Now().UnixMilli(),
+		Quantity:           currentSub.Quantity,
+		Status:             entity.SubscriptionStatusActive,
+		CurrentPeriodStart: nowMilli,
+		CurrentPeriodEnd:   PeriodEnd(billedPlan, nowMilli),
+		CreatedAt:          nowMilli,
+		UpdatedAt:          nowMilli,
 	}
 	newSub.Plan = *newPlan
+	if price != nil {
+		newSub.PlanPriceID = &price.ID
+		newSub.PlanPrice = price
+	}
+	if carried {
+		newSub.CouponID = currentSub.CouponID
+		newSub.DiscountEndsAt = currentSub.DiscountEndsAt
+	} else if coupon != nil {
+		StartDiscount(newSub, coupon)
+	}
 
 	if err := u.SubscriptionRepository.Create(tx, newSub); err != nil {
 		u.Log.Warnf("Failed to create new subscription: %+v", err)
 		return nil, fiber.ErrInternalServerError
 	}
 
+	if coupon != nil && !carried {
+		if err := u.CouponService.Redeem(tx, coupon, newSub); err != nil {
+			return nil, err
+		}
+	}
+
+	// Seat changes not yet invoiced are billed with the upgrade
+	if err := u.InvoiceRepository.MovePendingItems(tx, currentSub.ID, newSub.ID); err != nil {
+		u.Log.Warnf("Failed to move pending invoice items: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	invoice, err := u.InvoiceService.Generate(tx, newSub, credits...)
+	if err != nil {
+		u.Log.Warnf("Failed to generate invoice: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         request.ActorID,
+		OrganizationID: request.OrganizationID,
+		Action:         entity.AuditActionSubscriptionUpgrade,
+		Resource:       entity.AuditResourceSubscription,
+		ResourceID:     newSub.ID,
+		Details: map[string]interface{}{
+			"previous_plan_id": currentSub.PlanID,
+			"plan_id":          newSub.PlanID,
+			"plan_price_id":    newSub.PlanPriceID,
+			"credit":           proration.Credit,
+			"discount":         proration.Discount,
+			"promo_code":       proration.PromoCode,
+			"amount_due":       proration.AmountDue,
+		},
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	// The new subscription is only active once its invoice is paid
+	if invoice != nil && invoice.AmountDue > 0 {
+		if err := u.PaymentService.Collect(ctx, tx, invoice); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		u.PaymentService.Refund(ctx, invoice)
 		return nil, fiber.ErrInternalServerError
 	}
 
 	return converter.SubscriptionToResponse(newSub), nil
 }
 
+// schedulePlan sets the plan, at the price variant, the subscription switches to at renewal, or clears it when plan is nil
+func (u *SubscriptionUseCase) schedulePlan(ctx context.Context, tx *gorm.DB, subscription *entity.Subscription, plan *entity.Plan, price *entity.PlanPrice, actorID string) (*model.SubscriptionResponse, error) {
+	details := map[string]interface{}{"plan_id": subscription.PlanID, "effective_at": subscription.CurrentPeriodEnd}
+	if plan != nil {
+		subscription.ScheduledPlanID = &plan.ID
+		subscription.ScheduledPlanPriceID = nil
+		details["scheduled_plan_id"] = plan.ID
+		if price != nil {
+			subscription.ScheduledPlanPriceID = &price.ID
+			details["scheduled_plan_price_id"] = price.ID
+		}
+	} else {
+		details["previous_scheduled_plan_id"] = *subscription.ScheduledPlanID
+		subscription.ScheduledPlanID = nil
+		subscription.ScheduledPlanPriceID = nil
+	}
+
+	if err := u.SubscriptionRepository.Update(tx, subscription); err != nil {
+		u.Log.Warnf("Failed to schedule plan change: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         actorID,
+		OrganizationID: subscription.OrganizationID,
+		Action:         entity.AuditActionSubscriptionSchedule,
+		Resource:       entity.AuditResourceSubscription,
+		ResourceID:     subscription.ID,
+		Details:        details,
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.SubscriptionToResponse(subscription), nil
+}
+
+// PreviewChange returns what changing to another plan would cost now without changing anything
+func (u *SubscriptionUseCase) PreviewChange(ctx context.Context, request *model.PreviewPlanChangeRequest) (*model.ProrationResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	subscription := new(entity.Subscription)
+	if err := u.SubscriptionRepository.FindActiveByOrganization(tx, subscription, request.OrganizationID); err != nil {
+		u.Log.Warnf("Failed to find current subscription: %+v", err)
+		return nil, fiber.ErrNotFound
+	}
+
+	plan := new(entity.Plan)
+	if err := u.PlanRepository.FindById(tx, plan, request.PlanID); err != nil {
+		u.Log.Warnf("Failed to find plan: %+v", err)
+		return nil, fiber.ErrNotFound
+	}
+
+	price, err := u.findPlanPrice(tx, plan, request.PriceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if onPlanPrice(subscription, plan, price) {
+		u.Log.Warnf("Organization %s is already on plan %s", request.OrganizationID, plan.Slug)
+		return nil, fiber.NewError(fiber.StatusConflict, "Organization is already on this plan")
+	}
+
+	now := time.Now()
+	proration := CalculateProration(subscription, PricedPlan(plan, price), now)
+	if proration.Change == model.PlanChangeDowngrade && request.PromoCode != "" {
+		u.Log.Warnf("Promo code given for a downgrade of organization %s", request.OrganizationID)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Promo codes only apply to upgrades")
+	}
+
+	if proration.Change == model.PlanChangeUpgrade {
+		coupon, _, err := u.upgradeCoupon(tx, subscription, plan, request.PromoCode, now)
+		if err != nil {
+			return nil, err
+		}
+		if coupon != nil {
+			ApplyDiscount(proration, coupon)
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return proration, nil
+}
+
+// upgradeCoupon returns the coupon discounting an upgrade to plan: the promotion code's, or else the current
+// subscription's when its discount is still running and covers plan. carried reports the latter.
+// A once coupon is used up by the period it discounted, so it is not carried.
+func (u *SubscriptionUseCase) upgradeCoupon(tx *gorm.DB, current *entity.Subscription, plan *entity.Plan, promoCode string, now time.Time) (*entity.Coupon, bool, error) {
+	if promoCode != "" {
+		coupon, err := u.CouponService.Find(tx, promoCode, current.OrganizationID, plan, now)
+		return coupon, false, err
+	}
+
+	coupon, err := u.CouponService.ForPeriod(tx, current, plan, now.UnixMilli())
+	if err != nil {
+		u.Log.Warnf("Failed to find coupon of subscription %s: %+v", current.ID, err)
+		return nil, false, fiber.ErrInternalServerError
+	}
+	if coupon == nil || coupon.Duration == entity.CouponDurationOnce {
+		return nil, false, nil
+	}
+	return coupon, true, nil
+}
+
+// findPlanPrice finds the price variant of plan picked by priceID, or nil for the plan's own price
+func (u *SubscriptionUseCase) findPlanPrice(tx *gorm.DB, plan *entity.Plan, priceID string) (*entity.PlanPrice, error) {
+	if priceID == "" {
+		return nil, nil
+	}
+
+	price := new(entity.PlanPrice)
+	if err := u.PlanRepository.FindPrice(tx, price, plan.ID, priceID); err != nil {
+		u.Log.Warnf("Failed to find price %s of plan %s: %+v", priceID, plan.Slug, err)
+		return nil, fiber.ErrNotFound
+	}
+	return price, nil
+}
+
+// onPlanPrice reports whether the subscription is billed for plan at price, nil being the plan's own price
+func onPlanPrice(subscription *entity.Subscription, plan *entity.Plan, price *entity.PlanPrice) bool {
+	if subscription.PlanID != plan.ID {
+		return false
+	}
+	if price == nil {
+		return subscription.PlanPriceID == nil
+	}
+	return subscription.PlanPriceID != nil && *subscription.PlanPriceID == price.ID
+}
+
+// lockCurrentSubscription reloads a subscription found without a lock and locks its row until the transaction
+// ends. It returns 409 if a concurrent change ended the subscription or scheduled or undid its cancellation.
+func (u *SubscriptionUseCase) lockCurrentSubscription(tx *gorm.DB, subscription *entity.Subscription) error {
+	locked := new(entity.Subscription)
+	if err := u.SubscriptionRepository.FindByIdForUpdate(tx, locked, subscription.ID); err != nil {
+		u.Log.Warnf("Failed to lock subscription: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+	if !slices.Contains(entity.SubscriptionCurrentStatuses, locked.Status) || locked.CancelAtPeriodEnd != subscription.CancelAtPeriodEnd {
+		u.Log.Warnf("Subscription %s changed while it was being updated", subscription.ID)
+		return fiber.NewError(fiber.StatusConflict, "The subscription changed, please try again")
+	}
+	*subscription = *locked
+	return nil
+}
+
 func (u *SubscriptionUseCase) Cancel(ctx context.Context, request *model.CancelSubscriptionRequest) error {
 	tx := u.DB.WithContext(ctx).Begin()
 	defer tx.Rollback()
@@ -128,9 +464,62 @@ func (u *SubscriptionUseCase) Cancel(ctx context.Context, request *model.CancelS
 		return fiber.ErrNotFound
 	}
 
-	subscription.Status = "cancelled"
-	if err := u.SubscriptionRepository.Update(tx, subscription); err != nil {
-		u.Log.Warnf("Failed to cancel subscription: %+v", err)
+	// A renewal or payment webhook running concurrently must not be overwritten with the stale row
+	if err := u.lockCurrentSubscription(tx, subscription); err != nil {
+		return err
+	}
+
+	now := time.Now().UnixMilli()
+	details := map[string]interface{}{"plan_id": subscription.PlanID, "immediately": request.Immediately}
+
+	// Cancelling drops a scheduled downgrade
+	subscription.ScheduledPlanID = nil
+	subscription.ScheduledPlanPriceID = nil
+
+	if request.Immediately {
+		if subscription.Plan.Slug == "free" {
+			u.Log.Warnf("Cannot cancel the free plan immediately for organization %s", request.OrganizationID)
+			return fiber.NewError(fiber.StatusBadRequest, "The free plan cannot be cancelled immediately")
+		}
+
+		subscription.Status = entity.SubscriptionStatusCancelled
+		subscription.CancelledAt = &now
+		subscription.CurrentPeriodEnd = now
+		if err := u.SubscriptionRepository.Update(tx, subscription); err != nil {
+			u.Log.Warnf("Failed to cancel subscription: %+v", err)
+			return fiber.ErrInternalServerError
+		}
+
+		fallback, err := u.fallbackToFreePlan(tx, request.OrganizationID, time.UnixMilli(now))
+		if err != nil {
+			u.Log.Warnf("Failed to fall back to free plan: %+v", err)
+			return fiber.ErrInternalServerError
+		}
+		details["fallback_subscription_id"] = fallback.ID
+	} else {
+		if subscription.CancelAtPeriodEnd {
+			u.Log.Warnf("Subscription %s is already scheduled for cancellation", subscription.ID)
+			return fiber.NewError(fiber.StatusConflict, "Subscription is already scheduled for cancellation")
+		}
+
+		// Access continues until the period ends, when the scheduler expires the subscription
+		subscription.CancelAtPeriodEnd = true
+		subscription.CancelledAt = &now
+		if err := u.SubscriptionRepository.Update(tx, subscription); err != nil {
+			u.Log.Warnf("Failed to cancel subscription: %+v", err)
+			return fiber.ErrInternalServerError
+		}
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         request.ActorID,
+		OrganizationID: request.OrganizationID,
+		Action:         entity.AuditActionSubscriptionCancel,
+		Resource:       entity.AuditResourceSubscription,
+		ResourceID:     subscription.ID,
+		Details:        details,
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
 		return fiber.ErrInternalServerError
 	}
 
@@ -141,3 +530,1021 @@ func (u *SubscriptionUseCase) Cancel(ctx context.Context, request *model.CancelS
 
 	return nil
 }
+
+// Resume undoes a cancellation scheduled for the end of the period
+func (u *SubscriptionUseCase) Resume(ctx context.Context, request *model.ResumeSubscriptionRequest) (*model.SubscriptionResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	subscription := new(entity.Subscription)
+	if err := u.SubscriptionRepository.FindActiveByOrganization(tx, subscription, request.OrganizationID); err != nil {
+		u.Log.Warnf("Failed to find subscription: %+v", err)
+		return nil, fiber.ErrNotFound
+	}
+
+	if err := u.lockCurrentSubscription(tx, subscription); err != nil {
+		return nil, err
+	}
+
+	if !subscription.CancelAtPeriodEnd {
+		u.Log.Warnf("Subscription %s is not scheduled for cancellation", subscription.ID)
+		return nil, fiber.NewError(fiber.StatusConflict, "Subscription is not scheduled for cancellation")
+	}
+
+	subscription.CancelAtPeriodEnd = false
+	subscription.CancelledAt = nil
+	if err := u.SubscriptionRepository.Update(tx, subscription); err != nil {
+		u.Log.Warnf("Failed to resume subscription: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         request.ActorID,
+		OrganizationID: request.OrganizationID,
+		Action:         entity.AuditActionSubscriptionResume,
+		Resource:       entity.AuditResourceSubscription,
+		ResourceID:     subscription.ID,
+		Details:        map[string]interface{}{"plan_id": subscription.PlanID},
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.SubscriptionToResponse(subscription), nil
+}
+
+// GetEntitlements returns the limits and features of the organization's plan with current usage
+func (u *SubscriptionUseCase) GetEntitlements(ctx context.Context, orgID string) (*model.EntitlementsResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	entitlements, err := u.EntitlementService.Get(tx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := u.EntitlementService.Usage(tx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return &model.EntitlementsResponse{
+		Plan:     entitlements.PlanSlug,
+		Limits:   entitlements.Limits,
+		Features: entitlements.Features,
+		Usage:    *usage,
+	}, nil
+}
+
+// GetUsage returns the organization's API calls this month, including calls not yet flushed, against its plan limit
+func (u *SubscriptionUseCase) GetUsage(ctx context.Context, orgID string) (*model.UsageResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	entitlements, err := u.EntitlementService.Get(tx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	periodStart, periodEnd := UsagePeriod(time.Now())
+	stored, err := u.UsageRecordRepository.FindQuantity(tx, orgID, entity.UsageMetricAPICalls, periodStart)
+	if err != nil {
+		u.Log.Warnf("Failed to find usage: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	used := stored + u.UsageMeter.Pending(orgID, periodStart)
+	limit := entitlements.Limits.APICallsPerMonth
+	remaining := entity.PlanLimitUnlimited
+	if limit != entity.PlanLimitUnlimited {
+		remaining = max(limit-used, 0)
+	}
+
+	return &model.UsageResponse{
+		Metric:      entity.UsageMetricAPICalls,
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+		Used:        used,
+		Limit:       limit,
+		Remaining:   remaining,
+	}, nil
+}
+
+// HandleBillingWebhook verifies a payment provider webhook and applies it to the subscription its invoice
+// belongs to. Every event is stored in billing_events in the same transaction as its effects, so a
+// redelivered event changes nothing. A failed payment moves the subscription to past_due, starting dunning,
+// and a successful one back to active; a full refund or a dispute cancels it and the organization falls back
+// to the free plan.
+func (u *SubscriptionUseCase) HandleBillingWebhook(ctx context.Context, request *model.BillingWebhookRequest) (*model.BillingWebhookResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	provider := u.PaymentService.Provider
+	event, err := provider.ParseWebhook(request.Payload, request.Signature)
+	if err != nil {
+		u.Log.Warnf("Failed to parse billing webhook: %+v", err)
+		if errors.Is(err, payment.ErrWebhookSecretMissing) {
+			return nil, fiber.NewError(fiber.StatusServiceUnavailable, "Billing webhooks are not configured")
+		}
+		if errors.Is(err, payment.ErrInvalidSignature) {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid webhook signature")
+		}
+		return nil, fiber.ErrBadRequest
+	}
+	if event.ID == "" {
+		u.Log.Warnf("Billing webhook of type %s has no event ID", event.Type)
+		return nil, fiber.ErrBadRequest
+	}
+
+	invoice, err := u.findEventInvoice(tx, event)
+	if err != nil {
+		u.Log.Warnf("Failed to find invoice of billing event %s: %+v", event.ID, err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	billingEvent := &entity.BillingEvent{
+		ID:       uuid.New().String(),
+		Provider: provider.Name(),
+		EventID:  event.ID,
+		Type:     event.Type,
+		Payload:  string(request.Payload),
+	}
+	if invoice != nil {
+		billingEvent.OrganizationID = &invoice.OrganizationID
+	}
+
+	created, err := u.BillingEventRepository.CreateIfNotExists(tx, billingEvent)
+	if err != nil {
+		u.Log.Warnf("Failed to store billing event %s: %+v", event.ID, err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	response := &model.BillingWebhookResponse{EventID: event.ID, Type: event.Type}
+	if !created {
+		u.Log.Infof("Billing event %s was already processed", event.ID)
+		response.Duplicate = true
+		return response, nil
+	}
+
+	// Events about charges we did not create are stored but change nothing
+	var pastDue *entity.Subscription
+	if invoice != nil {
+		if pastDue, err = u.applyBillingEvent(ctx, tx, event, invoice); err != nil {
+			u.Log.Warnf("Failed to apply billing event %s: %+v", event.ID, err)
+			return nil, fiber.ErrInternalServerError
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if pastDue != nil {
+		u.sendPaymentFailedEmails(ctx, pastDue, invoice)
+	}
+
+	return response, nil
+}
+
+// findEventInvoice returns the invoice named in the event's metadata, or paid by its charge, or nil when there is none
+func (u *SubscriptionUseCase) findEventInvoice(tx *gorm.DB, event *payment.Event) (*entity.Invoice, error) {
+	invoice := new(entity.Invoice)
+
+	var err error
+	if invoiceID, parseErr := uuid.Parse(event.Metadata["invoice_id"]); parseErr == nil {
+		err = u.InvoiceRepository.FindById(tx, invoice, invoiceID.String())
+	} else if event.ChargeID != "" {
+		err = u.InvoiceRepository.FindByChargeId(tx, invoice, event.ChargeID)
+	} else {
+		return nil, nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// applyBillingEvent updates the invoice and moves its subscription to the status the event implies.
+// It returns the subscription when the event made it past_due.
+func (u *SubscriptionUseCase) applyBillingEvent(ctx context.Context, tx *gorm.DB, event *payment.Event, invoice *entity.Invoice) (*entity.Subscription, error) {
+	subscription := new(entity.Subscription)
+	if err := u.SubscriptionRepository.FindByIdForUpdate(tx, subscription, invoice.SubscriptionID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	current := slices.Contains(entity.SubscriptionCurrentStatuses, subscription.Status)
+	status := subscription.Status
+
+	switch event.Type {
+	case payment.EventPaymentSucceeded:
+		if invoice.Status == entity.InvoiceStatusOpen {
+			if err := u.InvoiceRepository.MarkPaid(tx, invoice, event.ChargeID, now.UnixMilli()); err != nil {
+				return nil, err
+			}
+		}
+		if subscription.Status == entity.SubscriptionStatusPastDue {
+			status = entity.SubscriptionStatusActive
+		}
+	case payment.EventPaymentFailed:
+		if invoice.Status == entity.InvoiceStatusOpen && subscription.Status == entity.SubscriptionStatusActive {
+			status = entity.SubscriptionStatusPastDue
+		}
+	case payment.EventRefunded, payment.EventPaymentDisputed:
+		// A partial refund is a goodwill credit and leaves the subscription alone
+		if event.Type == payment.EventRefunded {
+			if event.Amount < invoice.AmountDue {
+				return nil, nil
+			}
+			if err := u.InvoiceRepository.MarkRefunded(tx, invoice); err != nil {
+				return nil, err
+			}
+		}
+		if current {
+			status = entity.SubscriptionStatusCancelled
+		}
+	}
+
+	if status == subscription.Status {
+		return nil, nil
+	}
+
+	details := map[string]interface{}{
+		"plan_id":         subscription.PlanID,
+		"previous_status": subscription.Status,
+		"status":          status,
+		"event_id":        event.ID,
+		"event_type":      event.Type,
+		"invoice_id":      invoice.ID,
+	}
+
+	switch status {
+	case entity.SubscriptionStatusPastDue:
+		u.Dunning.Start(subscription, now)
+		details["grace_period_ends_at"] = *subscription.GracePeriodEndsAt
+	case entity.SubscriptionStatusActive:
+		EndDunning(subscription)
+	case entity.SubscriptionStatusCancelled:
+		nowMilli := now.UnixMilli()
+		subscription.Status = status
+		subscription.CancelledAt = &nowMilli
+		subscription.CurrentPeriodEnd = nowMilli
+		subscription.ScheduledPlanID = nil
+		subscription.ScheduledPlanPriceID = nil
+		subscription.NextDunningAt = nil
+	}
+	if err := u.SubscriptionRepository.Update(tx, subscription); err != nil {
+		return nil, err
+	}
+
+	if status == entity.SubscriptionStatusCancelled {
+		fallback, err := u.fallbackToFreePlan(tx, subscription.OrganizationID, now)
+		if err != nil {
+			return nil, err
+		}
+		details["fallback_subscription_id"] = fallback.ID
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		OrganizationID: subscription.OrganizationID,
+		Action:         entity.AuditActionSubscriptionStatus,
+		Resource:       entity.AuditResourceSubscription,
+		ResourceID:     subscription.ID,
+		Details:        details,
+	}); err != nil {
+		return nil, err
+	}
+
+	if status == entity.SubscriptionStatusPastDue {
+		return subscription, nil
+	}
+	return nil, nil
+}
+
+// subscriptionBatchSize bounds how many subscriptions one scheduler run processes per job
+const subscriptionBatchSize = 100
+
+// RenewDueSubscriptions starts a new period for active subscriptions whose period has ended,
+// switching to a scheduled downgrade if there is one, and charges its invoice. A subscription whose
+// payment is declined stays on its plan as past_due while the payment is retried (see RetryPastDuePayments).
+// Each subscription is renewed in its own transaction so one failure does not block the rest.
+func (u *SubscriptionUseCase) RenewDueSubscriptions(ctx context.Context) (int, error) {
+	subscriptions, err := u.SubscriptionRepository.FindDueForRenewal(u.DB.WithContext(ctx), time.Now().UnixMilli(), subscriptionBatchSize)
+	if err != nil {
+		u.Log.Warnf("Failed to find subscriptions due for renewal: %+v", err)
+		return 0, err
+	}
+
+	renewed := 0
+	for _, subscription := range subscriptions {
+		if err := u.renew(ctx, subscription.ID); err != nil {
+			u.Log.Warnf("Failed to renew subscription %s: %+v", subscription.ID, err)
+			continue
+		}
+		renewed++
+	}
+
+	return renewed, nil
+}
+
+func (u *SubscriptionUseCase) renew(ctx context.Context, subscriptionID string) error {
+	// A cancelled run must not roll back a charge already taken, or fail to refund it
+	ctx = context.WithoutCancel(ctx)
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	subscription := new(entity.Subscription)
+	if err := u.SubscriptionRepository.FindByIdForUpdate(tx, subscription, subscriptionID); err != nil {
+		return err
+	}
+
+	// Another run may have handled it since it was listed
+	now := time.Now().UnixMilli()
+	if subscription.Status != entity.SubscriptionStatusActive || subscription.CancelAtPeriodEnd || subscription.CurrentPeriodEnd > now {
+		return nil
+	}
+
+	details := map[string]interface{}{"plan_id": subscription.PlanID, "previous_period_end": subscription.CurrentPeriodEnd}
+
+	if err := u.applyScheduledPlan(tx, subscription, details); err != nil {
+		return err
+	}
+
+	// Skip whole periods missed while the scheduler was not running
+	for subscription.CurrentPeriodEnd <= now {
+		subscription.CurrentPeriodStart = subscription.CurrentPeriodEnd
+		subscription.CurrentPeriodEnd = PeriodEnd(BillingPlan(subscription), subscription.CurrentPeriodStart)
+	}
+
+	if err := u.SubscriptionRepository.Update(tx, subscription); err != nil {
+		return err
+	}
+
+	invoice, err := u.InvoiceService.Generate(tx, subscription)
+	if err != nil {
+		return err
+	}
+
+	details["period_end"] = subscription.CurrentPeriodEnd
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		OrganizationID: subscription.OrganizationID,
+		Action:         entity.AuditActionSubscriptionRenew,
+		Resource:       entity.AuditResourceSubscription,
+		ResourceID:     subscription.ID,
+		Details:        details,
+	}); err != nil {
+		return err
+	}
+
+	// The organization cannot pay; provider outages roll back and the renewal is retried on the next run
+	pastDue := false
+	if invoice != nil && invoice.AmountDue > 0 {
+		if err := u.PaymentService.Collect(ctx, tx, invoice); err != nil {
+			declined := paymentRequired(err)
+			if declined == nil {
+				return err
+			}
+			if err := u.startDunning(ctx, tx, subscription, invoice, declined.Message); err != nil {
+				return err
+			}
+			pastDue = true
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.PaymentService.Refund(ctx, invoice)
+		return err
+	}
+
+	if pastDue {
+		u.sendPaymentFailedEmails(ctx, subscription, invoice)
+	}
+
+	return nil
+}
+
+// startDunning moves a subscription whose invoice could not be charged to past_due
+func (u *SubscriptionUseCase) startDunning(ctx context.Context, tx *gorm.DB, subscription *entity.Subscription, invoice *entity.Invoice, reason string) error {
+	previousStatus := subscription.Status
+	u.Dunning.Start(subscription, time.Now())
+	if err := u.SubscriptionRepository.Update(tx, subscription); err != nil {
+		return err
+	}
+
+	return u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		OrganizationID: subscription.OrganizationID,
+		Action:         entity.AuditActionSubscriptionStatus,
+		Resource:       entity.AuditResourceSubscription,
+		ResourceID:     subscription.ID,
+		Details: map[string]interface{}{
+			"plan_id":              subscription.PlanID,
+			"previous_status":      previousStatus,
+			"status":               subscription.Status,
+			"invoice_id":           invoice.ID,
+			"reason":               reason,
+			"grace_period_ends_at": *subscription.GracePeriodEndsAt,
+		},
+	})
+}
+
+// applyScheduledPlan switches the subscription to its scheduled downgrade, if there is one, as a new period starts
+func (u *SubscriptionUseCase) applyScheduledPlan(tx *gorm.DB, subscription *entity.Subscription, details map[string]interface{}) error {
+	if subscription.ScheduledPlanID == nil {
+		return nil
+	}
+
+	plan := new(entity.Plan)
+	if err := u.PlanRepository.FindById(tx, plan, *subscription.ScheduledPlanID); err != nil {
+		return err
+	}
+
+	var price *entity.PlanPrice
+	if subscription.ScheduledPlanPriceID != nil {
+		price = new(entity.PlanPrice)
+		if err := u.PlanRepository.FindPrice(tx, price, plan.ID, *subscription.ScheduledPlanPriceID); err != nil {
+			return err
+		}
+		details["plan_price_id"] = price.ID
+	}
+
+	details["previous_plan_id"] = subscription.PlanID
+	details["plan_id"] = plan.ID
+	subscription.PlanID = plan.ID
+	subscription.Plan = *plan
+	subscription.PlanPriceID = subscription.ScheduledPlanPriceID
+	subscription.PlanPrice = price
+	subscription.ScheduledPlanID = nil
+	subscription.ScheduledPlanPriceID = nil
+	return nil
+}
+
+// EndTrials converts trialing subscriptions whose trial has ended into paid ones, charging the organization's
+// payment method for the first period. Organizations without a payment method, or whose payment is declined,
+// fall back to the free plan.
+func (u *SubscriptionUseCase) EndTrials(ctx context.Context) (int, error) {
+	subscriptions, err := u.SubscriptionRepository.FindEndedTrials(u.DB.WithContext(ctx), time.Now().UnixMilli(), subscriptionBatchSize)
+	if err != nil {
+		u.Log.Warnf("Failed to find ended trials: %+v", err)
+		return 0, err
+	}
+
+	ended := 0
+	for _, subscription := range subscriptions {
+		if err := u.endTrial(ctx, subscription.ID); err != nil {
+			u.Log.Warnf("Failed to end trial %s: %+v", subscription.ID, err)
+			continue
+		}
+		ended++
+	}
+
+	return ended, nil
+}
+
+func (u *SubscriptionUseCase) endTrial(ctx context.Context, subscriptionID string) error {
+	err := u.convertTrial(ctx, subscriptionID)
+
+	// The organization cannot pay; provider outages are retried on the next run instead
+	if declined := paymentRequired(err); declined != nil {
+		u.Log.Infof("Trial %s ends without payment: %s", subscriptionID, declined.Message)
+		return u.expireTrial(ctx, subscriptionID, declined.Message)
+	}
+	return err
+}
+
+// paymentRequired returns the error when it means the organization cannot pay, and nil for any other error
+func paymentRequired(err error) *fiber.Error {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) && fiberErr.Code == fiber.StatusPaymentRequired {
+		return fiberErr
+	}
+	return nil
+}
+
+// convertTrial starts the first paid period of an ended trial, switching to a downgrade scheduled during the trial
+func (u *SubscriptionUseCase) convertTrial(ctx context.Context, subscriptionID string) error {
+	// A cancelled run must not roll back a charge already taken, or fail to refund it
+	ctx = context.WithoutCancel(ctx)
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	subscription := new(entity.Subscription)
+	if err := u.SubscriptionRepository.FindByIdForUpdate(tx, subscription, subscriptionID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if !trialEnded(subscription, now) {
+		return nil
+	}
+
+	details := map[string]interface{}{"plan_id": subscription.PlanID, "trial_ends_at": *subscription.TrialEndsAt, "converted": true}
+	if err := u.applyScheduledPlan(tx, subscription, details); err != nil {
+		return err
+	}
+
+	subscription.Status = entity.SubscriptionStatusActive
+	subscription.CurrentPeriodStart = *subscription.TrialEndsAt
+	billedPlan := BillingPlan(subscription)
+	subscription.CurrentPeriodEnd = PeriodEnd(billedPlan, subscription.CurrentPeriodStart)
+	for subscription.CurrentPeriodEnd <= now.UnixMilli() {
+		subscription.CurrentPeriodStart = subscription.CurrentPeriodEnd
+		subscription.CurrentPeriodEnd = PeriodEnd(billedPlan, subscription.CurrentPeriodStart)
+	}
+	if err := u.SubscriptionRepository.Update(tx, subscription); err != nil {
+		return err
+	}
+
+	invoice, err := u.InvoiceService.Generate(tx, subscription)
+	if err != nil {
+		return err
+	}
+
+	details["period_end"] = subscription.CurrentPeriodEnd
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		OrganizationID: subscription.OrganizationID,
+		Action:         entity.AuditActionSubscriptionTrialEnd,
+		Resource:       entity.AuditResourceSubscription,
+		ResourceID:     subscription.ID,
+		Details:        details,
+	}); err != nil {
+		return err
+	}
+
+	if invoice != nil && invoice.AmountDue > 0 {
+		if err := u.PaymentService.Collect(ctx, tx, invoice); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.PaymentService.Refund(ctx, invoice)
+		return err
+	}
+
+	return nil
+}
+
+// expireTrial ends a trial that could not be paid for and moves the organization to the free plan
+func (u *SubscriptionUseCase) expireTrial(ctx context.Context, subscriptionID string, reason string) error {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	subscription := new(entity.Subscription)
+	if err := u.SubscriptionRepository.FindByIdForUpdate(tx, subscription, subscriptionID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if !trialEnded(subscription, now) {
+		return nil
+	}
+
+	subscription.Status = entity.SubscriptionStatusExpired
+	subscription.ScheduledPlanID = nil
+	subscription.ScheduledPlanPriceID = nil
+	if err := u.SubscriptionRepository.Update(tx, subscription); err != nil {
+		return err
+	}
+
+	fallback, err := u.fallbackToFreePlan(tx, subscription.OrganizationID, now)
+	if err != nil {
+		return err
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		OrganizationID: subscription.OrganizationID,
+		Action:         entity.AuditActionSubscriptionTrialEnd,
+		Resource:       entity.AuditResourceSubscription,
+		ResourceID:     subscription.ID,
+		Details: map[string]interface{}{
+			"plan_id":                  subscription.PlanID,
+			"trial_ends_at":            *subscription.TrialEndsAt,
+			"converted":                false,
+			"reason":                   reason,
+			"fallback_subscription_id": fallback.ID,
+		},
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// trialEnded reports whether the subscription is a trial past its end that is not being cancelled
+func trialEnded(subscription *entity.Subscription, now time.Time) bool {
+	return subscription.Status == entity.SubscriptionStatusTrialing &&
+		!subscription.CancelAtPeriodEnd &&
+		subscription.TrialEndsAt != nil &&
+		*subscription.TrialEndsAt <= now.UnixMilli()
+}
+
+// RemindEndingTrials emails the owners of organizations whose trial ends within TrialReminderWindow, once per trial
+func (u *SubscriptionUseCase) RemindEndingTrials(ctx context.Context) (int, error) {
+	deadline := time.Now().Add(u.TrialReminderWindow).UnixMilli()
+	subscriptions, err := u.SubscriptionRepository.FindTrialsToRemind(u.DB.WithContext(ctx), deadline, subscriptionBatchSize)
+	if err != nil {
+		u.Log.Warnf("Failed to find trials to remind: %+v", err)
+		return 0, err
+	}
+
+	reminded := 0
+	for _, subscription := range subscriptions {
+		if err := u.remindTrial(ctx, subscription.ID); err != nil {
+			u.Log.Warnf("Failed to remind trial %s: %+v", subscription.ID, err)
+			continue
+		}
+		reminded++
+	}
+
+	return reminded, nil
+}
+
+func (u *SubscriptionUseCase) remindTrial(ctx context.Context, subscriptionID string) error {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	subscription := new(entity.Subscription)
+	if err := u.SubscriptionRepository.FindByIdForUpdate(tx, subscription, subscriptionID); err != nil {
+		return err
+	}
+
+	// Another run may have handled it since it was listed
+	if subscription.Status != entity.SubscriptionStatusTrialing || subscription.CancelAtPeriodEnd ||
+		subscription.TrialEndsAt == nil || subscription.TrialReminderSentAt != nil {
+		return nil
+	}
+
+	organization, owners, err := u.findOwners(tx, subscription.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UnixMilli()
+	subscription.TrialReminderSentAt = &now
+	if err := u.SubscriptionRepository.Update(tx, subscription); err != nil {
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	// Sent after the commit so a reminder goes out at most once; a failed send is not retried
+	hasPaymentMethod := organization.PaymentMethodID != nil
+	for _, owner := range owners {
+		if err := u.EmailService.SendTrialEndingEmail(
+			owner.Email,
+			owner.Name,
+			organization.Name,
+			subscription.Plan.Name,
+			time.UnixMilli(*subscription.TrialEndsAt),
+			hasPaymentMethod,
+			u.BaseURL,
+		); err != nil {
+			u.Log.Warnf("Failed to send trial reminder to %s: %+v", owner.Email, err)
+		}
+	}
+
+	return nil
+}
+
+// RetryPastDuePayments charges the open invoice of past_due subscriptions again on the Dunning retry schedule,
+// emailing the owners after every failed attempt. A subscription still unpaid when its grace period ends
+// expires and the organization falls back to the free plan.
+func (u *SubscriptionUseCase) RetryPastDuePayments(ctx context.Context) (int, error) {
+	subscriptions, err := u.SubscriptionRepository.FindDunningDue(u.DB.WithContext(ctx), time.Now().UnixMilli(), subscriptionBatchSize)
+	if err != nil {
+		u.Log.Warnf("Failed to find past due subscriptions: %+v", err)
+		return 0, err
+	}
+
+	processed := 0
+	for _, subscription := range subscriptions {
+		if err := u.dunningStep(ctx, subscription.ID); err != nil {
+			u.Log.Warnf("Failed to retry payment of subscription %s: %+v", subscription.ID, err)
+			continue
+		}
+		processed++
+	}
+
+	return processed, nil
+}
+
+func (u *SubscriptionUseCase) dunningStep(ctx context.Context, subscriptionID string) error {
+	// A cancelled run must not roll back a charge already taken, or fail to refund it
+	ctx = context.WithoutCancel(ctx)
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	subscription := new(entity.Subscription)
+	if err := u.SubscriptionRepository.FindByIdForUpdate(tx, subscription, subscriptionID); err != nil {
+		return err
+	}
+
+	// Another run may have handled it since it was listed, or a webhook reported the payment
+	now := time.Now()
+	if subscription.Status != entity.SubscriptionStatusPastDue || subscription.NextDunningAt == nil || *subscription.NextDunningAt > now.UnixMilli() {
+		return nil
+	}
+
+	if GracePeriodEnded(subscription, now) {
+		return u.downgradeUnpaid(ctx, tx, subscription, now)
+	}
+
+	// The invoice may have been settled some other way
+	invoice := new(entity.Invoice)
+	if err := u.InvoiceRepository.FindLatestOpenBySubscription(tx, invoice, subscription.ID); err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		invoice = nil
+	}
+
+	details := map[string]interface{}{"plan_id": subscription.PlanID, "attempt": subscription.PaymentRetryCount + 1}
+	var declined *fiber.Error
+	if invoice != nil {
+		details["invoice_id"] = invoice.ID
+
+		// Provider outages roll back and are retried on the next run
+		err := u.PaymentService.Collect(ctx, tx, invoice)
+		if declined = paymentRequired(err); err != nil && declined == nil {
+			return err
+		}
+	}
+
+	if declined != nil {
+		u.Dunning.RetryFailed(subscription)
+		details["paid"] = false
+		details["reason"] = declined.Message
+		details["next_dunning_at"] = *subscription.NextDunningAt
+	} else {
+		EndDunning(subscription)
+		details["paid"] = true
+	}
+	if err := u.SubscriptionRepository.Update(tx, subscription); err != nil {
+		return err
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		OrganizationID: subscription.OrganizationID,
+		Action:         entity.AuditActionSubscriptionRetry,
+		Resource:       entity.AuditResourceSubscription,
+		ResourceID:     subscription.ID,
+		Details:        details,
+	}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		if declined == nil {
+			u.PaymentService.Refund(ctx, invoice)
+		}
+		return err
+	}
+
+	if declined != nil {
+		u.sendPaymentFailedEmails(ctx, subscription, invoice)
+	}
+
+	return nil
+}
+
+// downgradeUnpaid expires a past_due subscription at the end of its grace period and moves the organization
+// to the free plan. The unpaid invoice stays open.
+func (u *SubscriptionUseCase) downgradeUnpaid(ctx context.Context, tx *gorm.DB, subscription *entity.Subscription, now time.Time) error {
+	subscription.Status = entity.SubscriptionStatusExpired
+	subscription.ScheduledPlanID = nil
+	subscription.ScheduledPlanPriceID = nil
+	subscription.NextDunningAt = nil
+	if err := u.SubscriptionRepository.Update(tx, subscription); err != nil {
+		return err
+	}
+
+	fallback, err := u.fallbackToFreePlan(tx, subscription.OrganizationID, now)
+	if err != nil {
+		return err
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		OrganizationID: subscription.OrganizationID,
+		Action:         entity.AuditActionSubscriptionStatus,
+		Resource:       entity.AuditResourceSubscription,
+		ResourceID:     subscription.ID,
+		Details: map[string]interface{}{
+			"plan_id":                  subscription.PlanID,
+			"previous_status":          entity.SubscriptionStatusPastDue,
+			"status":                   subscription.Status,
+			"reason":                   "Grace period ended without payment",
+			"payment_retry_count":      subscription.PaymentRetryCount,
+			"fallback_subscription_id": fallback.ID,
+		},
+	}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	organization, owners, err := u.findOwners(u.DB.WithContext(ctx), subscription.OrganizationID)
+	if err != nil {
+		u.Log.Warnf("Failed to find owners of organization %s: %+v", subscription.OrganizationID, err)
+		return nil
+	}
+	for _, owner := range owners {
+		if err := u.EmailService.SendSubscriptionDowngradedEmail(owner.Email, owner.Name, organization.Name, subscription.Plan.Name, u.BaseURL); err != nil {
+			u.Log.Warnf("Failed to send downgrade notice to %s: %+v", owner.Email, err)
+		}
+	}
+
+	return nil
+}
+
+// sendPaymentFailedEmails tells the organization's owners that the subscription's payment failed and when it is
+// retried. Call it after the commit; failures are only logged.
+func (u *SubscriptionUseCase) sendPaymentFailedEmails(ctx context.Context, subscription *entity.Subscription, invoice *entity.Invoice) {
+	organization, owners, err := u.findOwners(u.DB.WithContext(ctx), subscription.OrganizationID)
+	if err != nil {
+		u.Log.Warnf("Failed to find owners of organization %s: %+v", subscription.OrganizationID, err)
+		return
+	}
+
+	var nextRetryAt *time.Time
+	if *subscription.NextDunningAt < *subscription.GracePeriodEndsAt {
+		next := time.UnixMilli(*subscription.NextDunningAt)
+		nextRetryAt = &next
+	}
+
+	amountDue, currency := 0.0, ""
+	if invoice != nil {
+		amountDue, currency = invoice.AmountDue, invoice.Currency
+	}
+
+	for _, owner := range owners {
+		if err := u.EmailService.SendPaymentFailedEmail(
+			owner.Email,
+			owner.Name,
+			organization.Name,
+			subscription.Plan.Name,
+			amountDue,
+			currency,
+			nextRetryAt,
+			time.UnixMilli(*subscription.GracePeriodEndsAt),
+			u.BaseURL,
+		); err != nil {
+			u.Log.Warnf("Failed to send payment failure notice to %s: %+v", owner.Email, err)
+		}
+	}
+}
+
+// findOwners returns the organization and its owners, who receive billing emails
+func (u *SubscriptionUseCase) findOwners(db *gorm.DB, orgID string) (*entity.Organization, []entity.User, error) {
+	organization := new(entity.Organization)
+	if err := u.OrganizationRepository.FindById(db, organization, orgID); err != nil {
+		return nil, nil, err
+	}
+
+	members, err := u.OrganizationMemberRepository.ListByOrganization(db, orgID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var owners []entity.User
+	for _, member := range members {
+		if member.Role == entity.OrgRoleOwner {
+			owners = append(owners, member.User)
+		}
+	}
+	return organization, owners, nil
+}
+
+// ExpireLapsedSubscriptions moves subscriptions cancelled at period end, or replaced by another plan,
+// to expired once their period has ended.
+// An organization left without an active subscription falls back to the free plan.
+func (u *SubscriptionUseCase) ExpireLapsedSubscriptions(ctx context.Context) (int, error) {
+	subscriptions, err := u.SubscriptionRepository.FindLapsed(u.DB.WithContext(ctx), time.Now().UnixMilli(), subscriptionBatchSize)
+	if err != nil {
+		u.Log.Warnf("Failed to find lapsed subscriptions: %+v", err)
+		return 0, err
+	}
+
+	expired := 0
+	for _, subscription := range subscriptions {
+		if err := u.expire(ctx, subscription.ID); err != nil {
+			u.Log.Warnf("Failed to expire subscription %s: %+v", subscription.ID, err)
+			continue
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
+func (u *SubscriptionUseCase) expire(ctx context.Context, subscriptionID string) error {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	subscription := new(entity.Subscription)
+	if err := u.SubscriptionRepository.FindByIdForUpdate(tx, subscription, subscriptionID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	lapsed := subscription.Status == entity.SubscriptionStatusCancelled ||
+		(slices.Contains(entity.SubscriptionCurrentStatuses, subscription.Status) && subscription.CancelAtPeriodEnd)
+	if !lapsed || subscription.CurrentPeriodEnd > now.UnixMilli() {
+		return nil
+	}
+
+	subscription.Status = entity.SubscriptionStatusExpired
+	if err := u.SubscriptionRepository.Update(tx, subscription); err != nil {
+		return err
+	}
+
+	details := map[string]interface{}{"plan_id": subscription.PlanID}
+
+	current := new(entity.Subscription)
+	if err := u.SubscriptionRepository.FindActiveByOrganization(tx, current, subscription.OrganizationID); err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		fallback, err := u.fallbackToFreePlan(tx, subscription.OrganizationID, now)
+		if err != nil {
+			return err
+		}
+		details["fallback_subscription_id"] = fallback.ID
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		OrganizationID: subscription.OrganizationID,
+		Action:         entity.AuditActionSubscriptionExpire,
+		Resource:       entity.AuditResourceSubscription,
+		ResourceID:     subscription.ID,
+		Details:        details,
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// fallbackToFreePlan starts a free subscription for an organization left without an active one
+func (u *SubscriptionUseCase) fallbackToFreePlan(tx *gorm.DB, orgID string, now time.Time) (*entity.Subscription, error) {
+	freePlan := new(entity.Plan)
+	if err := u.PlanRepository.FindBySlug(tx, freePlan, "free"); err != nil {
+		return nil, err
+	}
+
+	members, err := u.OrganizationMemberRepository.CountByOrganization(tx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	fallback := &entity.Subscription{
+		ID:                 uuid.New().String(),
+		OrganizationID:     orgID,
+		PlanID:             freePlan.ID,
+		Quantity:           int(max(members, 1)),
+		Status:             entity.SubscriptionStatusActive,
+		CurrentPeriodStart: now.UnixMilli(),
+		CurrentPeriodEnd:   PeriodEnd(freePlan, now.UnixMilli()),
+		CreatedAt:          now.UnixMilli(),
+		UpdatedAt:          now.UnixMilli(),
+	}
+	if err := u.SubscriptionRepository.Create(tx, fallback); err != nil {
+		return nil, err
+	}
+
+	return fallback, nil
+}
@@ -0,0 +1,220 @@
+package usecase
+
+import (
+	"context"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/repository"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// usageQuotaTTL is how long a cached quota is trusted before it is reloaded from the database
+const usageQuotaTTL = time.Minute
+
+type usageKey struct {
+	OrganizationID string
+	PeriodStart    int64
+}
+
+// usageQuota caches an organization's API call limit and consumption for quota checks
+type usageQuota struct {
+	PeriodStart int64
+	Limit       int64
+	Used        int64
+	ExpiresAt   time.Time
+}
+
+// UsageMeter counts API calls per organization in memory and flushes them to usage_records in batches,
+// so metering does not cost a database write per request
+type UsageMeter struct {
+	DB                    *gorm.DB
+	Log                   *logrus.Logger
+	UsageRecordRepository *repository.UsageRecordRepository
+	EntitlementService    *EntitlementService
+	FlushInterval         time.Duration
+
+	mu      sync.Mutex
+	pending map[usageKey]int64
+	quotas  map[string]*usageQuota
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+func NewUsageMeter(
+	db *gorm.DB,
+	logger *logrus.Logger,
+	usageRecordRepo *repository.UsageRecordRepository,
+	entitlementService *EntitlementService,
+	flushInterval time.Duration,
+) *UsageMeter {
+	return &UsageMeter{
+		DB:                    db,
+		Log:                   logger,
+		UsageRecordRepository: usageRecordRepo,
+		EntitlementService:    entitlementService,
+		FlushInterval:         flushInterval,
+		pending:               map[usageKey]int64{},
+		quotas:                map[string]*usageQuota{},
+	}
+}
+
+// UsagePeriod returns the calendar month (UTC) containing now, in milliseconds
+func UsagePeriod(now time.Time) (int64, int64) {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start.UnixMilli(), start.AddDate(0, 1, 0).UnixMilli()
+}
+
+// Record counts one API call for the organization
+func (m *UsageMeter) Record(orgID string) {
+	periodStart, _ := UsagePeriod(time.Now())
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pending[usageKey{OrganizationID: orgID, PeriodStart: periodStart}]++
+	if quota, ok := m.quotas[orgID]; ok && quota.PeriodStart == periodStart {
+		quota.Used++
+	}
+}
+
+// Pending returns the calls counted but not yet flushed
+func (m *UsageMeter) Pending(orgID string, periodStart int64) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.pending[usageKey{OrganizationID: orgID, PeriodStart: periodStart}]
+}
+
+// CheckQuota returns a 429 EntitlementError once the organization has used its api_calls_per_month.
+// Counts are cached for usageQuotaTTL, so with several replicas the block may trail the limit slightly.
+func (m *UsageMeter) CheckQuota(ctx context.Context, orgID string) error {
+	now := time.Now()
+	periodStart, _ := UsagePeriod(now)
+
+	m.mu.Lock()
+	quota, ok := m.quotas[orgID]
+	m.mu.Unlock()
+
+	if !ok || quota.PeriodStart != periodStart || now.After(quota.ExpiresAt) {
+		loaded, err := m.loadQuota(ctx, orgID, periodStart)
+		if err != nil {
+			// Metering must not lock an organization out, e.g. while it has no active subscription
+			m.Log.Warnf("Failed to load API quota for organization %s: %+v", orgID, err)
+			return nil
+		}
+
+		m.mu.Lock()
+		m.quotas[orgID] = loaded
+		m.mu.Unlock()
+		quota = loaded
+	}
+
+	m.mu.Lock()
+	limit, used := quota.Limit, quota.Used
+	m.mu.Unlock()
+
+	if limit == entity.PlanLimitUnlimited || used < limit {
+		return nil
+	}
+
+	return &model.EntitlementError{
+		Code:    fiber.StatusTooManyRequests,
+		Message: "API call quota exceeded",
+		Limit:   entity.PlanLimitAPICallsPerMonth,
+		Allowed: limit,
+		Current: used,
+	}
+}
+
+func (m *UsageMeter) loadQuota(ctx context.Context, orgID string, periodStart int64) (*usageQuota, error) {
+	tx := m.DB.WithContext(ctx)
+
+	entitlements, err := m.EntitlementService.Get(tx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := m.UsageRecordRepository.FindQuantity(tx, orgID, entity.UsageMetricAPICalls, periodStart)
+	if err != nil {
+		return nil, err
+	}
+
+	return &usageQuota{
+		PeriodStart: periodStart,
+		Limit:       entitlements.Limits.APICallsPerMonth,
+		Used:        stored + m.Pending(orgID, periodStart),
+		ExpiresAt:   time.Now().Add(usageQuotaTTL),
+	}, nil
+}
+
+// Flush writes the pending counts. Counts that fail to write are kept for the next flush.
+func (m *UsageMeter) Flush(ctx context.Context) {
+	m.mu.Lock()
+	pending := m.pending
+	m.pending = map[usageKey]int64{}
+	m.mu.Unlock()
+
+	now := time.Now().UnixMilli()
+	for key, quantity := range pending {
+		periodStart, periodEnd := UsagePeriod(time.UnixMilli(key.PeriodStart))
+		record := &entity.UsageRecord{
+			ID:             uuid.New().String(),
+			OrganizationID: key.OrganizationID,
+			Metric:         entity.UsageMetricAPICalls,
+			PeriodStart:    periodStart,
+			PeriodEnd:      periodEnd,
+			Quantity:       quantity,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+
+		if err := m.UsageRecordRepository.Increment(m.DB.WithContext(ctx), record); err != nil {
+			m.Log.Warnf("Failed to flush usage for organization %s: %+v", key.OrganizationID, err)
+			m.mu.Lock()
+			m.pending[key] += quantity
+			m.mu.Unlock()
+		}
+	}
+}
+
+// Start flushes pending counts every FlushInterval until Stop is called
+func (m *UsageMeter) Start() {
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+
+	go func() {
+		defer close(m.done)
+
+		ticker := time.NewTicker(m.FlushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				m.Flush(context.Background())
+			case <-m.stop:
+				m.Flush(context.Background())
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the flush loop after a final flush
+func (m *UsageMeter) Stop() error {
+	if m.stop == nil {
+		return nil
+	}
+
+	close(m.stop)
+	<-m.done
+	m.stop = nil
+	return nil
+}
@@ -15,19 +15,24 @@ import (
 )
 
 type UserUseCase struct {
-	DB             *gorm.DB
-	Log            *logrus.Logger
-	Validate       *validator.Validate
-	UserRepository *repository.UserRepository
+	DB                           *gorm.DB
+	Log                          *logrus.Logger
+	Validate                     *validator.Validate
+	UserRepository               *repository.UserRepository
+	OrganizationMemberRepository *repository.OrganizationMemberRepository
+	AuditService                 *AuditService
 }
 
 func NewUserUseCase(db *gorm.DB, logger *logrus.Logger, validate *validator.Validate,
-	userRepository *repository.UserRepository) *UserUseCase {
+	userRepository *repository.UserRepository, organizationMemberRepository *repository.OrganizationMemberRepository,
+	auditService *AuditService) *UserUseCase {
 	return &UserUseCase{
-		DB:             db,
-		Log:            logger,
-		Validate:       validate,
-		UserRepository: userRepository,
+		DB:                           db,
+		Log:                          logger,
+		Validate:                     validate,
+		UserRepository:               userRepository,
+		OrganizationMemberRepository: organizationMemberRepository,
+		AuditService:                 auditService,
 	}
 }
 
@@ -87,6 +92,21 @@ func (c *UserUseCase) Update(ctx context.Context, request *model.UpdateUserReque
 		return nil, fiber.ErrInternalServerError
 	}
 
+	action := entity.AuditActionUserUpdate
+	if request.Password != "" {
+		action = entity.AuditActionPasswordChange
+	}
+	if err := c.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         user.ID,
+		OrganizationID: request.OrganizationID,
+		Action:         action,
+		Resource:       entity.AuditResourceUser,
+		ResourceID:     user.ID,
+	}); err != nil {
+		c.Log.Warnf("Failed to record audit log: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		c.Log.Warnf("Failed commit transaction : %+v", err)
 		return nil, fiber.ErrInternalServerError
@@ -94,3 +114,31 @@ func (c *UserUseCase) Update(ctx context.Context, request *model.UpdateUserReque
 
 	return converter.UserToResponse(user), nil
 }
+
+func (c *UserUseCase) ListOrganizations(ctx context.Context, request *model.ListUserOrganizationsRequest) ([]model.UserOrganizationResponse, error) {
+	tx := c.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.Warnf("Invalid request body : %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	members, err := c.OrganizationMemberRepository.ListByUser(tx, request.UserID)
+	if err != nil {
+		c.Log.Warnf("Failed list memberships : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.Warnf("Failed commit transaction : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	responses := make([]model.UserOrganizationResponse, 0, len(members))
+	for _, member := range members {
+		responses = append(responses, *converter.UserOrganizationToResponse(&member, request.CurrentOrganizationID))
+	}
+
+	return responses, nil
+}
@@ -0,0 +1,295 @@
+package usecase
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/repository"
+	jwtPkg "go-clean-arch-saas/pkg/jwt"
+	"go-clean-arch-saas/pkg/webauthn"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// webAuthnTimeout is how long the user has to complete a WebAuthn ceremony
+const webAuthnTimeout = 5 * time.Minute
+
+// WebAuthnService runs the relying party side of WebAuthn ceremonies. The challenge of a ceremony travels
+// in a signed challenge token between its begin and finish steps instead of being stored.
+type WebAuthnService struct {
+	Log                      *logrus.Logger
+	UserCredentialRepository *repository.UserCredentialRepository
+	JWTService               *jwtPkg.JWTService
+	RelyingParty             *webauthn.RelyingParty
+}
+
+func NewWebAuthnService(
+	logger *logrus.Logger,
+	userCredentialRepo *repository.UserCredentialRepository,
+	jwtService *jwtPkg.JWTService,
+	relyingParty *webauthn.RelyingParty,
+) *WebAuthnService {
+	return &WebAuthnService{
+		Log:                      logger,
+		UserCredentialRepository: userCredentialRepo,
+		JWTService:               jwtService,
+		RelyingParty:             relyingParty,
+	}
+}
+
+// BeginRegistration returns the options to create a new credential for the user, excluding the ones they already have
+func (s *WebAuthnService) BeginRegistration(tx *gorm.DB, user *entity.User) (*model.WebAuthnRegisterBeginResponse, error) {
+	credentials, err := s.UserCredentialRepository.ListByUser(tx, user.ID)
+	if err != nil {
+		s.Log.Warnf("Failed to list credentials: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	challenge, challengeToken, err := s.newChallenge(jwtPkg.AudienceWebAuthnRegistration, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	params := make([]model.WebAuthnCredentialParameter, 0, len(webauthn.SupportedAlgorithms))
+	for _, algorithm := range webauthn.SupportedAlgorithms {
+		params = append(params, model.WebAuthnCredentialParameter{Type: "public-key", Alg: algorithm})
+	}
+
+	return &model.WebAuthnRegisterBeginResponse{
+		ChallengeToken: challengeToken,
+		PublicKey: model.WebAuthnCreationOptions{
+			Challenge: challenge,
+			RP: model.WebAuthnRelyingPartyEntity{
+				ID:   s.RelyingParty.ID,
+				Name: s.RelyingParty.Name,
+			},
+			User: model.WebAuthnUserEntity{
+				ID:          webauthn.EncodeBase64URL([]byte(user.ID)),
+				Name:        user.Email,
+				DisplayName: user.Name,
+			},
+			PubKeyCredParams:   params,
+			Timeout:            webAuthnTimeout.Milliseconds(),
+			ExcludeCredentials: credentialDescriptors(credentials),
+			AuthenticatorSelection: model.WebAuthnAuthenticatorSelection{
+				ResidentKey:      "preferred",
+				UserVerification: "preferred",
+			},
+			Attestation: "none",
+		},
+	}, nil
+}
+
+// FinishRegistration verifies a new credential created for the user and stores it
+func (s *WebAuthnService) FinishRegistration(tx *gorm.DB, request *model.WebAuthnRegisterFinishRequest) (*entity.UserCredential, error) {
+	challenge, err := s.challenge(request.ChallengeToken, jwtPkg.AudienceWebAuthnRegistration, request.UserID)
+	if err != nil {
+		s.Log.Warnf("Invalid WebAuthn challenge token: %+v", err)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid or expired challenge token")
+	}
+
+	clientDataJSON, err := webauthn.DecodeBase64URL(request.Credential.Response.ClientDataJSON)
+	if err != nil {
+		s.Log.Warnf("Invalid client data encoding: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+	attestationObject, err := webauthn.DecodeBase64URL(request.Credential.Response.AttestationObject)
+	if err != nil {
+		s.Log.Warnf("Invalid attestation object encoding: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	created, err := s.RelyingParty.VerifyRegistration(challenge, clientDataJSON, attestationObject)
+	if err != nil {
+		s.Log.Warnf("Failed to verify WebAuthn registration: %+v", err)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid passkey registration")
+	}
+
+	credentialID := webauthn.EncodeBase64URL(created.ID)
+	if credentialID != strings.TrimRight(request.Credential.ID, "=") {
+		s.Log.Warnf("Credential ID %s does not match the attested credential", request.Credential.ID)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid passkey registration")
+	}
+
+	count, err := s.UserCredentialRepository.CountByCredentialID(tx, credentialID)
+	if err != nil {
+		s.Log.Warnf("Failed to count credentials: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+	if count > 0 {
+		s.Log.Warnf("Credential already registered: %s", credentialID)
+		return nil, fiber.NewError(fiber.StatusConflict, "Passkey already registered")
+	}
+
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		name = "Passkey"
+	}
+
+	var transports *string
+	if len(request.Credential.Response.Transports) > 0 {
+		joined := strings.Join(request.Credential.Response.Transports, ",")
+		transports = &joined
+	}
+
+	credential := &entity.UserCredential{
+		ID:           uuid.New().String(),
+		UserID:       request.UserID,
+		CredentialID: credentialID,
+		PublicKey:    created.PublicKey,
+		Algorithm:    created.Algorithm,
+		SignCount:    int64(created.SignCount),
+		AAGUID:       hex.EncodeToString(created.AAGUID),
+		Transports:   transports,
+		Name:         name,
+	}
+
+	if err := s.UserCredentialRepository.Create(tx, credential); err != nil {
+		s.Log.Warnf("Failed to create credential: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return credential, nil
+}
+
+// BeginLogin returns the options to sign in with a credential of the user, or with any discoverable
+// credential when userID is empty
+func (s *WebAuthnService) BeginLogin(tx *gorm.DB, userID string) (*model.WebAuthnLoginBeginResponse, error) {
+	var credentials []entity.UserCredential
+	if userID != "" {
+		var err error
+		if credentials, err = s.UserCredentialRepository.ListByUser(tx, userID); err != nil {
+			s.Log.Warnf("Failed to list credentials: %+v", err)
+			return nil, fiber.ErrInternalServerError
+		}
+	}
+
+	challenge, challengeToken, err := s.newChallenge(jwtPkg.AudienceWebAuthnLogin, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.WebAuthnLoginBeginResponse{
+		ChallengeToken: challengeToken,
+		PublicKey: model.WebAuthnRequestOptions{
+			Challenge:        challenge,
+			RPID:             s.RelyingParty.ID,
+			Timeout:          webAuthnTimeout.Milliseconds(),
+			AllowCredentials: credentialDescriptors(credentials),
+			UserVerification: "preferred",
+		},
+	}, nil
+}
+
+// FinishLogin verifies an assertion and records the credential's new signature counter.
+// It returns the credential, whose user signs in, and whether the authenticator verified the user.
+func (s *WebAuthnService) FinishLogin(tx *gorm.DB, request *model.WebAuthnLoginFinishRequest) (*entity.UserCredential, bool, error) {
+	claims, err := s.JWTService.ValidateChallengeToken(request.ChallengeToken, jwtPkg.AudienceWebAuthnLogin)
+	if err != nil {
+		s.Log.Warnf("Invalid WebAuthn challenge token: %+v", err)
+		return nil, false, fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired challenge token")
+	}
+	challenge, err := webauthn.DecodeBase64URL(claims.Challenge)
+	if err != nil {
+		s.Log.Warnf("Invalid WebAuthn challenge: %+v", err)
+		return nil, false, fiber.ErrUnauthorized
+	}
+
+	// Locked so concurrent assertions cannot both pass the signature counter check
+	credential := new(entity.UserCredential)
+	if err := s.UserCredentialRepository.FindByCredentialIDForUpdate(tx, credential, strings.TrimRight(request.Credential.ID, "=")); err != nil {
+		s.Log.Warnf("Failed to find credential: %+v", err)
+		return nil, false, fiber.ErrUnauthorized
+	}
+
+	// A login started for a user only accepts that user's credentials
+	if claims.UserID != "" && claims.UserID != credential.UserID {
+		s.Log.Warnf("Credential %s does not belong to user %s", credential.ID, claims.UserID)
+		return nil, false, fiber.ErrUnauthorized
+	}
+	if request.Credential.Response.UserHandle != "" {
+		userHandle, err := webauthn.DecodeBase64URL(request.Credential.Response.UserHandle)
+		if err != nil || string(userHandle) != credential.UserID {
+			s.Log.Warnf("User handle does not match credential %s", credential.ID)
+			return nil, false, fiber.ErrUnauthorized
+		}
+	}
+
+	clientDataJSON, errClientData := webauthn.DecodeBase64URL(request.Credential.Response.ClientDataJSON)
+	authenticatorData, errAuthData := webauthn.DecodeBase64URL(request.Credential.Response.AuthenticatorData)
+	signature, errSignature := webauthn.DecodeBase64URL(request.Credential.Response.Signature)
+	if err := errors.Join(errClientData, errAuthData, errSignature); err != nil {
+		s.Log.Warnf("Invalid assertion encoding: %+v", err)
+		return nil, false, fiber.ErrBadRequest
+	}
+
+	assertion, err := s.RelyingParty.VerifyAssertion(challenge, credential.PublicKey, uint32(credential.SignCount), clientDataJSON, authenticatorData, signature)
+	if err != nil {
+		if errors.Is(err, webauthn.ErrSignCount) {
+			s.Log.Warnf("Signature counter of credential %s did not increase, the authenticator may be cloned", credential.ID)
+		} else {
+			s.Log.Warnf("Failed to verify WebAuthn assertion: %+v", err)
+		}
+		return nil, false, fiber.ErrUnauthorized
+	}
+
+	now := time.Now().UnixMilli()
+	credential.SignCount = int64(assertion.SignCount)
+	credential.LastUsedAt = &now
+	if err := s.UserCredentialRepository.Update(tx, credential); err != nil {
+		s.Log.Warnf("Failed to update credential: %+v", err)
+		return nil, false, fiber.ErrInternalServerError
+	}
+
+	return credential, assertion.UserVerified, nil
+}
+
+// newChallenge returns a new challenge, base64url encoded, and the challenge token carrying it to the finish step
+func (s *WebAuthnService) newChallenge(audience string, userID string) (string, string, error) {
+	bytes, err := webauthn.NewChallenge()
+	if err != nil {
+		s.Log.Warnf("Failed to generate WebAuthn challenge: %+v", err)
+		return "", "", fiber.ErrInternalServerError
+	}
+
+	challenge := webauthn.EncodeBase64URL(bytes)
+	challengeToken, err := s.JWTService.GenerateChallengeToken(audience, userID, challenge, webAuthnTimeout)
+	if err != nil {
+		s.Log.Warnf("Failed to generate challenge token: %+v", err)
+		return "", "", fiber.ErrInternalServerError
+	}
+
+	return challenge, challengeToken, nil
+}
+
+// challenge returns the challenge of a challenge token issued for the audience and user
+func (s *WebAuthnService) challenge(challengeToken string, audience string, userID string) ([]byte, error) {
+	claims, err := s.JWTService.ValidateChallengeToken(challengeToken, audience)
+	if err != nil {
+		return nil, err
+	}
+	if claims.UserID != userID {
+		return nil, fmt.Errorf("challenge token of user %s used by user %s", claims.UserID, userID)
+	}
+	return webauthn.DecodeBase64URL(claims.Challenge)
+}
+
+// credentialDescriptors lists credentials for allowCredentials or excludeCredentials
+func credentialDescriptors(credentials []entity.UserCredential) []model.WebAuthnCredentialDescriptor {
+	descriptors := make([]model.WebAuthnCredentialDescriptor, 0, len(credentials))
+	for _, credential := range credentials {
+		descriptor := model.WebAuthnCredentialDescriptor{Type: "public-key", ID: credential.CredentialID}
+		if credential.Transports != nil {
+			descriptor.Transports = strings.Split(*credential.Transports, ",")
+		}
+		descriptors = append(descriptors, descriptor)
+	}
+	return descriptors
+}
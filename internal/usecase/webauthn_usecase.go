@@ -0,0 +1,174 @@
+package usecase
+
+import (
+	"context"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/model/converter"
+	"go-clean-arch-saas/internal/repository"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// WebAuthnUseCase lets signed-in users register and remove passkeys. Signing in with them is in AuthUseCase.
+type WebAuthnUseCase struct {
+	DB                       *gorm.DB
+	Log                      *logrus.Logger
+	Validate                 *validator.Validate
+	UserRepository           *repository.UserRepository
+	UserCredentialRepository *repository.UserCredentialRepository
+	WebAuthnService          *WebAuthnService
+	AuditService             *AuditService
+}
+
+func NewWebAuthnUseCase(
+	db *gorm.DB,
+	logger *logrus.Logger,
+	validate *validator.Validate,
+	userRepo *repository.UserRepository,
+	userCredentialRepo *repository.UserCredentialRepository,
+	webAuthnService *WebAuthnService,
+	auditService *AuditService,
+) *WebAuthnUseCase {
+	return &WebAuthnUseCase{
+		DB:                       db,
+		Log:                      logger,
+		Validate:                 validate,
+		UserRepository:           userRepo,
+		UserCredentialRepository: userCredentialRepo,
+		WebAuthnService:          webAuthnService,
+		AuditService:             auditService,
+	}
+}
+
+func (u *WebAuthnUseCase) BeginRegistration(ctx context.Context, request *model.WebAuthnRegisterBeginRequest) (*model.WebAuthnRegisterBeginResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	user := new(entity.User)
+	if err := u.UserRepository.FindById(tx, user, request.UserID); err != nil {
+		u.Log.Warnf("Failed to find user: %+v", err)
+		return nil, fiber.ErrNotFound
+	}
+
+	response, err := u.WebAuthnService.BeginRegistration(tx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return response, nil
+}
+
+func (u *WebAuthnUseCase) FinishRegistration(ctx context.Context, request *model.WebAuthnRegisterFinishRequest) (*model.WebAuthnCredentialResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	credential, err := u.WebAuthnService.FinishRegistration(tx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         request.UserID,
+		OrganizationID: request.OrganizationID,
+		Action:         entity.AuditActionPasskeyRegister,
+		Resource:       entity.AuditResourceUser,
+		ResourceID:     request.UserID,
+		Details:        map[string]interface{}{"credential_id": credential.ID, "name": credential.Name},
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.UserCredentialToResponse(credential), nil
+}
+
+func (u *WebAuthnUseCase) List(ctx context.Context, request *model.ListWebAuthnCredentialsRequest) ([]model.WebAuthnCredentialResponse, error) {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	credentials, err := u.UserCredentialRepository.ListByUser(tx, request.UserID)
+	if err != nil {
+		u.Log.Warnf("Failed to list credentials: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	responses := make([]model.WebAuthnCredentialResponse, 0, len(credentials))
+	for _, credential := range credentials {
+		responses = append(responses, *converter.UserCredentialToResponse(&credential))
+	}
+
+	return responses, nil
+}
+
+func (u *WebAuthnUseCase) Delete(ctx context.Context, request *model.DeleteWebAuthnCredentialRequest) error {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Invalid request body: %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	deleted, err := u.UserCredentialRepository.DeleteByUser(tx, request.UserID, request.ID)
+	if err != nil {
+		u.Log.Warnf("Failed to delete credential: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+	if deleted == 0 {
+		u.Log.Warnf("Credential not found: %s", request.ID)
+		return fiber.ErrNotFound
+	}
+
+	if err := u.AuditService.Record(ctx, tx, &model.AuditEvent{
+		UserID:         request.UserID,
+		OrganizationID: request.OrganizationID,
+		Action:         entity.AuditActionPasskeyRemove,
+		Resource:       entity.AuditResourceUser,
+		ResourceID:     request.UserID,
+		Details:        map[string]interface{}{"credential_id": request.ID},
+	}); err != nil {
+		u.Log.Warnf("Failed to record audit log: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction: %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	return nil
+}
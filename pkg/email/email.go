@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"html/template"
 	"net/smtp"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -65,6 +66,190 @@ func (s *EmailService) SendVerificationEmail(toEmail, userName, verificationToke
 	return s.send(toEmail, subject, body.String())
 }
 
+// SendPasswordResetEmail sends password reset link to user
+func (s *EmailService) SendPasswordResetEmail(toEmail, userName, resetToken, baseURL string) error {
+	resetLink := fmt.Sprintf("%s/reset-password?token=%s", baseURL, resetToken)
+
+	// Load template from embedded file
+	tmpl, err := template.ParseFS(templateFS, "templates/reset_password.html")
+	if err != nil {
+		s.Log.Errorf("Failed to parse email template: %+v", err)
+		return fmt.Errorf("failed to load email template")
+	}
+
+	// Prepare template data
+	data := struct {
+		UserName  string
+		ResetLink string
+	}{
+		UserName:  userName,
+		ResetLink: resetLink,
+	}
+
+	// Execute template
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		s.Log.Errorf("Failed to execute email template: %+v", err)
+		return fmt.Errorf("failed to render email template")
+	}
+
+	subject := "Reset Your Password"
+	return s.send(toEmail, subject, body.String())
+}
+
+// SendInvitationEmail sends organization invitation link to invitee
+func (s *EmailService) SendInvitationEmail(toEmail, organizationName, inviterName, role, invitationToken, baseURL string) error {
+	invitationLink := fmt.Sprintf("%s/accept-invitation?token=%s", baseURL, invitationToken)
+
+	// Load template from embedded file
+	tmpl, err := template.ParseFS(templateFS, "templates/invitation.html")
+	if err != nil {
+		s.Log.Errorf("Failed to parse email template: %+v", err)
+		return fmt.Errorf("failed to load email template")
+	}
+
+	// Prepare template data
+	data := struct {
+		OrganizationName string
+		InviterName      string
+		Role             string
+		InvitationLink   string
+	}{
+		OrganizationName: organizationName,
+		InviterName:      inviterName,
+		Role:             role,
+		InvitationLink:   invitationLink,
+	}
+
+	// Execute template
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		s.Log.Errorf("Failed to execute email template: %+v", err)
+		return fmt.Errorf("failed to render email template")
+	}
+
+	subject := fmt.Sprintf("You've been invited to join %s", organizationName)
+	return s.send(toEmail, subject, body.String())
+}
+
+// SendTrialEndingEmail reminds an organization owner that the free trial is about to end
+func (s *EmailService) SendTrialEndingEmail(toEmail, userName, organizationName, planName string, trialEndsAt time.Time, hasPaymentMethod bool, baseURL string) error {
+	billingLink := fmt.Sprintf("%s/billing", baseURL)
+
+	// Load template from embedded file
+	tmpl, err := template.ParseFS(templateFS, "templates/trial_ending.html")
+	if err != nil {
+		s.Log.Errorf("Failed to parse email template: %+v", err)
+		return fmt.Errorf("failed to load email template")
+	}
+
+	// Prepare template data
+	data := struct {
+		UserName         string
+		OrganizationName string
+		PlanName         string
+		TrialEndsAt      string
+		HasPaymentMethod bool
+		BillingLink      string
+	}{
+		UserName:         userName,
+		OrganizationName: organizationName,
+		PlanName:         planName,
+		TrialEndsAt:      trialEndsAt.UTC().Format("January 2, 2006"),
+		HasPaymentMethod: hasPaymentMethod,
+		BillingLink:      billingLink,
+	}
+
+	// Execute template
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		s.Log.Errorf("Failed to execute email template: %+v", err)
+		return fmt.Errorf("failed to render email template")
+	}
+
+	subject := fmt.Sprintf("Your %s trial ends soon", planName)
+	return s.send(toEmail, subject, body.String())
+}
+
+// SendPaymentFailedEmail tells an organization owner that a subscription payment failed. nextRetryAt is nil
+// when no retry is left before the grace period ends.
+func (s *EmailService) SendPaymentFailedEmail(toEmail, userName, organizationName, planName string, amountDue float64, currency string, nextRetryAt *time.Time, gracePeriodEndsAt time.Time, baseURL string) error {
+	billingLink := fmt.Sprintf("%s/billing", baseURL)
+
+	// Load template from embedded file
+	tmpl, err := template.ParseFS(templateFS, "templates/payment_failed.html")
+	if err != nil {
+		s.Log.Errorf("Failed to parse email template: %+v", err)
+		return fmt.Errorf("failed to load email template")
+	}
+
+	// Prepare template data
+	data := struct {
+		UserName          string
+		OrganizationName  string
+		PlanName          string
+		AmountDue         string
+		NextRetryAt       string
+		GracePeriodEndsAt string
+		BillingLink       string
+	}{
+		UserName:          userName,
+		OrganizationName:  organizationName,
+		PlanName:          planName,
+		AmountDue:         fmt.Sprintf("%.2f %s", amountDue, currency),
+		GracePeriodEndsAt: gracePeriodEndsAt.UTC().Format("January 2, 2006"),
+		BillingLink:       billingLink,
+	}
+	if nextRetryAt != nil {
+		data.NextRetryAt = nextRetryAt.UTC().Format("January 2, 2006")
+	}
+
+	// Execute template
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		s.Log.Errorf("Failed to execute email template: %+v", err)
+		return fmt.Errorf("failed to render email template")
+	}
+
+	subject := fmt.Sprintf("Payment for %s failed", organizationName)
+	return s.send(toEmail, subject, body.String())
+}
+
+// SendSubscriptionDowngradedEmail tells an organization owner that an unpaid subscription moved to the free plan
+func (s *EmailService) SendSubscriptionDowngradedEmail(toEmail, userName, organizationName, planName, baseURL string) error {
+	billingLink := fmt.Sprintf("%s/billing", baseURL)
+
+	// Load template from embedded file
+	tmpl, err := template.ParseFS(templateFS, "templates/subscription_downgraded.html")
+	if err != nil {
+		s.Log.Errorf("Failed to parse email template: %+v", err)
+		return fmt.Errorf("failed to load email template")
+	}
+
+	// Prepare template data
+	data := struct {
+		UserName         string
+		OrganizationName string
+		PlanName         string
+		BillingLink      string
+	}{
+		UserName:         userName,
+		OrganizationName: organizationName,
+		PlanName:         planName,
+		BillingLink:      billingLink,
+	}
+
+	// Execute template
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		s.Log.Errorf("Failed to execute email template: %+v", err)
+		return fmt.Errorf("failed to render email template")
+	}
+
+	subject := fmt.Sprintf("%s moved to the free plan", organizationName)
+	return s.send(toEmail, subject, body.String())
+}
+
 // send sends email using SMTP
 func (s *EmailService) send(to, subject, body string) error {
 	// If email service not configured, log and return nil (development mode)
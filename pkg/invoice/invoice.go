@@ -0,0 +1,70 @@
+package invoice
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+// Document is the data printed on an invoice. Timestamps are Unix milliseconds.
+type Document struct {
+	Number           string
+	OrganizationName string
+	Status           string
+	Currency         string
+	IssuedAt         int64
+	PaidAt           int64
+	PeriodStart      int64
+	PeriodEnd        int64
+	Lines            []Line
+	Total            float64
+	AmountDue        float64
+}
+
+type Line struct {
+	Description string
+	Quantity    int64
+	UnitAmount  float64
+	Amount      float64
+	PeriodStart int64
+	PeriodEnd   int64
+}
+
+type Renderer struct {
+	Log *logrus.Logger
+}
+
+func NewRenderer(log *logrus.Logger) *Renderer {
+	return &Renderer{
+		Log: log,
+	}
+}
+
+// RenderHTML renders the invoice as a standalone, print-ready HTML page
+func (r *Renderer) RenderHTML(document *Document) ([]byte, error) {
+	// Load template from embedded file
+	tmpl, err := template.New("invoice.html").Funcs(template.FuncMap{
+		"money": func(amount float64) string { return fmt.Sprintf("%.2f", amount) },
+		"date":  func(millis int64) string { return time.UnixMilli(millis).UTC().Format("Jan 2, 2006") },
+	}).ParseFS(templateFS, "templates/invoice.html")
+	if err != nil {
+		r.Log.Errorf("Failed to parse invoice template: %+v", err)
+		return nil, fmt.Errorf("failed to load invoice template")
+	}
+
+	// Execute template
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, document); err != nil {
+		r.Log.Errorf("Failed to execute invoice template: %+v", err)
+		return nil, fmt.Errorf("failed to render invoice template")
+	}
+
+	return body.Bytes(), nil
+}
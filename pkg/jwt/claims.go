@@ -8,5 +8,7 @@ type Claims struct {
 	UserID         string `json:"user_id"`
 	Email          string `json:"email"`
 	OrganizationID string `json:"organization_id"`
+	SessionID      string `json:"session_id,omitempty"`
+	Challenge      string `json:"challenge,omitempty"`
 	jwt.RegisteredClaims
 }
@@ -6,6 +6,13 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Audiences of challenge tokens, which carry the state of a multi-step ceremony and are not access tokens
+const (
+	AudienceMFAChallenge         = "mfa_challenge"
+	AudienceWebAuthnRegistration = "webauthn_registration"
+	AudienceWebAuthnLogin        = "webauthn_login"
+)
+
 type JWTService struct {
 	secretKey              string
 	accessTokenExpiration  time.Duration
@@ -20,11 +27,12 @@ func NewJWTService(secret string, accessExpireMinutes, refreshExpireDays int) *J
 	}
 }
 
-func (s *JWTService) GenerateAccessToken(userID, email, orgID string) (string, error) {
+func (s *JWTService) GenerateAccessToken(userID, email, orgID, sessionID string) (string, error) {
 	claims := &Claims{
 		UserID:         userID,
 		Email:          email,
 		OrganizationID: orgID,
+		SessionID:      sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.accessTokenExpiration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -44,6 +52,43 @@ func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
 		return nil, err
 	}
 
+	// Access tokens carry no audience; challenge tokens must not authenticate requests
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		if len(claims.Audience) > 0 {
+			return nil, jwt.ErrTokenInvalidAudience
+		}
+		return claims, nil
+	}
+
+	return nil, jwt.ErrSignatureInvalid
+}
+
+// GenerateChallengeToken issues a short-lived token for the audience's ceremony, such as the second step of a login,
+// carrying the user and the challenge the next step must answer
+func (s *JWTService) GenerateChallengeToken(audience, userID, challenge string, ttl time.Duration) (string, error) {
+	claims := &Claims{
+		UserID:    userID,
+		Challenge: challenge,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{audience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.secretKey))
+}
+
+func (s *JWTService) ValidateChallengeToken(tokenString, audience string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte(s.secretKey), nil
+	}, jwt.WithAudience(audience))
+
+	if err != nil {
+		return nil, err
+	}
+
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
 		return claims, nil
 	}
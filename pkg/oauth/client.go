@@ -0,0 +1,46 @@
+package oauth
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress means a request was about to connect to an address that is not on the public internet
+var ErrPrivateAddress = errors.New("oauth: address is not public")
+
+// sharedAddressSpace is the carrier-grade NAT range, which net.IP does not count as private
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// NewPublicClient returns a client that only connects to public addresses, for fetching URLs that users
+// choose, such as the issuer of an organization's identity provider. The check runs on the resolved address
+// of every connection, so neither DNS names nor redirects pointing inside the network get through.
+func NewPublicClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+				return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// IsPublicIP reports whether ip is a global unicast address outside the private, loopback, link-local and
+// shared ranges
+func IsPublicIP(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip)
+}
@@ -0,0 +1,47 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrDiscovery means an issuer does not publish a usable OpenID Connect discovery document
+var ErrDiscovery = errors.New("oauth: discovery failed")
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Discover reads the endpoints of the OpenID Connect provider at an issuer from its discovery document. The
+// returned provider has no client credentials nor redirect URL yet.
+func Discover(ctx context.Context, client *http.Client, issuer string) (*Provider, error) {
+	provider := &Provider{Issuer: issuer, Client: client}
+
+	document := new(discoveryDocument)
+	endpoint := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	if err := provider.get(ctx, endpoint, "", document); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiscovery, err)
+	}
+
+	// the document must be the issuer's own, or ID tokens would be checked against another issuer
+	if document.Issuer != issuer {
+		return nil, fmt.Errorf("%w: issuer mismatch %q", ErrDiscovery, document.Issuer)
+	}
+	if document.AuthorizationEndpoint == "" || document.TokenEndpoint == "" || document.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: missing endpoints", ErrDiscovery)
+	}
+
+	provider.AuthURL = document.AuthorizationEndpoint
+	provider.TokenURL = document.TokenEndpoint
+	provider.UserInfoURL = document.UserInfoEndpoint
+	provider.JWKSURL = document.JWKSURI
+	provider.Scopes = []string{"openid", "email", "profile"}
+	return provider, nil
+}
@@ -0,0 +1,241 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrExchange means the provider refused the authorization code
+	ErrExchange = errors.New("oauth: code exchange failed")
+	// ErrUserInfo means the provider did not return the user's identity
+	ErrUserInfo = errors.New("oauth: user info request failed")
+)
+
+// Provider is an OAuth 2.0 authorization server signing users in with the authorization code flow and PKCE.
+// Providers with an Issuer are OpenID Connect providers: the user is read from the ID token. Others, such as
+// GitHub, are read from the user info endpoint and, when set, the emails endpoint.
+type Provider struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+	AuthURL      string
+	TokenURL     string
+	UserInfoURL  string
+	EmailsURL    string // GitHub-style list of the user's addresses, for providers not returning a verified email
+	Issuer       string
+	JWKSURL      string
+	Scopes       []string
+	RedirectURL  string
+	Client       *http.Client
+
+	keysMu        sync.Mutex
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+// Token is the response of the token endpoint
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+}
+
+// UserInfo is the identity of the signed-in user at the provider
+type UserInfo struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// IsOIDC reports whether the user is read from an ID token
+func (p *Provider) IsOIDC() bool {
+	return p.Issuer != ""
+}
+
+// RandomString returns a random base64url value, used for states, nonces and PKCE verifiers
+func RandomString() (string, error) {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// CodeChallenge returns the S256 PKCE challenge of a verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL returns the URL sending the user to the provider's consent page
+func (p *Provider) AuthCodeURL(state string, nonce string, codeVerifier string) string {
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.ClientID)
+	query.Set("redirect_uri", p.RedirectURL)
+	if len(p.Scopes) > 0 {
+		query.Set("scope", strings.Join(p.Scopes, " "))
+	}
+	query.Set("state", state)
+	query.Set("code_challenge", CodeChallenge(codeVerifier))
+	query.Set("code_challenge_method", "S256")
+	if p.IsOIDC() {
+		query.Set("nonce", nonce)
+	}
+
+	separator := "?"
+	if strings.Contains(p.AuthURL, "?") {
+		separator = "&"
+	}
+	return p.AuthURL + separator + query.Encode()
+}
+
+// Exchange redeems an authorization code with the PKCE verifier of the authorization request
+func (p *Provider) Exchange(ctx context.Context, code string, codeVerifier string) (*Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.RedirectURL)
+	form.Set("client_id", p.ClientID)
+	form.Set("client_secret", p.ClientSecret)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	token := new(Token)
+	if err := p.do(req, token); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchange, err)
+	}
+	// GitHub reports errors with a 200 response without a token
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("%w: no access token", ErrExchange)
+	}
+	if p.IsOIDC() && token.IDToken == "" {
+		return nil, fmt.Errorf("%w: no ID token", ErrExchange)
+	}
+	return token, nil
+}
+
+// Identify returns the user a token was issued for. The nonce is the one of the authorization request.
+func (p *Provider) Identify(ctx context.Context, token *Token, nonce string) (*UserInfo, error) {
+	if p.IsOIDC() {
+		return p.VerifyIDToken(ctx, token.IDToken, nonce)
+	}
+	return p.FetchUserInfo(ctx, token.AccessToken)
+}
+
+// FetchUserInfo reads the user from the user info endpoint, which may be OpenID Connect's or GitHub-style
+func (p *Provider) FetchUserInfo(ctx context.Context, accessToken string) (*UserInfo, error) {
+	var claims map[string]interface{}
+	if err := p.get(ctx, p.UserInfoURL, accessToken, &claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUserInfo, err)
+	}
+
+	info := &UserInfo{
+		Subject:       stringClaim(claims, "sub", "id"),
+		Email:         stringClaim(claims, "email"),
+		EmailVerified: boolClaim(claims, "email_verified"),
+		Name:          stringClaim(claims, "name", "login"),
+	}
+	if info.Subject == "" {
+		return nil, fmt.Errorf("%w: no subject", ErrUserInfo)
+	}
+
+	if p.EmailsURL != "" {
+		var emails []struct {
+			Email    string `json:"email"`
+			Primary  bool   `json:"primary"`
+			Verified bool   `json:"verified"`
+		}
+		if err := p.get(ctx, p.EmailsURL, accessToken, &emails); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUserInfo, err)
+		}
+		for _, email := range emails {
+			if email.Primary {
+				info.Email = email.Email
+				info.EmailVerified = email.Verified
+			}
+		}
+	}
+
+	return info, nil
+}
+
+func (p *Provider) get(ctx context.Context, endpoint string, accessToken string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	return p.do(req, out)
+}
+
+func (p *Provider) do(req *http.Request, out interface{}) error {
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %d: %s", req.URL.Path, resp.StatusCode, body)
+	}
+	return json.Unmarshal(body, out)
+}
+
+// stringClaim returns the first of the claims present, formatting numeric IDs such as GitHub's
+func stringClaim(claims map[string]interface{}, names ...string) string {
+	for _, name := range names {
+		switch value := claims[name].(type) {
+		case string:
+			if value != "" {
+				return value
+			}
+		case float64:
+			return strconv.FormatFloat(value, 'f', -1, 64)
+		}
+	}
+	return ""
+}
+
+// boolClaim reads a boolean claim, which some providers send as a string
+func boolClaim(claims map[string]interface{}, name string) bool {
+	switch value := claims[name].(type) {
+	case bool:
+		return value
+	case string:
+		return value == "true"
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// keysRefreshInterval limits how often the JWKS is fetched again for an unknown key ID
+const keysRefreshInterval = time.Minute
+
+// ErrInvalidIDToken means an ID token is not signed by the provider, not issued to this client, expired or
+// not bound to the authorization request
+var ErrInvalidIDToken = errors.New("oauth: invalid ID token")
+
+type idTokenClaims struct {
+	Nonce         string      `json:"nonce"`
+	Email         string      `json:"email"`
+	EmailVerified interface{} `json:"email_verified"`
+	Name          string      `json:"name"`
+	jwt.RegisteredClaims
+}
+
+// VerifyIDToken verifies an OpenID Connect ID token and returns the user it identifies
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken string, nonce string) (*UserInfo, error) {
+	claims := new(idTokenClaims)
+	_, err := jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "ES256"}),
+		jwt.WithIssuer(p.Issuer),
+		jwt.WithAudience(p.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if claims.Subject == "" || subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, ErrInvalidIDToken
+	}
+
+	return &UserInfo{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: boolClaim(map[string]interface{}{"email_verified": claims.EmailVerified}, "email_verified"),
+		Name:          claims.Name,
+	}, nil
+}
+
+// key returns the provider's signing key with the ID, fetching the JWKS when the key is not known yet
+func (p *Provider) key(ctx context.Context, kid string) (interface{}, error) {
+	p.keysMu.Lock()
+	defer p.keysMu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < keysRefreshInterval {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	keys, err := p.fetchKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (p *Provider) fetchKeys(ctx context.Context) (map[string]interface{}, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.get(ctx, p.JWKSURL, "", &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key := jwk.publicKey(); key != nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+// publicKey decodes RSA and P-256 keys; other keys are skipped
+func (k *jsonWebKey) publicKey() interface{} {
+	decode := func(value string) *big.Int {
+		data, err := base64.RawURLEncoding.DecodeString(value)
+		if err != nil || len(data) == 0 {
+			return nil
+		}
+		return new(big.Int).SetBytes(data)
+	}
+
+	switch k.Kty {
+	case "RSA":
+		n, e := decode(k.N), decode(k.E)
+		if n == nil || e == nil || !e.IsInt64() {
+			return nil
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}
+	case "EC":
+		x, y := decode(k.X), decode(k.Y)
+		if k.Crv != "P-256" || x == nil || y == nil || !elliptic.P256().IsOnCurve(x, y) {
+			return nil
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Payment methods the fake provider declines, named after Stripe's test cards. Every other ID succeeds.
+const (
+	FakePaymentMethodDeclined = "pm_card_chargeDeclined"
+)
+
+// FakeProvider is an in-process provider for development and tests. It never touches the network.
+// Webhooks are the JSON encoding of Event, signed with the hex HMAC-SHA256 of the payload.
+type FakeProvider struct {
+	Log           *logrus.Logger
+	WebhookSecret string
+
+	mutex          sync.Mutex
+	paymentMethods map[string]string
+	charges        map[string]*Charge
+}
+
+func NewFakeProvider(log *logrus.Logger, webhookSecret string) *FakeProvider {
+	return &FakeProvider{
+		Log:            log,
+		WebhookSecret:  webhookSecret,
+		paymentMethods: map[string]string{},
+		charges:        map[string]*Charge{},
+	}
+}
+
+func (p *FakeProvider) Name() string {
+	return ProviderFake
+}
+
+func (p *FakeProvider) SignatureHeader() string {
+	return "X-Webhook-Signature"
+}
+
+func (p *FakeProvider) CreateCustomer(ctx context.Context, params *CustomerParams) (string, error) {
+	return "cus_fake_" + uuid.New().String(), nil
+}
+
+func (p *FakeProvider) AttachPaymentMethod(ctx context.Context, customerID string, paymentMethodID string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.paymentMethods[customerID] = paymentMethodID
+	return nil
+}
+
+func (p *FakeProvider) Charge(ctx context.Context, params *ChargeParams) (*Charge, error) {
+	if params.PaymentMethodID == FakePaymentMethodDeclined {
+		return nil, ErrPaymentDeclined
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if charge, ok := p.charges[params.IdempotencyKey]; ok && params.IdempotencyKey != "" {
+		return charge, nil
+	}
+
+	charge := &Charge{ID: "ch_fake_" + uuid.New().String(), Amount: params.Amount}
+	p.charges[params.IdempotencyKey] = charge
+	p.Log.Infof("Fake payment provider charged %.2f %s to %s", params.Amount, params.Currency, params.CustomerID)
+	return charge, nil
+}
+
+func (p *FakeProvider) Refund(ctx context.Context, chargeID string, amount float64) error {
+	p.Log.Infof("Fake payment provider refunded %.2f of %s", amount, chargeID)
+	return nil
+}
+
+func (p *FakeProvider) ParseWebhook(payload []byte, signature string) (*Event, error) {
+	if p.WebhookSecret == "" {
+		return nil, ErrWebhookSecretMissing
+	}
+	if !hmac.Equal([]byte(signature), []byte(p.Sign(payload))) {
+		return nil, ErrInvalidSignature
+	}
+
+	event := new(Event)
+	if err := json.Unmarshal(payload, event); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	return event, nil
+}
+
+// Sign returns the signature the fake provider expects for a webhook payload
+func (p *FakeProvider) Sign(payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(p.WebhookSecret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,78 @@
+package payment
+
+import (
+	"context"
+	"errors"
+)
+
+// Provider names accepted by the payment.provider setting
+const (
+	ProviderFake   = "fake"
+	ProviderStripe = "stripe"
+)
+
+// Webhook event types, normalized across providers
+const (
+	EventPaymentSucceeded = "payment.succeeded"
+	EventPaymentFailed    = "payment.failed"
+	EventRefunded         = "payment.refunded"
+	EventPaymentDisputed  = "payment.disputed"
+)
+
+var (
+	// ErrPaymentDeclined means the provider refused the payment method; retrying will not help
+	ErrPaymentDeclined = errors.New("payment declined")
+	// ErrInvalidSignature means a webhook payload was not signed with the configured secret
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrWebhookSecretMissing means no webhook secret is configured, so no webhook can be trusted
+	ErrWebhookSecretMissing = errors.New("webhook secret is not configured")
+)
+
+// PaymentProvider is implemented by each payment processor. Amounts are in major currency units, e.g. 29.00.
+type PaymentProvider interface {
+	// Name returns the provider name, one of the Provider constants
+	Name() string
+	// SignatureHeader returns the HTTP header that carries the webhook signature
+	SignatureHeader() string
+	// CreateCustomer registers the organization with the provider and returns the customer ID
+	CreateCustomer(ctx context.Context, params *CustomerParams) (string, error)
+	// AttachPaymentMethod makes the payment method the customer's default
+	AttachPaymentMethod(ctx context.Context, customerID string, paymentMethodID string) error
+	// Charge collects an amount off-session. Requests with the same IdempotencyKey are charged once.
+	Charge(ctx context.Context, params *ChargeParams) (*Charge, error)
+	// Refund returns all or part of a charge
+	Refund(ctx context.Context, chargeID string, amount float64) error
+	// ParseWebhook verifies the signature of a webhook payload and returns the event it describes
+	ParseWebhook(payload []byte, signature string) (*Event, error)
+}
+
+type CustomerParams struct {
+	OrganizationID string
+	Name           string
+	Email          string
+}
+
+type ChargeParams struct {
+	CustomerID      string
+	PaymentMethodID string
+	Amount          float64
+	Currency        string
+	Description     string
+	IdempotencyKey  string
+	Metadata        map[string]string
+}
+
+type Charge struct {
+	ID     string
+	Amount float64
+}
+
+// Event is a provider notification. Metadata carries the values given in ChargeParams.Metadata.
+type Event struct {
+	ID         string            `json:"id"`
+	Type       string            `json:"type"`
+	CustomerID string            `json:"customer_id"`
+	ChargeID   string            `json:"charge_id"`
+	Amount     float64           `json:"amount"`
+	Metadata   map[string]string `json:"metadata"`
+}
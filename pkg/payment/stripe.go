@@ -0,0 +1,255 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// stripeSignatureTolerance is how old a webhook's signed timestamp may be before it is rejected as a replay
+const stripeSignatureTolerance = 5 * time.Minute
+
+// StripeProvider talks to the Stripe REST API. Charges are off-session PaymentIntents confirmed on creation.
+type StripeProvider struct {
+	Log           *logrus.Logger
+	SecretKey     string
+	WebhookSecret string
+	BaseURL       string
+	Client        *http.Client
+}
+
+func NewStripeProvider(log *logrus.Logger, secretKey string, webhookSecret string, baseURL string) *StripeProvider {
+	return &StripeProvider{
+		Log:           log,
+		SecretKey:     secretKey,
+		WebhookSecret: webhookSecret,
+		BaseURL:       strings.TrimSuffix(baseURL, "/"),
+		Client:        &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type stripeError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// stripeObject holds the fields read from customers, PaymentIntents, charges and disputes
+type stripeObject struct {
+	ID             string            `json:"id"`
+	Status         string            `json:"status"`
+	Customer       string            `json:"customer"`
+	PaymentIntent  string            `json:"payment_intent"`
+	Amount         int64             `json:"amount"`
+	AmountReceived int64             `json:"amount_received"`
+	AmountRefunded int64             `json:"amount_refunded"`
+	Metadata       map[string]string `json:"metadata"`
+}
+
+type stripeEvent struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		Object stripeObject `json:"object"`
+	} `json:"data"`
+}
+
+func (p *StripeProvider) Name() string {
+	return ProviderStripe
+}
+
+func (p *StripeProvider) SignatureHeader() string {
+	return "Stripe-Signature"
+}
+
+func (p *StripeProvider) CreateCustomer(ctx context.Context, params *CustomerParams) (string, error) {
+	form := url.Values{}
+	form.Set("name", params.Name)
+	form.Set("email", params.Email)
+	form.Set("metadata[organization_id]", params.OrganizationID)
+
+	customer := new(stripeObject)
+	if err := p.post(ctx, "/v1/customers", form, "", customer); err != nil {
+		return "", err
+	}
+	return customer.ID, nil
+}
+
+func (p *StripeProvider) AttachPaymentMethod(ctx context.Context, customerID string, paymentMethodID string) error {
+	form := url.Values{}
+	form.Set("customer", customerID)
+	if err := p.post(ctx, "/v1/payment_methods/"+url.PathEscape(paymentMethodID)+"/attach", form, "", nil); err != nil {
+		return err
+	}
+
+	form = url.Values{}
+	form.Set("invoice_settings[default_payment_method]", paymentMethodID)
+	return p.post(ctx, "/v1/customers/"+url.PathEscape(customerID), form, "", nil)
+}
+
+func (p *StripeProvider) Charge(ctx context.Context, params *ChargeParams) (*Charge, error) {
+	form := url.Values{}
+	form.Set("amount", strconv.FormatInt(toMinorUnits(params.Amount), 10))
+	form.Set("currency", strings.ToLower(params.Currency))
+	form.Set("customer", params.CustomerID)
+	form.Set("payment_method", params.PaymentMethodID)
+	form.Set("description", params.Description)
+	form.Set("confirm", "true")
+	form.Set("off_session", "true")
+	for key, value := range params.Metadata {
+		form.Set("metadata["+key+"]", value)
+	}
+
+	intent := new(stripeObject)
+	if err := p.post(ctx, "/v1/payment_intents", form, params.IdempotencyKey, intent); err != nil {
+		return nil, err
+	}
+
+	// Anything short of success needs the customer, e.g. for 3-D Secure, which off-session charges cannot ask for
+	if intent.Status != "succeeded" {
+		return nil, fmt.Errorf("%w: payment intent %s is %s", ErrPaymentDeclined, intent.ID, intent.Status)
+	}
+
+	return &Charge{ID: intent.ID, Amount: fromMinorUnits(intent.AmountReceived)}, nil
+}
+
+// Refund returns amount of a charge. A charge is refunded at most once, however often the refund is retried.
+func (p *StripeProvider) Refund(ctx context.Context, chargeID string, amount float64) error {
+	form := url.Values{}
+	form.Set("payment_intent", chargeID)
+	form.Set("amount", strconv.FormatInt(toMinorUnits(amount), 10))
+	return p.post(ctx, "/v1/refunds", form, "refund_"+chargeID, nil)
+}
+
+// ParseWebhook checks the Stripe-Signature header ("t=<timestamp>,v1=<signature>") and maps the event.
+// Event types without a normalized equivalent are returned with their Stripe type and no other fields.
+func (p *StripeProvider) ParseWebhook(payload []byte, signature string) (*Event, error) {
+	if err := p.verifySignature(payload, signature, time.Now()); err != nil {
+		return nil, err
+	}
+
+	raw := new(stripeEvent)
+	if err := json.Unmarshal(payload, raw); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+
+	object := raw.Data.Object
+	event := &Event{ID: raw.ID, Type: raw.Type}
+	switch raw.Type {
+	case "payment_intent.succeeded":
+		event.Type = EventPaymentSucceeded
+		event.ChargeID = object.ID
+		event.Amount = fromMinorUnits(object.AmountReceived)
+	case "payment_intent.payment_failed":
+		event.Type = EventPaymentFailed
+		event.ChargeID = object.ID
+		event.Amount = fromMinorUnits(object.Amount)
+	case "charge.refunded":
+		event.Type = EventRefunded
+		event.ChargeID = object.PaymentIntent
+		event.Amount = fromMinorUnits(object.AmountRefunded)
+	case "charge.dispute.created":
+		event.Type = EventPaymentDisputed
+		event.ChargeID = object.PaymentIntent
+		event.Amount = fromMinorUnits(object.Amount)
+	default:
+		return event, nil
+	}
+	event.CustomerID = object.Customer
+	event.Metadata = object.Metadata
+
+	return event, nil
+}
+
+func (p *StripeProvider) verifySignature(payload []byte, header string, now time.Time) error {
+	if p.WebhookSecret == "" {
+		return ErrWebhookSecretMissing
+	}
+
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+	if now.Sub(time.Unix(seconds, 0)).Abs() > stripeSignatureTolerance {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(p.WebhookSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// post sends a form-encoded request and decodes the response into out when it is not nil
+func (p *StripeProvider) post(ctx context.Context, path string, form url.Values, idempotencyKey string, out interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, p.BaseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	request.SetBasicAuth(p.SecretKey, "")
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if idempotencyKey != "" {
+		request.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	response, err := p.Client.Do(request)
+	if err != nil {
+		return fmt.Errorf("stripe request %s: %w", path, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		body := new(stripeError)
+		json.NewDecoder(response.Body).Decode(body)
+		p.Log.Warnf("Stripe request %s failed with %d: %s", path, response.StatusCode, body.Error.Message)
+		if body.Error.Type == "card_error" {
+			return fmt.Errorf("%w: %s", ErrPaymentDeclined, body.Error.Code)
+		}
+		return fmt.Errorf("stripe request %s failed with status %d", path, response.StatusCode)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(response.Body).Decode(out)
+}
+
+func toMinorUnits(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func fromMinorUnits(amount int64) float64 {
+	return float64(amount) / 100
+}
@@ -0,0 +1,126 @@
+package saml
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+const (
+	nsDSig     = "http://www.w3.org/2000/09/xmldsig#"
+	nsExcC14N  = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	algExcC14N = "http://www.w3.org/2001/10/xml-exc-c14n#"
+
+	algEnvelopedSignature = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	algRSASHA256          = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	algSHA256             = "http://www.w3.org/2001/04/xmlenc#sha256"
+)
+
+// errUnsigned means an element has no enveloped signature
+var errUnsigned = errors.New("not signed")
+
+// verifySignature checks the enveloped XML signature of an element against the identity provider's
+// certificates. Only the algorithms identity providers default to are accepted: Exclusive Canonicalization,
+// RSA-SHA256 and SHA-256, with a single signature holding a single reference to the signed element itself.
+func verifySignature(el *element, certificates []*x509.Certificate) error {
+	signatures := el.childElements(nsDSig, "Signature")
+	if len(signatures) == 0 {
+		return errUnsigned
+	}
+	if len(signatures) > 1 {
+		return errors.New("expected a single signature")
+	}
+	signature := signatures[0]
+
+	signedInfo := signature.child(nsDSig, "SignedInfo")
+	if signedInfo == nil {
+		return errors.New("missing SignedInfo")
+	}
+	signedInfoPrefixes, err := canonicalizationMethod(signedInfo.child(nsDSig, "CanonicalizationMethod"))
+	if err != nil {
+		return err
+	}
+	if method := signedInfo.child(nsDSig, "SignatureMethod"); method == nil || method.attr("Algorithm") != algRSASHA256 {
+		return errors.New("unsupported signature method")
+	}
+
+	references := signedInfo.childElements(nsDSig, "Reference")
+	if len(references) != 1 {
+		return errors.New("expected a single reference")
+	}
+	reference := references[0]
+	if id := el.attr("ID"); id == "" || reference.attr("URI") != "#"+id {
+		return errors.New("reference does not point to the signed element")
+	}
+
+	var referencePrefixes []string
+	enveloped := false
+	if transforms := reference.child(nsDSig, "Transforms"); transforms != nil {
+		for _, transform := range transforms.childElements(nsDSig, "Transform") {
+			if transform.attr("Algorithm") == algEnvelopedSignature {
+				enveloped = true
+				continue
+			}
+			if referencePrefixes, err = canonicalizationMethod(transform); err != nil {
+				return err
+			}
+		}
+	}
+	if !enveloped {
+		return errors.New("signature is not enveloped")
+	}
+
+	if method := reference.child(nsDSig, "DigestMethod"); method == nil || method.attr("Algorithm") != algSHA256 {
+		return errors.New("unsupported digest method")
+	}
+	digestValue := reference.child(nsDSig, "DigestValue")
+	if digestValue == nil {
+		return errors.New("missing digest")
+	}
+	expectedDigest, err := decodeBase64(digestValue.text())
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(canonicalize(el, referencePrefixes, signature))
+	if subtle.ConstantTimeCompare(digest[:], expectedDigest) != 1 {
+		return errors.New("digest mismatch")
+	}
+
+	signatureValue := signature.child(nsDSig, "SignatureValue")
+	if signatureValue == nil {
+		return errors.New("missing signature value")
+	}
+	value, err := decodeBase64(signatureValue.text())
+	if err != nil {
+		return err
+	}
+	hashed := sha256.Sum256(canonicalize(signedInfo, signedInfoPrefixes, nil))
+	for _, certificate := range certificates {
+		key, ok := certificate.PublicKey.(*rsa.PublicKey)
+		if ok && rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed[:], value) == nil {
+			return nil
+		}
+	}
+	return errors.New("signature mismatch")
+}
+
+// canonicalizationMethod checks a method is Exclusive Canonicalization and returns its inclusive prefixes
+func canonicalizationMethod(method *element) ([]string, error) {
+	if method == nil || method.attr("Algorithm") != algExcC14N {
+		return nil, errors.New("unsupported canonicalization method")
+	}
+	if inclusive := method.child(nsExcC14N, "InclusiveNamespaces"); inclusive != nil {
+		return strings.Fields(inclusive.attr("PrefixList")), nil
+	}
+	return nil, nil
+}
+
+// decodeBase64 decodes base64 that may be wrapped over several lines
+func decodeBase64(value string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+}
@@ -0,0 +1,381 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	nsMetadata  = "urn:oasis:names:tc:SAML:2.0:metadata"
+	nsAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+	nsProtocol  = "urn:oasis:names:tc:SAML:2.0:protocol"
+
+	bindingHTTPRedirect = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	bindingHTTPPost     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	statusSuccess       = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	confirmationBearer  = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	nameIDEmail         = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+
+	// clockSkew is tolerated between the identity provider's clock and ours
+	clockSkew = 3 * time.Minute
+)
+
+var (
+	// ErrInvalidMetadata means identity provider metadata lacks an entity ID, an HTTP-Redirect single sign-on
+	// service or a signing certificate
+	ErrInvalidMetadata = errors.New("saml: invalid metadata")
+	// ErrInvalidResponse means a response is not a successful, signed answer to our request
+	ErrInvalidResponse = errors.New("saml: invalid response")
+)
+
+// IdentityProvider is the part of an identity provider's metadata needed to sign users in
+type IdentityProvider struct {
+	EntityID     string
+	SSOURL       string
+	Certificates []*x509.Certificate
+}
+
+// ServiceProvider is this application as seen by an identity provider
+type ServiceProvider struct {
+	EntityID string
+	ACSURL   string
+}
+
+// Assertion is the user an identity provider vouched for
+type Assertion struct {
+	NameID       string
+	NameIDFormat string
+	Attributes   map[string][]string
+}
+
+// ParseMetadata reads the metadata of an identity provider
+func ParseMetadata(data []byte) (*IdentityProvider, error) {
+	root, err := parseXML(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMetadata, err)
+	}
+
+	// the metadata may be a single entity or an aggregate, of which the first identity provider is used
+	var descriptor, idpDescriptor *element
+	root.walk(func(el *element) {
+		if idpDescriptor == nil && el.is(nsMetadata, "EntityDescriptor") {
+			if child := el.child(nsMetadata, "IDPSSODescriptor"); child != nil {
+				descriptor, idpDescriptor = el, child
+			}
+		}
+	})
+	if idpDescriptor == nil {
+		return nil, fmt.Errorf("%w: no identity provider descriptor", ErrInvalidMetadata)
+	}
+
+	idp := &IdentityProvider{EntityID: descriptor.attr("entityID")}
+	for _, service := range idpDescriptor.childElements(nsMetadata, "SingleSignOnService") {
+		if service.attr("Binding") == bindingHTTPRedirect {
+			idp.SSOURL = service.attr("Location")
+			break
+		}
+	}
+	for _, key := range idpDescriptor.childElements(nsMetadata, "KeyDescriptor") {
+		if use := key.attr("use"); use != "" && use != "signing" {
+			continue
+		}
+		key.walk(func(el *element) {
+			if !el.is(nsDSig, "X509Certificate") {
+				return
+			}
+			der, err := decodeBase64(el.text())
+			if err != nil {
+				return
+			}
+			if certificate, err := x509.ParseCertificate(der); err == nil {
+				idp.Certificates = append(idp.Certificates, certificate)
+			}
+		})
+	}
+
+	if idp.EntityID == "" || idp.SSOURL == "" || len(idp.Certificates) == 0 {
+		return nil, ErrInvalidMetadata
+	}
+	if location, err := url.Parse(idp.SSOURL); err != nil || (location.Scheme != "https" && location.Scheme != "http") {
+		return nil, fmt.Errorf("%w: invalid single sign-on URL", ErrInvalidMetadata)
+	}
+	return idp, nil
+}
+
+// NewRequestID returns a random ID for an authentication request
+func NewRequestID() (string, error) {
+	data := make([]byte, 20)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	// IDs are XML names, which cannot start with a digit
+	return "_" + hex.EncodeToString(data), nil
+}
+
+// AuthnRequestURL returns the URL sending the user to the identity provider with an authentication request,
+// using the HTTP-Redirect binding. The response is expected at the service provider's ACS URL.
+func (sp *ServiceProvider) AuthnRequestURL(idp *IdentityProvider, requestID string, relayState string, now time.Time) (string, error) {
+	var request bytes.Buffer
+	request.WriteString(`<samlp:AuthnRequest xmlns:samlp="` + nsProtocol + `" xmlns:saml="` + nsAssertion + `"`)
+	writeAttribute(&request, "ID", requestID)
+	writeAttribute(&request, "Version", "2.0")
+	writeAttribute(&request, "IssueInstant", now.UTC().Format(time.RFC3339))
+	writeAttribute(&request, "Destination", idp.SSOURL)
+	writeAttribute(&request, "AssertionConsumerServiceURL", sp.ACSURL)
+	writeAttribute(&request, "ProtocolBinding", bindingHTTPPost)
+	request.WriteString(`><saml:Issuer>`)
+	if err := xml.EscapeText(&request, []byte(sp.EntityID)); err != nil {
+		return "", err
+	}
+	request.WriteString(`</saml:Issuer><samlp:NameIDPolicy Format="` + nameIDEmail + `" AllowCreate="true"/></samlp:AuthnRequest>`)
+
+	var deflated bytes.Buffer
+	writer, err := flate.NewWriter(&deflated, flate.DefaultCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := writer.Write(request.Bytes()); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("SAMLRequest", base64.StdEncoding.EncodeToString(deflated.Bytes()))
+	query.Set("RelayState", relayState)
+
+	separator := "?"
+	if strings.Contains(idp.SSOURL, "?") {
+		separator = "&"
+	}
+	return idp.SSOURL + separator + query.Encode(), nil
+}
+
+// Metadata returns the service provider metadata identity providers are configured with
+func (sp *ServiceProvider) Metadata() []byte {
+	var metadata bytes.Buffer
+	metadata.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	metadata.WriteString(`<md:EntityDescriptor xmlns:md="` + nsMetadata + `"`)
+	writeAttribute(&metadata, "entityID", sp.EntityID)
+	metadata.WriteString(`><md:SPSSODescriptor AuthnRequestsSigned="false" WantAssertionsSigned="true" protocolSupportEnumeration="` + nsProtocol + `">`)
+	metadata.WriteString(`<md:NameIDFormat>` + nameIDEmail + `</md:NameIDFormat>`)
+	metadata.WriteString(`<md:AssertionConsumerService Binding="` + bindingHTTPPost + `"`)
+	writeAttribute(&metadata, "Location", sp.ACSURL)
+	metadata.WriteString(` index="0" isDefault="true"/></md:SPSSODescriptor></md:EntityDescriptor>`)
+	return metadata.Bytes()
+}
+
+// ParseResponse verifies a base64 SAMLResponse posted to the ACS URL in answer to the request with the ID and
+// returns its assertion. Either the response or the assertion must be signed by the identity provider;
+// encrypted assertions and unsolicited responses are not supported.
+func (sp *ServiceProvider) ParseResponse(idp *IdentityProvider, samlResponse string, requestID string, now time.Time) (*Assertion, error) {
+	invalid := func(reason string) error {
+		return fmt.Errorf("%w: %s", ErrInvalidResponse, reason)
+	}
+
+	data, err := decodeBase64(samlResponse)
+	if err != nil {
+		return nil, invalid("malformed encoding")
+	}
+	response, err := parseXML(data)
+	if err != nil {
+		return nil, invalid(err.Error())
+	}
+	if !response.is(nsProtocol, "Response") || response.attr("Version") != "2.0" {
+		return nil, invalid("not a response")
+	}
+
+	// signatures reference elements by ID, so duplicates could make the signed element another than the one read
+	ids := map[string]bool{}
+	duplicate := false
+	response.walk(func(el *element) {
+		if id := el.attr("ID"); id != "" {
+			duplicate = duplicate || ids[id]
+			ids[id] = true
+		}
+	})
+	if duplicate {
+		return nil, invalid("duplicate IDs")
+	}
+
+	if destination := response.attr("Destination"); destination != "" && destination != sp.ACSURL {
+		return nil, invalid("wrong destination")
+	}
+	if requestID == "" || response.attr("InResponseTo") != requestID {
+		return nil, invalid("not in response to the request")
+	}
+	if issuer := response.child(nsAssertion, "Issuer"); issuer != nil && issuer.text() != idp.EntityID {
+		return nil, invalid("wrong issuer")
+	}
+	status := response.child(nsProtocol, "Status")
+	if status == nil {
+		return nil, invalid("missing status")
+	}
+	if code := status.child(nsProtocol, "StatusCode"); code == nil || code.attr("Value") != statusSuccess {
+		return nil, invalid("authentication failed")
+	}
+
+	if response.child(nsAssertion, "EncryptedAssertion") != nil {
+		return nil, invalid("encrypted assertions are not supported")
+	}
+	assertions := response.childElements(nsAssertion, "Assertion")
+	if len(assertions) != 1 {
+		return nil, invalid("expected a single assertion")
+	}
+	assertion := assertions[0]
+
+	responseErr := verifySignature(response, idp.Certificates)
+	assertionErr := verifySignature(assertion, idp.Certificates)
+	switch {
+	case responseErr == nil && (assertionErr == nil || assertionErr == errUnsigned):
+	case assertionErr == nil && responseErr == errUnsigned:
+	case responseErr != nil && responseErr != errUnsigned:
+		return nil, invalid("response signature: " + responseErr.Error())
+	case assertionErr != nil && assertionErr != errUnsigned:
+		return nil, invalid("assertion signature: " + assertionErr.Error())
+	default:
+		return nil, invalid("not signed")
+	}
+
+	if issuer := assertion.child(nsAssertion, "Issuer"); issuer == nil || issuer.text() != idp.EntityID {
+		return nil, invalid("wrong assertion issuer")
+	}
+
+	subject := assertion.child(nsAssertion, "Subject")
+	if subject == nil {
+		return nil, invalid("missing subject")
+	}
+	nameID := subject.child(nsAssertion, "NameID")
+	if nameID == nil || nameID.text() == "" {
+		return nil, invalid("missing name ID")
+	}
+	confirmed := false
+	for _, confirmation := range subject.childElements(nsAssertion, "SubjectConfirmation") {
+		data := confirmation.child(nsAssertion, "SubjectConfirmationData")
+		if confirmation.attr("Method") != confirmationBearer || data == nil {
+			continue
+		}
+		if data.attr("Recipient") != sp.ACSURL {
+			continue
+		}
+		if inResponseTo := data.attr("InResponseTo"); inResponseTo != "" && inResponseTo != requestID {
+			continue
+		}
+		if notOnOrAfter, err := parseTime(data.attr("NotOnOrAfter")); err != nil || !now.Before(notOnOrAfter.Add(clockSkew)) {
+			continue
+		}
+		confirmed = true
+		break
+	}
+	if !confirmed {
+		return nil, invalid("subject not confirmed")
+	}
+
+	conditions := assertion.child(nsAssertion, "Conditions")
+	if conditions == nil {
+		return nil, invalid("missing conditions")
+	}
+	if value := conditions.attr("NotBefore"); value != "" {
+		if notBefore, err := parseTime(value); err != nil || now.Add(clockSkew).Before(notBefore) {
+			return nil, invalid("assertion not yet valid")
+		}
+	}
+	if value := conditions.attr("NotOnOrAfter"); value != "" {
+		if notOnOrAfter, err := parseTime(value); err != nil || !now.Before(notOnOrAfter.Add(clockSkew)) {
+			return nil, invalid("assertion expired")
+		}
+	}
+	restrictions := conditions.childElements(nsAssertion, "AudienceRestriction")
+	if len(restrictions) == 0 {
+		return nil, invalid("missing audience")
+	}
+	// each restriction must be met, meaning each lists this service provider
+	for _, restriction := range restrictions {
+		audienceMatched := false
+		for _, audience := range restriction.childElements(nsAssertion, "Audience") {
+			audienceMatched = audienceMatched || audience.text() == sp.EntityID
+		}
+		if !audienceMatched {
+			return nil, invalid("wrong audience")
+		}
+	}
+
+	result := &Assertion{
+		NameID:       nameID.text(),
+		NameIDFormat: nameID.attr("Format"),
+		Attributes:   map[string][]string{},
+	}
+	for _, statement := range assertion.childElements(nsAssertion, "AttributeStatement") {
+		for _, attr := range statement.childElements(nsAssertion, "Attribute") {
+			name := attr.attr("Name")
+			for _, value := range attr.childElements(nsAssertion, "AttributeValue") {
+				result.Attributes[name] = append(result.Attributes[name], value.text())
+			}
+		}
+	}
+	return result, nil
+}
+
+// Email returns the user's address from the usual attributes, or the name ID when it is an address
+func (a *Assertion) Email() string {
+	if email := a.attribute(
+		"email",
+		"mail",
+		"emailAddress",
+		"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/emailaddress",
+		"urn:oid:0.9.2342.19200300.100.1.3",
+	); email != "" {
+		return email
+	}
+	if a.NameIDFormat == nameIDEmail || strings.Contains(a.NameID, "@") {
+		return a.NameID
+	}
+	return ""
+}
+
+// Name returns the user's display name from the usual attributes
+func (a *Assertion) Name() string {
+	if name := a.attribute(
+		"name",
+		"displayName",
+		"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/name",
+		"urn:oid:2.16.840.1.113730.3.1.241",
+	); name != "" {
+		return name
+	}
+	given := a.attribute("firstName", "givenName", "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/givenname", "urn:oid:2.5.4.42")
+	surname := a.attribute("lastName", "surname", "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/surname", "urn:oid:2.5.4.4")
+	return strings.TrimSpace(given + " " + surname)
+}
+
+func (a *Assertion) attribute(names ...string) string {
+	for _, name := range names {
+		for _, value := range a.Attributes[name] {
+			if value != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+func parseTime(value string) (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+func writeAttribute(buf *bytes.Buffer, name string, value string) {
+	buf.WriteString(" " + name + `="`)
+	escapeAttribute(buf, value)
+	buf.WriteByte('"')
+}
@@ -0,0 +1,336 @@
+package saml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const nsXML = "http://www.w3.org/XML/1998/namespace"
+
+// element is a node of a parsed document. encoding/xml does not keep namespace prefixes and declarations
+// when unmarshaling, both of which canonicalization needs, so signed documents are parsed into this tree.
+type element struct {
+	prefix     string
+	local      string
+	namespaces []attribute // declarations, the prefix in local and "" for the default namespace
+	attrs      []attribute
+	children   []interface{} // *element, string or xml.ProcInst
+	parent     *element
+}
+
+type attribute struct {
+	prefix string
+	local  string
+	value  string
+}
+
+// parseXML parses a document. Document type declarations are refused, which rules out entity expansion.
+func parseXML(data []byte) (*element, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = true
+
+	var root, current *element
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			if current == nil && root != nil {
+				return nil, errors.New("multiple root elements")
+			}
+			el := &element{prefix: token.Name.Space, local: token.Name.Local, parent: current}
+			for _, attr := range token.Attr {
+				switch {
+				case attr.Name.Space == "xmlns":
+					el.namespaces = append(el.namespaces, attribute{local: attr.Name.Local, value: attr.Value})
+				case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+					el.namespaces = append(el.namespaces, attribute{value: attr.Value})
+				default:
+					el.attrs = append(el.attrs, attribute{prefix: attr.Name.Space, local: attr.Name.Local, value: attr.Value})
+				}
+			}
+			if _, ok := el.lookupNamespace(el.prefix); !ok {
+				return nil, fmt.Errorf("unbound prefix %q", el.prefix)
+			}
+			for _, attr := range el.attrs {
+				if _, ok := el.lookupNamespace(attr.prefix); !ok {
+					return nil, fmt.Errorf("unbound prefix %q", attr.prefix)
+				}
+			}
+
+			if current == nil {
+				root = el
+			} else {
+				current.children = append(current.children, el)
+			}
+			current = el
+		case xml.EndElement:
+			if current == nil || token.Name.Space != current.prefix || token.Name.Local != current.local {
+				return nil, errors.New("mismatched end element")
+			}
+			current = current.parent
+		case xml.CharData:
+			if current == nil {
+				if len(bytes.TrimSpace(token)) > 0 {
+					return nil, errors.New("text outside the root element")
+				}
+				continue
+			}
+			current.children = append(current.children, string(token))
+		case xml.ProcInst:
+			if current != nil {
+				current.children = append(current.children, token.Copy())
+			}
+		case xml.Directive:
+			return nil, errors.New("document type declarations are not allowed")
+		}
+	}
+
+	if root == nil || current != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return root, nil
+}
+
+// lookupNamespace resolves a prefix in the scope of the element; the empty prefix resolves to the default
+// namespace, which may be none
+func (e *element) lookupNamespace(prefix string) (string, bool) {
+	if prefix == "xml" {
+		return nsXML, true
+	}
+	for el := e; el != nil; el = el.parent {
+		for _, ns := range el.namespaces {
+			if ns.local == prefix {
+				return ns.value, true
+			}
+		}
+	}
+	return "", prefix == ""
+}
+
+func (e *element) namespace() string {
+	ns, _ := e.lookupNamespace(e.prefix)
+	return ns
+}
+
+func (e *element) is(namespace string, local string) bool {
+	return e.local == local && e.namespace() == namespace
+}
+
+// childElements returns the child elements with the name
+func (e *element) childElements(namespace string, local string) []*element {
+	var elements []*element
+	for _, child := range e.children {
+		if el, ok := child.(*element); ok && el.is(namespace, local) {
+			elements = append(elements, el)
+		}
+	}
+	return elements
+}
+
+// child returns the first child element with the name, or nil
+func (e *element) child(namespace string, local string) *element {
+	if elements := e.childElements(namespace, local); len(elements) > 0 {
+		return elements[0]
+	}
+	return nil
+}
+
+// attr returns the value of an unqualified attribute
+func (e *element) attr(local string) string {
+	for _, attr := range e.attrs {
+		if attr.prefix == "" && attr.local == local {
+			return attr.value
+		}
+	}
+	return ""
+}
+
+// text returns the concatenated text of the element's children, trimmed
+func (e *element) text() string {
+	var builder strings.Builder
+	for _, child := range e.children {
+		if text, ok := child.(string); ok {
+			builder.WriteString(text)
+		}
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+// walk calls fn for the element and each of its descendants
+func (e *element) walk(fn func(*element)) {
+	fn(e)
+	for _, child := range e.children {
+		if el, ok := child.(*element); ok {
+			el.walk(fn)
+		}
+	}
+}
+
+func (e *element) qualifiedName() string {
+	if e.prefix == "" {
+		return e.local
+	}
+	return e.prefix + ":" + e.local
+}
+
+// canonicalize serializes an element with Exclusive XML Canonicalization without comments. The prefixes
+// listed in inclusive ("#default" being the default namespace) are rendered as in inclusive canonicalization.
+// The excluded element is left out, as the enveloped-signature transform does with the signature.
+func canonicalize(e *element, inclusive []string, excluded *element) []byte {
+	var buf bytes.Buffer
+	writeCanonical(&buf, e, map[string]string{"": ""}, inclusive, excluded)
+	return buf.Bytes()
+}
+
+func writeCanonical(buf *bytes.Buffer, e *element, rendered map[string]string, inclusive []string, excluded *element) {
+	// namespaces visibly utilized by the element and its attributes, plus the inclusive ones in scope
+	prefixes := map[string]bool{e.prefix: true}
+	for _, attr := range e.attrs {
+		if attr.prefix != "" {
+			prefixes[attr.prefix] = true
+		}
+	}
+	for _, prefix := range inclusive {
+		if prefix == "#default" {
+			prefix = ""
+		}
+		if _, ok := e.lookupNamespace(prefix); ok {
+			prefixes[prefix] = true
+		}
+	}
+
+	var declarations []attribute
+	scope := rendered
+	for prefix := range prefixes {
+		if prefix == "xml" {
+			continue
+		}
+		ns, _ := e.lookupNamespace(prefix)
+		if value, ok := rendered[prefix]; ok && value == ns {
+			continue
+		}
+		if prefix != "" && ns == "" {
+			continue
+		}
+		if len(declarations) == 0 {
+			scope = make(map[string]string, len(rendered)+1)
+			for key, value := range rendered {
+				scope[key] = value
+			}
+		}
+		declarations = append(declarations, attribute{local: prefix, value: ns})
+		scope[prefix] = ns
+	}
+	sort.Slice(declarations, func(i, j int) bool {
+		return declarations[i].local < declarations[j].local
+	})
+
+	attrs := make([]attribute, len(e.attrs))
+	copy(attrs, e.attrs)
+	attrNamespace := func(attr attribute) string {
+		if attr.prefix == "" {
+			return ""
+		}
+		ns, _ := e.lookupNamespace(attr.prefix)
+		return ns
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		nsI, nsJ := attrNamespace(attrs[i]), attrNamespace(attrs[j])
+		if nsI != nsJ {
+			return nsI < nsJ
+		}
+		return attrs[i].local < attrs[j].local
+	})
+
+	buf.WriteByte('<')
+	buf.WriteString(e.qualifiedName())
+	for _, ns := range declarations {
+		if ns.local == "" {
+			buf.WriteString(` xmlns="`)
+		} else {
+			buf.WriteString(" xmlns:" + ns.local + `="`)
+		}
+		escapeAttribute(buf, ns.value)
+		buf.WriteByte('"')
+	}
+	for _, attr := range attrs {
+		buf.WriteByte(' ')
+		if attr.prefix != "" {
+			buf.WriteString(attr.prefix + ":")
+		}
+		buf.WriteString(attr.local + `="`)
+		escapeAttribute(buf, attr.value)
+		buf.WriteByte('"')
+	}
+	buf.WriteByte('>')
+
+	for _, child := range e.children {
+		switch child := child.(type) {
+		case *element:
+			if child != excluded {
+				writeCanonical(buf, child, scope, inclusive, excluded)
+			}
+		case string:
+			escapeText(buf, child)
+		case xml.ProcInst:
+			buf.WriteString("<?" + child.Target)
+			if len(child.Inst) > 0 {
+				buf.WriteByte(' ')
+				buf.Write(child.Inst)
+			}
+			buf.WriteString("?>")
+		}
+	}
+
+	buf.WriteString("</" + e.qualifiedName() + ">")
+}
+
+func escapeText(buf *bytes.Buffer, text string) {
+	for _, r := range text {
+		switch r {
+		case '&':
+			buf.WriteString("&amp;")
+		case '<':
+			buf.WriteString("&lt;")
+		case '>':
+			buf.WriteString("&gt;")
+		case '\r':
+			buf.WriteString("&#xD;")
+		default:
+			buf.WriteRune(r)
+		}
+	}
+}
+
+func escapeAttribute(buf *bytes.Buffer, value string) {
+	for _, r := range value {
+		switch r {
+		case '&':
+			buf.WriteString("&amp;")
+		case '<':
+			buf.WriteString("&lt;")
+		case '"':
+			buf.WriteString("&quot;")
+		case '\t':
+			buf.WriteString("&#x9;")
+		case '\n':
+			buf.WriteString("&#xA;")
+		case '\r':
+			buf.WriteString("&#xD;")
+		default:
+			buf.WriteRune(r)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+// COSE algorithms supported for credential public keys
+const (
+	AlgES256 = -7   // ECDSA with P-256 and SHA-256
+	AlgEdDSA = -8   // Ed25519
+	AlgRS256 = -257 // RSASSA-PKCS1-v1_5 with SHA-256
+)
+
+// SupportedAlgorithms lists the algorithms offered to authenticators, in order of preference
+var SupportedAlgorithms = []int{AlgES256, AlgEdDSA, AlgRS256}
+
+// COSE key parameters (RFC 9053)
+const (
+	coseKeyType      = 1
+	coseKeyAlgorithm = 3
+	coseKeyCurve     = -1 // crv for EC2 and OKP keys, n for RSA keys
+	coseKeyX         = -2 // x for EC2 and OKP keys, e for RSA keys
+	coseKeyY         = -3
+
+	coseKeyTypeOKP = 1
+	coseKeyTypeEC2 = 2
+	coseKeyTypeRSA = 3
+
+	coseCurveP256    = 1
+	coseCurveEd25519 = 6
+)
+
+var (
+	// ErrUnsupportedKey means a credential public key uses an algorithm or key type not supported
+	ErrUnsupportedKey = errors.New("webauthn: unsupported public key")
+	// ErrInvalidSignature means an assertion signature does not verify with the credential public key
+	ErrInvalidSignature = errors.New("webauthn: invalid signature")
+)
+
+// publicKey is a credential public key decoded from its COSE_Key encoding
+type publicKey struct {
+	algorithm int
+	key       crypto.PublicKey
+}
+
+// parsePublicKey decodes a COSE_Key as stored for a credential
+func parsePublicKey(data []byte) (*publicKey, error) {
+	value, rest, err := decodeCBOR(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errCBOR
+	}
+
+	params, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, errCBOR
+	}
+
+	keyType, _ := params[int64(coseKeyType)].(int64)
+	algorithm, _ := params[int64(coseKeyAlgorithm)].(int64)
+
+	switch {
+	case keyType == coseKeyTypeEC2 && algorithm == AlgES256:
+		curve, _ := params[int64(coseKeyCurve)].(int64)
+		x, _ := params[int64(coseKeyX)].([]byte)
+		y, _ := params[int64(coseKeyY)].([]byte)
+		if curve != coseCurveP256 || len(x) != 32 || len(y) != 32 {
+			return nil, ErrUnsupportedKey
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, ErrUnsupportedKey
+		}
+		return &publicKey{algorithm: AlgES256, key: key}, nil
+
+	case keyType == coseKeyTypeOKP && algorithm == AlgEdDSA:
+		curve, _ := params[int64(coseKeyCurve)].(int64)
+		x, _ := params[int64(coseKeyX)].([]byte)
+		if curve != coseCurveEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, ErrUnsupportedKey
+		}
+		return &publicKey{algorithm: AlgEdDSA, key: ed25519.PublicKey(x)}, nil
+
+	case keyType == coseKeyTypeRSA && algorithm == AlgRS256:
+		n, _ := params[int64(coseKeyCurve)].([]byte)
+		e, _ := params[int64(coseKeyX)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, ErrUnsupportedKey
+		}
+		exponent := int(new(big.Int).SetBytes(e).Int64())
+		return &publicKey{algorithm: AlgRS256, key: &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}}, nil
+
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
+
+// verify checks the signature of message
+func (k *publicKey) verify(message []byte, signature []byte) error {
+	digest := sha256.Sum256(message)
+
+	var ok bool
+	switch key := k.key.(type) {
+	case *ecdsa.PublicKey:
+		ok = ecdsa.VerifyASN1(key, digest[:], signature)
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(key, message, signature)
+	case *rsa.PublicKey:
+		ok = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	}
+
+	if !ok {
+		return ErrInvalidSignature
+	}
+	return nil
+}
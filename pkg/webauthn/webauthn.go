@@ -0,0 +1,251 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// Client data types of the two ceremonies
+const (
+	ceremonyCreate = "webauthn.create"
+	ceremonyGet    = "webauthn.get"
+)
+
+// Authenticator data flags
+const (
+	flagUserPresent       = 0x01
+	flagUserVerified      = 0x04
+	flagBackupEligible    = 0x08
+	flagBackedUp          = 0x10
+	flagAttestedData      = 0x40
+	flagExtensionIncluded = 0x80
+)
+
+// challengeSize is the length of a challenge in bytes; the specification requires at least 16
+const challengeSize = 32
+
+var (
+	// ErrInvalidClientData means the client data is malformed or was made for another ceremony, challenge or origin
+	ErrInvalidClientData = errors.New("webauthn: invalid client data")
+	// ErrInvalidAuthenticatorData means the authenticator data is malformed, for another relying party or lacks user presence
+	ErrInvalidAuthenticatorData = errors.New("webauthn: invalid authenticator data")
+	// ErrInvalidAttestation means the attestation object is malformed
+	ErrInvalidAttestation = errors.New("webauthn: invalid attestation object")
+	// ErrSignCount means the signature counter did not increase, a sign the authenticator may have been cloned
+	ErrSignCount = errors.New("webauthn: signature counter did not increase")
+)
+
+// RelyingParty verifies WebAuthn registration and assertion ceremonies (https://www.w3.org/TR/webauthn-3/).
+// Attestation statements are not verified: credentials are trusted as the user's own, as with "none" attestation.
+type RelyingParty struct {
+	// ID is the domain credentials are scoped to, e.g. "example.com"
+	ID string
+	// Name is shown by authenticators when creating a credential
+	Name string
+	// Origins are the web origins ceremonies may run on, e.g. "https://app.example.com"
+	Origins []string
+}
+
+// Credential is a public key credential created by an authenticator
+type Credential struct {
+	ID             []byte
+	PublicKey      []byte // COSE_Key encoding
+	Algorithm      int
+	SignCount      uint32
+	AAGUID         []byte
+	UserVerified   bool
+	BackupEligible bool
+}
+
+// Assertion is the result of a verified authentication ceremony
+type Assertion struct {
+	SignCount    uint32
+	UserVerified bool
+}
+
+// NewChallenge returns a random challenge for a ceremony
+func NewChallenge() ([]byte, error) {
+	challenge := make([]byte, challengeSize)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// EncodeBase64URL encodes binary values the way the WebAuthn JSON serialization does
+func EncodeBase64URL(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeBase64URL decodes base64url values, with or without padding
+func DecodeBase64URL(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
+
+// VerifyRegistration verifies the response of navigator.credentials.create() to a challenge and returns the new credential
+func (rp *RelyingParty) VerifyRegistration(challenge []byte, clientDataJSON []byte, attestationObject []byte) (*Credential, error) {
+	if err := rp.verifyClientData(clientDataJSON, ceremonyCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	value, rest, err := decodeCBOR(attestationObject)
+	if err != nil || len(rest) > 0 {
+		return nil, ErrInvalidAttestation
+	}
+	attestation, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, ErrInvalidAttestation
+	}
+	authData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, ErrInvalidAttestation
+	}
+
+	data, err := rp.parseAuthenticatorData(authData)
+	if err != nil {
+		return nil, err
+	}
+	if data.flags&flagAttestedData == 0 {
+		return nil, ErrInvalidAuthenticatorData
+	}
+
+	// Attested credential data: AAGUID, credential ID length and ID, then the COSE_Key
+	attested := data.rest
+	if len(attested) < 18 {
+		return nil, ErrInvalidAuthenticatorData
+	}
+	aaguid := attested[:16]
+	idLength := int(binary.BigEndian.Uint16(attested[16:18]))
+	if idLength == 0 || idLength > 1023 || len(attested) < 18+idLength {
+		return nil, ErrInvalidAuthenticatorData
+	}
+	credentialID := attested[18 : 18+idLength]
+
+	keyData := attested[18+idLength:]
+	if _, rest, err = decodeCBOR(keyData); err != nil {
+		return nil, ErrInvalidAuthenticatorData
+	}
+	// Extensions follow the key only when flagged
+	if len(rest) > 0 && data.flags&flagExtensionIncluded == 0 {
+		return nil, ErrInvalidAuthenticatorData
+	}
+	keyData = keyData[:len(keyData)-len(rest)]
+
+	key, err := parsePublicKey(keyData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		ID:             bytes.Clone(credentialID),
+		PublicKey:      bytes.Clone(keyData),
+		Algorithm:      key.algorithm,
+		SignCount:      data.signCount,
+		AAGUID:         bytes.Clone(aaguid),
+		UserVerified:   data.flags&flagUserVerified != 0,
+		BackupEligible: data.flags&flagBackupEligible != 0,
+	}, nil
+}
+
+// VerifyAssertion verifies the response of navigator.credentials.get() to a challenge, signed with a credential
+// whose stored public key and signature counter are given
+func (rp *RelyingParty) VerifyAssertion(challenge []byte, publicKeyData []byte, storedSignCount uint32, clientDataJSON []byte, authenticatorData []byte, signature []byte) (*Assertion, error) {
+	if err := rp.verifyClientData(clientDataJSON, ceremonyGet, challenge); err != nil {
+		return nil, err
+	}
+
+	data, err := rp.parseAuthenticatorData(authenticatorData)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := parsePublicKey(publicKeyData)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	if err := key.verify(append(bytes.Clone(authenticatorData), clientDataHash[:]...), signature); err != nil {
+		return nil, err
+	}
+
+	// Authenticators without a counter always report zero
+	if (data.signCount != 0 || storedSignCount != 0) && data.signCount <= storedSignCount {
+		return nil, ErrSignCount
+	}
+
+	return &Assertion{
+		SignCount:    data.signCount,
+		UserVerified: data.flags&flagUserVerified != 0,
+	}, nil
+}
+
+// clientData is the subset of CollectedClientData that is verified
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+func (rp *RelyingParty) verifyClientData(clientDataJSON []byte, ceremony string, challenge []byte) error {
+	var data clientData
+	if err := json.Unmarshal(clientDataJSON, &data); err != nil {
+		return ErrInvalidClientData
+	}
+
+	received, err := DecodeBase64URL(data.Challenge)
+	if err != nil {
+		return ErrInvalidClientData
+	}
+
+	if data.Type != ceremony ||
+		subtle.ConstantTimeCompare(received, challenge) != 1 ||
+		!slices.Contains(rp.Origins, data.Origin) ||
+		data.CrossOrigin {
+		return ErrInvalidClientData
+	}
+
+	return nil
+}
+
+// authenticatorData is parsed authenticator data; rest holds the attested credential data and extensions
+type authenticatorData struct {
+	flags     byte
+	signCount uint32
+	rest      []byte
+}
+
+func (rp *RelyingParty) parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, ErrInvalidAuthenticatorData
+	}
+
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if subtle.ConstantTimeCompare(data[:32], rpIDHash[:]) != 1 {
+		return nil, ErrInvalidAuthenticatorData
+	}
+
+	flags := data[32]
+	if flags&flagUserPresent == 0 {
+		return nil, ErrInvalidAuthenticatorData
+	}
+	// A credential cannot be backed up without being eligible for backup
+	if flags&flagBackedUp != 0 && flags&flagBackupEligible == 0 {
+		return nil, ErrInvalidAuthenticatorData
+	}
+
+	return &authenticatorData{
+		flags:     flags,
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+		rest:      data[37:],
+	}, nil
+}
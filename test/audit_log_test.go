@@ -0,0 +1,207 @@
+package test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"go-clean-arch-saas/internal/entity"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// FindAuditLogs returns the audit rows for an action, oldest first
+func FindAuditLogs(t *testing.T, action string) []entity.AuditLog {
+	var logs []entity.AuditLog
+	err := db.Where("action = ?", action).Order("created_at ASC").Find(&logs).Error
+	assert.NoError(t, err)
+	return logs
+}
+
+func TestAuditLog_RegisterAndLogin(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+	orgID := GetOrganizationID(t, token)
+
+	var user entity.User
+	err := db.Where("email = ?", "test@example.com").First(&user).Error
+	assert.NoError(t, err)
+
+	registers := FindAuditLogs(t, entity.AuditActionRegister)
+	assert.Len(t, registers, 1)
+	assert.Equal(t, user.ID, *registers[0].UserID)
+	assert.Equal(t, orgID, *registers[0].OrganizationID)
+	assert.Equal(t, entity.AuditResourceUser, registers[0].Resource)
+
+	logins := FindAuditLogs(t, entity.AuditActionLogin)
+	assert.Len(t, logins, 1)
+	assert.Equal(t, entity.AuditResourceSession, logins[0].Resource)
+	assert.NotNil(t, logins[0].ResourceID)
+	assert.NotEmpty(t, logins[0].IPAddress)
+}
+
+func TestAuditLog_OrganizationUpdate(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+	orgID := GetOrganizationID(t, token)
+
+	resp, err := MakeRequest("PATCH", "/api/v1/organizations/current", `{"name": "Renamed Org"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	logs := FindAuditLogs(t, entity.AuditActionOrganizationUpdate)
+	assert.Len(t, logs, 1)
+	assert.Equal(t, orgID, *logs[0].ResourceID)
+
+	var details map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(logs[0].Details), &details))
+	assert.Equal(t, "Test Org", details["previous_name"])
+	assert.Equal(t, "Renamed Org", details["name"])
+}
+
+func TestAuditLog_MemberRemove(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+	orgID := GetOrganizationID(t, token)
+	CreateTestMember(t, orgID, "member@example.com", entity.OrgRoleMember)
+
+	var member entity.User
+	err := db.Where("email = ?", "member@example.com").First(&member).Error
+	assert.NoError(t, err)
+
+	resp, err := MakeRequest("DELETE", "/api/v1/organizations/members/"+member.ID, "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	logs := FindAuditLogs(t, entity.AuditActionMemberRemove)
+	assert.Len(t, logs, 1)
+	assert.Equal(t, member.ID, *logs[0].ResourceID)
+	assert.NotEqual(t, member.ID, *logs[0].UserID)
+}
+
+func TestAuditLog_RolledBackWithFailedChange(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+
+	// Cancelling twice fails the second time and must not leave a second row
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/cancel", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = MakeRequest("POST", "/api/v1/subscriptions/cancel", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
+
+	assert.Len(t, FindAuditLogs(t, entity.AuditActionSubscriptionCancel), 1)
+}
+
+func TestListAuditLogs_Success(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+
+	resp, err := MakeRequest("PATCH", "/api/v1/organizations/current", `{"name": "Renamed Org"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = MakeRequest("GET", "/api/v1/organizations/audit-logs?size=1", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	result := ParseResponse(t, resp)
+	data := result["data"].([]interface{})
+	assert.Len(t, data, 1)
+
+	// Newest first
+	entry := data[0].(map[string]interface{})
+	assert.Equal(t, entity.AuditActionOrganizationUpdate, entry["action"])
+	details := entry["details"].(map[string]interface{})
+	assert.Equal(t, "Renamed Org", details["name"])
+
+	// register, login and organization update
+	paging := result["paging"].(map[string]interface{})
+	assert.Equal(t, float64(1), paging["page"])
+	assert.Equal(t, float64(3), paging["total_item"])
+	assert.Equal(t, float64(3), paging["total_page"])
+}
+
+func TestListAuditLogs_Filter(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+	Login(t, "test@example.com", "password123")
+
+	resp, err := MakeRequest("GET", "/api/v1/organizations/audit-logs?action="+entity.AuditActionLogin, "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	result := ParseResponse(t, resp)
+	assert.Len(t, result["data"].([]interface{}), 2)
+
+	resp, err = MakeRequest("GET", "/api/v1/organizations/audit-logs?from=1&to=2", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	result = ParseResponse(t, resp)
+	assert.Len(t, result["data"].([]interface{}), 0)
+}
+
+func TestListAuditLogs_ExportCSV(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+
+	resp, err := MakeRequest("GET", "/api/v1/organizations/audit-logs?format=csv", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/csv")
+
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, records, 3) // header, register, login
+	assert.Equal(t, "action", records[0][3])
+	assert.Equal(t, entity.AuditActionRegister, records[1][3])
+}
+
+func TestListAuditLogs_ExportNDJSON(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+
+	resp, err := MakeRequest("GET", "/api/v1/organizations/audit-logs?format=ndjson", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	decoder := json.NewDecoder(resp.Body)
+	var actions []string
+	for decoder.More() {
+		var entry map[string]interface{}
+		assert.NoError(t, decoder.Decode(&entry))
+		actions = append(actions, entry["action"].(string))
+	}
+	assert.Equal(t, []string{entity.AuditActionRegister, entity.AuditActionLogin}, actions)
+}
+
+func TestListAuditLogs_InvalidFormat(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+
+	resp, err := MakeRequest("GET", "/api/v1/organizations/audit-logs?format=xml", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+}
+
+func TestListAuditLogs_MemberForbidden(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+	memberToken := CreateTestMember(t, GetOrganizationID(t, token), "member@example.com", entity.OrgRoleMember)
+
+	resp, err := MakeRequest("GET", "/api/v1/organizations/audit-logs", "", memberToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+}
@@ -0,0 +1,169 @@
+package test
+
+import (
+	"context"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// CreateTestPlanPrice adds a price variant to a plan
+func CreateTestPlanPrice(t *testing.T, plan *entity.Plan, price float64, billingPeriod string, billingInterval int) *entity.PlanPrice {
+	planPrice := &entity.PlanPrice{
+		ID:              uuid.New().String(),
+		PlanID:          plan.ID,
+		Price:           price,
+		BillingPeriod:   billingPeriod,
+		BillingInterval: billingInterval,
+		IsActive:        true,
+	}
+
+	err := db.Create(planPrice).Error
+	assert.NoError(t, err)
+
+	return planPrice
+}
+
+func TestPeriodEnd(t *testing.T) {
+	start := time.Date(2024, time.January, 31, 12, 0, 0, 0, time.Local)
+
+	periodEnd := func(billingPeriod string, billingInterval int) int64 {
+		plan := &entity.Plan{BillingPeriod: billingPeriod, BillingInterval: billingInterval}
+		return usecase.PeriodEnd(plan, start.UnixMilli())
+	}
+
+	assert.Equal(t, start.AddDate(0, 1, 0).UnixMilli(), periodEnd(entity.BillingPeriodMonthly, 1))
+	assert.Equal(t, start.AddDate(0, 3, 0).UnixMilli(), periodEnd(entity.BillingPeriodMonthly, 3))
+	assert.Equal(t, start.AddDate(1, 0, 0).UnixMilli(), periodEnd(entity.BillingPeriodYearly, 1))
+	assert.Equal(t, start.AddDate(0, 0, 14).UnixMilli(), periodEnd(entity.BillingPeriodWeekly, 2))
+	assert.Equal(t, start.AddDate(0, 0, 1).UnixMilli(), periodEnd(entity.BillingPeriodDaily, 0))
+
+	// Unknown periods are monthly
+	assert.Equal(t, start.AddDate(0, 1, 0).UnixMilli(), periodEnd("", 0))
+}
+
+func TestCalculateProration_PriceVariant(t *testing.T) {
+	now := time.Now()
+	pro := &entity.Plan{ID: "pro", Price: 29, BillingPeriod: entity.BillingPeriodMonthly}
+	quarterly := &entity.PlanPrice{ID: "pro-quarterly", Price: 60, BillingPeriod: entity.BillingPeriodMonthly, BillingInterval: 3}
+
+	// 60 a quarter is cheaper than 29 a month
+	proration := usecase.CalculateProration(subscriptionHalfway(pro, now), usecase.PricedPlan(pro, quarterly), now)
+	assert.Equal(t, model.PlanChangeDowngrade, proration.Change)
+
+	// The credit is the variant the subscription is billed at
+	subscription := subscriptionHalfway(pro, now)
+	subscription.PlanPrice = quarterly
+	proration = usecase.CalculateProration(subscription, pro, now)
+	assert.Equal(t, model.PlanChangeUpgrade, proration.Change)
+	assert.Equal(t, 30.0, proration.Credit)
+}
+
+func TestListPlans_IncludesPrices(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	yearly := CreateTestPlanPrice(t, proPlan, 290.00, entity.BillingPeriodYearly, 1)
+	inactive := CreateTestPlanPrice(t, proPlan, 80.00, entity.BillingPeriodMonthly, 3)
+	assert.NoError(t, db.Model(inactive).Update("is_active", false).Error)
+
+	resp, err := MakeRequest("GET", "/api/v1/plans/pro", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	assert.Equal(t, 1.0, data["billing_interval"])
+	prices := data["prices"].([]interface{})
+	assert.Len(t, prices, 1)
+	assert.Equal(t, yearly.ID, prices[0].(map[string]interface{})["id"])
+	assert.Equal(t, "yearly", prices[0].(map[string]interface{})["billing_period"])
+}
+
+func TestUpgradeSubscription_YearlyPrice(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	yearly := CreateTestPlanPrice(t, proPlan, 290.00, entity.BillingPeriodYearly, 1)
+	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, "pm_card_visa")
+	orgID := GetOrganizationID(t, token)
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`", "price_id": "`+yearly.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	assert.Equal(t, yearly.ID, data["plan_price_id"])
+	plan := data["plan"].(map[string]interface{})
+	assert.Equal(t, 290.0, plan["price"])
+	assert.Equal(t, "yearly", plan["billing_period"])
+
+	periodStart := time.UnixMilli(int64(data["current_period_start"].(float64)))
+	assert.Equal(t, periodStart.AddDate(1, 0, 0).UnixMilli(), int64(data["current_period_end"].(float64)))
+
+	invoices := FindInvoices(t, orgID)
+	assert.Len(t, invoices, 1)
+	assert.Equal(t, 290.0, invoices[0].AmountDue)
+
+	// The same plan and price again is a conflict
+	resp, err = MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`", "price_id": "`+yearly.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
+}
+
+func TestUpgradeSubscription_PriceOfOtherPlan(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	enterprisePlan := CreateTestPlan(t, "enterprise", "Enterprise Plan", 99.00)
+	yearly := CreateTestPlanPrice(t, enterprisePlan, 990.00, entity.BillingPeriodYearly, 1)
+	token := GetAccessToken(t)
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`", "price_id": "`+yearly.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+}
+
+func TestUpgradeSubscription_SwitchToYearlyAtRenewal(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	yearly := CreateTestPlanPrice(t, proPlan, 290.00, entity.BillingPeriodYearly, 1)
+	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, "pm_card_visa")
+	orgID := GetOrganizationID(t, token)
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	// Yearly billing costs less per month, so it starts with the next period
+	resp, err = MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`", "price_id": "`+yearly.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	assert.Equal(t, proPlan.ID, data["scheduled_plan_id"])
+	assert.Equal(t, yearly.ID, data["scheduled_plan_price_id"])
+	assert.Nil(t, data["plan_price_id"])
+
+	EndSubscriptionPeriod(t, orgID, entity.SubscriptionStatusActive)
+	assert.True(t, jobs.RunOnce(context.Background()))
+
+	var subscription entity.Subscription
+	err = db.Where("organization_id = ? AND status = ?", orgID, entity.SubscriptionStatusActive).First(&subscription).Error
+	assert.NoError(t, err)
+	assert.Equal(t, yearly.ID, *subscription.PlanPriceID)
+	assert.Nil(t, subscription.ScheduledPlanID)
+	assert.Nil(t, subscription.ScheduledPlanPriceID)
+	assert.Equal(t, time.UnixMilli(subscription.CurrentPeriodStart).AddDate(1, 0, 0).UnixMilli(), subscription.CurrentPeriodEnd)
+
+	invoices := FindInvoices(t, orgID)
+	assert.Len(t, invoices, 2)
+	assert.Equal(t, 290.0, invoices[1].AmountDue)
+}
@@ -0,0 +1,133 @@
+package test
+
+import (
+	"context"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/pkg/payment"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// AddPaymentMethod sets the default payment method of the token's organization
+func AddPaymentMethod(t *testing.T, token string, paymentMethodID string) {
+	resp, err := MakeRequest("PUT", "/api/v1/billing/payment-method", `{"payment_method_id": "`+paymentMethodID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestUpdatePaymentMethod_Success(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+	orgID := GetOrganizationID(t, token)
+
+	resp, err := MakeRequest("PUT", "/api/v1/billing/payment-method", `{"payment_method_id": "pm_card_visa"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	assert.True(t, strings.HasPrefix(data["customer_id"].(string), "cus_fake_"))
+	assert.Equal(t, "pm_card_visa", data["payment_method_id"])
+
+	// Replacing the payment method keeps the customer
+	AddPaymentMethod(t, token, "pm_card_mastercard")
+
+	var organization entity.Organization
+	assert.NoError(t, db.Where("id = ?", orgID).First(&organization).Error)
+	assert.Equal(t, data["customer_id"], *organization.PaymentCustomerID)
+	assert.Equal(t, "pm_card_mastercard", *organization.PaymentMethodID)
+
+	assert.Len(t, FindAuditLogs(t, entity.AuditActionPaymentMethodUpdate), 2)
+}
+
+func TestUpdatePaymentMethod_AdminForbidden(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+	adminToken := CreateTestMember(t, GetOrganizationID(t, token), "admin@example.com", entity.OrgRoleAdmin)
+
+	resp, err := MakeRequest("PUT", "/api/v1/billing/payment-method", `{"payment_method_id": "pm_card_visa"}`, adminToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+}
+
+func TestUpgradeSubscription_PaymentCollected(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, "pm_card_visa")
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	invoices := FindInvoices(t, GetOrganizationID(t, token))
+	assert.Len(t, invoices, 1)
+	assert.Equal(t, entity.InvoiceStatusPaid, invoices[0].Status)
+	assert.NotNil(t, invoices[0].PaidAt)
+	assert.True(t, strings.HasPrefix(*invoices[0].PaymentChargeID, "ch_fake_"))
+}
+
+func TestUpgradeSubscription_PaymentMethodRequired(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	token := GetAccessToken(t)
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 402, resp.StatusCode)
+
+	assertStillOnFreePlan(t, token)
+}
+
+func TestUpgradeSubscription_PaymentDeclined(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, payment.FakePaymentMethodDeclined)
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 402, resp.StatusCode)
+	assert.Equal(t, "Payment was declined", ParseResponse(t, resp)["errors"])
+
+	assertStillOnFreePlan(t, token)
+}
+
+// assertStillOnFreePlan checks that a failed upgrade left no trace
+func assertStillOnFreePlan(t *testing.T, token string) {
+	resp, err := MakeRequest("GET", "/api/v1/subscriptions/current", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	plan := ParseResponse(t, resp)["data"].(map[string]interface{})["plan"].(map[string]interface{})
+	assert.Equal(t, "free", plan["slug"])
+
+	assert.Len(t, FindInvoices(t, GetOrganizationID(t, token)), 0)
+	assert.Len(t, FindAuditLogs(t, entity.AuditActionSubscriptionUpgrade), 0)
+}
+
+func TestStripeRefund_RetriesAreIdempotent(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		w.Write([]byte(`{"id": "re_1"}`))
+	}))
+	defer server.Close()
+
+	// A retried refund of the same charge must not refund it twice
+	provider := payment.NewStripeProvider(log, "sk_test", "whsec_test", server.URL)
+	assert.NoError(t, provider.Refund(context.Background(), "pi_1", 29.00))
+	assert.NoError(t, provider.Refund(context.Background(), "pi_1", 29.00))
+
+	assert.Len(t, keys, 2)
+	assert.NotEmpty(t, keys[0])
+	assert.Equal(t, keys[0], keys[1])
+}
@@ -0,0 +1,189 @@
+package test
+
+import (
+	"context"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// CreateTestCoupon creates an active 20% coupon, changed by configure before it is stored
+func CreateTestCoupon(t *testing.T, code string, configure func(coupon *entity.Coupon)) *entity.Coupon {
+	coupon := &entity.Coupon{
+		ID:           uuid.New().String(),
+		Code:         code,
+		Name:         "Test Coupon",
+		DiscountType: entity.CouponDiscountPercent,
+		PercentOff:   20,
+		Duration:     entity.CouponDurationForever,
+		IsActive:     true,
+	}
+	if configure != nil {
+		configure(coupon)
+	}
+
+	err := db.Create(coupon).Error
+	assert.NoError(t, err)
+
+	return coupon
+}
+
+// upgradeWithPromoCode upgrades the token's organization to plan with a promotion code
+func upgradeWithPromoCode(t *testing.T, token string, plan *entity.Plan, promoCode string) int {
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+plan.ID+`", "promo_code": "`+promoCode+`"}`, token)
+	assert.NoError(t, err)
+	return resp.StatusCode
+}
+
+func TestApplyDiscount(t *testing.T) {
+	now := time.Now()
+	pro := &entity.Plan{ID: "pro", Price: 29, BillingPeriod: entity.BillingPeriodMonthly}
+	enterprise := &entity.Plan{ID: "enterprise", Price: 99, BillingPeriod: entity.BillingPeriodMonthly}
+
+	percent := usecase.CalculateProration(subscriptionHalfway(pro, now), enterprise, now)
+	usecase.ApplyDiscount(percent, &entity.Coupon{Code: "HALF", DiscountType: entity.CouponDiscountPercent, PercentOff: 50})
+	assert.Equal(t, 49.5, percent.Discount)
+	assert.Equal(t, 35.0, percent.AmountDue)
+	assert.Equal(t, "HALF", percent.PromoCode)
+
+	// A fixed discount never exceeds the charge; the credit left over becomes a balance
+	fixed := usecase.CalculateProration(subscriptionHalfway(pro, now), enterprise, now)
+	usecase.ApplyDiscount(fixed, &entity.Coupon{Code: "BIG", DiscountType: entity.CouponDiscountFixed, AmountOff: 150})
+	assert.Equal(t, 99.0, fixed.Discount)
+	assert.Equal(t, 0.0, fixed.AmountDue)
+	assert.Equal(t, 14.5, fixed.CreditBalance)
+}
+
+func TestPreviewChange_WithPromoCode(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	CreateTestCoupon(t, "SAVE20", nil)
+	token := GetAccessToken(t)
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/preview-change", `{"plan_id": "`+proPlan.ID+`", "promo_code": "save20"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	assert.Equal(t, 5.8, data["discount"])
+	assert.Equal(t, 23.2, data["amount_due"])
+	assert.Equal(t, "SAVE20", data["promo_code"])
+}
+
+func TestUpgradeSubscription_WithPromoCode(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	coupon := CreateTestCoupon(t, "SAVE20", nil)
+	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, "pm_card_visa")
+	orgID := GetOrganizationID(t, token)
+
+	assert.Equal(t, 200, upgradeWithPromoCode(t, token, proPlan, "SAVE20"))
+
+	invoices := FindInvoices(t, orgID)
+	assert.Len(t, invoices, 1)
+	assert.Equal(t, 23.2, invoices[0].AmountDue)
+	assert.Equal(t, entity.InvoiceLineKindDiscount, invoices[0].LineItems[1].Kind)
+	assert.Equal(t, -5.8, invoices[0].LineItems[1].Amount)
+
+	assert.NoError(t, db.Where("id = ?", coupon.ID).First(coupon).Error)
+	assert.Equal(t, 1, coupon.TimesRedeemed)
+
+	// The discount carries over to renewals
+	EndSubscriptionPeriod(t, orgID, entity.SubscriptionStatusActive)
+	assert.True(t, jobs.RunOnce(context.Background()))
+
+	invoices = FindInvoices(t, orgID)
+	assert.Len(t, invoices, 2)
+	assert.Equal(t, 23.2, invoices[1].AmountDue)
+}
+
+func TestUpgradeSubscription_OnceCouponEndsAfterFirstPeriod(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	CreateTestCoupon(t, "FIRSTMONTH", func(coupon *entity.Coupon) {
+		coupon.Duration = entity.CouponDurationOnce
+	})
+	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, "pm_card_visa")
+	orgID := GetOrganizationID(t, token)
+
+	assert.Equal(t, 200, upgradeWithPromoCode(t, token, proPlan, "FIRSTMONTH"))
+
+	// The discount of a once coupon ends with the period it was redeemed in
+	EndSubscriptionPeriod(t, orgID, entity.SubscriptionStatusActive)
+	err := db.Exec("UPDATE subscriptions SET discount_ends_at = current_period_end WHERE organization_id = ?", orgID).Error
+	assert.NoError(t, err)
+	assert.True(t, jobs.RunOnce(context.Background()))
+
+	invoices := FindInvoices(t, orgID)
+	assert.Len(t, invoices, 2)
+	assert.Equal(t, 23.2, invoices[0].AmountDue)
+	assert.Equal(t, 29.0, invoices[1].AmountDue)
+	assert.Len(t, invoices[1].LineItems, 1)
+}
+
+func TestUpgradeSubscription_InvalidPromoCodes(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	enterprisePlan := CreateTestPlan(t, "enterprise", "Enterprise Plan", 99.00)
+	expired := time.Now().Add(-time.Hour).UnixMilli()
+	CreateTestCoupon(t, "EXPIRED", func(coupon *entity.Coupon) {
+		coupon.ExpiresAt = &expired
+	})
+	limit := 1
+	CreateTestCoupon(t, "USEDUP", func(coupon *entity.Coupon) {
+		coupon.MaxRedemptions = &limit
+		coupon.TimesRedeemed = 1
+	})
+	enterpriseOnly := `["` + enterprisePlan.ID + `"]`
+	CreateTestCoupon(t, "ENTERPRISE", func(coupon *entity.Coupon) {
+		coupon.PlanIDs = &enterpriseOnly
+	})
+	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, "pm_card_visa")
+
+	assert.Equal(t, 404, upgradeWithPromoCode(t, token, proPlan, "UNKNOWN"))
+	assert.Equal(t, 400, upgradeWithPromoCode(t, token, proPlan, "EXPIRED"))
+	assert.Equal(t, 409, upgradeWithPromoCode(t, token, proPlan, "USEDUP"))
+	assert.Equal(t, 400, upgradeWithPromoCode(t, token, proPlan, "ENTERPRISE"))
+
+	assertStillOnFreePlan(t, token)
+}
+
+func TestUpgradeSubscription_PromoCodeOncePerOrganization(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	enterprisePlan := CreateTestPlan(t, "enterprise", "Enterprise Plan", 99.00)
+	CreateTestCoupon(t, "SAVE20", func(coupon *entity.Coupon) {
+		coupon.Duration = entity.CouponDurationOnce
+	})
+	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, "pm_card_visa")
+
+	assert.Equal(t, 200, upgradeWithPromoCode(t, token, proPlan, "SAVE20"))
+	assert.Equal(t, 409, upgradeWithPromoCode(t, token, enterprisePlan, "SAVE20"))
+}
+
+func TestProrationResponse_DiscountOmittedForDowngrade(t *testing.T) {
+	now := time.Now()
+	pro := &entity.Plan{ID: "pro", Price: 29, BillingPeriod: entity.BillingPeriodMonthly}
+	basic := &entity.Plan{ID: "basic", Price: 9, BillingPeriod: entity.BillingPeriodMonthly}
+
+	proration := usecase.CalculateProration(subscriptionHalfway(pro, now), basic, now)
+	usecase.ApplyDiscount(proration, &entity.Coupon{Code: "HALF", DiscountType: entity.CouponDiscountPercent, PercentOff: 50})
+
+	assert.Equal(t, model.PlanChangeDowngrade, proration.Change)
+	assert.Equal(t, 0.0, proration.Discount)
+	assert.Empty(t, proration.PromoCode)
+}
@@ -0,0 +1,162 @@
+package test
+
+import (
+	"context"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// FindPastDueSubscription returns the organization's past due subscription
+func FindPastDueSubscription(t *testing.T, orgID string) *entity.Subscription {
+	subscription := new(entity.Subscription)
+	err := db.Where("organization_id = ? AND status = ?", orgID, entity.SubscriptionStatusPastDue).First(subscription).Error
+	assert.NoError(t, err)
+	return subscription
+}
+
+// DueDunning makes the next dunning step of the organization's past due subscription due, and ends its grace
+// period too when graceEnded is set
+func DueDunning(t *testing.T, orgID string, graceEnded bool) {
+	past := time.Now().Add(-time.Minute).UnixMilli()
+	updates := map[string]interface{}{"next_dunning_at": past}
+	if graceEnded {
+		updates["grace_period_ends_at"] = past
+	}
+	err := db.Model(&entity.Subscription{}).
+		Where("organization_id = ? AND status = ?", orgID, entity.SubscriptionStatusPastDue).
+		Updates(updates).Error
+	assert.NoError(t, err)
+}
+
+func TestDunningPolicy_Schedule(t *testing.T) {
+	day := 24 * time.Hour
+	policy := usecase.DunningPolicy{RetryDelays: []time.Duration{day, 3 * day, 10 * day}, GracePeriod: 7 * day}
+	now := time.Now()
+	subscription := &entity.Subscription{Status: entity.SubscriptionStatusActive}
+
+	policy.Start(subscription, now)
+	assert.Equal(t, entity.SubscriptionStatusPastDue, subscription.Status)
+	assert.Equal(t, now.Add(7*day).UnixMilli(), *subscription.GracePeriodEndsAt)
+	assert.Equal(t, now.Add(day).UnixMilli(), *subscription.NextDunningAt)
+
+	policy.RetryFailed(subscription)
+	assert.Equal(t, 1, subscription.PaymentRetryCount)
+	assert.Equal(t, now.Add(3*day).UnixMilli(), *subscription.NextDunningAt)
+
+	// The third retry falls after the grace period, which ends instead
+	policy.RetryFailed(subscription)
+	assert.Equal(t, *subscription.GracePeriodEndsAt, *subscription.NextDunningAt)
+	assert.True(t, usecase.GracePeriodEnded(subscription, now.Add(7*day)))
+
+	usecase.EndDunning(subscription)
+	assert.Equal(t, entity.SubscriptionStatusActive, subscription.Status)
+	assert.Nil(t, subscription.GracePeriodEndsAt)
+	assert.Nil(t, subscription.NextDunningAt)
+	assert.Equal(t, 0, subscription.PaymentRetryCount)
+}
+
+func TestDunning_DeclinedRenewalKeepsPlanInGracePeriod(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+	invoice := CreateRenewalInvoice(t, token)
+	assert.Equal(t, 29.0, invoice.AmountDue)
+
+	resp, err := MakeRequest("GET", "/api/v1/subscriptions/current", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	assert.Equal(t, entity.SubscriptionStatusPastDue, data["status"])
+	assert.Equal(t, true, data["in_grace_period"])
+	assert.Greater(t, data["grace_period_ends_at"].(float64), float64(time.Now().UnixMilli()))
+	assert.Equal(t, "pro", data["plan"].(map[string]interface{})["slug"])
+
+	resp, err = MakeRequest("GET", "/api/v1/subscriptions/entitlements", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, "pro", ParseResponse(t, resp)["data"].(map[string]interface{})["plan"])
+
+	subscription := FindPastDueSubscription(t, GetOrganizationID(t, token))
+	assert.Greater(t, *subscription.NextDunningAt, time.Now().UnixMilli())
+	assert.Equal(t, 0, subscription.PaymentRetryCount)
+}
+
+func TestDunning_FailedRetrySchedulesNext(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+	CreateRenewalInvoice(t, token)
+	orgID := GetOrganizationID(t, token)
+
+	DueDunning(t, orgID, false)
+	assert.True(t, jobs.RunOnce(context.Background()))
+
+	subscription := FindPastDueSubscription(t, orgID)
+	assert.Equal(t, 1, subscription.PaymentRetryCount)
+	assert.Greater(t, *subscription.NextDunningAt, time.Now().UnixMilli())
+
+	logs := FindAuditLogs(t, entity.AuditActionSubscriptionRetry)
+	assert.Len(t, logs, 1)
+	assert.Contains(t, logs[0].Details, `"paid":false`)
+}
+
+func TestDunning_PaymentMethodUpdateRetriesPayment(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+	invoice := CreateRenewalInvoice(t, token)
+	orgID := GetOrganizationID(t, token)
+
+	// Updating the payment method makes the retry due on the next run
+	AddPaymentMethod(t, token, "pm_card_visa")
+	assert.True(t, jobs.RunOnce(context.Background()))
+
+	status, slug := getSubscriptionStatus(t, token)
+	assert.Equal(t, entity.SubscriptionStatusActive, status)
+	assert.Equal(t, "pro", slug)
+
+	var paid entity.Invoice
+	assert.NoError(t, db.Where("id = ?", invoice.ID).First(&paid).Error)
+	assert.Equal(t, entity.InvoiceStatusPaid, paid.Status)
+
+	var subscription entity.Subscription
+	assert.NoError(t, db.Where("organization_id = ? AND status = ?", orgID, entity.SubscriptionStatusActive).First(&subscription).Error)
+	assert.Nil(t, subscription.GracePeriodEndsAt)
+	assert.Nil(t, subscription.NextDunningAt)
+
+	logs := FindAuditLogs(t, entity.AuditActionSubscriptionRetry)
+	assert.Len(t, logs, 1)
+	assert.Contains(t, logs[0].Details, `"paid":true`)
+}
+
+func TestDunning_GracePeriodEndFallsBackToFree(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+	invoice := CreateRenewalInvoice(t, token)
+	orgID := GetOrganizationID(t, token)
+
+	DueDunning(t, orgID, true)
+	assert.True(t, jobs.RunOnce(context.Background()))
+
+	status, slug := getSubscriptionStatus(t, token)
+	assert.Equal(t, entity.SubscriptionStatusActive, status)
+	assert.Equal(t, "free", slug)
+
+	var count int64
+	db.Model(&entity.Subscription{}).Where("organization_id = ? AND status = ?", orgID, entity.SubscriptionStatusPastDue).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	// The unpaid invoice is still owed
+	var open entity.Invoice
+	assert.NoError(t, db.Where("id = ?", invoice.ID).First(&open).Error)
+	assert.Equal(t, entity.InvoiceStatusOpen, open.Status)
+
+	logs := FindAuditLogs(t, entity.AuditActionSubscriptionStatus)
+	assert.Len(t, logs, 2)
+	assert.Contains(t, logs[1].Details, `"status":"expired"`)
+}
@@ -0,0 +1,103 @@
+package test
+
+import (
+	"go-clean-arch-saas/internal/entity"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// SetPlanLimits replaces the limits JSON of a plan
+func SetPlanLimits(t *testing.T, slug string, limits string) {
+	err := db.Model(&entity.Plan{}).Where("slug = ?", slug).Update("limits", limits).Error
+	assert.NoError(t, err)
+}
+
+func TestGetEntitlements_Success(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+	SetPlanLimits(t, "free", `{"max_users": 3, "api_calls_per_month": 1000}`)
+
+	resp, err := MakeRequest("GET", "/api/v1/subscriptions/entitlements", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	assert.Equal(t, "free", data["plan"])
+
+	limits := data["limits"].(map[string]interface{})
+	assert.Equal(t, float64(3), limits["max_users"])
+	assert.Equal(t, float64(1000), limits["api_calls_per_month"])
+	assert.Equal(t, float64(-1), limits["storage_gb"]) // missing means unlimited
+
+	features := data["features"].(map[string]interface{})
+	assert.Equal(t, true, features["feature1"])
+
+	usage := data["usage"].(map[string]interface{})
+	assert.Equal(t, float64(1), usage["users"])
+}
+
+func TestEntitlements_InvitationBlockedByMaxUsers(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+	SetPlanLimits(t, "free", `{"max_users": 2}`)
+
+	// First invitation reserves the last seat
+	resp, err := MakeRequest("POST", "/api/v1/organizations/invitations", `{"email": "first@example.com", "role": "member"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = MakeRequest("POST", "/api/v1/organizations/invitations", `{"email": "second@example.com", "role": "member"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 402, resp.StatusCode)
+
+	result := ParseResponse(t, resp)
+	assert.Equal(t, "Plan limit exceeded: max_users", result["errors"])
+	details := result["entitlement"].(map[string]interface{})
+	assert.Equal(t, "max_users", details["limit"])
+	assert.Equal(t, float64(2), details["allowed"])
+	assert.Equal(t, float64(2), details["current"])
+}
+
+func TestEntitlements_AcceptBlockedByMaxUsers(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+	orgID := GetOrganizationID(t, token)
+	CreateTestInvitation(t, orgID, "invitee@example.com", entity.OrgRoleMember, "accept-token")
+
+	// Plan shrinks after the invitation was sent
+	SetPlanLimits(t, "free", `{"max_users": 1}`)
+
+	resp, err := MakeRequest("POST", "/api/v1/organizations/invitations/accept", `{
+		"token": "accept-token",
+		"name": "Invitee",
+		"password": "password123"
+	}`, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 402, resp.StatusCode)
+
+	// The invitee account is rolled back with the membership
+	var count int64
+	db.Model(&entity.User{}).Where("email = ?", "invitee@example.com").Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestEntitlements_DowngradeBlockedByMaxUsers(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+	CreateTestMember(t, GetOrganizationID(t, token), "member@example.com", entity.OrgRoleMember)
+
+	smallPlan := CreateTestPlan(t, "solo", "Solo Plan", 5.00)
+	SetPlanLimits(t, "solo", `{"max_users": 1}`)
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+smallPlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 402, resp.StatusCode)
+
+	details := ParseResponse(t, resp)["entitlement"].(map[string]interface{})
+	assert.Equal(t, "max_users", details["limit"])
+}
@@ -7,9 +7,11 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // CleanupDatabase clears all tables for testing
@@ -21,6 +23,51 @@ func CleanupDatabase(t *testing.T) {
 	err = db.Exec("TRUNCATE TABLE audit_logs").Error
 	assert.NoError(t, err)
 
+	err = db.Exec("TRUNCATE TABLE usage_records").Error
+	assert.NoError(t, err)
+
+	err = db.Exec("TRUNCATE TABLE coupon_redemptions").Error
+	assert.NoError(t, err)
+
+	err = db.Exec("TRUNCATE TABLE coupons").Error
+	assert.NoError(t, err)
+
+	err = db.Exec("TRUNCATE TABLE billing_events").Error
+	assert.NoError(t, err)
+
+	err = db.Exec("TRUNCATE TABLE pending_invoice_items").Error
+	assert.NoError(t, err)
+
+	err = db.Exec("TRUNCATE TABLE invoice_line_items").Error
+	assert.NoError(t, err)
+
+	err = db.Exec("TRUNCATE TABLE invoices").Error
+	assert.NoError(t, err)
+
+	err = db.Exec("TRUNCATE TABLE invoice_sequences").Error
+	assert.NoError(t, err)
+
+	err = db.Exec("TRUNCATE TABLE sessions").Error
+	assert.NoError(t, err)
+
+	err = db.Exec("TRUNCATE TABLE recovery_codes").Error
+	assert.NoError(t, err)
+
+	err = db.Exec("TRUNCATE TABLE user_credentials").Error
+	assert.NoError(t, err)
+
+	err = db.Exec("TRUNCATE TABLE identities").Error
+	assert.NoError(t, err)
+
+	err = db.Exec("TRUNCATE TABLE sso_domains").Error
+	assert.NoError(t, err)
+
+	err = db.Exec("TRUNCATE TABLE sso_connections").Error
+	assert.NoError(t, err)
+
+	err = db.Exec("TRUNCATE TABLE organization_invitations").Error
+	assert.NoError(t, err)
+
 	err = db.Exec("TRUNCATE TABLE subscriptions").Error
 	assert.NoError(t, err)
 
@@ -33,6 +80,9 @@ func CleanupDatabase(t *testing.T) {
 	err = db.Exec("TRUNCATE TABLE organizations").Error
 	assert.NoError(t, err)
 
+	err = db.Exec("TRUNCATE TABLE plan_prices").Error
+	assert.NoError(t, err)
+
 	err = db.Exec("TRUNCATE TABLE plans").Error
 	assert.NoError(t, err)
 
@@ -118,3 +168,56 @@ func GetAccessToken(t *testing.T) string {
 
 	return data["access_token"].(string)
 }
+
+// GetOrganizationID returns the current organization ID for the given token
+func GetOrganizationID(t *testing.T, token string) string {
+	resp, err := MakeRequest("GET", "/api/v1/organizations/current", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	result := ParseResponse(t, resp)
+	data := result["data"].(map[string]interface{})
+
+	return data["id"].(string)
+}
+
+// CreateTestMember adds a user with the given role to an organization and returns its access token
+func CreateTestMember(t *testing.T, orgID string, email string, role string) string {
+	password, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	assert.NoError(t, err)
+
+	user := &entity.User{
+		ID:             uuid.New().String(),
+		Name:           "Test Member",
+		Email:          email,
+		Password:       string(password),
+		SystemRole:     entity.SystemRoleUser,
+		EmailVerified:  true,
+		OrganizationID: orgID,
+	}
+	err = db.Create(user).Error
+	assert.NoError(t, err)
+
+	member := &entity.OrganizationMember{
+		OrganizationID: orgID,
+		UserID:         user.ID,
+		Role:           role,
+		JoinedAt:       time.Now().UnixMilli(),
+	}
+	err = db.Create(member).Error
+	assert.NoError(t, err)
+
+	loginBody := `{
+		"email": "` + email + `",
+		"password": "password123"
+	}`
+
+	resp, err := MakeRequest("POST", "/api/v1/auth/login", loginBody, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	result := ParseResponse(t, resp)
+	data := result["data"].(map[string]interface{})
+
+	return data["access_token"].(string)
+}
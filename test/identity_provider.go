@@ -0,0 +1,207 @@
+package test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Client credentials the test identity provider accepts
+const (
+	IdentityProviderClientID     = "test-client"
+	IdentityProviderClientSecret = "test-client-secret"
+	identityProviderKeyID        = "test-key"
+)
+
+// IdentityProviderUser is an account at the test identity provider
+type IdentityProviderUser struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type identityProviderGrant struct {
+	user          IdentityProviderUser
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+}
+
+// IdentityProvider is an OAuth 2.0 / OpenID Connect provider for tests. It issues ID tokens from its token
+// endpoint and serves a GitHub-style user info and emails endpoint, so it can stand in for both kinds of provider.
+// Its discovery document lets SSO connections use it as an issuer.
+type IdentityProvider struct {
+	Server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu     sync.Mutex
+	codes  map[string]*identityProviderGrant
+	tokens map[string]IdentityProviderUser
+}
+
+func NewIdentityProvider() *IdentityProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	provider := &IdentityProvider{
+		key:    key,
+		codes:  make(map[string]*identityProviderGrant),
+		tokens: make(map[string]IdentityProviderUser),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", provider.discovery)
+	mux.HandleFunc("/token", provider.token)
+	mux.HandleFunc("/jwks", provider.jwks)
+	mux.HandleFunc("/userinfo", provider.userInfo)
+	mux.HandleFunc("/emails", provider.emails)
+	provider.Server = httptest.NewServer(mux)
+	return provider
+}
+
+// Authorize stands for the user signing in on the consent page of the authorization URL. It returns the code and
+// state the provider redirects back to the application with.
+func (p *IdentityProvider) Authorize(authorizationURL string, user IdentityProviderUser) (string, string, error) {
+	parsed, err := url.Parse(authorizationURL)
+	if err != nil {
+		return "", "", err
+	}
+	query := parsed.Query()
+	if query.Get("client_id") != IdentityProviderClientID || query.Get("code_challenge_method") != "S256" {
+		return "", "", errors.New("invalid authorization request")
+	}
+
+	code := randomValue()
+	p.mu.Lock()
+	p.codes[code] = &identityProviderGrant{
+		user:          user,
+		redirectURI:   query.Get("redirect_uri"),
+		codeChallenge: query.Get("code_challenge"),
+		nonce:         query.Get("nonce"),
+	}
+	p.mu.Unlock()
+
+	return code, query.Get("state"), nil
+}
+
+func (p *IdentityProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"issuer":                 p.Server.URL,
+		"authorization_endpoint": p.Server.URL + "/authorize",
+		"token_endpoint":         p.Server.URL + "/token",
+		"userinfo_endpoint":      p.Server.URL + "/userinfo",
+		"jwks_uri":               p.Server.URL + "/jwks",
+	})
+}
+
+func (p *IdentityProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	// Codes are single use
+	p.mu.Lock()
+	grant, ok := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok ||
+		r.PostForm.Get("client_id") != IdentityProviderClientID ||
+		r.PostForm.Get("client_secret") != IdentityProviderClientSecret ||
+		r.PostForm.Get("redirect_uri") != grant.redirectURI ||
+		base64.RawURLEncoding.EncodeToString(verifier[:]) != grant.codeChallenge {
+		http.Error(w, "invalid_grant", http.StatusBadRequest)
+		return
+	}
+
+	accessToken := randomValue()
+	p.mu.Lock()
+	p.tokens[accessToken] = grant.user
+	p.mu.Unlock()
+
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            p.Server.URL,
+		"sub":            grant.user.Subject,
+		"aud":            IdentityProviderClientID,
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          grant.nonce,
+		"email":          grant.user.Email,
+		"email_verified": grant.user.EmailVerified,
+		"name":           grant.user.Name,
+	})
+	idToken.Header["kid"] = identityProviderKeyID
+	signed, err := idToken.SignedString(p.key)
+	if err != nil {
+		http.Error(w, "server_error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{"access_token": accessToken, "token_type": "Bearer", "id_token": signed})
+}
+
+func (p *IdentityProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{"keys": []map[string]string{{
+		"kid": identityProviderKeyID,
+		"kty": "RSA",
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+	}}})
+}
+
+func (p *IdentityProvider) user(r *http.Request) (IdentityProviderUser, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	user, ok := p.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	return user, ok
+}
+
+func (p *IdentityProvider) userInfo(w http.ResponseWriter, r *http.Request) {
+	user, ok := p.user(r)
+	if !ok {
+		http.Error(w, "invalid_token", http.StatusUnauthorized)
+		return
+	}
+	// GitHub-style: a numeric ID and no verified email
+	id, _ := new(big.Int).SetString(user.Subject, 10)
+	writeJSON(w, map[string]interface{}{"id": id, "login": user.Name, "email": user.Email})
+}
+
+func (p *IdentityProvider) emails(w http.ResponseWriter, r *http.Request) {
+	user, ok := p.user(r)
+	if !ok {
+		http.Error(w, "invalid_token", http.StatusUnauthorized)
+		return
+	}
+	writeJSON(w, []map[string]interface{}{{"email": user.Email, "primary": true, "verified": user.EmailVerified}})
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+func randomValue() string {
+	data := make([]byte, 16)
+	rand.Read(data)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -2,6 +2,7 @@ package test
 
 import (
 	"go-clean-arch-saas/internal/config"
+	"go-clean-arch-saas/internal/delivery/scheduler"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
@@ -20,6 +21,12 @@ var log *logrus.Logger
 
 var validate *validator.Validate
 
+var jobs *scheduler.Scheduler
+
+var identityProvider *IdentityProvider
+
+var samlIdentityProvider *SAMLIdentityProvider
+
 func init() {
 	viperConfig = config.NewViper()
 	log = config.NewLogger(viperConfig)
@@ -27,7 +34,30 @@ func init() {
 	app = config.NewFiber(viperConfig)
 	db = config.NewDatabase(viperConfig, log)
 
-	config.Bootstrap(&config.BootstrapConfig{
+	// The test identity provider signs users in as an OpenID Connect provider ("oidc") and as a
+	// GitHub-style OAuth provider ("oauth2")
+	identityProvider = NewIdentityProvider()
+	for _, name := range []string{"oidc", "oauth2"} {
+		key := "oauth.providers." + name + "."
+		viperConfig.Set(key+"client_id", IdentityProviderClientID)
+		viperConfig.Set(key+"client_secret", IdentityProviderClientSecret)
+		viperConfig.Set(key+"auth_url", identityProvider.Server.URL+"/authorize")
+		viperConfig.Set(key+"token_url", identityProvider.Server.URL+"/token")
+	}
+	viperConfig.Set("oauth.providers.oidc.issuer", identityProvider.Server.URL)
+	viperConfig.Set("oauth.providers.oidc.jwks_url", identityProvider.Server.URL+"/jwks")
+	viperConfig.Set("oauth.providers.oidc.scopes", "openid email profile")
+	viperConfig.Set("oauth.providers.oauth2.userinfo_url", identityProvider.Server.URL+"/userinfo")
+	viperConfig.Set("oauth.providers.oauth2.emails_url", identityProvider.Server.URL+"/emails")
+
+	// Billing webhooks are rejected until a secret is configured
+	viperConfig.Set("payment.webhook_secret", "whsec_test")
+
+	// Organizations connect their own identity providers for single sign-on; the test one runs on localhost
+	samlIdentityProvider = NewSAMLIdentityProvider()
+	viperConfig.Set("sso.allow_private_issuers", true)
+
+	jobs = config.Bootstrap(&config.BootstrapConfig{
 		DB:       db,
 		App:      app,
 		Log:      log,
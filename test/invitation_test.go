@@ -0,0 +1,162 @@
+package test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"go-clean-arch-saas/internal/entity"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// CreateTestInvitation inserts a pending invitation with a known token
+func CreateTestInvitation(t *testing.T, orgID string, email string, role string, token string) *entity.OrganizationInvitation {
+	sum := sha256.Sum256([]byte(token))
+	invitation := &entity.OrganizationInvitation{
+		ID:             uuid.New().String(),
+		OrganizationID: orgID,
+		Email:          email,
+		Role:           role,
+		TokenHash:      hex.EncodeToString(sum[:]),
+		Status:         entity.InvitationStatusPending,
+		ExpiresAt:      time.Now().Add(time.Hour).UnixMilli(),
+	}
+
+	err := db.Create(invitation).Error
+	assert.NoError(t, err)
+
+	return invitation
+}
+
+func TestCreateInvitation_Success(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+
+	requestBody := `{
+		"email": "invitee@example.com",
+		"role": "member"
+	}`
+
+	resp, err := MakeRequest("POST", "/api/v1/organizations/invitations", requestBody, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	result := ParseResponse(t, resp)
+	data := result["data"].(map[string]interface{})
+	assert.Equal(t, "invitee@example.com", data["email"])
+	assert.Equal(t, "member", data["role"])
+	assert.Equal(t, "pending", data["status"])
+
+	// Duplicate pending invitation is rejected
+	resp, err = MakeRequest("POST", "/api/v1/organizations/invitations", requestBody, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
+
+	resp, err = MakeRequest("GET", "/api/v1/organizations/invitations", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	result = ParseResponse(t, resp)
+	assert.Len(t, result["data"].([]interface{}), 1)
+}
+
+func TestCreateInvitation_InvalidRole(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+
+	requestBody := `{
+		"email": "invitee@example.com",
+		"role": "owner"
+	}`
+
+	resp, err := MakeRequest("POST", "/api/v1/organizations/invitations", requestBody, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+}
+
+func TestCreateInvitation_MemberForbidden(t *testing.T) {
+	CleanupDatabase(t)
+
+	ownerToken := GetAccessToken(t)
+	orgID := GetOrganizationID(t, ownerToken)
+	memberToken := CreateTestMember(t, orgID, "member@example.com", entity.OrgRoleMember)
+
+	requestBody := `{
+		"email": "invitee@example.com",
+		"role": "member"
+	}`
+
+	resp, err := MakeRequest("POST", "/api/v1/organizations/invitations", requestBody, memberToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+}
+
+func TestAcceptInvitation_NewUser(t *testing.T) {
+	CleanupDatabase(t)
+
+	ownerToken := GetAccessToken(t)
+	orgID := GetOrganizationID(t, ownerToken)
+	CreateTestInvitation(t, orgID, "invitee@example.com", entity.OrgRoleAdmin, "invite-token")
+
+	// New account requires name and password
+	resp, err := MakeRequest("POST", "/api/v1/organizations/invitations/accept", `{"token": "invite-token"}`, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+
+	requestBody := `{
+		"token": "invite-token",
+		"name": "Invitee",
+		"password": "password123"
+	}`
+
+	resp, err = MakeRequest("POST", "/api/v1/organizations/invitations/accept", requestBody, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	result := ParseResponse(t, resp)
+	data := result["data"].(map[string]interface{})
+	assert.Equal(t, "admin", data["role"])
+	assert.Equal(t, orgID, data["organization"].(map[string]interface{})["id"])
+	assert.Equal(t, true, data["user"].(map[string]interface{})["email_verified"])
+
+	// Token is single use
+	resp, err = MakeRequest("POST", "/api/v1/organizations/invitations/accept", requestBody, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+
+	// Invitee can log in
+	resp, err = MakeRequest("POST", "/api/v1/auth/login", `{"email": "invitee@example.com", "password": "password123"}`, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = MakeRequest("GET", "/api/v1/organizations/members", "", ownerToken)
+	assert.NoError(t, err)
+	result = ParseResponse(t, resp)
+	assert.Len(t, result["data"].([]interface{}), 2)
+}
+
+func TestAcceptInvitation_Revoked(t *testing.T) {
+	CleanupDatabase(t)
+
+	ownerToken := GetAccessToken(t)
+	orgID := GetOrganizationID(t, ownerToken)
+	invitation := CreateTestInvitation(t, orgID, "invitee@example.com", entity.OrgRoleMember, "invite-token")
+
+	resp, err := MakeRequest("DELETE", "/api/v1/organizations/invitations/"+invitation.ID, "", ownerToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	requestBody := `{
+		"token": "invite-token",
+		"name": "Invitee",
+		"password": "password123"
+	}`
+
+	resp, err = MakeRequest("POST", "/api/v1/organizations/invitations/accept", requestBody, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+}
@@ -0,0 +1,194 @@
+package test
+
+import (
+	"context"
+	"go-clean-arch-saas/internal/entity"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// FindInvoices returns the organization's invoices in issue order
+func FindInvoices(t *testing.T, orgID string) []entity.Invoice {
+	var invoices []entity.Invoice
+	err := db.Where("organization_id = ?", orgID).Preload("LineItems").Order("sequence ASC").Find(&invoices).Error
+	assert.NoError(t, err)
+	return invoices
+}
+
+func TestInvoice_FreePlanNotInvoiced(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+
+	assert.Len(t, FindInvoices(t, GetOrganizationID(t, token)), 0)
+}
+
+func TestInvoice_UpgradeWithProration(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	enterprisePlan := CreateTestPlan(t, "enterprise", "Enterprise Plan", 99.00)
+	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, "pm_card_visa")
+	orgID := GetOrganizationID(t, token)
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+enterprisePlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	invoices := FindInvoices(t, orgID)
+	assert.Len(t, invoices, 2)
+
+	assert.Equal(t, "INV-000001", invoices[0].Number)
+	assert.Equal(t, entity.InvoiceStatusPaid, invoices[0].Status)
+	assert.Equal(t, 29.0, invoices[0].AmountDue)
+	assert.Len(t, invoices[0].LineItems, 1)
+
+	// Almost the whole pro period is unused and credited
+	assert.Equal(t, "INV-000002", invoices[1].Number)
+	assert.Len(t, invoices[1].LineItems, 2)
+	assert.Less(t, invoices[1].AmountDue, 99.0)
+	assert.GreaterOrEqual(t, invoices[1].AmountDue, 70.0)
+	for _, item := range invoices[1].LineItems {
+		if item.Kind == entity.InvoiceLineKindProration {
+			assert.Less(t, item.Amount, 0.0)
+			assert.Equal(t, proPlan.ID, *item.PlanID)
+		}
+	}
+}
+
+func TestInvoice_RenewalInvoiced(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, "pm_card_visa")
+	orgID := GetOrganizationID(t, token)
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	EndSubscriptionPeriod(t, orgID, entity.SubscriptionStatusActive)
+	assert.True(t, jobs.RunOnce(context.Background()))
+
+	invoices := FindInvoices(t, orgID)
+	assert.Len(t, invoices, 2)
+	assert.Equal(t, "INV-000002", invoices[1].Number)
+	assert.Equal(t, 29.0, invoices[1].Total)
+}
+
+func TestListInvoices_Success(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	enterprisePlan := CreateTestPlan(t, "enterprise", "Enterprise Plan", 99.00)
+	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, "pm_card_visa")
+
+	MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`"}`, token)
+	MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+enterprisePlan.ID+`"}`, token)
+
+	resp, err := MakeRequest("GET", "/api/v1/billing/invoices?size=1", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	result := ParseResponse(t, resp)
+	data := result["data"].([]interface{})
+	assert.Len(t, data, 1)
+
+	// Newest first
+	assert.Equal(t, "INV-000002", data[0].(map[string]interface{})["number"])
+
+	paging := result["paging"].(map[string]interface{})
+	assert.Equal(t, float64(2), paging["total_item"])
+}
+
+func TestGetInvoice_Success(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, "pm_card_visa")
+
+	MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`"}`, token)
+	invoice := FindInvoices(t, GetOrganizationID(t, token))[0]
+
+	resp, err := MakeRequest("GET", "/api/v1/billing/invoices/"+invoice.ID, "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	assert.Equal(t, "INV-000001", data["number"])
+	assert.Equal(t, "USD", data["currency"])
+
+	lines := data["line_items"].([]interface{})
+	assert.Len(t, lines, 1)
+	line := lines[0].(map[string]interface{})
+	assert.Equal(t, entity.InvoiceLineKindSubscription, line["kind"])
+	assert.Equal(t, "Pro Plan", line["description"])
+	assert.Equal(t, float64(29), line["amount"])
+}
+
+func TestGetInvoice_HTML(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, "pm_card_visa")
+
+	MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`"}`, token)
+	invoice := FindInvoices(t, GetOrganizationID(t, token))[0]
+
+	resp, err := MakeRequest("GET", "/api/v1/billing/invoices/"+invoice.ID+"?format=html", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/html")
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), "Invoice INV-000001")
+	assert.Contains(t, string(body), "Test Org")
+	assert.Contains(t, string(body), "29.00 USD")
+}
+
+func TestGetInvoice_OtherOrganization(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, "pm_card_visa")
+
+	MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`"}`, token)
+	invoice := FindInvoices(t, GetOrganizationID(t, token))[0]
+
+	otherToken := RegisterAndLogin(t, "other@example.com", "Other Org")
+
+	resp, err := MakeRequest("GET", "/api/v1/billing/invoices/"+invoice.ID, "", otherToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+}
+
+func TestListInvoices_MemberForbidden(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+	orgID := GetOrganizationID(t, token)
+	memberToken := CreateTestMember(t, orgID, "member@example.com", entity.OrgRoleMember)
+	adminToken := CreateTestMember(t, orgID, "admin@example.com", entity.OrgRoleAdmin)
+
+	resp, err := MakeRequest("GET", "/api/v1/billing/invoices", "", memberToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+
+	// Billing is owner-only
+	resp, err = MakeRequest("GET", "/api/v1/billing/invoices", "", adminToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+}
@@ -0,0 +1,233 @@
+package test
+
+import (
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/pkg/totp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TOTPCode returns the code of the secret offset time steps from now. Codes are accepted once, so a test
+// using several codes takes each from a later step.
+func TOTPCode(t *testing.T, secret string, offset int64) string {
+	code, err := totp.Code(secret, totp.Step(time.Now())+offset)
+	assert.NoError(t, err)
+	return code
+}
+
+// EnableMFA enrolls the user of the token in two-factor authentication and returns the TOTP secret and recovery codes
+func EnableMFA(t *testing.T, token string) (string, []string) {
+	resp, err := MakeRequest("POST", "/api/v1/auth/mfa/enroll", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	secret := ParseResponse(t, resp)["data"].(map[string]interface{})["secret"].(string)
+
+	resp, err = MakeRequest("POST", "/api/v1/auth/mfa/confirm", `{"code": "`+TOTPCode(t, secret, 0)+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var recoveryCodes []string
+	for _, code := range ParseResponse(t, resp)["data"].(map[string]interface{})["recovery_codes"].([]interface{}) {
+		recoveryCodes = append(recoveryCodes, code.(string))
+	}
+	return secret, recoveryCodes
+}
+
+// LoginMFAChallenge logs in the test user, who has two-factor authentication enabled, and returns the MFA token
+func LoginMFAChallenge(t *testing.T) string {
+	resp, err := MakeRequest("POST", "/api/v1/auth/login", `{"email": "test@example.com", "password": "password123"}`, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	assert.Equal(t, true, data["mfa_required"])
+	assert.Nil(t, data["access_token"])
+	assert.Nil(t, data["refresh_token"])
+	return data["mfa_token"].(string)
+}
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 test vector for SHA-1 at T=59, truncated to 6 digits
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	code, err := totp.Code(secret, totp.Step(time.Unix(59, 0)))
+	assert.NoError(t, err)
+	assert.Equal(t, "287082", code)
+
+	step, ok := totp.Validate(secret, "287082", time.Unix(89, 0), 1)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), step)
+
+	_, ok = totp.Validate(secret, "287082", time.Unix(119, 0), 1)
+	assert.False(t, ok)
+}
+
+func TestMFA_EnrollAndConfirm(t *testing.T) {
+	CleanupDatabase(t)
+	token := GetAccessToken(t)
+
+	resp, err := MakeRequest("POST", "/api/v1/auth/mfa/enroll", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	secret := data["secret"].(string)
+	assert.NotEmpty(t, secret)
+	assert.True(t, strings.HasPrefix(data["otpauth_uri"].(string), "otpauth://totp/"))
+	assert.Contains(t, data["otpauth_uri"], "secret="+secret)
+
+	// The secret is stored encrypted, and not enabled before confirmation
+	var user entity.User
+	assert.NoError(t, db.Where("email = ?", "test@example.com").First(&user).Error)
+	assert.NotNil(t, user.MFASecret)
+	assert.NotContains(t, *user.MFASecret, secret)
+	assert.False(t, user.MFAEnabled)
+
+	resp, err = MakeRequest("POST", "/api/v1/auth/mfa/confirm", `{"code": "000000"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+
+	resp, err = MakeRequest("POST", "/api/v1/auth/mfa/confirm", `{"code": "`+TOTPCode(t, secret, 0)+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	recoveryCodes := ParseResponse(t, resp)["data"].(map[string]interface{})["recovery_codes"].([]interface{})
+	assert.Len(t, recoveryCodes, 10)
+
+	resp, err = MakeRequest("POST", "/api/v1/auth/mfa/enroll", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
+
+	resp, err = MakeRequest("GET", "/api/v1/auth/mfa", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	data = ParseResponse(t, resp)["data"].(map[string]interface{})
+	assert.Equal(t, true, data["enabled"])
+	assert.Equal(t, 10.0, data["recovery_codes_remaining"])
+
+	resp, err = MakeRequest("GET", "/api/v1/users/current", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, true, ParseResponse(t, resp)["data"].(map[string]interface{})["mfa_enabled"])
+}
+
+func TestMFA_LoginRequiresSecondStep(t *testing.T) {
+	CleanupDatabase(t)
+	secret, _ := EnableMFA(t, GetAccessToken(t))
+
+	mfaToken := LoginMFAChallenge(t)
+
+	// The challenge is not an access token
+	resp, err := MakeRequest("GET", "/api/v1/users/current", "", mfaToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+
+	resp, err = MakeRequest("POST", "/api/v1/auth/mfa/verify", `{"mfa_token": "`+mfaToken+`", "code": "000000"}`, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+
+	// The challenge was used up by the wrong code
+	code := TOTPCode(t, secret, 1)
+	resp, err = MakeRequest("POST", "/api/v1/auth/mfa/verify", `{"mfa_token": "`+mfaToken+`", "code": "`+code+`"}`, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+
+	mfaToken = LoginMFAChallenge(t)
+	resp, err = MakeRequest("POST", "/api/v1/auth/mfa/verify", `{"mfa_token": "`+mfaToken+`", "code": "`+code+`"}`, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	assert.NotEmpty(t, data["refresh_token"])
+	assert.Equal(t, "test@example.com", data["user"].(map[string]interface{})["email"])
+
+	resp, err = MakeRequest("GET", "/api/v1/users/current", "", data["access_token"].(string))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	// A code is accepted once
+	resp, err = MakeRequest("POST", "/api/v1/auth/mfa/verify", `{"mfa_token": "`+LoginMFAChallenge(t)+`", "code": "`+code+`"}`, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+
+	// So is a challenge
+	resp, err = MakeRequest("POST", "/api/v1/auth/mfa/verify", `{"mfa_token": "`+mfaToken+`", "code": "`+TOTPCode(t, secret, 2)+`"}`, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+}
+
+func TestMFA_LockoutAfterFailedCodes(t *testing.T) {
+	CleanupDatabase(t)
+	secret, _ := EnableMFA(t, GetAccessToken(t))
+
+	// Every wrong code needs a new challenge, and the fifth in a row locks the second step
+	for i := 0; i < 5; i++ {
+		resp, err := MakeRequest("POST", "/api/v1/auth/mfa/verify", `{"mfa_token": "`+LoginMFAChallenge(t)+`", "code": "000000"}`, "")
+		assert.NoError(t, err)
+		assert.Equal(t, 401, resp.StatusCode)
+	}
+
+	// The right code is refused while locked
+	resp, err := MakeRequest("POST", "/api/v1/auth/mfa/verify", `{"mfa_token": "`+LoginMFAChallenge(t)+`", "code": "`+TOTPCode(t, secret, 1)+`"}`, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 429, resp.StatusCode)
+
+	// Once the lockout ends the right code works again
+	assert.NoError(t, db.Model(&entity.User{}).Where("email = ?", "test@example.com").Update("mfa_locked_until", time.Now().Add(-time.Minute).UnixMilli()).Error)
+	resp, err = MakeRequest("POST", "/api/v1/auth/mfa/verify", `{"mfa_token": "`+LoginMFAChallenge(t)+`", "code": "`+TOTPCode(t, secret, 1)+`"}`, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestMFA_RecoveryCodeIsOneTime(t *testing.T) {
+	CleanupDatabase(t)
+	token := GetAccessToken(t)
+	_, recoveryCodes := EnableMFA(t, token)
+
+	// Recovery codes are accepted in any case
+	body := `{"mfa_token": "` + LoginMFAChallenge(t) + `", "code": "` + strings.ToUpper(recoveryCodes[0]) + `"}`
+	resp, err := MakeRequest("POST", "/api/v1/auth/mfa/verify", body, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	body = `{"mfa_token": "` + LoginMFAChallenge(t) + `", "code": "` + recoveryCodes[0] + `"}`
+	resp, err = MakeRequest("POST", "/api/v1/auth/mfa/verify", body, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+
+	resp, err = MakeRequest("GET", "/api/v1/auth/mfa", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 9.0, ParseResponse(t, resp)["data"].(map[string]interface{})["recovery_codes_remaining"])
+
+	// Regenerating replaces the remaining codes
+	resp, err = MakeRequest("POST", "/api/v1/auth/mfa/recovery-codes", `{"code": "`+recoveryCodes[1]+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	body = `{"mfa_token": "` + LoginMFAChallenge(t) + `", "code": "` + recoveryCodes[2] + `"}`
+	resp, err = MakeRequest("POST", "/api/v1/auth/mfa/verify", body, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+}
+
+func TestMFA_Disable(t *testing.T) {
+	CleanupDatabase(t)
+	token := GetAccessToken(t)
+	secret, _ := EnableMFA(t, token)
+
+	resp, err := MakeRequest("POST", "/api/v1/auth/mfa/disable", `{"code": "000000"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+
+	resp, err = MakeRequest("POST", "/api/v1/auth/mfa/disable", `{"code": "`+TOTPCode(t, secret, 1)+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var count int64
+	assert.NoError(t, db.Model(&entity.RecoveryCode{}).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+
+	// Login is back to a single step
+	accessToken, _ := Login(t, "test@example.com", "password123")
+	assert.NotEmpty(t, accessToken)
+}
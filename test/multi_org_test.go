@@ -0,0 +1,93 @@
+package test
+
+import (
+	"fmt"
+	"go-clean-arch-saas/internal/entity"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RegisterAndLogin registers another user with its own organization and returns the access token.
+// The free plan must already exist.
+func RegisterAndLogin(t *testing.T, email string, organizationName string) string {
+	registerBody := fmt.Sprintf(`{
+		"name": "Other User",
+		"email": "%s",
+		"password": "password123",
+		"organization_name": "%s"
+	}`, email, organizationName)
+
+	resp, err := MakeRequest("POST", "/api/v1/auth/register", registerBody, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	accessToken, _ := Login(t, email, "password123")
+	return accessToken
+}
+
+func TestSwitchOrganization_Success(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+	homeOrgID := GetOrganizationID(t, token)
+
+	otherToken := RegisterAndLogin(t, "other@example.com", "Other Org")
+	otherOrgID := GetOrganizationID(t, otherToken)
+
+	// Add test user to the other organization
+	var user entity.User
+	err := db.Where("email = ?", "test@example.com").First(&user).Error
+	assert.NoError(t, err)
+	err = db.Create(&entity.OrganizationMember{
+		OrganizationID: otherOrgID,
+		UserID:         user.ID,
+		Role:           entity.OrgRoleMember,
+		JoinedAt:       time.Now().UnixMilli(),
+	}).Error
+	assert.NoError(t, err)
+
+	resp, err := MakeRequest("GET", "/api/v1/users/current/organizations", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	memberships := ParseResponse(t, resp)["data"].([]interface{})
+	assert.Len(t, memberships, 2)
+
+	for _, item := range memberships {
+		membership := item.(map[string]interface{})
+		org := membership["organization"].(map[string]interface{})
+		assert.Equal(t, org["id"] == homeOrgID, membership["current"])
+	}
+
+	resp, err = MakeRequest("POST", "/api/v1/auth/switch-organization", `{"organization_id": "`+otherOrgID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	assert.Equal(t, "member", data["role"])
+	switchedToken := data["access_token"].(string)
+
+	assert.Equal(t, otherOrgID, GetOrganizationID(t, switchedToken))
+
+	// Removing the membership invalidates the switched token
+	resp, err = MakeRequest("DELETE", "/api/v1/organizations/members/"+user.ID, "", otherToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = MakeRequest("GET", "/api/v1/organizations/current", "", switchedToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+}
+
+func TestSwitchOrganization_NotMember(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+	otherToken := RegisterAndLogin(t, "other@example.com", "Other Org")
+	otherOrgID := GetOrganizationID(t, otherToken)
+
+	resp, err := MakeRequest("POST", "/api/v1/auth/switch-organization", `{"organization_id": "`+otherOrgID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+}
@@ -0,0 +1,256 @@
+package test
+
+import (
+	"go-clean-arch-saas/internal/entity"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// AuthorizeOAuth starts a login with the provider and returns the authorization URL and state token
+func AuthorizeOAuth(t *testing.T, provider string) (string, string) {
+	resp, err := MakeRequest("POST", "/api/v1/auth/oauth/"+provider+"/authorize", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	return data["authorization_url"].(string), data["state_token"].(string)
+}
+
+func finishOAuthLogin(t *testing.T, provider string, stateToken string, state string, code string) (int, map[string]interface{}) {
+	body := `{"state_token": "` + stateToken + `", "state": "` + state + `", "code": "` + code + `"}`
+	resp, err := MakeRequest("POST", "/api/v1/auth/oauth/"+provider+"/callback", body, "")
+	assert.NoError(t, err)
+	return resp.StatusCode, ParseResponse(t, resp)
+}
+
+// LoginOAuth signs the user in with the provider and returns the response status and body
+func LoginOAuth(t *testing.T, provider string, user IdentityProviderUser) (int, map[string]interface{}) {
+	authorizationURL, stateToken := AuthorizeOAuth(t, provider)
+	code, state, err := identityProvider.Authorize(authorizationURL, user)
+	assert.NoError(t, err)
+	return finishOAuthLogin(t, provider, stateToken, state, code)
+}
+
+func TestOAuth_ListProviders(t *testing.T) {
+	resp, err := MakeRequest("GET", "/api/v1/auth/oauth/providers", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	providers := ParseResponse(t, resp)["data"].([]interface{})
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "oauth2"},
+		map[string]interface{}{"name": "oidc"},
+	}, providers)
+
+	resp, err = MakeRequest("POST", "/api/v1/auth/oauth/unknown/authorize", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+}
+
+func TestOAuth_SignUpCreatesUserAndOrganization(t *testing.T) {
+	CleanupDatabase(t)
+	CreateTestPlan(t, "free", "Free Plan", 0)
+
+	user := IdentityProviderUser{Subject: "oidc-user-1", Email: "jane@example.com", EmailVerified: true, Name: "Jane Doe"}
+	status, result := LoginOAuth(t, "oidc", user)
+	assert.Equal(t, 200, status)
+
+	data := result["data"].(map[string]interface{})
+	token := data["access_token"].(string)
+	assert.NotEmpty(t, data["refresh_token"])
+	assert.Equal(t, "jane@example.com", data["user"].(map[string]interface{})["email"])
+
+	var created entity.User
+	assert.NoError(t, db.Where("email = ?", "jane@example.com").First(&created).Error)
+	assert.True(t, created.EmailVerified)
+	assert.Empty(t, created.Password)
+	assert.Equal(t, "Jane Doe", created.Name)
+
+	// A personal organization owned by the user, on the free plan
+	var member entity.OrganizationMember
+	assert.NoError(t, db.Where("user_id = ? AND organization_id = ?", created.ID, created.OrganizationID).First(&member).Error)
+	assert.Equal(t, entity.OrgRoleOwner, member.Role)
+	var subscription entity.Subscription
+	assert.NoError(t, db.Preload("Plan").Where("organization_id = ?", created.OrganizationID).First(&subscription).Error)
+	assert.Equal(t, "free", subscription.Plan.Slug)
+	assert.Equal(t, created.OrganizationID, GetOrganizationID(t, token))
+
+	resp, err := MakeRequest("GET", "/api/v1/auth/identities", "", token)
+	assert.NoError(t, err)
+	identities := ParseResponse(t, resp)["data"].([]interface{})
+	assert.Len(t, identities, 1)
+	assert.Equal(t, "oidc", identities[0].(map[string]interface{})["provider"])
+
+	// Signing in again finds the same user
+	status, _ = LoginOAuth(t, "oidc", user)
+	assert.Equal(t, 200, status)
+	var count int64
+	assert.NoError(t, db.Model(&entity.User{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestOAuth_LinksExistingAccountByEmail(t *testing.T) {
+	CleanupDatabase(t)
+	orgID := GetOrganizationID(t, GetAccessToken(t))
+	CreateTestMember(t, orgID, "member@example.com", entity.OrgRoleMember)
+
+	var member entity.User
+	assert.NoError(t, db.Where("email = ?", "member@example.com").First(&member).Error)
+
+	status, result := LoginOAuth(t, "oauth2", IdentityProviderUser{Subject: "1001", Email: "member@example.com", EmailVerified: true, Name: "member"})
+	assert.Equal(t, 200, status)
+	assert.Equal(t, member.ID, result["data"].(map[string]interface{})["user"].(map[string]interface{})["id"])
+
+	var identity entity.Identity
+	assert.NoError(t, db.Where("provider = ? AND subject = ?", "oauth2", "1001").First(&identity).Error)
+	assert.Equal(t, member.ID, identity.UserID)
+
+	// The verified account keeps its password
+	accessToken, _ := Login(t, "member@example.com", "password123")
+	assert.NotEmpty(t, accessToken)
+}
+
+func TestOAuth_LinkingUnverifiedAccountDropsPassword(t *testing.T) {
+	CleanupDatabase(t)
+	GetAccessToken(t)
+
+	// Whoever registered the address first may not own it: the provider's verification wins
+	status, _ := LoginOAuth(t, "oidc", IdentityProviderUser{Subject: "oidc-user-2", Email: "test@example.com", EmailVerified: true, Name: "Test"})
+	assert.Equal(t, 200, status)
+
+	var user entity.User
+	assert.NoError(t, db.Where("email = ?", "test@example.com").First(&user).Error)
+	assert.True(t, user.EmailVerified)
+
+	resp, err := MakeRequest("POST", "/api/v1/auth/login", `{"email": "test@example.com", "password": "password123"}`, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+}
+
+func TestOAuth_RequiresVerifiedEmail(t *testing.T) {
+	CleanupDatabase(t)
+	CreateTestPlan(t, "free", "Free Plan", 0)
+
+	status, _ := LoginOAuth(t, "oauth2", IdentityProviderUser{Subject: "1002", Email: "unverified@example.com", EmailVerified: false, Name: "unverified"})
+	assert.Equal(t, 403, status)
+
+	var count int64
+	assert.NoError(t, db.Model(&entity.User{}).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestOAuth_RejectsInvalidCallbacks(t *testing.T) {
+	CleanupDatabase(t)
+	CreateTestPlan(t, "free", "Free Plan", 0)
+	user := IdentityProviderUser{Subject: "oidc-user-3", Email: "callback@example.com", EmailVerified: true, Name: "Callback"}
+
+	// Another state
+	authorizationURL, stateToken := AuthorizeOAuth(t, "oidc")
+	code, _, err := identityProvider.Authorize(authorizationURL, user)
+	assert.NoError(t, err)
+	status, _ := finishOAuthLogin(t, "oidc", stateToken, "forged-state", code)
+	assert.Equal(t, 401, status)
+
+	// A state token of another provider
+	authorizationURL, stateToken = AuthorizeOAuth(t, "oidc")
+	code, state, err := identityProvider.Authorize(authorizationURL, user)
+	assert.NoError(t, err)
+	status, _ = finishOAuthLogin(t, "oauth2", stateToken, state, code)
+	assert.Equal(t, 401, status)
+
+	// A tampered state token
+	status, _ = finishOAuthLogin(t, "oidc", stateToken[:len(stateToken)-4]+"AAAA", state, code)
+	assert.Equal(t, 401, status)
+
+	// A code redeemed with the PKCE verifier of another authorization request
+	authorizationURL, _ = AuthorizeOAuth(t, "oidc")
+	code, _, err = identityProvider.Authorize(authorizationURL, user)
+	assert.NoError(t, err)
+	otherAuthorizationURL, otherStateToken := AuthorizeOAuth(t, "oidc")
+	_, otherState, err := identityProvider.Authorize(otherAuthorizationURL, user)
+	assert.NoError(t, err)
+	status, _ = finishOAuthLogin(t, "oidc", otherStateToken, otherState, code)
+	assert.Equal(t, 401, status)
+
+	// Codes are single use
+	authorizationURL, stateToken = AuthorizeOAuth(t, "oidc")
+	code, state, err = identityProvider.Authorize(authorizationURL, user)
+	assert.NoError(t, err)
+	status, _ = finishOAuthLogin(t, "oidc", stateToken, state, code)
+	assert.Equal(t, 200, status)
+	status, _ = finishOAuthLogin(t, "oidc", stateToken, state, code)
+	assert.Equal(t, 401, status)
+}
+
+func TestOAuth_UnlinkIdentity(t *testing.T) {
+	CleanupDatabase(t)
+	CreateTestPlan(t, "free", "Free Plan", 0)
+
+	status, result := LoginOAuth(t, "oidc", IdentityProviderUser{Subject: "oidc-user-4", Email: "unlink@example.com", EmailVerified: true, Name: "Unlink"})
+	assert.Equal(t, 200, status)
+	token := result["data"].(map[string]interface{})["access_token"].(string)
+
+	resp, err := MakeRequest("GET", "/api/v1/auth/identities", "", token)
+	assert.NoError(t, err)
+	oidcID := ParseResponse(t, resp)["data"].([]interface{})[0].(map[string]interface{})["id"].(string)
+
+	// Without a password the last linked account cannot go
+	resp, err = MakeRequest("DELETE", "/api/v1/auth/identities/"+oidcID, "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
+
+	status, _ = LoginOAuth(t, "oauth2", IdentityProviderUser{Subject: "1004", Email: "unlink@example.com", EmailVerified: true, Name: "unlink"})
+	assert.Equal(t, 200, status)
+
+	resp, err = MakeRequest("DELETE", "/api/v1/auth/identities/"+oidcID, "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = MakeRequest("GET", "/api/v1/auth/identities", "", token)
+	assert.NoError(t, err)
+	identities := ParseResponse(t, resp)["data"].([]interface{})
+	assert.Len(t, identities, 1)
+	assert.Equal(t, "oauth2", identities[0].(map[string]interface{})["provider"])
+}
+
+func TestOAuth_UnlinkIdentityKeepingPasskey(t *testing.T) {
+	CleanupDatabase(t)
+	CreateTestPlan(t, "free", "Free Plan", 0)
+
+	status, result := LoginOAuth(t, "oidc", IdentityProviderUser{Subject: "oidc-user-6", Email: "passkey@example.com", EmailVerified: true, Name: "Passkey"})
+	assert.Equal(t, 200, status)
+	token := result["data"].(map[string]interface{})["access_token"].(string)
+
+	resp, err := MakeRequest("GET", "/api/v1/auth/identities", "", token)
+	assert.NoError(t, err)
+	oidcID := ParseResponse(t, resp)["data"].([]interface{})[0].(map[string]interface{})["id"].(string)
+
+	// A passkey is a sign-in method of its own, so the only linked account can go
+	authenticator := RegisterPasskey(t, token)
+	resp, err = MakeRequest("DELETE", "/api/v1/auth/identities/"+oidcID, "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	challengeToken, challenge := BeginPasskeyLogin(t, "passkey@example.com")
+	status, _ = finishPasskeyLogin(t, challengeToken, authenticator.Get(t, challenge, true))
+	assert.Equal(t, 200, status)
+}
+
+func TestOAuth_LoginWithMFA(t *testing.T) {
+	CleanupDatabase(t)
+	orgID := GetOrganizationID(t, GetAccessToken(t))
+	token := CreateTestMember(t, orgID, "mfa@example.com", entity.OrgRoleMember)
+	secret, _ := EnableMFA(t, token)
+
+	status, result := LoginOAuth(t, "oidc", IdentityProviderUser{Subject: "oidc-user-5", Email: "mfa@example.com", EmailVerified: true, Name: "MFA"})
+	assert.Equal(t, 200, status)
+	data := result["data"].(map[string]interface{})
+	assert.Equal(t, true, data["mfa_required"])
+	assert.Nil(t, data["access_token"])
+
+	body := `{"mfa_token": "` + data["mfa_token"].(string) + `", "code": "` + TOTPCode(t, secret, 1) + `"}`
+	resp, err := MakeRequest("POST", "/api/v1/auth/mfa/verify", body, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+}
@@ -0,0 +1,151 @@
+package test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// SetPasswordResetToken stores the hash of a known reset token for the given user
+func SetPasswordResetToken(t *testing.T, email string, token string, expiresAt int64) {
+	sum := sha256.Sum256([]byte(token))
+	err := db.Model(&entity.User{}).Where("email = ?", email).Updates(map[string]interface{}{
+		"password_reset_token":      hex.EncodeToString(sum[:]),
+		"password_reset_expires_at": expiresAt,
+	}).Error
+	assert.Nil(t, err)
+}
+
+func TestForgotPassword_ExistingEmail(t *testing.T) {
+	CleanupDatabase(t)
+	GetAccessToken(t)
+
+	resp, err := MakeRequest("POST", "/api/v1/auth/forgot-password", `{"email": "test@example.com"}`, "")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var forgotResponse model.WebResponse[model.ForgotPasswordResponse]
+	json.NewDecoder(resp.Body).Decode(&forgotResponse)
+	assert.Equal(t, "If the email exists, a password reset link has been sent", forgotResponse.Data.Message)
+
+	// Only the hash is stored
+	var user entity.User
+	err = db.Where("email = ?", "test@example.com").First(&user).Error
+	assert.Nil(t, err)
+	assert.NotNil(t, user.PasswordResetToken)
+	assert.Len(t, *user.PasswordResetToken, 64)
+	assert.NotNil(t, user.PasswordResetExpiresAt)
+}
+
+func TestForgotPassword_NonExistentEmail(t *testing.T) {
+	CleanupDatabase(t)
+
+	resp, err := MakeRequest("POST", "/api/v1/auth/forgot-password", `{"email": "nobody@example.com"}`, "")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var forgotResponse model.WebResponse[model.ForgotPasswordResponse]
+	json.NewDecoder(resp.Body).Decode(&forgotResponse)
+	assert.Equal(t, "If the email exists, a password reset link has been sent", forgotResponse.Data.Message)
+}
+
+func TestResetPassword_Success(t *testing.T) {
+	CleanupDatabase(t)
+	GetAccessToken(t)
+	SetPasswordResetToken(t, "test@example.com", "reset-token", time.Now().Add(time.Hour).UnixMilli())
+
+	resetBody := `{
+		"token": "reset-token",
+		"password": "newpassword123"
+	}`
+
+	resp, err := MakeRequest("POST", "/api/v1/auth/reset-password", resetBody, "")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Old password no longer works, new one does
+	resp, err = MakeRequest("POST", "/api/v1/auth/login", `{"email": "test@example.com", "password": "password123"}`, "")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, err = MakeRequest("POST", "/api/v1/auth/login", `{"email": "test@example.com", "password": "newpassword123"}`, "")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Token is single use
+	resp, err = MakeRequest("POST", "/api/v1/auth/reset-password", resetBody, "")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestResetPassword_RevokesRefreshToken(t *testing.T) {
+	CleanupDatabase(t)
+	GetAccessToken(t)
+
+	resp, err := MakeRequest("POST", "/api/v1/auth/login", `{"email": "test@example.com", "password": "password123"}`, "")
+	assert.Nil(t, err)
+	var loginResponse model.WebResponse[model.LoginResponse]
+	json.NewDecoder(resp.Body).Decode(&loginResponse)
+	refreshToken := loginResponse.Data.RefreshToken
+	assert.NotEmpty(t, refreshToken)
+
+	SetPasswordResetToken(t, "test@example.com", "reset-token", time.Now().Add(time.Hour).UnixMilli())
+
+	resp, err = MakeRequest("POST", "/api/v1/auth/reset-password", `{"token": "reset-token", "password": "newpassword123"}`, "")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = MakeRequest("POST", "/api/v1/auth/refresh", `{"refresh_token": "`+refreshToken+`"}`, "")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestResetPassword_ExpiredToken(t *testing.T) {
+	CleanupDatabase(t)
+	GetAccessToken(t)
+	SetPasswordResetToken(t, "test@example.com", "reset-token", time.Now().Add(-time.Minute).UnixMilli())
+
+	resp, err := MakeRequest("POST", "/api/v1/auth/reset-password", `{"token": "reset-token", "password": "newpassword123"}`, "")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestResetPassword_ConcurrentUseSucceedsOnce(t *testing.T) {
+	CleanupDatabase(t)
+	GetAccessToken(t)
+	SetPasswordResetToken(t, "test@example.com", "reset-token", time.Now().Add(time.Hour).UnixMilli())
+
+	// The same token presented concurrently resets the password once
+	const requests = 5
+	var wg sync.WaitGroup
+	statuses := make([]int, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := MakeRequest("POST", "/api/v1/auth/reset-password", `{"token": "reset-token", "password": "newpassword123"}`, "")
+			if assert.Nil(t, err) {
+				statuses[i] = resp.StatusCode
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, status := range statuses {
+		if status == http.StatusOK {
+			succeeded++
+		} else {
+			assert.Equal(t, http.StatusBadRequest, status)
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+}
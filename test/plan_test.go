@@ -0,0 +1,81 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListPlans_Success(t *testing.T) {
+	CleanupDatabase(t)
+
+	CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	CreateTestPlan(t, "free", "Free Plan", 0)
+
+	// No token required
+	resp, err := MakeRequest("GET", "/api/v1/plans", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Cache-Control"), "max-age")
+	assert.NotEmpty(t, resp.Header.Get("ETag"))
+
+	result := ParseResponse(t, resp)
+	data := result["data"].([]interface{})
+	assert.Len(t, data, 2)
+
+	// Cheapest first
+	assert.Equal(t, "free", data[0].(map[string]interface{})["slug"])
+	assert.Equal(t, "pro", data[1].(map[string]interface{})["slug"])
+}
+
+func TestListPlans_NotModified(t *testing.T) {
+	CleanupDatabase(t)
+
+	CreateTestPlan(t, "free", "Free Plan", 0)
+
+	resp, err := MakeRequest("GET", "/api/v1/plans", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	etag := resp.Header.Get("ETag")
+
+	req, err := http.NewRequest("GET", "/api/v1/plans", nil)
+	assert.NoError(t, err)
+	req.Header.Set("If-None-Match", etag)
+
+	resp, err = app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	// Catalog change produces a new ETag
+	CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+
+	resp, err = app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.NotEqual(t, etag, resp.Header.Get("ETag"))
+}
+
+func TestGetPlan_Success(t *testing.T) {
+	CleanupDatabase(t)
+
+	CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+
+	resp, err := MakeRequest("GET", "/api/v1/plans/pro", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	result := ParseResponse(t, resp)
+	data := result["data"].(map[string]interface{})
+	assert.Equal(t, "pro", data["slug"])
+	assert.Equal(t, "Pro Plan", data["name"])
+	assert.Equal(t, float64(29), data["price"])
+}
+
+func TestGetPlan_NotFound(t *testing.T) {
+	CleanupDatabase(t)
+
+	resp, err := MakeRequest("GET", "/api/v1/plans/unknown", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+}
@@ -0,0 +1,123 @@
+package test
+
+import (
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/model"
+	"go-clean-arch-saas/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// subscriptionHalfway returns a subscription on the plan whose 30-day period is half over at now
+func subscriptionHalfway(plan *entity.Plan, now time.Time) *entity.Subscription {
+	return &entity.Subscription{
+		PlanID:             plan.ID,
+		Plan:               *plan,
+		CurrentPeriodStart: now.Add(-15 * 24 * time.Hour).UnixMilli(),
+		CurrentPeriodEnd:   now.Add(15 * 24 * time.Hour).UnixMilli(),
+	}
+}
+
+func TestCalculateProration_Upgrade(t *testing.T) {
+	now := time.Now()
+	pro := &entity.Plan{ID: "pro", Price: 29, BillingPeriod: entity.BillingPeriodMonthly}
+	enterprise := &entity.Plan{ID: "enterprise", Price: 99, BillingPeriod: entity.BillingPeriodMonthly}
+
+	proration := usecase.CalculateProration(subscriptionHalfway(pro, now), enterprise, now)
+
+	assert.Equal(t, model.PlanChangeUpgrade, proration.Change)
+	assert.Equal(t, now.UnixMilli(), proration.EffectiveAt)
+	assert.Equal(t, 0.5, proration.RemainingFraction)
+	assert.Equal(t, 14.5, proration.Credit)
+	assert.Equal(t, 99.0, proration.Charge)
+	assert.Equal(t, 84.5, proration.AmountDue)
+	assert.Equal(t, 0.0, proration.CreditBalance)
+}
+
+func TestCalculateProration_Downgrade(t *testing.T) {
+	now := time.Now()
+	pro := &entity.Plan{ID: "pro", Price: 29, BillingPeriod: entity.BillingPeriodMonthly}
+	basic := &entity.Plan{ID: "basic", Price: 9, BillingPeriod: entity.BillingPeriodMonthly}
+	subscription := subscriptionHalfway(pro, now)
+
+	proration := usecase.CalculateProration(subscription, basic, now)
+
+	assert.Equal(t, model.PlanChangeDowngrade, proration.Change)
+	assert.Equal(t, subscription.CurrentPeriodEnd, proration.EffectiveAt)
+	assert.Equal(t, 0.0, proration.Credit)
+	assert.Equal(t, 0.0, proration.AmountDue)
+}
+
+func TestCalculateProration_ComparesMonthlyPrice(t *testing.T) {
+	now := time.Now()
+	yearly := &entity.Plan{ID: "pro-yearly", Price: 290, BillingPeriod: entity.BillingPeriodYearly}
+	monthly := &entity.Plan{ID: "pro", Price: 29, BillingPeriod: entity.BillingPeriodMonthly}
+
+	// 290 a year is cheaper than 29 a month
+	proration := usecase.CalculateProration(subscriptionHalfway(monthly, now), yearly, now)
+	assert.Equal(t, model.PlanChangeDowngrade, proration.Change)
+
+	// Credit beyond the new price is reported as a balance
+	proration = usecase.CalculateProration(subscriptionHalfway(yearly, now), monthly, now)
+	assert.Equal(t, model.PlanChangeUpgrade, proration.Change)
+	assert.Equal(t, 145.0, proration.Credit)
+	assert.Equal(t, 0.0, proration.AmountDue)
+	assert.Equal(t, 116.0, proration.CreditBalance)
+}
+
+func TestPreviewPlanChange_Success(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	token := GetAccessToken(t)
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/preview-change", `{"plan_id": "`+proPlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	assert.Equal(t, model.PlanChangeUpgrade, data["change"])
+	assert.Equal(t, proPlan.ID, data["new_plan_id"])
+	assert.Equal(t, float64(0), data["credit"])
+	assert.Equal(t, float64(29), data["amount_due"])
+
+	// Previewing changes nothing
+	resp, err = MakeRequest("GET", "/api/v1/subscriptions/current", "", token)
+	assert.NoError(t, err)
+	plan := ParseResponse(t, resp)["data"].(map[string]interface{})["plan"].(map[string]interface{})
+	assert.Equal(t, "free", plan["slug"])
+}
+
+func TestPreviewPlanChange_CurrentPlan(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+
+	var free entity.Plan
+	assert.NoError(t, db.Where("slug = ?", "free").First(&free).Error)
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/preview-change", `{"plan_id": "`+free.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
+}
+
+func TestPreviewPlanChange_MemberForbidden(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	token := GetAccessToken(t)
+	orgID := GetOrganizationID(t, token)
+	memberToken := CreateTestMember(t, orgID, "member@example.com", entity.OrgRoleMember)
+	adminToken := CreateTestMember(t, orgID, "admin@example.com", entity.OrgRoleAdmin)
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/preview-change", `{"plan_id": "`+proPlan.ID+`"}`, memberToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+
+	// Plan changes are owner-only, previews included
+	resp, err = MakeRequest("POST", "/api/v1/subscriptions/preview-change", `{"plan_id": "`+proPlan.ID+`"}`, adminToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+}
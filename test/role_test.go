@@ -0,0 +1,90 @@
+package test
+
+import (
+	"go-clean-arch-saas/internal/entity"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrgRole_MemberCannotUpdateOrganization(t *testing.T) {
+	CleanupDatabase(t)
+
+	ownerToken := GetAccessToken(t)
+	orgID := GetOrganizationID(t, ownerToken)
+	memberToken := CreateTestMember(t, orgID, "member@example.com", entity.OrgRoleMember)
+
+	requestBody := `{
+		"name": "Renamed By Member"
+	}`
+
+	resp, err := MakeRequest("PATCH", "/api/v1/organizations/current", requestBody, memberToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+}
+
+func TestOrgRole_AdminCanUpdateOrganization(t *testing.T) {
+	CleanupDatabase(t)
+
+	ownerToken := GetAccessToken(t)
+	orgID := GetOrganizationID(t, ownerToken)
+	adminToken := CreateTestMember(t, orgID, "admin@example.com", entity.OrgRoleAdmin)
+
+	requestBody := `{
+		"name": "Renamed By Admin"
+	}`
+
+	resp, err := MakeRequest("PATCH", "/api/v1/organizations/current", requestBody, adminToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestOrgRole_MemberCannotRemoveMember(t *testing.T) {
+	CleanupDatabase(t)
+
+	ownerToken := GetAccessToken(t)
+	orgID := GetOrganizationID(t, ownerToken)
+	memberToken := CreateTestMember(t, orgID, "member@example.com", entity.OrgRoleMember)
+	CreateTestMember(t, orgID, "other@example.com", entity.OrgRoleMember)
+
+	resp, err := MakeRequest("GET", "/api/v1/users/current", "", ownerToken)
+	assert.NoError(t, err)
+	ownerID := ParseResponse(t, resp)["data"].(map[string]interface{})["id"].(string)
+
+	resp, err = MakeRequest("DELETE", "/api/v1/organizations/members/"+ownerID, "", memberToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+}
+
+func TestOrgRole_AdminCannotCancelSubscription(t *testing.T) {
+	CleanupDatabase(t)
+
+	ownerToken := GetAccessToken(t)
+	orgID := GetOrganizationID(t, ownerToken)
+	adminToken := CreateTestMember(t, orgID, "admin@example.com", entity.OrgRoleAdmin)
+	memberToken := CreateTestMember(t, orgID, "member@example.com", entity.OrgRoleMember)
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/cancel", "", memberToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+
+	resp, err = MakeRequest("POST", "/api/v1/subscriptions/cancel", "", adminToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+	resp, err = MakeRequest("POST", "/api/v1/subscriptions/cancel", `{"immediately": true}`, adminToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+
+	// Owner can still cancel
+	resp, err = MakeRequest("POST", "/api/v1/subscriptions/cancel", "", ownerToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	// Undoing it is owner-only too
+	resp, err = MakeRequest("POST", "/api/v1/subscriptions/resume", "", adminToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+	resp, err = MakeRequest("POST", "/api/v1/subscriptions/resume", "", ownerToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+}
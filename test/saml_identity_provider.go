@@ -0,0 +1,182 @@
+package test
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/big"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Entity ID and single sign-on URL of the test SAML identity provider
+const (
+	SAMLIdentityProviderEntityID = "https://idp.example.test/saml"
+	SAMLIdentityProviderSSOURL   = "https://idp.example.test/saml/sso"
+)
+
+const (
+	samlAssertionNamespace = "urn:oasis:names:tc:SAML:2.0:assertion"
+	samlProtocolNamespace  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	samlDSigNamespace      = "http://www.w3.org/2000/09/xmldsig#"
+	samlExcC14N            = "http://www.w3.org/2001/10/xml-exc-c14n#"
+)
+
+var samlIDPattern = regexp.MustCompile(`\sID="([^"]+)"`)
+
+// SAMLAssertion is what the test SAML identity provider asserts in a response
+type SAMLAssertion struct {
+	RequestID string
+	Recipient string // ACS URL of the service provider
+	Audience  string // entity ID of the service provider
+	NameID    string
+	Email     string
+	Name      string
+}
+
+// SAMLIdentityProvider is a SAML 2.0 identity provider for tests. It writes its assertions in canonical form, so
+// they can be signed without an XML canonicalization library.
+type SAMLIdentityProvider struct {
+	key         *rsa.PrivateKey
+	certificate []byte
+}
+
+func NewSAMLIdentityProvider() *SAMLIdentityProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp.example.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		panic(err)
+	}
+
+	return &SAMLIdentityProvider{key: key, certificate: certificate}
+}
+
+// Metadata returns the metadata organizations configure their SAML connection with
+func (p *SAMLIdentityProvider) Metadata() string {
+	return `<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" xmlns:ds="` + samlDSigNamespace + `" entityID="` + SAMLIdentityProviderEntityID + `">` +
+		`<md:IDPSSODescriptor protocolSupportEnumeration="` + samlProtocolNamespace + `">` +
+		`<md:KeyDescriptor use="signing"><ds:KeyInfo><ds:X509Data><ds:X509Certificate>` + base64.StdEncoding.EncodeToString(p.certificate) + `</ds:X509Certificate></ds:X509Data></ds:KeyInfo></md:KeyDescriptor>` +
+		`<md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="` + SAMLIdentityProviderSSOURL + `"/>` +
+		`</md:IDPSSODescriptor></md:EntityDescriptor>`
+}
+
+// ParseRequest reads the request ID and relay state of an HTTP-Redirect authorization URL
+func (p *SAMLIdentityProvider) ParseRequest(authorizationURL string) (string, string, error) {
+	parsed, err := url.Parse(authorizationURL)
+	if err != nil {
+		return "", "", err
+	}
+	deflated, err := base64.StdEncoding.DecodeString(parsed.Query().Get("SAMLRequest"))
+	if err != nil {
+		return "", "", err
+	}
+	request, err := io.ReadAll(flate.NewReader(bytes.NewReader(deflated)))
+	if err != nil {
+		return "", "", err
+	}
+
+	match := samlIDPattern.FindSubmatch(request)
+	if match == nil {
+		return "", "", errors.New("authentication request has no ID")
+	}
+	return string(match[1]), parsed.Query().Get("RelayState"), nil
+}
+
+// Response returns a response with the assertion, signed by the identity provider. Values are written as is, so
+// they must not need escaping.
+func (p *SAMLIdentityProvider) Response(assertion SAMLAssertion) string {
+	assertionID, head, body := p.assertion(assertion)
+	responseHead, responseBody := p.response(assertion)
+	return responseHead + head + p.signature(assertionID, head+body) + body + responseBody
+}
+
+// SignedResponse returns a response signed as a whole by the identity provider, with an unsigned assertion
+func (p *SAMLIdentityProvider) SignedResponse(assertion SAMLAssertion) string {
+	_, head, body := p.assertion(assertion)
+	responseHead, responseBody := p.response(assertion)
+
+	// The signature goes after the issuer, the first child of the response
+	responseID := samlIDPattern.FindStringSubmatch(responseHead)[1]
+	issuerEnd := strings.Index(responseHead, "</saml:Issuer>") + len("</saml:Issuer>")
+	signature := p.signature(responseID, responseHead+head+body+responseBody)
+	return responseHead[:issuerEnd] + signature + responseHead[issuerEnd:] + head + body + responseBody
+}
+
+// assertion returns the ID of an assertion and its parts before and after the signature. Each part is in
+// canonical form: attributes sorted, no whitespace and explicit end tags.
+func (p *SAMLIdentityProvider) assertion(assertion SAMLAssertion) (string, string, string) {
+	now := time.Now().UTC()
+	assertionID := "_" + randomHex()
+
+	head := `<saml:Assertion xmlns:saml="` + samlAssertionNamespace + `" ID="` + assertionID + `" IssueInstant="` + now.Format(time.RFC3339) + `" Version="2.0">` +
+		`<saml:Issuer>` + SAMLIdentityProviderEntityID + `</saml:Issuer>`
+	body := `<saml:Subject>` +
+		`<saml:NameID Format="urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress">` + assertion.NameID + `</saml:NameID>` +
+		`<saml:SubjectConfirmation Method="urn:oasis:names:tc:SAML:2.0:cm:bearer">` +
+		`<saml:SubjectConfirmationData InResponseTo="` + assertion.RequestID + `" NotOnOrAfter="` + now.Add(5*time.Minute).Format(time.RFC3339) + `" Recipient="` + assertion.Recipient + `"></saml:SubjectConfirmationData>` +
+		`</saml:SubjectConfirmation></saml:Subject>` +
+		`<saml:Conditions NotBefore="` + now.Add(-time.Minute).Format(time.RFC3339) + `" NotOnOrAfter="` + now.Add(5*time.Minute).Format(time.RFC3339) + `">` +
+		`<saml:AudienceRestriction><saml:Audience>` + assertion.Audience + `</saml:Audience></saml:AudienceRestriction></saml:Conditions>` +
+		`<saml:AttributeStatement>` +
+		`<saml:Attribute Name="email"><saml:AttributeValue>` + assertion.Email + `</saml:AttributeValue></saml:Attribute>` +
+		`<saml:Attribute Name="displayName"><saml:AttributeValue>` + assertion.Name + `</saml:AttributeValue></saml:Attribute>` +
+		`</saml:AttributeStatement></saml:Assertion>`
+	return assertionID, head, body
+}
+
+// response returns the parts of a response before and after its assertion, in canonical form like assertions
+func (p *SAMLIdentityProvider) response(assertion SAMLAssertion) (string, string) {
+	head := `<samlp:Response xmlns:samlp="` + samlProtocolNamespace + `" Destination="` + assertion.Recipient +
+		`" ID="_` + randomHex() + `" InResponseTo="` + assertion.RequestID + `" IssueInstant="` + time.Now().UTC().Format(time.RFC3339) + `" Version="2.0">` +
+		`<saml:Issuer xmlns:saml="` + samlAssertionNamespace + `">` + SAMLIdentityProviderEntityID + `</saml:Issuer>` +
+		`<samlp:Status><samlp:StatusCode Value="urn:oasis:names:tc:SAML:2.0:status:Success"></samlp:StatusCode></samlp:Status>`
+	return head, `</samlp:Response>`
+}
+
+// signature returns an enveloped signature of the element with the ID, given in canonical form without it
+func (p *SAMLIdentityProvider) signature(id string, canonical string) string {
+	digest := sha256.Sum256([]byte(canonical))
+	signedInfo := `<ds:CanonicalizationMethod Algorithm="` + samlExcC14N + `"></ds:CanonicalizationMethod>` +
+		`<ds:SignatureMethod Algorithm="http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"></ds:SignatureMethod>` +
+		`<ds:Reference URI="#` + id + `"><ds:Transforms>` +
+		`<ds:Transform Algorithm="http://www.w3.org/2000/09/xmldsig#enveloped-signature"></ds:Transform>` +
+		`<ds:Transform Algorithm="` + samlExcC14N + `"></ds:Transform>` +
+		`</ds:Transforms><ds:DigestMethod Algorithm="http://www.w3.org/2001/04/xmlenc#sha256"></ds:DigestMethod>` +
+		`<ds:DigestValue>` + base64.StdEncoding.EncodeToString(digest[:]) + `</ds:DigestValue></ds:Reference>`
+
+	// Canonicalized on its own, SignedInfo declares the namespace its ancestor declares in the document
+	hashed := sha256.Sum256([]byte(`<ds:SignedInfo xmlns:ds="` + samlDSigNamespace + `">` + signedInfo + `</ds:SignedInfo>`))
+	signatureValue, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, hashed[:])
+	if err != nil {
+		panic(err)
+	}
+	return `<ds:Signature xmlns:ds="` + samlDSigNamespace + `"><ds:SignedInfo>` + signedInfo + `</ds:SignedInfo>` +
+		`<ds:SignatureValue>` + base64.StdEncoding.EncodeToString(signatureValue) + `</ds:SignatureValue></ds:Signature>`
+}
+
+func randomHex() string {
+	data := make([]byte, 16)
+	rand.Read(data)
+	return hex.EncodeToString(data)
+}
@@ -0,0 +1,150 @@
+package test
+
+import (
+	"context"
+	"go-clean-arch-saas/internal/delivery/scheduler"
+	"go-clean-arch-saas/internal/entity"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// EndSubscriptionPeriod moves the organization's subscriptions in the status to a period that ended an hour ago
+func EndSubscriptionPeriod(t *testing.T, orgID string, status string) {
+	now := time.Now()
+	err := db.Model(&entity.Subscription{}).
+		Where("organization_id = ? AND status = ?", orgID, status).
+		Updates(map[string]interface{}{
+			"current_period_start": now.AddDate(0, -1, 0).Add(-time.Hour).UnixMilli(),
+			"current_period_end":   now.Add(-time.Hour).UnixMilli(),
+		}).Error
+	assert.NoError(t, err)
+}
+
+func TestScheduler_RenewsEndedPeriod(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+	orgID := GetOrganizationID(t, token)
+	EndSubscriptionPeriod(t, orgID, entity.SubscriptionStatusActive)
+
+	assert.True(t, jobs.RunOnce(context.Background()))
+
+	var subscription entity.Subscription
+	err := db.Where("organization_id = ?", orgID).First(&subscription).Error
+	assert.NoError(t, err)
+	assert.Equal(t, entity.SubscriptionStatusActive, subscription.Status)
+	assert.Greater(t, subscription.CurrentPeriodEnd, time.Now().UnixMilli())
+	assert.Less(t, subscription.CurrentPeriodStart, time.Now().UnixMilli())
+
+	assert.Len(t, FindAuditLogs(t, entity.AuditActionSubscriptionRenew), 1)
+}
+
+func TestScheduler_AppliesScheduledDowngrade(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, "pm_card_visa")
+	orgID := GetOrganizationID(t, token)
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	basicPlan := CreateTestPlan(t, "basic", "Basic Plan", 9.00)
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+basicPlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	EndSubscriptionPeriod(t, orgID, entity.SubscriptionStatusActive)
+	assert.True(t, jobs.RunOnce(context.Background()))
+
+	var subscription entity.Subscription
+	err = db.Where("organization_id = ? AND status = ?", orgID, entity.SubscriptionStatusActive).First(&subscription).Error
+	assert.NoError(t, err)
+	assert.Equal(t, basicPlan.ID, subscription.PlanID)
+	assert.Nil(t, subscription.ScheduledPlanID)
+	assert.Greater(t, subscription.CurrentPeriodEnd, time.Now().UnixMilli())
+}
+
+func TestScheduler_ExpiresCancelledAndFallsBackToFree(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, "pm_card_visa")
+	orgID := GetOrganizationID(t, token)
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = MakeRequest("POST", "/api/v1/subscriptions/cancel", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	EndSubscriptionPeriod(t, orgID, entity.SubscriptionStatusActive)
+	EndSubscriptionPeriod(t, orgID, entity.SubscriptionStatusCancelled)
+	assert.True(t, jobs.RunOnce(context.Background()))
+
+	var count int64
+	db.Model(&entity.Subscription{}).Where("organization_id = ? AND status = ?", orgID, entity.SubscriptionStatusExpired).Count(&count)
+	assert.Equal(t, int64(2), count) // free subscription replaced by the upgrade, and the pro subscription cancelled at period end
+
+	resp, err = MakeRequest("GET", "/api/v1/subscriptions/current", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	plan := ParseResponse(t, resp)["data"].(map[string]interface{})["plan"].(map[string]interface{})
+	assert.Equal(t, "free", plan["slug"])
+}
+
+func TestScheduler_SkipsWhenLockHeld(t *testing.T) {
+	CleanupDatabase(t)
+
+	tx := db.Begin()
+	defer tx.Rollback()
+
+	// Another replica holds the leader lock
+	var locked bool
+	err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", 7236001).Scan(&locked).Error
+	assert.NoError(t, err)
+	assert.True(t, locked)
+
+	assert.False(t, jobs.RunOnce(context.Background()))
+}
+
+func TestScheduler_StopLetsRunningJobFinish(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	jobErr := make(chan error, 1)
+	runner := scheduler.NewScheduler(db, log, time.Hour, scheduler.Job{
+		Name: "blocking",
+		Run: func(ctx context.Context) (int, error) {
+			close(started)
+			<-release
+			jobErr <- ctx.Err()
+			return 0, nil
+		},
+	})
+	runner.Start()
+	<-started
+
+	stopped := make(chan struct{})
+	go func() {
+		runner.Stop()
+		close(stopped)
+	}()
+
+	// Stop waits for the job instead of cancelling it
+	select {
+	case <-stopped:
+		t.Fatal("Stop returned before the running job finished")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	<-stopped
+	assert.NoError(t, <-jobErr)
+}
@@ -0,0 +1,166 @@
+package test
+
+import (
+	"context"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// CreatePerSeatPlan creates a test plan billed per organization member
+func CreatePerSeatPlan(t *testing.T, slug string, name string, price float64) *entity.Plan {
+	plan := CreateTestPlan(t, slug, name, price)
+
+	err := db.Model(plan).Update("pricing_model", entity.PricingModelPerSeat).Error
+	assert.NoError(t, err)
+
+	return plan
+}
+
+// JoinByInvitation accepts a new invitation to the organization as a new user and returns the user's ID
+func JoinByInvitation(t *testing.T, orgID string, email string) string {
+	CreateTestInvitation(t, orgID, email, entity.OrgRoleMember, "token-"+email)
+
+	requestBody := `{"token": "token-` + email + `", "name": "Invitee", "password": "password123"}`
+	resp, err := MakeRequest("POST", "/api/v1/organizations/invitations/accept", requestBody, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	return data["user"].(map[string]interface{})["id"].(string)
+}
+
+// FindCurrentSubscription returns the organization's active subscription
+func FindCurrentSubscription(t *testing.T, orgID string) *entity.Subscription {
+	subscription := new(entity.Subscription)
+	err := db.Where("organization_id = ? AND status = ?", orgID, entity.SubscriptionStatusActive).Preload("Plan").First(subscription).Error
+	assert.NoError(t, err)
+	return subscription
+}
+
+func TestPlanAmount(t *testing.T) {
+	flat := &entity.Plan{Price: 99, PricingModel: entity.PricingModelFlat}
+	perSeat := &entity.Plan{Price: 29, PricingModel: entity.PricingModelPerSeat}
+
+	assert.Equal(t, 99.0, usecase.PlanAmount(flat, 5))
+	assert.Equal(t, 145.0, usecase.PlanAmount(perSeat, 5))
+	assert.Equal(t, 29.0, usecase.PlanAmount(perSeat, 0))
+
+	// Enough seats make a per-seat plan cost more than a flat one
+	assert.False(t, usecase.IsDowngrade(perSeat, flat, 3))
+	assert.True(t, usecase.IsDowngrade(perSeat, flat, 4))
+}
+
+func TestSeatProrationItem(t *testing.T) {
+	now := time.Now()
+	pro := &entity.Plan{ID: "pro", Name: "Pro", Price: 30, PricingModel: entity.PricingModelPerSeat, BillingPeriod: entity.BillingPeriodMonthly}
+	subscription := subscriptionHalfway(pro, now)
+	subscription.Status = entity.SubscriptionStatusActive
+	subscription.Quantity = 2
+
+	added := usecase.SeatProrationItem(subscription, 4, now)
+	assert.Equal(t, entity.InvoiceLineKindSeats, added.Kind)
+	assert.Equal(t, int64(2), added.Quantity)
+	assert.Equal(t, 15.0, added.UnitAmount)
+	assert.Equal(t, 30.0, added.Amount)
+	assert.Equal(t, subscription.CurrentPeriodEnd, added.PeriodEnd)
+
+	removed := usecase.SeatProrationItem(subscription, 1, now)
+	assert.Equal(t, int64(-1), removed.Quantity)
+	assert.Equal(t, -15.0, removed.Amount)
+
+	// Flat plans and trials are not prorated per seat
+	subscription.Status = entity.SubscriptionStatusTrialing
+	assert.Nil(t, usecase.SeatProrationItem(subscription, 4, now))
+	subscription.Status = entity.SubscriptionStatusActive
+	subscription.Plan.PricingModel = entity.PricingModelFlat
+	assert.Nil(t, usecase.SeatProrationItem(subscription, 4, now))
+}
+
+func TestSeats_UpgradeChargesPerMember(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreatePerSeatPlan(t, "pro", "Pro Plan", 29.00)
+	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, "pm_card_visa")
+	orgID := GetOrganizationID(t, token)
+	JoinByInvitation(t, orgID, "second@example.com")
+
+	// The free plan is flat, so joining only updates the quantity
+	assert.Equal(t, 2, FindCurrentSubscription(t, orgID).Quantity)
+	var pending int64
+	assert.NoError(t, db.Model(&entity.PendingInvoiceItem{}).Count(&pending).Error)
+	assert.Equal(t, int64(0), pending)
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	assert.Equal(t, 2.0, data["quantity"])
+	assert.Equal(t, entity.PricingModelPerSeat, data["plan"].(map[string]interface{})["pricing_model"])
+
+	invoices := FindInvoices(t, orgID)
+	assert.Len(t, invoices, 1)
+	assert.Equal(t, 58.0, invoices[0].AmountDue)
+	assert.Equal(t, int64(2), invoices[0].LineItems[0].Quantity)
+	assert.Equal(t, 29.0, invoices[0].LineItems[0].UnitAmount)
+}
+
+func TestSeats_MemberChangesProratedOnRenewal(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreatePerSeatPlan(t, "pro", "Pro Plan", 29.00)
+	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, "pm_card_visa")
+	orgID := GetOrganizationID(t, token)
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	// Joining charges the new seat for the rest of the period
+	userID := JoinByInvitation(t, orgID, "second@example.com")
+	subscription := FindCurrentSubscription(t, orgID)
+	assert.Equal(t, 2, subscription.Quantity)
+
+	var items []entity.PendingInvoiceItem
+	assert.NoError(t, db.Where("subscription_id = ?", subscription.ID).Order("created_at ASC").Find(&items).Error)
+	assert.Len(t, items, 1)
+	assert.Equal(t, int64(1), items[0].Quantity)
+	assert.Greater(t, items[0].Amount, 28.0)
+
+	// Removing the member credits the seat back
+	resp, err = MakeRequest("DELETE", "/api/v1/organizations/members/"+userID, "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, 1, FindCurrentSubscription(t, orgID).Quantity)
+
+	assert.NoError(t, db.Where("subscription_id = ?", subscription.ID).Order("created_at ASC").Find(&items).Error)
+	assert.Len(t, items, 2)
+	assert.Equal(t, int64(-1), items[1].Quantity)
+	assert.Less(t, items[1].Amount, 0.0)
+	assert.Len(t, FindAuditLogs(t, entity.AuditActionSubscriptionQuantity), 2)
+
+	// The renewal invoice carries both seat changes and clears them
+	EndSubscriptionPeriod(t, orgID, entity.SubscriptionStatusActive)
+	assert.True(t, jobs.RunOnce(context.Background()))
+
+	invoices := FindInvoices(t, orgID)
+	assert.Len(t, invoices, 2)
+	seats := 0
+	for _, item := range invoices[1].LineItems {
+		if item.Kind == entity.InvoiceLineKindSeats {
+			seats++
+		}
+	}
+	assert.Equal(t, 2, seats)
+	assert.InDelta(t, 29.0, invoices[1].AmountDue, 0.1)
+
+	var pending int64
+	assert.NoError(t, db.Model(&entity.PendingInvoiceItem{}).Count(&pending).Error)
+	assert.Equal(t, int64(0), pending)
+}
@@ -0,0 +1,235 @@
+package test
+
+import (
+	"fmt"
+	"go-clean-arch-saas/internal/config"
+	"go-clean-arch-saas/internal/entity"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Login logs in the test user and returns the access and refresh tokens
+func Login(t *testing.T, email string, password string) (string, string) {
+	loginBody := fmt.Sprintf(`{"email": "%s", "password": "%s"}`, email, password)
+
+	resp, err := MakeRequest("POST", "/api/v1/auth/login", loginBody, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	result := ParseResponse(t, resp)
+	data := result["data"].(map[string]interface{})
+
+	return data["access_token"].(string), data["refresh_token"].(string)
+}
+
+func TestRefreshToken_Rotation(t *testing.T) {
+	CleanupDatabase(t)
+	GetAccessToken(t)
+
+	_, refreshToken := Login(t, "test@example.com", "password123")
+
+	resp, err := MakeRequest("POST", "/api/v1/auth/refresh", fmt.Sprintf(`{"refresh_token": "%s"}`, refreshToken), "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	result := ParseResponse(t, resp)
+	data := result["data"].(map[string]interface{})
+	rotatedToken := data["refresh_token"].(string)
+	assert.NotEmpty(t, rotatedToken)
+	assert.NotEqual(t, refreshToken, rotatedToken)
+
+	// Rotated token keeps working
+	resp, err = MakeRequest("POST", "/api/v1/auth/refresh", fmt.Sprintf(`{"refresh_token": "%s"}`, rotatedToken), "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestRefreshToken_ReuseRevokesFamily(t *testing.T) {
+	CleanupDatabase(t)
+	GetAccessToken(t)
+
+	_, refreshToken := Login(t, "test@example.com", "password123")
+
+	resp, err := MakeRequest("POST", "/api/v1/auth/refresh", fmt.Sprintf(`{"refresh_token": "%s"}`, refreshToken), "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	rotatedToken := ParseResponse(t, resp)["data"].(map[string]interface{})["refresh_token"].(string)
+
+	// Replay the old token
+	resp, err = MakeRequest("POST", "/api/v1/auth/refresh", fmt.Sprintf(`{"refresh_token": "%s"}`, refreshToken), "")
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+
+	// The legitimate successor is revoked as well
+	resp, err = MakeRequest("POST", "/api/v1/auth/refresh", fmt.Sprintf(`{"refresh_token": "%s"}`, rotatedToken), "")
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+}
+
+func TestRefreshToken_ConcurrentReuseRevokesFamily(t *testing.T) {
+	CleanupDatabase(t)
+	GetAccessToken(t)
+
+	_, refreshToken := Login(t, "test@example.com", "password123")
+
+	// The same token presented concurrently rotates at most once; the other requests are reuse
+	const requests = 5
+	var wg sync.WaitGroup
+	statuses := make([]int, requests)
+	rotatedTokens := make([]string, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := MakeRequest("POST", "/api/v1/auth/refresh", fmt.Sprintf(`{"refresh_token": "%s"}`, refreshToken), "")
+			if !assert.NoError(t, err) {
+				return
+			}
+			statuses[i] = resp.StatusCode
+			if resp.StatusCode == 200 {
+				rotatedTokens[i] = ParseResponse(t, resp)["data"].(map[string]interface{})["refresh_token"].(string)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for i, status := range statuses {
+		if status == 200 {
+			succeeded++
+			// The family was revoked by the concurrent reuse, so the token issued by the winner is dead too
+			resp, err := MakeRequest("POST", "/api/v1/auth/refresh", fmt.Sprintf(`{"refresh_token": "%s"}`, rotatedTokens[i]), "")
+			assert.NoError(t, err)
+			assert.Equal(t, 401, resp.StatusCode)
+		} else {
+			assert.Equal(t, 401, status)
+		}
+	}
+	assert.LessOrEqual(t, succeeded, 1)
+}
+
+func TestSessions_MultipleDevices(t *testing.T) {
+	CleanupDatabase(t)
+	GetAccessToken(t)
+
+	firstAccess, firstRefresh := Login(t, "test@example.com", "password123")
+	Login(t, "test@example.com", "password123")
+
+	// Second login does not log out the first device
+	resp, err := MakeRequest("POST", "/api/v1/auth/refresh", fmt.Sprintf(`{"refresh_token": "%s"}`, firstRefresh), "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = MakeRequest("GET", "/api/v1/auth/sessions", "", firstAccess)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	sessions := ParseResponse(t, resp)["data"].([]interface{})
+	// GetAccessToken also logged in once
+	assert.Len(t, sessions, 3)
+
+	var currentCount int
+	var otherID string
+	for _, item := range sessions {
+		session := item.(map[string]interface{})
+		if session["current"].(bool) {
+			currentCount++
+		} else {
+			otherID = session["id"].(string)
+		}
+	}
+	assert.Equal(t, 1, currentCount)
+	assert.NotEmpty(t, otherID)
+
+	resp, err = MakeRequest("DELETE", "/api/v1/auth/sessions/"+otherID, "", firstAccess)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = MakeRequest("GET", "/api/v1/auth/sessions", "", firstAccess)
+	assert.NoError(t, err)
+	assert.Len(t, ParseResponse(t, resp)["data"].([]interface{}), 2)
+}
+
+func TestSessions_RevokeNotFound(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+
+	resp, err := MakeRequest("DELETE", "/api/v1/auth/sessions/00000000-0000-0000-0000-000000000000", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+}
+
+func TestLogout_RevokesCurrentSession(t *testing.T) {
+	CleanupDatabase(t)
+	GetAccessToken(t)
+
+	accessToken, refreshToken := Login(t, "test@example.com", "password123")
+
+	resp, err := MakeRequest("DELETE", "/api/v1/auth/logout", "", accessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = MakeRequest("POST", "/api/v1/auth/refresh", fmt.Sprintf(`{"refresh_token": "%s"}`, refreshToken), "")
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+
+	// The access token of the revoked session stops working before it expires
+	resp, err = MakeRequest("GET", "/api/v1/users/current", "", accessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+}
+
+func TestSessions_RevokeInvalidatesAccessToken(t *testing.T) {
+	CleanupDatabase(t)
+	token := GetAccessToken(t)
+
+	otherAccessToken, otherRefreshToken := Login(t, "test@example.com", "password123")
+
+	// Rotation keeps the session, so access tokens issued before it stay valid
+	resp, err := MakeRequest("POST", "/api/v1/auth/refresh", fmt.Sprintf(`{"refresh_token": "%s"}`, otherRefreshToken), "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	resp, err = MakeRequest("GET", "/api/v1/users/current", "", otherAccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = MakeRequest("GET", "/api/v1/auth/sessions", "", token)
+	assert.NoError(t, err)
+	var otherSessionID string
+	for _, session := range ParseResponse(t, resp)["data"].([]interface{}) {
+		if session := session.(map[string]interface{}); session["current"] != true {
+			otherSessionID = session["id"].(string)
+		}
+	}
+	assert.NotEmpty(t, otherSessionID)
+
+	resp, err = MakeRequest("DELETE", "/api/v1/auth/sessions/"+otherSessionID, "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = MakeRequest("GET", "/api/v1/users/current", "", otherAccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+	resp, err = MakeRequest("GET", "/api/v1/users/current", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestSessions_AccessTokenWithoutSessionRejected(t *testing.T) {
+	CleanupDatabase(t)
+	token := GetAccessToken(t)
+
+	var user entity.User
+	assert.NoError(t, db.Where("email = ?", "test@example.com").First(&user).Error)
+
+	// A validly signed token that names no session cannot be revoked, so it is not accepted
+	sessionless, err := config.NewJWT(viperConfig).GenerateAccessToken(user.ID, user.Email, GetOrganizationID(t, token), "")
+	assert.NoError(t, err)
+
+	resp, err := MakeRequest("GET", "/api/v1/users/current", "", sessionless)
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+}
@@ -0,0 +1,608 @@
+package test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/internal/usecase"
+	"go-clean-arch-saas/pkg/oauth"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+const ssoDomain = "acme-corp.test"
+
+// ConfigureSSO sets the organization's SSO connection and returns it
+func ConfigureSSO(t *testing.T, token string, connection map[string]interface{}) map[string]interface{} {
+	body, err := json.Marshal(connection)
+	assert.NoError(t, err)
+
+	resp, err := MakeRequest("PUT", "/api/v1/organizations/sso", string(body), token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	return ParseResponse(t, resp)["data"].(map[string]interface{})
+}
+
+// ConfigureOIDCSSO connects the organization to the test identity provider over OpenID Connect
+func ConfigureOIDCSSO(t *testing.T, token string, enforce bool) map[string]interface{} {
+	return ConfigureSSO(t, token, map[string]interface{}{
+		"protocol":           "oidc",
+		"enforce_sso":        enforce,
+		"oidc_issuer":        identityProvider.Server.URL,
+		"oidc_client_id":     IdentityProviderClientID,
+		"oidc_client_secret": IdentityProviderClientSecret,
+	})
+}
+
+// AddVerifiedSSODomain claims the domain for the organization and marks it verified, standing in for the DNS check
+func AddVerifiedSSODomain(t *testing.T, token string, domain string) string {
+	resp, err := MakeRequest("POST", "/api/v1/organizations/sso/domains", `{"domain": "`+domain+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	id := ParseResponse(t, resp)["data"].(map[string]interface{})["id"].(string)
+	assert.NoError(t, db.Model(&entity.SSODomain{}).Where("id = ?", id).Update("verified_at", time.Now().UnixMilli()).Error)
+	return id
+}
+
+// AuthorizeSSO starts a single sign-on login for the email and returns the authorization URL and state token
+func AuthorizeSSO(t *testing.T, email string) (string, string) {
+	resp, err := MakeRequest("POST", "/api/v1/auth/sso/authorize", `{"email": "`+email+`"}`, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	return data["authorization_url"].(string), data["state_token"].(string)
+}
+
+func finishSSOLogin(t *testing.T, stateToken string, state string, code string) (int, map[string]interface{}) {
+	body, err := json.Marshal(map[string]string{"state_token": stateToken, "state": state, "code": code})
+	assert.NoError(t, err)
+
+	resp, err := MakeRequest("POST", "/api/v1/auth/sso/callback", string(body), "")
+	assert.NoError(t, err)
+	return resp.StatusCode, ParseResponse(t, resp)
+}
+
+// LoginOIDCSSO signs the user in through an OIDC connection and returns the response status and body
+func LoginOIDCSSO(t *testing.T, email string, user IdentityProviderUser) (int, map[string]interface{}) {
+	authorizationURL, stateToken := AuthorizeSSO(t, email)
+	code, state, err := identityProvider.Authorize(authorizationURL, user)
+	assert.NoError(t, err)
+	return finishSSOLogin(t, stateToken, state, code)
+}
+
+// postSAMLResponse posts the response to the assertion consumer service and returns the callback page query
+// the browser is redirected to
+func postSAMLResponse(t *testing.T, acsURL string, response string, relayState string) url.Values {
+	form := url.Values{}
+	form.Set("SAMLResponse", base64.StdEncoding.EncodeToString([]byte(response)))
+	form.Set("RelayState", relayState)
+
+	path, err := url.Parse(acsURL)
+	assert.NoError(t, err)
+	req, err := http.NewRequest("POST", path.Path, strings.NewReader(form.Encode()))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 303, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, "/auth/sso/callback", location.Path)
+	return location.Query()
+}
+
+var samlSignaturePattern = regexp.MustCompile(`<ds:Signature .*?</ds:Signature>`)
+
+// splitSAMLResponse splits a response of the test identity provider into the parts before, of and after its assertion
+func splitSAMLResponse(response string) (string, string, string) {
+	start := strings.Index(response, "<saml:Assertion ")
+	end := strings.Index(response, "</saml:Assertion>") + len("</saml:Assertion>")
+	return response[:start], response[start:end], response[end:]
+}
+
+func ssoMemberRole(t *testing.T, orgID string, email string) string {
+	var user entity.User
+	assert.NoError(t, db.Where("email = ?", email).First(&user).Error)
+	var member entity.OrganizationMember
+	assert.NoError(t, db.Where("organization_id = ? AND user_id = ?", orgID, user.ID).First(&member).Error)
+	return member.Role
+}
+
+func TestSSO_OwnerManagesConnection(t *testing.T) {
+	CleanupDatabase(t)
+	token := GetAccessToken(t)
+	orgID := GetOrganizationID(t, token)
+
+	resp, err := MakeRequest("GET", "/api/v1/organizations/sso", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+
+	// OIDC connections need a client secret, and an issuer that can be discovered
+	resp, err = MakeRequest("PUT", "/api/v1/organizations/sso", `{"protocol": "oidc", "oidc_issuer": "`+identityProvider.Server.URL+`", "oidc_client_id": "test-client"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+	resp, err = MakeRequest("PUT", "/api/v1/organizations/sso", `{"protocol": "oidc", "oidc_issuer": "`+identityProvider.Server.URL+`/unknown", "oidc_client_id": "test-client", "oidc_client_secret": "secret"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+
+	connection := ConfigureOIDCSSO(t, token, false)
+	assert.Equal(t, "oidc", connection["protocol"])
+	assert.Equal(t, entity.OrgRoleMember, connection["default_role"])
+	assert.Equal(t, identityProvider.Server.URL, connection["oidc_issuer"])
+	assert.NotEmpty(t, connection["redirect_url"])
+	assert.NotContains(t, connection, "oidc_client_secret")
+
+	// Updating without a secret keeps the current one
+	connection = ConfigureSSO(t, token, map[string]interface{}{
+		"protocol":       "oidc",
+		"default_role":   entity.OrgRoleAdmin,
+		"oidc_issuer":    identityProvider.Server.URL,
+		"oidc_client_id": IdentityProviderClientID,
+	})
+	assert.Equal(t, entity.OrgRoleAdmin, connection["default_role"])
+
+	// Only owners manage single sign-on
+	adminToken := CreateTestMember(t, orgID, "admin@example.com", entity.OrgRoleAdmin)
+	resp, err = MakeRequest("GET", "/api/v1/organizations/sso", "", adminToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+
+	var logs []entity.AuditLog
+	assert.NoError(t, db.Where("organization_id = ? AND action = ?", orgID, entity.AuditActionSSOConnectionUpdate).Find(&logs).Error)
+	assert.Len(t, logs, 2)
+
+	resp, err = MakeRequest("DELETE", "/api/v1/organizations/sso", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	resp, err = MakeRequest("GET", "/api/v1/organizations/sso", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+}
+
+func TestSSO_OIDCIssuerMustBePublic(t *testing.T) {
+	service := &usecase.SSOService{Log: log, Client: oauth.NewPublicClient(5 * time.Second)}
+
+	// Outside development, issuers must be https
+	_, err := service.OIDCProvider(context.Background(), identityProvider.Server.URL)
+	assert.Error(t, err)
+	assert.Equal(t, 400, err.(*fiber.Error).Code)
+	assert.Contains(t, err.Error(), "https")
+
+	// and discovery never reaches loopback, private or link-local addresses, whatever the URL names
+	tlsServer := httptest.NewTLSServer(identityProvider.Server.Config.Handler)
+	defer tlsServer.Close()
+	_, err = service.OIDCProvider(context.Background(), tlsServer.URL)
+	assert.Error(t, err)
+	assert.Equal(t, 400, err.(*fiber.Error).Code)
+
+	for _, target := range []string{tlsServer.URL, "http://169.254.169.254/latest/meta-data/", "http://10.0.0.1/", "http://[::1]/", "http://100.64.0.1/"} {
+		_, err = service.Client.Get(target)
+		assert.ErrorIs(t, err, oauth.ErrPrivateAddress, target)
+	}
+	assert.True(t, oauth.IsPublicIP(net.ParseIP("93.184.216.34")))
+}
+
+func TestSSO_Domains(t *testing.T) {
+	CleanupDatabase(t)
+	token := GetAccessToken(t)
+
+	resp, err := MakeRequest("POST", "/api/v1/organizations/sso/domains", `{"domain": "SSO-Unpublished.invalid."}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	domain := ParseResponse(t, resp)["data"].(map[string]interface{})
+	assert.Equal(t, "sso-unpublished.invalid", domain["domain"])
+	assert.Equal(t, false, domain["verified"])
+	assert.Equal(t, "_sso-verification.sso-unpublished.invalid", domain["verification_record"])
+	assert.True(t, strings.HasPrefix(domain["verification_value"].(string), "sso-verification="))
+
+	resp, err = MakeRequest("POST", "/api/v1/organizations/sso/domains", `{"domain": "sso-unpublished.invalid"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
+
+	// The record was never published
+	id := domain["id"].(string)
+	resp, err = MakeRequest("POST", "/api/v1/organizations/sso/domains/"+id+"/verify", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 422, resp.StatusCode)
+
+	resp, err = MakeRequest("GET", "/api/v1/organizations/sso/domains", "", token)
+	assert.NoError(t, err)
+	assert.Len(t, ParseResponse(t, resp)["data"].([]interface{}), 1)
+
+	resp, err = MakeRequest("DELETE", "/api/v1/organizations/sso/domains/"+id, "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = MakeRequest("GET", "/api/v1/organizations/sso/domains", "", token)
+	assert.NoError(t, err)
+	assert.Len(t, ParseResponse(t, resp)["data"].([]interface{}), 0)
+}
+
+func TestSSO_OIDCLoginProvisionsMember(t *testing.T) {
+	CleanupDatabase(t)
+	token := GetAccessToken(t)
+	orgID := GetOrganizationID(t, token)
+	ConfigureOIDCSSO(t, token, false)
+	AddVerifiedSSODomain(t, token, ssoDomain)
+
+	user := IdentityProviderUser{Subject: "sso-user-1", Email: "alice@" + ssoDomain, EmailVerified: true, Name: "Alice"}
+	status, result := LoginOIDCSSO(t, user.Email, user)
+	assert.Equal(t, 200, status)
+
+	data := result["data"].(map[string]interface{})
+	assert.NotEmpty(t, data["refresh_token"])
+	assert.Equal(t, orgID, GetOrganizationID(t, data["access_token"].(string)))
+
+	// Provisioned just in time with the default role and without a password
+	var created entity.User
+	assert.NoError(t, db.Where("email = ?", user.Email).First(&created).Error)
+	assert.Equal(t, "Alice", created.Name)
+	assert.True(t, created.EmailVerified)
+	assert.Empty(t, created.Password)
+	assert.Equal(t, entity.OrgRoleMember, ssoMemberRole(t, orgID, user.Email))
+
+	var session entity.Session
+	assert.NoError(t, db.Where("user_id = ?", created.ID).First(&session).Error)
+
+	// Signing in again reuses the membership
+	status, _ = LoginOIDCSSO(t, user.Email, user)
+	assert.Equal(t, 200, status)
+	var count int64
+	assert.NoError(t, db.Model(&entity.OrganizationMember{}).Where("organization_id = ?", orgID).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestSSO_AuthorizeNeedsVerifiedDomain(t *testing.T) {
+	CleanupDatabase(t)
+	token := GetAccessToken(t)
+	ConfigureOIDCSSO(t, token, false)
+
+	resp, err := MakeRequest("POST", "/api/v1/auth/sso/authorize", `{"email": "alice@`+ssoDomain+`"}`, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+
+	// A claimed but unverified domain does not route logins either
+	resp, err = MakeRequest("POST", "/api/v1/organizations/sso/domains", `{"domain": "`+ssoDomain+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	resp, err = MakeRequest("POST", "/api/v1/auth/sso/authorize", `{"email": "alice@`+ssoDomain+`"}`, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+}
+
+func TestSSO_RejectsEmailOutsideVerifiedDomains(t *testing.T) {
+	CleanupDatabase(t)
+	token := GetAccessToken(t)
+	ConfigureOIDCSSO(t, token, false)
+	AddVerifiedSSODomain(t, token, ssoDomain)
+
+	// The identity provider is not trusted for addresses the organization does not own
+	status, _ := LoginOIDCSSO(t, "alice@"+ssoDomain, IdentityProviderUser{Subject: "sso-user-2", Email: "mallory@example.org", EmailVerified: true})
+	assert.Equal(t, 403, status)
+
+	var count int64
+	assert.NoError(t, db.Model(&entity.User{}).Where("email = ?", "mallory@example.org").Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestSSO_RejectsMismatchedState(t *testing.T) {
+	CleanupDatabase(t)
+	token := GetAccessToken(t)
+	ConfigureOIDCSSO(t, token, false)
+	AddVerifiedSSODomain(t, token, ssoDomain)
+
+	authorizationURL, stateToken := AuthorizeSSO(t, "alice@"+ssoDomain)
+	code, _, err := identityProvider.Authorize(authorizationURL, IdentityProviderUser{Subject: "sso-user-3", Email: "alice@" + ssoDomain, EmailVerified: true})
+	assert.NoError(t, err)
+
+	status, _ := finishSSOLogin(t, stateToken, "another-state", code)
+	assert.Equal(t, 401, status)
+}
+
+func TestSSO_EnforceBlocksPasswordLogin(t *testing.T) {
+	CleanupDatabase(t)
+	token := GetAccessToken(t)
+	orgID := GetOrganizationID(t, token)
+	CreateTestMember(t, orgID, "bob@"+ssoDomain, entity.OrgRoleMember)
+	ConfigureOIDCSSO(t, token, true)
+	AddVerifiedSSODomain(t, token, ssoDomain)
+
+	resp, err := MakeRequest("POST", "/api/v1/auth/login", `{"email": "bob@`+ssoDomain+`", "password": "password123"}`, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+
+	// Owners keep password access so a broken identity provider cannot lock the organization out
+	accessToken, _ := Login(t, "test@example.com", "password123")
+	assert.NotEmpty(t, accessToken)
+
+	// The member signs in through the identity provider instead
+	status, _ := LoginOIDCSSO(t, "bob@"+ssoDomain, IdentityProviderUser{Subject: "sso-user-4", Email: "bob@" + ssoDomain, EmailVerified: true, Name: "Bob"})
+	assert.Equal(t, 200, status)
+
+	// Without enforcement passwords work again
+	ConfigureOIDCSSO(t, token, false)
+	accessToken, _ = Login(t, "bob@"+ssoDomain, "password123")
+	assert.NotEmpty(t, accessToken)
+}
+
+func TestSSO_EnforceSignsOutMembers(t *testing.T) {
+	CleanupDatabase(t)
+	token := GetAccessToken(t)
+	orgID := GetOrganizationID(t, token)
+	CreateTestMember(t, orgID, "bob@"+ssoDomain, entity.OrgRoleMember)
+	ConfigureOIDCSSO(t, token, false)
+
+	memberAccessToken, memberRefreshToken := Login(t, "bob@"+ssoDomain, "password123")
+
+	// Password sessions started before enforcement end with it
+	ConfigureOIDCSSO(t, token, true)
+
+	resp, err := MakeRequest("POST", "/api/v1/auth/refresh", `{"refresh_token": "`+memberRefreshToken+`"}`, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+	resp, err = MakeRequest("GET", "/api/v1/users/current", "", memberAccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+
+	// Owners stay signed in
+	resp, err = MakeRequest("GET", "/api/v1/users/current", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	// Saving the enforced connection again signs out no one
+	AddVerifiedSSODomain(t, token, ssoDomain)
+	status, result := LoginOIDCSSO(t, "bob@"+ssoDomain, IdentityProviderUser{Subject: "sso-user-7", Email: "bob@" + ssoDomain, EmailVerified: true, Name: "Bob"})
+	assert.Equal(t, 200, status)
+	ConfigureOIDCSSO(t, token, true)
+	resp, err = MakeRequest("GET", "/api/v1/users/current", "", result["data"].(map[string]interface{})["access_token"].(string))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestSSO_EnforceBlocksPasskeyAndSocialLogin(t *testing.T) {
+	CleanupDatabase(t)
+	token := GetAccessToken(t)
+	orgID := GetOrganizationID(t, token)
+	CreateTestMember(t, orgID, "bob@"+ssoDomain, entity.OrgRoleMember)
+
+	// The member registers a passkey and links a social account before the organization enforces SSO
+	memberToken, _ := Login(t, "bob@"+ssoDomain, "password123")
+	authenticator := RegisterPasskey(t, memberToken)
+	socialUser := IdentityProviderUser{Subject: "2001", Email: "bob@" + ssoDomain, EmailVerified: true, Name: "Bob"}
+	status, _ := LoginOAuth(t, "oauth2", socialUser)
+	assert.Equal(t, 200, status)
+
+	ConfigureOIDCSSO(t, token, true)
+	AddVerifiedSSODomain(t, token, ssoDomain)
+
+	challengeToken, challenge := BeginPasskeyLogin(t, "bob@"+ssoDomain)
+	status, _ = finishPasskeyLogin(t, challengeToken, authenticator.Get(t, challenge, true))
+	assert.Equal(t, 403, status)
+
+	status, _ = LoginOAuth(t, "oauth2", socialUser)
+	assert.Equal(t, 403, status)
+
+	// Accounts linked after enforcement are refused too
+	status, _ = LoginOAuth(t, "oidc", IdentityProviderUser{Subject: "2002", Email: "bob@" + ssoDomain, EmailVerified: true, Name: "Bob"})
+	assert.Equal(t, 403, status)
+
+	// Owners keep every login method
+	ownerAuthenticator := RegisterPasskey(t, token)
+	challengeToken, challenge = BeginPasskeyLogin(t, "test@example.com")
+	status, _ = finishPasskeyLogin(t, challengeToken, ownerAuthenticator.Get(t, challenge, true))
+	assert.Equal(t, 200, status)
+}
+
+func TestSSO_SAMLLogin(t *testing.T) {
+	CleanupDatabase(t)
+	token := GetAccessToken(t)
+	orgID := GetOrganizationID(t, token)
+	connection := ConfigureSSO(t, token, map[string]interface{}{
+		"protocol":      "saml",
+		"default_role":  entity.OrgRoleAdmin,
+		"saml_metadata": samlIdentityProvider.Metadata(),
+	})
+	assert.Equal(t, SAMLIdentityProviderEntityID, connection["saml_entity_id"])
+	assert.Equal(t, SAMLIdentityProviderSSOURL, connection["saml_sso_url"])
+	AddVerifiedSSODomain(t, token, ssoDomain)
+
+	email := "carol@" + ssoDomain
+	authorizationURL, stateToken := AuthorizeSSO(t, email)
+	assert.True(t, strings.HasPrefix(authorizationURL, SAMLIdentityProviderSSOURL+"?"))
+	requestID, relayState, err := samlIdentityProvider.ParseRequest(authorizationURL)
+	assert.NoError(t, err)
+
+	query := postSAMLResponse(t, connection["acs_url"].(string), samlIdentityProvider.Response(SAMLAssertion{
+		RequestID: requestID,
+		Recipient: connection["acs_url"].(string),
+		Audience:  connection["sp_entity_id"].(string),
+		NameID:    email,
+		Email:     email,
+		Name:      "Carol",
+	}), relayState)
+	assert.Empty(t, query.Get("error"))
+	assert.Equal(t, relayState, query.Get("state"))
+
+	status, result := finishSSOLogin(t, stateToken, query.Get("state"), query.Get("code"))
+	assert.Equal(t, 200, status)
+	assert.Equal(t, email, result["data"].(map[string]interface{})["user"].(map[string]interface{})["email"])
+	assert.Equal(t, entity.OrgRoleAdmin, ssoMemberRole(t, orgID, email))
+
+	// The code belongs to this login only
+	_, otherStateToken := AuthorizeSSO(t, email)
+	status, _ = finishSSOLogin(t, otherStateToken, query.Get("state"), query.Get("code"))
+	assert.Equal(t, 401, status)
+}
+
+func TestSSO_SAMLRejectsInvalidResponses(t *testing.T) {
+	CleanupDatabase(t)
+	token := GetAccessToken(t)
+	connection := ConfigureSSO(t, token, map[string]interface{}{
+		"protocol":      "saml",
+		"saml_metadata": samlIdentityProvider.Metadata(),
+	})
+	AddVerifiedSSODomain(t, token, ssoDomain)
+
+	email := "dave@" + ssoDomain
+	authorizationURL, _ := AuthorizeSSO(t, email)
+	requestID, relayState, err := samlIdentityProvider.ParseRequest(authorizationURL)
+	assert.NoError(t, err)
+	assertion := SAMLAssertion{
+		RequestID: requestID,
+		Recipient: connection["acs_url"].(string),
+		Audience:  connection["sp_entity_id"].(string),
+		NameID:    email,
+		Email:     email,
+	}
+
+	// The signature covers the assertion
+	tampered := strings.Replace(samlIdentityProvider.Response(assertion), email, "mallory@"+ssoDomain, -1)
+	query := postSAMLResponse(t, connection["acs_url"].(string), tampered, relayState)
+	assert.Equal(t, "access_denied", query.Get("error"))
+	assert.Empty(t, query.Get("code"))
+
+	// Responses answer the request of the login they are posted for
+	query = postSAMLResponse(t, connection["acs_url"].(string), samlIdentityProvider.Response(assertion), "another-state")
+	assert.Equal(t, "access_denied", query.Get("error"))
+
+	// Assertions are for this service provider only
+	assertion.Audience = "https://other.example.test/metadata"
+	query = postSAMLResponse(t, connection["acs_url"].(string), samlIdentityProvider.Response(assertion), relayState)
+	assert.Equal(t, "access_denied", query.Get("error"))
+}
+
+func TestSSO_SAMLRejectsSignatureWrapping(t *testing.T) {
+	CleanupDatabase(t)
+	token := GetAccessToken(t)
+	connection := ConfigureSSO(t, token, map[string]interface{}{
+		"protocol":      "saml",
+		"saml_metadata": samlIdentityProvider.Metadata(),
+	})
+	AddVerifiedSSODomain(t, token, ssoDomain)
+	acsURL := connection["acs_url"].(string)
+
+	authorizationURL, _ := AuthorizeSSO(t, "erin@"+ssoDomain)
+	requestID, relayState, err := samlIdentityProvider.ParseRequest(authorizationURL)
+	assert.NoError(t, err)
+	assertion := SAMLAssertion{
+		RequestID: requestID,
+		Recipient: acsURL,
+		Audience:  connection["sp_entity_id"].(string),
+		NameID:    "erin@" + ssoDomain,
+		Email:     "erin@" + ssoDomain,
+	}
+	head, signed, tail := splitSAMLResponse(samlIdentityProvider.Response(assertion))
+	signature := samlSignaturePattern.FindString(signed)
+	signedID := samlIDPattern.FindStringSubmatch(signed)[1]
+
+	// An attacker holding Erin's signed assertion writes their own one beside it
+	assertion.NameID, assertion.Email = "mallory@"+ssoDomain, "mallory@"+ssoDomain
+	forgedHead, forged, forgedTail := splitSAMLResponse(samlIdentityProvider.Response(assertion))
+	forged = samlSignaturePattern.ReplaceAllString(forged, "")
+	forgedIssuerEnd := strings.Index(forged, "</saml:Issuer>") + len("</saml:Issuer>")
+	extensions := "<samlp:Extensions>" + signed + "</samlp:Extensions>"
+
+	for name, response := range map[string]string{
+		"wrapped in extensions": head + extensions + forged + tail,
+		"wrapped in assertion":  head + strings.Replace(forged, "</saml:Assertion>", signed+"</saml:Assertion>", 1) + tail,
+		"reference elsewhere":   head + extensions + forged[:forgedIssuerEnd] + signature + forged[forgedIssuerEnd:] + tail,
+		"duplicate ID":          head + extensions + strings.Replace(forged[:forgedIssuerEnd], samlIDPattern.FindStringSubmatch(forged)[1], signedID, 1) + signature + forged[forgedIssuerEnd:] + tail,
+		"second signature":      head + strings.Replace(signed, signature, signature+signature, 1) + tail,
+		"second assertion":      head + signed + forged + tail,
+	} {
+		query := postSAMLResponse(t, acsURL, response, relayState)
+		assert.Equal(t, "access_denied", query.Get("error"), name)
+		assert.Empty(t, query.Get("code"), name)
+	}
+
+	// A response signed as a whole vouches for its assertion, but only for the assertion it was signed with
+	assertion.NameID, assertion.Email = "erin@"+ssoDomain, "erin@"+ssoDomain
+	signedResponse := samlIdentityProvider.SignedResponse(assertion)
+	query := postSAMLResponse(t, acsURL, signedResponse, relayState)
+	assert.Empty(t, query.Get("error"))
+	assert.NotEmpty(t, query.Get("code"))
+
+	signedHead, unsigned, signedTail := splitSAMLResponse(signedResponse)
+	for name, response := range map[string]string{
+		"replaced assertion":   signedHead + forged + signedTail,
+		"added assertion":      signedHead + unsigned + forged + signedTail,
+		"wrapped in extension": forgedHead + "<samlp:Extensions>" + signedResponse + "</samlp:Extensions>" + forged + forgedTail,
+	} {
+		query := postSAMLResponse(t, acsURL, response, relayState)
+		assert.Equal(t, "access_denied", query.Get("error"), name)
+		assert.Empty(t, query.Get("code"), name)
+	}
+}
+
+func TestSSO_SAMLNameIDWithComment(t *testing.T) {
+	CleanupDatabase(t)
+	token := GetAccessToken(t)
+	connection := ConfigureSSO(t, token, map[string]interface{}{
+		"protocol":      "saml",
+		"saml_metadata": samlIdentityProvider.Metadata(),
+	})
+	AddVerifiedSSODomain(t, token, ssoDomain)
+
+	// Canonicalization drops comments, so a comment splitting the signed name ID keeps the signature valid; the
+	// whole name must still be read, not the part before the comment
+	email := "frank@" + ssoDomain
+	authorizationURL, stateToken := AuthorizeSSO(t, email)
+	requestID, relayState, err := samlIdentityProvider.ParseRequest(authorizationURL)
+	assert.NoError(t, err)
+	response := samlIdentityProvider.Response(SAMLAssertion{
+		RequestID: requestID,
+		Recipient: connection["acs_url"].(string),
+		Audience:  connection["sp_entity_id"].(string),
+		NameID:    email + ".evil.test",
+		Email:     email + ".evil.test",
+	})
+	response = strings.Replace(response, ">"+email+".evil.test<", ">"+email+"<!---->.evil.test<", -1)
+
+	query := postSAMLResponse(t, connection["acs_url"].(string), response, relayState)
+	status, _ := finishSSOLogin(t, stateToken, query.Get("state"), query.Get("code"))
+	assert.Equal(t, 403, status)
+
+	var count int64
+	assert.NoError(t, db.Model(&entity.User{}).Where("email = ?", email).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestSSO_SAMLMetadata(t *testing.T) {
+	CleanupDatabase(t)
+	token := GetAccessToken(t)
+	connection := ConfigureSSO(t, token, map[string]interface{}{
+		"protocol":      "saml",
+		"saml_metadata": samlIdentityProvider.Metadata(),
+	})
+
+	path, err := url.Parse(connection["metadata_url"].(string))
+	assert.NoError(t, err)
+	resp, err := MakeRequest("GET", path.Path, "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "xml")
+
+	metadata, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(metadata), `entityID="`+connection["sp_entity_id"].(string)+`"`)
+	assert.Contains(t, string(metadata), `Location="`+connection["acs_url"].(string)+`"`)
+
+	resp, err = MakeRequest("GET", "/api/v1/auth/sso/saml/unknown/metadata", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+}
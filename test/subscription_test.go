@@ -1,6 +1,8 @@
 package test
 
 import (
+	"go-clean-arch-saas/internal/entity"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -44,6 +46,7 @@ func TestUpgradeSubscription_Success(t *testing.T) {
 	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
 
 	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, "pm_card_visa")
 
 	// Upgrade to pro plan using plan_id
 	requestBody := `{
@@ -66,6 +69,50 @@ func TestUpgradeSubscription_Success(t *testing.T) {
 	assert.Equal(t, float64(29), plan["price"])
 }
 
+func TestUpgradeSubscription_ConcurrentChargesOnce(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+
+	token := GetAccessToken(t)
+	orgID := GetOrganizationID(t, token)
+	AddPaymentMethod(t, token, "pm_card_visa")
+
+	// Upgrades racing on the same subscription must not both replace and charge it
+	const requests = 3
+	var wg sync.WaitGroup
+	statuses := make([]int, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`"}`, token)
+			if assert.NoError(t, err) {
+				statuses[i] = resp.StatusCode
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, status := range statuses {
+		if status == 200 {
+			succeeded++
+		} else {
+			assert.Equal(t, 409, status)
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+
+	var current int64
+	db.Model(&entity.Subscription{}).Where("organization_id = ? AND status IN ?", orgID, entity.SubscriptionCurrentStatuses).Count(&current)
+	assert.Equal(t, int64(1), current)
+
+	var invoices int64
+	db.Model(&entity.Invoice{}).Where("organization_id = ?", orgID).Count(&invoices)
+	assert.Equal(t, int64(1), invoices)
+}
+
 func TestUpgradeSubscription_PlanNotFound(t *testing.T) {
 	CleanupDatabase(t)
 
@@ -115,6 +162,7 @@ func TestDowngradeSubscription_Success(t *testing.T) {
 	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
 
 	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, "pm_card_visa")
 
 	// First upgrade to pro
 	upgradeBody := `{
@@ -136,8 +184,62 @@ func TestDowngradeSubscription_Success(t *testing.T) {
 	result := ParseResponse(t, resp)
 	data := result["data"].(map[string]interface{})
 
+	// The downgrade waits for the end of the current period
 	plan := data["plan"].(map[string]interface{})
-	assert.Equal(t, "free-downgrade", plan["slug"])
+	assert.Equal(t, "pro", plan["slug"])
+	assert.Equal(t, freePlan.ID, data["scheduled_plan_id"])
+	assert.Len(t, FindAuditLogs(t, entity.AuditActionSubscriptionSchedule), 1)
+}
+
+func TestDowngradeSubscription_ClearedByCurrentPlan(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	basicPlan := CreateTestPlan(t, "basic", "Basic Plan", 9.00)
+	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, "pm_card_visa")
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+basicPlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	// Choosing the current plan again drops the scheduled downgrade
+	resp, err = MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	assert.Nil(t, data["scheduled_plan_id"])
+
+	// Nothing left to change
+	resp, err = MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
+}
+
+func TestDowngradeSubscription_ScheduledForCancellation(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	basicPlan := CreateTestPlan(t, "basic", "Basic Plan", 9.00)
+	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, "pm_card_visa")
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = MakeRequest("POST", "/api/v1/subscriptions/cancel", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+basicPlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
 }
 
 func TestCancelSubscription_Success(t *testing.T) {
@@ -152,7 +254,58 @@ func TestCancelSubscription_Success(t *testing.T) {
 	result := ParseResponse(t, resp)
 	// Response data is a string message, not an object
 	data := result["data"].(string)
-	assert.Equal(t, "Subscription cancelled successfully", data)
+	assert.Equal(t, "Subscription will be cancelled at the end of the current period", data)
+
+	// Access continues until the period ends
+	resp, err = MakeRequest("GET", "/api/v1/subscriptions/current", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	subscription := ParseResponse(t, resp)["data"].(map[string]interface{})
+	assert.Equal(t, "active", subscription["status"])
+	assert.Equal(t, true, subscription["cancel_at_period_end"])
+	assert.NotNil(t, subscription["cancelled_at"])
+}
+
+func TestCancelSubscription_ConcurrentImmediateCancelsOnce(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	token := GetAccessToken(t)
+	orgID := GetOrganizationID(t, token)
+	AddPaymentMethod(t, token, "pm_card_visa")
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	// Only one cancel ends the subscription and falls back to the free plan
+	const requests = 3
+	var wg sync.WaitGroup
+	statuses := make([]int, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := MakeRequest("POST", "/api/v1/subscriptions/cancel", `{"immediately": true}`, token)
+			if assert.NoError(t, err) {
+				statuses[i] = resp.StatusCode
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, status := range statuses {
+		if status == 200 {
+			succeeded++
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+
+	var current int64
+	db.Model(&entity.Subscription{}).Where("organization_id = ? AND status IN ?", orgID, entity.SubscriptionCurrentStatuses).Count(&current)
+	assert.Equal(t, int64(1), current)
 }
 
 func TestCancelSubscription_Unauthorized(t *testing.T) {
@@ -173,10 +326,73 @@ func TestCancelSubscription_AlreadyCancelled(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 200, resp.StatusCode)
 
-	// Try to cancel again - should return 404 since no active subscription exists
+	// Try to cancel again - the cancellation is already scheduled
 	resp, err = MakeRequest("POST", "/api/v1/subscriptions/cancel", "", token)
 	assert.NoError(t, err)
-	assert.Equal(t, 404, resp.StatusCode) // No active subscription to cancel
+	assert.Equal(t, 409, resp.StatusCode)
+}
+
+func TestCancelSubscription_Immediately(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, "pm_card_visa")
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = MakeRequest("POST", "/api/v1/subscriptions/cancel", `{"immediately": true}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "Subscription cancelled successfully", ParseResponse(t, resp)["data"])
+
+	// Organization falls back to the free plan right away
+	resp, err = MakeRequest("GET", "/api/v1/subscriptions/current", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	plan := ParseResponse(t, resp)["data"].(map[string]interface{})["plan"].(map[string]interface{})
+	assert.Equal(t, "free", plan["slug"])
+}
+
+func TestCancelSubscription_ImmediatelyOnFreePlan(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/cancel", `{"immediately": true}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+}
+
+func TestResumeSubscription_Success(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/cancel", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = MakeRequest("POST", "/api/v1/subscriptions/resume", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	assert.Equal(t, false, data["cancel_at_period_end"])
+	assert.Nil(t, data["cancelled_at"])
+}
+
+func TestResumeSubscription_NotScheduled(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/resume", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
 }
 
 func TestSubscriptionWorkflow_Complete(t *testing.T) {
@@ -188,6 +404,7 @@ func TestSubscriptionWorkflow_Complete(t *testing.T) {
 	enterprisePlan := CreateTestPlan(t, "enterprise", "Enterprise Plan", 99.00)
 
 	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, "pm_card_visa")
 
 	// 1. Check initial subscription (free)
 	resp, err := MakeRequest("GET", "/api/v1/subscriptions/current", "", token)
@@ -233,5 +450,5 @@ func TestSubscriptionWorkflow_Complete(t *testing.T) {
 
 	result = ParseResponse(t, resp)
 	message := result["data"].(string)
-	assert.Equal(t, "Subscription cancelled successfully", message)
+	assert.Equal(t, "Subscription will be cancelled at the end of the current period", message)
 }
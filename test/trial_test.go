@@ -0,0 +1,185 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"go-clean-arch-saas/internal/entity"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RegisterWithTrial registers test@example.com with a trial of the given plan and returns the access token.
+// The free plan and the trial plan must already exist.
+func RegisterWithTrial(t *testing.T, planSlug string) string {
+	registerBody := fmt.Sprintf(`{
+		"name": "Test User",
+		"email": "test@example.com",
+		"password": "password123",
+		"organization_name": "Test Org",
+		"plan": "%s"
+	}`, planSlug)
+
+	resp, err := MakeRequest("POST", "/api/v1/auth/register", registerBody, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	accessToken, _ := Login(t, "test@example.com", "password123")
+	return accessToken
+}
+
+// CreateTrialPlan creates a paid plan offering a trial of the given length
+func CreateTrialPlan(t *testing.T, trialDays int) *entity.Plan {
+	plan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	plan.TrialDays = trialDays
+	assert.NoError(t, db.Model(plan).Update("trial_days", trialDays).Error)
+	return plan
+}
+
+// EndTrial moves the organization's trial end, and its period end, to the past
+func EndTrial(t *testing.T, orgID string) {
+	ended := time.Now().Add(-time.Hour).UnixMilli()
+	err := db.Model(&entity.Subscription{}).
+		Where("organization_id = ? AND status = ?", orgID, entity.SubscriptionStatusTrialing).
+		Updates(map[string]interface{}{"trial_ends_at": ended, "current_period_end": ended}).Error
+	assert.NoError(t, err)
+}
+
+func TestRegister_StartsTrial(t *testing.T) {
+	CleanupDatabase(t)
+
+	CreateTestPlan(t, "free", "Free Plan", 0)
+	CreateTrialPlan(t, 14)
+	token := RegisterWithTrial(t, "pro")
+
+	resp, err := MakeRequest("GET", "/api/v1/subscriptions/current", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	assert.Equal(t, entity.SubscriptionStatusTrialing, data["status"])
+	assert.Equal(t, "pro", data["plan"].(map[string]interface{})["slug"])
+	assert.Equal(t, data["current_period_end"], data["trial_ends_at"])
+
+	trialEndsAt := time.UnixMilli(int64(data["trial_ends_at"].(float64)))
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, 14), trialEndsAt, time.Minute)
+
+	// Trials are not invoiced
+	assert.Len(t, FindInvoices(t, GetOrganizationID(t, token)), 0)
+}
+
+func TestRegister_PlanWithoutTrial(t *testing.T) {
+	CleanupDatabase(t)
+
+	CreateTestPlan(t, "free", "Free Plan", 0)
+	CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+
+	registerBody := `{
+		"name": "Test User",
+		"email": "test@example.com",
+		"password": "password123",
+		"organization_name": "Test Org",
+		"plan": "pro"
+	}`
+	resp, err := MakeRequest("POST", "/api/v1/auth/register", registerBody, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+	assert.Equal(t, "Plan has no free trial", ParseResponse(t, resp)["errors"])
+
+	var total int64
+	assert.NoError(t, db.Model(&entity.User{}).Count(&total).Error)
+	assert.Equal(t, int64(0), total)
+}
+
+func TestScheduler_ConvertsTrialWithPaymentMethod(t *testing.T) {
+	CleanupDatabase(t)
+
+	CreateTestPlan(t, "free", "Free Plan", 0)
+	CreateTrialPlan(t, 14)
+	token := RegisterWithTrial(t, "pro")
+	AddPaymentMethod(t, token, "pm_card_visa")
+	orgID := GetOrganizationID(t, token)
+	EndTrial(t, orgID)
+
+	assert.True(t, jobs.RunOnce(context.Background()))
+
+	status, slug := getSubscriptionStatus(t, token)
+	assert.Equal(t, entity.SubscriptionStatusActive, status)
+	assert.Equal(t, "pro", slug)
+
+	invoices := FindInvoices(t, orgID)
+	assert.Len(t, invoices, 1)
+	assert.Equal(t, entity.InvoiceStatusPaid, invoices[0].Status)
+	assert.Equal(t, 29.00, invoices[0].AmountDue)
+
+	logs := FindAuditLogs(t, entity.AuditActionSubscriptionTrialEnd)
+	assert.Len(t, logs, 1)
+	assert.Contains(t, logs[0].Details, `"converted":true`)
+}
+
+func TestScheduler_TrialWithoutPaymentMethodFallsBackToFree(t *testing.T) {
+	CleanupDatabase(t)
+
+	CreateTestPlan(t, "free", "Free Plan", 0)
+	CreateTrialPlan(t, 14)
+	token := RegisterWithTrial(t, "pro")
+	orgID := GetOrganizationID(t, token)
+	EndTrial(t, orgID)
+
+	assert.True(t, jobs.RunOnce(context.Background()))
+
+	status, slug := getSubscriptionStatus(t, token)
+	assert.Equal(t, entity.SubscriptionStatusActive, status)
+	assert.Equal(t, "free", slug)
+
+	var trial entity.Subscription
+	assert.NoError(t, db.Where("organization_id = ? AND trial_ends_at IS NOT NULL", orgID).First(&trial).Error)
+	assert.Equal(t, entity.SubscriptionStatusExpired, trial.Status)
+
+	assert.Len(t, FindInvoices(t, orgID), 0)
+	logs := FindAuditLogs(t, entity.AuditActionSubscriptionTrialEnd)
+	assert.Len(t, logs, 1)
+	assert.Contains(t, logs[0].Details, `"converted":false`)
+}
+
+func TestScheduler_RemindsEndingTrialOnce(t *testing.T) {
+	CleanupDatabase(t)
+
+	CreateTestPlan(t, "free", "Free Plan", 0)
+	CreateTrialPlan(t, 2)
+	token := RegisterWithTrial(t, "pro")
+	orgID := GetOrganizationID(t, token)
+
+	assert.True(t, jobs.RunOnce(context.Background()))
+
+	var trial entity.Subscription
+	assert.NoError(t, db.Where("organization_id = ?", orgID).First(&trial).Error)
+	assert.Equal(t, entity.SubscriptionStatusTrialing, trial.Status)
+	assert.NotNil(t, trial.TrialReminderSentAt)
+	sentAt := *trial.TrialReminderSentAt
+
+	assert.True(t, jobs.RunOnce(context.Background()))
+
+	assert.NoError(t, db.Where("id = ?", trial.ID).First(&trial).Error)
+	assert.Equal(t, sentAt, *trial.TrialReminderSentAt)
+}
+
+func TestUpgradeSubscription_TrialHasNoCredit(t *testing.T) {
+	CleanupDatabase(t)
+
+	CreateTestPlan(t, "free", "Free Plan", 0)
+	CreateTrialPlan(t, 14)
+	enterprisePlan := CreateTestPlan(t, "enterprise", "Enterprise Plan", 99.00)
+	token := RegisterWithTrial(t, "pro")
+	AddPaymentMethod(t, token, "pm_card_visa")
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+enterprisePlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	invoices := FindInvoices(t, GetOrganizationID(t, token))
+	assert.Len(t, invoices, 1)
+	assert.Equal(t, 99.00, invoices[0].AmountDue)
+	assert.Len(t, invoices[0].LineItems, 1)
+}
@@ -0,0 +1,47 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUsage_CountsMeteredRequests(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+	SetPlanLimits(t, "free", `{"api_calls_per_month": 1000}`)
+
+	for i := 0; i < 3; i++ {
+		resp, err := MakeRequest("GET", "/api/v1/users/current", "", token)
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+	}
+
+	// Subscription routes are not metered
+	resp, err := MakeRequest("GET", "/api/v1/subscriptions/usage", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	assert.Equal(t, "api_calls", data["metric"])
+	assert.Equal(t, float64(3), data["used"])
+	assert.Equal(t, float64(1000), data["limit"])
+	assert.Equal(t, float64(997), data["remaining"])
+	assert.Less(t, data["period_start"].(float64), data["period_end"].(float64))
+}
+
+func TestGetUsage_Unlimited(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+
+	resp, err := MakeRequest("GET", "/api/v1/subscriptions/usage", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	assert.Equal(t, float64(0), data["used"])
+	assert.Equal(t, float64(-1), data["limit"])
+	assert.Equal(t, float64(-1), data["remaining"])
+}
@@ -0,0 +1,350 @@
+package test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"go-clean-arch-saas/internal/config"
+	"go-clean-arch-saas/pkg/webauthn"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Authenticator is a software WebAuthn authenticator holding a single ES256 credential
+type Authenticator struct {
+	ID        []byte
+	Key       *ecdsa.PrivateKey
+	SignCount uint32
+	Origin    string
+	RPID      string
+}
+
+func NewAuthenticator(t *testing.T) *Authenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	id := make([]byte, 16)
+	_, err = rand.Read(id)
+	assert.NoError(t, err)
+
+	relyingParty := config.NewRelyingParty(viperConfig)
+	return &Authenticator{ID: id, Key: key, Origin: relyingParty.Origins[0], RPID: relyingParty.ID}
+}
+
+// cborHead encodes the head of a CBOR data item of the major type
+func cborHead(major byte, value uint64) []byte {
+	switch {
+	case value < 24:
+		return []byte{major<<5 | byte(value)}
+	case value < 256:
+		return []byte{major<<5 | 24, byte(value)}
+	default:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(value))
+	}
+}
+
+func cborInt(value int64) []byte {
+	if value < 0 {
+		return cborHead(1, uint64(-1-value))
+	}
+	return cborHead(0, uint64(value))
+}
+
+func cborBytes(value []byte) []byte {
+	return append(cborHead(2, uint64(len(value))), value...)
+}
+
+func cborText(value string) []byte {
+	return append(cborHead(3, uint64(len(value))), value...)
+}
+
+// authenticatorData builds authenticator data with user presence, and user verification when verified
+func (a *Authenticator) authenticatorData(verified bool, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.RPID))
+	flags := byte(0x01)
+	if verified {
+		flags |= 0x04
+	}
+	if attested {
+		flags |= 0x40
+	}
+
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.SignCount)
+	if attested {
+		data = append(data, make([]byte, 16)...) // AAGUID
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.ID)))
+		data = append(data, a.ID...)
+
+		// COSE_Key {1: 2, 3: -7, -1: 1, -2: x, -3: y}
+		key := append([]byte{0xa5}, cborInt(1)...)
+		key = append(key, cborInt(2)...)
+		key = append(key, cborInt(3)...)
+		key = append(key, cborInt(webauthn.AlgES256)...)
+		key = append(key, cborInt(-1)...)
+		key = append(key, cborInt(1)...)
+		key = append(key, cborInt(-2)...)
+		key = append(key, cborBytes(a.Key.PublicKey.X.FillBytes(make([]byte, 32)))...)
+		key = append(key, cborInt(-3)...)
+		key = append(key, cborBytes(a.Key.PublicKey.Y.FillBytes(make([]byte, 32)))...)
+		data = append(data, key...)
+	}
+	return data
+}
+
+func (a *Authenticator) clientData(t *testing.T, ceremony string, challenge string) []byte {
+	clientData, err := json.Marshal(map[string]interface{}{"type": ceremony, "challenge": challenge, "origin": a.Origin})
+	assert.NoError(t, err)
+	return clientData
+}
+
+// Create answers navigator.credentials.create() options with the credential to send to /register/finish
+func (a *Authenticator) Create(t *testing.T, challenge string) map[string]interface{} {
+	attestation := append([]byte{0xa3}, cborText("fmt")...)
+	attestation = append(attestation, cborText("none")...)
+	attestation = append(attestation, cborText("attStmt")...)
+	attestation = append(attestation, 0xa0)
+	attestation = append(attestation, cborText("authData")...)
+	attestation = append(attestation, cborBytes(a.authenticatorData(true, true))...)
+
+	return map[string]interface{}{
+		"id":   webauthn.EncodeBase64URL(a.ID),
+		"type": "public-key",
+		"response": map[string]interface{}{
+			"clientDataJSON":    webauthn.EncodeBase64URL(a.clientData(t, "webauthn.create", challenge)),
+			"attestationObject": webauthn.EncodeBase64URL(attestation),
+			"transports":        []string{"internal"},
+		},
+	}
+}
+
+// Get answers navigator.credentials.get() options with the assertion to send to /login/finish
+func (a *Authenticator) Get(t *testing.T, challenge string, verified bool) map[string]interface{} {
+	a.SignCount++
+	authData := a.authenticatorData(verified, false)
+	clientData := a.clientData(t, "webauthn.get", challenge)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(authData, clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.Key, digest[:])
+	assert.NoError(t, err)
+
+	return map[string]interface{}{
+		"id":   webauthn.EncodeBase64URL(a.ID),
+		"type": "public-key",
+		"response": map[string]interface{}{
+			"clientDataJSON":    webauthn.EncodeBase64URL(clientData),
+			"authenticatorData": webauthn.EncodeBase64URL(authData),
+			"signature":         webauthn.EncodeBase64URL(signature),
+		},
+	}
+}
+
+func webAuthnBody(t *testing.T, body map[string]interface{}) string {
+	data, err := json.Marshal(body)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+// RegisterPasskey registers a new software authenticator for the user of the token
+func RegisterPasskey(t *testing.T, token string) *Authenticator {
+	authenticator := NewAuthenticator(t)
+
+	resp, err := MakeRequest("POST", "/api/v1/auth/webauthn/register/begin", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	challenge := data["public_key"].(map[string]interface{})["challenge"].(string)
+
+	body := webAuthnBody(t, map[string]interface{}{
+		"challenge_token": data["challenge_token"],
+		"name":            "Test key",
+		"credential":      authenticator.Create(t, challenge),
+	})
+	resp, err = MakeRequest("POST", "/api/v1/auth/webauthn/register/finish", body, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	return authenticator
+}
+
+// BeginPasskeyLogin starts a passkey login and returns the challenge token and challenge
+func BeginPasskeyLogin(t *testing.T, email string) (string, string) {
+	resp, err := MakeRequest("POST", "/api/v1/auth/webauthn/login/begin", `{"email": "`+email+`"}`, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	return data["challenge_token"].(string), data["public_key"].(map[string]interface{})["challenge"].(string)
+}
+
+func finishPasskeyLogin(t *testing.T, challengeToken string, credential map[string]interface{}) (int, map[string]interface{}) {
+	body := webAuthnBody(t, map[string]interface{}{"challenge_token": challengeToken, "credential": credential})
+	resp, err := MakeRequest("POST", "/api/v1/auth/webauthn/login/finish", body, "")
+	assert.NoError(t, err)
+	return resp.StatusCode, ParseResponse(t, resp)
+}
+
+func TestWebAuthn_RegisterAndList(t *testing.T) {
+	CleanupDatabase(t)
+	token := GetAccessToken(t)
+	authenticator := RegisterPasskey(t, token)
+
+	resp, err := MakeRequest("GET", "/api/v1/auth/webauthn/credentials", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	credentials := ParseResponse(t, resp)["data"].([]interface{})
+	assert.Len(t, credentials, 1)
+	credential := credentials[0].(map[string]interface{})
+	assert.NotEmpty(t, credential["id"])
+	assert.Equal(t, "Test key", credential["name"])
+	assert.Equal(t, []interface{}{"internal"}, credential["transports"])
+
+	// The registered credential is excluded from new registrations, and cannot be registered twice
+	resp, err = MakeRequest("POST", "/api/v1/auth/webauthn/register/begin", "", token)
+	assert.NoError(t, err)
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	options := data["public_key"].(map[string]interface{})
+	assert.Len(t, options["excludeCredentials"], 1)
+
+	body := webAuthnBody(t, map[string]interface{}{
+		"challenge_token": data["challenge_token"],
+		"credential":      authenticator.Create(t, options["challenge"].(string)),
+	})
+	resp, err = MakeRequest("POST", "/api/v1/auth/webauthn/register/finish", body, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
+}
+
+func TestWebAuthn_RegisterRejectsWrongChallenge(t *testing.T) {
+	CleanupDatabase(t)
+	token := GetAccessToken(t)
+	authenticator := NewAuthenticator(t)
+
+	resp, err := MakeRequest("POST", "/api/v1/auth/webauthn/register/begin", "", token)
+	assert.NoError(t, err)
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+
+	other, err := webauthn.NewChallenge()
+	assert.NoError(t, err)
+	body := webAuthnBody(t, map[string]interface{}{
+		"challenge_token": data["challenge_token"],
+		"credential":      authenticator.Create(t, webauthn.EncodeBase64URL(other)),
+	})
+	resp, err = MakeRequest("POST", "/api/v1/auth/webauthn/register/finish", body, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+}
+
+func TestWebAuthn_Login(t *testing.T) {
+	CleanupDatabase(t)
+	authenticator := RegisterPasskey(t, GetAccessToken(t))
+
+	challengeToken, challenge := BeginPasskeyLogin(t, "test@example.com")
+	status, result := finishPasskeyLogin(t, challengeToken, authenticator.Get(t, challenge, true))
+	assert.Equal(t, 200, status)
+
+	data := result["data"].(map[string]interface{})
+	assert.NotEmpty(t, data["refresh_token"])
+	assert.Equal(t, "test@example.com", data["user"].(map[string]interface{})["email"])
+
+	resp, err := MakeRequest("GET", "/api/v1/users/current", "", data["access_token"].(string))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	// The challenge token is not an access token
+	resp, err = MakeRequest("GET", "/api/v1/users/current", "", challengeToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+
+	// Discoverable credentials sign in without an email
+	challengeToken, challenge = BeginPasskeyLogin(t, "")
+	status, _ = finishPasskeyLogin(t, challengeToken, authenticator.Get(t, challenge, true))
+	assert.Equal(t, 200, status)
+}
+
+func TestWebAuthn_LoginRejectsInvalidAssertions(t *testing.T) {
+	CleanupDatabase(t)
+	authenticator := RegisterPasskey(t, GetAccessToken(t))
+
+	// Another origin
+	challengeToken, challenge := BeginPasskeyLogin(t, "test@example.com")
+	authenticator.Origin = "https://attacker.example"
+	status, _ := finishPasskeyLogin(t, challengeToken, authenticator.Get(t, challenge, true))
+	assert.Equal(t, 401, status)
+	authenticator.Origin = config.NewRelyingParty(viperConfig).Origins[0]
+
+	// Another challenge
+	other, err := webauthn.NewChallenge()
+	assert.NoError(t, err)
+	challengeToken, _ = BeginPasskeyLogin(t, "test@example.com")
+	status, _ = finishPasskeyLogin(t, challengeToken, authenticator.Get(t, webauthn.EncodeBase64URL(other), true))
+	assert.Equal(t, 401, status)
+
+	// Another key
+	challengeToken, challenge = BeginPasskeyLogin(t, "test@example.com")
+	impostor := NewAuthenticator(t)
+	impostor.ID = authenticator.ID
+	status, _ = finishPasskeyLogin(t, challengeToken, impostor.Get(t, challenge, true))
+	assert.Equal(t, 401, status)
+
+	// The signature counter must increase, which a cloned authenticator fails
+	challengeToken, challenge = BeginPasskeyLogin(t, "test@example.com")
+	status, _ = finishPasskeyLogin(t, challengeToken, authenticator.Get(t, challenge, true))
+	assert.Equal(t, 200, status)
+
+	authenticator.SignCount--
+	challengeToken, challenge = BeginPasskeyLogin(t, "test@example.com")
+	status, _ = finishPasskeyLogin(t, challengeToken, authenticator.Get(t, challenge, true))
+	assert.Equal(t, 401, status)
+}
+
+func TestWebAuthn_Delete(t *testing.T) {
+	CleanupDatabase(t)
+	token := GetAccessToken(t)
+	authenticator := RegisterPasskey(t, token)
+
+	resp, err := MakeRequest("GET", "/api/v1/auth/webauthn/credentials", "", token)
+	assert.NoError(t, err)
+	id := ParseResponse(t, resp)["data"].([]interface{})[0].(map[string]interface{})["id"].(string)
+
+	resp, err = MakeRequest("DELETE", "/api/v1/auth/webauthn/credentials/"+id, "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = MakeRequest("DELETE", "/api/v1/auth/webauthn/credentials/"+id, "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+
+	challengeToken, challenge := BeginPasskeyLogin(t, "test@example.com")
+	status, _ := finishPasskeyLogin(t, challengeToken, authenticator.Get(t, challenge, true))
+	assert.Equal(t, 401, status)
+}
+
+func TestWebAuthn_LoginWithMFA(t *testing.T) {
+	CleanupDatabase(t)
+	token := GetAccessToken(t)
+	secret, _ := EnableMFA(t, token)
+	authenticator := RegisterPasskey(t, token)
+
+	// A verified passkey is two factors on its own
+	challengeToken, challenge := BeginPasskeyLogin(t, "test@example.com")
+	status, result := finishPasskeyLogin(t, challengeToken, authenticator.Get(t, challenge, true))
+	assert.Equal(t, 200, status)
+	assert.NotEmpty(t, result["data"].(map[string]interface{})["access_token"])
+
+	// Without user verification the second step is still required
+	challengeToken, challenge = BeginPasskeyLogin(t, "test@example.com")
+	status, result = finishPasskeyLogin(t, challengeToken, authenticator.Get(t, challenge, false))
+	assert.Equal(t, 200, status)
+	data := result["data"].(map[string]interface{})
+	assert.Equal(t, true, data["mfa_required"])
+	assert.Nil(t, data["access_token"])
+
+	body := `{"mfa_token": "` + data["mfa_token"].(string) + `", "code": "` + TOTPCode(t, secret, 1) + `"}`
+	resp, err := MakeRequest("POST", "/api/v1/auth/mfa/verify", body, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+}
@@ -0,0 +1,218 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"go-clean-arch-saas/internal/entity"
+	"go-clean-arch-saas/pkg/payment"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// SendBillingWebhook posts the event signed the way the fake payment provider expects
+func SendBillingWebhook(t *testing.T, event *payment.Event) *http.Response {
+	payload, err := json.Marshal(event)
+	assert.NoError(t, err)
+
+	provider := payment.NewFakeProvider(log, viperConfig.GetString("payment.webhook_secret"))
+	req, err := http.NewRequest("POST", "/api/v1/webhooks/billing", bytes.NewReader(payload))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(provider.SignatureHeader(), provider.Sign(payload))
+
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	return resp
+}
+
+// CreateRenewalInvoice moves the token's organization to a paid plan and renews it with a declined payment method,
+// leaving the subscription past due with an open invoice
+func CreateRenewalInvoice(t *testing.T, token string) *entity.Invoice {
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	AddPaymentMethod(t, token, "pm_card_visa")
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	// The renewal charge is declined
+	AddPaymentMethod(t, token, payment.FakePaymentMethodDeclined)
+
+	orgID := GetOrganizationID(t, token)
+	EndSubscriptionPeriod(t, orgID, entity.SubscriptionStatusActive)
+	assert.True(t, jobs.RunOnce(context.Background()))
+
+	invoices := FindInvoices(t, orgID)
+	assert.Len(t, invoices, 2)
+	assert.Equal(t, entity.InvoiceStatusOpen, invoices[1].Status)
+	return &invoices[1]
+}
+
+// getSubscriptionStatus returns the status and plan slug of the token's current subscription
+func getSubscriptionStatus(t *testing.T, token string) (string, string) {
+	resp, err := MakeRequest("GET", "/api/v1/subscriptions/current", "", token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	data := ParseResponse(t, resp)["data"].(map[string]interface{})
+	return data["status"].(string), data["plan"].(map[string]interface{})["slug"].(string)
+}
+
+func TestBillingWebhook_InvalidSignature(t *testing.T) {
+	CleanupDatabase(t)
+
+	req, err := http.NewRequest("POST", "/api/v1/webhooks/billing", bytes.NewReader([]byte(`{"id": "evt_1", "type": "payment.failed"}`)))
+	assert.NoError(t, err)
+	req.Header.Set("X-Webhook-Signature", "not-a-signature")
+
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+
+	var total int64
+	assert.NoError(t, db.Model(&entity.BillingEvent{}).Count(&total).Error)
+	assert.Equal(t, int64(0), total)
+}
+
+func TestBillingWebhook_EmptySecretSignatureRejected(t *testing.T) {
+	CleanupDatabase(t)
+
+	// A payload signed with an empty key must not pass for one signed with the configured secret
+	payload := []byte(`{"id": "evt_1", "type": "payment.failed"}`)
+	req, err := http.NewRequest("POST", "/api/v1/webhooks/billing", bytes.NewReader(payload))
+	assert.NoError(t, err)
+	req.Header.Set("X-Webhook-Signature", payment.NewFakeProvider(log, "").Sign(payload))
+
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+
+	var total int64
+	assert.NoError(t, db.Model(&entity.BillingEvent{}).Count(&total).Error)
+	assert.Equal(t, int64(0), total)
+}
+
+func TestBillingWebhook_RejectedWithoutSecret(t *testing.T) {
+	// A provider without a webhook secret trusts no signature, not even its own
+	payload := []byte(`{"id": "evt_1", "type": "payment.failed"}`)
+	fake := payment.NewFakeProvider(log, "")
+	_, err := fake.ParseWebhook(payload, fake.Sign(payload))
+	assert.ErrorIs(t, err, payment.ErrWebhookSecretMissing)
+
+	stripe := payment.NewStripeProvider(log, "sk_test", "", "")
+	_, err = stripe.ParseWebhook(payload, "t=1,v1=signature")
+	assert.ErrorIs(t, err, payment.ErrWebhookSecretMissing)
+}
+
+func TestBillingWebhook_PaymentFailedThenSucceeded(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+	invoice := CreateRenewalInvoice(t, token)
+
+	// The subscription is already past due, so a reported failure changes nothing
+	resp := SendBillingWebhook(t, &payment.Event{
+		ID:       "evt_failed",
+		Type:     payment.EventPaymentFailed,
+		ChargeID: "ch_fake_renewal",
+		Amount:   invoice.AmountDue,
+		Metadata: map[string]string{"invoice_id": invoice.ID},
+	})
+	assert.Equal(t, 200, resp.StatusCode)
+
+	status, slug := getSubscriptionStatus(t, token)
+	assert.Equal(t, entity.SubscriptionStatusPastDue, status)
+	assert.Equal(t, "pro", slug)
+
+	resp = SendBillingWebhook(t, &payment.Event{
+		ID:       "evt_succeeded",
+		Type:     payment.EventPaymentSucceeded,
+		ChargeID: "ch_fake_renewal",
+		Amount:   invoice.AmountDue,
+		Metadata: map[string]string{"invoice_id": invoice.ID},
+	})
+	assert.Equal(t, 200, resp.StatusCode)
+
+	status, _ = getSubscriptionStatus(t, token)
+	assert.Equal(t, entity.SubscriptionStatusActive, status)
+
+	var paid entity.Invoice
+	assert.NoError(t, db.Where("id = ?", invoice.ID).First(&paid).Error)
+	assert.Equal(t, entity.InvoiceStatusPaid, paid.Status)
+	assert.Equal(t, "ch_fake_renewal", *paid.PaymentChargeID)
+
+	logs := FindAuditLogs(t, entity.AuditActionSubscriptionStatus)
+	assert.Len(t, logs, 2)
+}
+
+func TestBillingWebhook_DuplicateIgnored(t *testing.T) {
+	CleanupDatabase(t)
+
+	token := GetAccessToken(t)
+	invoice := CreateRenewalInvoice(t, token)
+
+	event := &payment.Event{
+		ID:       "evt_succeeded",
+		Type:     payment.EventPaymentSucceeded,
+		ChargeID: "ch_fake_renewal",
+		Metadata: map[string]string{"invoice_id": invoice.ID},
+	}
+	resp := SendBillingWebhook(t, event)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, false, ParseResponse(t, resp)["data"].(map[string]interface{})["duplicate"])
+
+	resp = SendBillingWebhook(t, event)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, true, ParseResponse(t, resp)["data"].(map[string]interface{})["duplicate"])
+
+	var total int64
+	assert.NoError(t, db.Model(&entity.BillingEvent{}).Where("event_id = ?", "evt_succeeded").Count(&total).Error)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, FindAuditLogs(t, entity.AuditActionSubscriptionStatus), 2) // past_due at renewal, then active
+}
+
+func TestBillingWebhook_RefundCancelsSubscription(t *testing.T) {
+	CleanupDatabase(t)
+
+	proPlan := CreateTestPlan(t, "pro", "Pro Plan", 29.00)
+	token := GetAccessToken(t)
+	AddPaymentMethod(t, token, "pm_card_visa")
+
+	resp, err := MakeRequest("POST", "/api/v1/subscriptions/upgrade", `{"plan_id": "`+proPlan.ID+`"}`, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	invoice := FindInvoices(t, GetOrganizationID(t, token))[0]
+
+	// Matched by charge, as Stripe refunds carry no invoice metadata
+	resp = SendBillingWebhook(t, &payment.Event{
+		ID:       "evt_refunded",
+		Type:     payment.EventRefunded,
+		ChargeID: *invoice.PaymentChargeID,
+		Amount:   invoice.AmountDue,
+	})
+	assert.Equal(t, 200, resp.StatusCode)
+
+	status, slug := getSubscriptionStatus(t, token)
+	assert.Equal(t, entity.SubscriptionStatusActive, status)
+	assert.Equal(t, "free", slug)
+
+	var refunded entity.Invoice
+	assert.NoError(t, db.Where("id = ?", invoice.ID).First(&refunded).Error)
+	assert.Equal(t, entity.InvoiceStatusRefunded, refunded.Status)
+}
+
+func TestBillingWebhook_UnknownEventStored(t *testing.T) {
+	CleanupDatabase(t)
+
+	resp := SendBillingWebhook(t, &payment.Event{ID: "evt_unknown", Type: "customer.created"})
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var event entity.BillingEvent
+	assert.NoError(t, db.Where("event_id = ?", "evt_unknown").First(&event).Error)
+	assert.Equal(t, payment.ProviderFake, event.Provider)
+	assert.Nil(t, event.OrganizationID)
+}